@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	workerConfig "github.com/traffic-tacos/reservation-worker/internal/config"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"github.com/traffic-tacos/reservation-worker/internal/redrive"
+	"go.uber.org/zap"
+)
+
+func main() {
+	// Load configuration
+	cfg := workerConfig.Load()
+
+	dlqURL := flag.String("dlq-url", "", "Dead-letter queue URL to read from (default: the configured DLQs of --event-types, or all of them)")
+	targetURL := flag.String("target-url", cfg.SQSQueueURL, "Queue URL to republish messages to")
+	eventTypes := flag.String("event-types", "", "Comma-separated event types to redrive (default: all)")
+	rate := flag.Float64("rate", 10, "Maximum messages republished per second (0 = unlimited)")
+	maxMessages := flag.Int("max-messages", 0, "Stop after redriving this many messages (0 = unlimited)")
+	dryRun := flag.Bool("dry-run", false, "Only report counts without republishing")
+	flag.Parse()
+
+	// Initialize logger
+	logger, err := observability.NewLogger(cfg.LogLevel)
+	if err != nil {
+		fmt.Printf("Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	if err := cfg.MergeWithSecrets(ctx); err != nil {
+		logger.Error("Failed to load secrets from AWS Secrets Manager", zap.Error(err))
+	}
+
+	awsCfg, err := cfg.LoadAWSConfig(ctx)
+	if err != nil {
+		logger.Error("Failed to load AWS config", zap.Error(err))
+		os.Exit(1)
+	}
+
+	var types []string
+	for _, t := range strings.Split(*eventTypes, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+
+	// Per-type DLQs are redriven one after another, with the same filter and limits
+	dlqURLs := cfg.DLQURLs(types)
+	if *dlqURL != "" {
+		dlqURLs = []string{*dlqURL}
+	}
+	if len(dlqURLs) == 0 {
+		logger.Error("No DLQ to redrive: set --dlq-url, SQS_DLQ_URL, DLQ_EXPIRED_URL, DLQ_PAYMENT_URL or DLQ_URLS")
+		os.Exit(1)
+	}
+
+	sqsClient := sqs.NewFromConfig(awsCfg)
+	report := &redrive.Report{}
+	for _, url := range dlqURLs {
+		remaining := *maxMessages
+		if remaining > 0 {
+			if remaining -= report.Matched; remaining <= 0 {
+				break
+			}
+		}
+
+		redriver := redrive.NewRedriver(sqsClient, redrive.Options{
+			SourceQueueURL: url,
+			TargetQueueURL: *targetURL,
+			EventTypes:     types,
+			RatePerSecond:  *rate,
+			MaxMessages:    remaining,
+			DryRun:         *dryRun,
+			WaitTimeSecs:   1,
+		}, logger.Logger)
+
+		logger.Info("Starting DLQ redrive",
+			zap.String("dlq_url", url),
+			zap.String("target_url", *targetURL),
+			zap.Strings("event_types", types),
+			zap.Float64("rate", *rate),
+			zap.Bool("dry_run", *dryRun),
+		)
+
+		var dlqReport *redrive.Report
+		dlqReport, err = redriver.Run(ctx)
+		report.Add(dlqReport)
+		if err != nil {
+			break
+		}
+	}
+
+	logger.Info("DLQ redrive finished",
+		zap.Int("received", report.Received),
+		zap.Int("matched", report.Matched),
+		zap.Int("skipped", report.Skipped),
+		zap.Int("redriven", report.Redriven),
+		zap.Int("failed", report.Failed),
+	)
+	if err != nil {
+		logger.Error("DLQ redrive failed", zap.Error(err))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/traffic-tacos/reservation-worker/internal/client"
+	workerConfig "github.com/traffic-tacos/reservation-worker/internal/config"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"github.com/traffic-tacos/reservation-worker/internal/replay"
+	"github.com/traffic-tacos/reservation-worker/internal/worker"
+	"go.uber.org/zap"
+)
+
+func main() {
+	// Load configuration
+	cfg := workerConfig.Load()
+
+	bucket := flag.String("bucket", "", "S3 bucket holding the archived events")
+	prefix := flag.String("prefix", "", "Only replay objects under this key prefix")
+	from := flag.String("from", "", "Only replay events at or after this time (RFC 3339 or YYYY-MM-DD)")
+	to := flag.String("to", "", "Only replay events before this time (RFC 3339 or YYYY-MM-DD)")
+	rate := flag.Float64("rate", 10, "Maximum events replayed per second (0 = unlimited)")
+	maxEvents := flag.Int("max-events", 0, "Stop after replaying this many events (0 = unlimited)")
+	dryRun := flag.Bool("dry-run", cfg.DryRun, "Run the handlers without mutating downstream services")
+	flag.Parse()
+
+	if *bucket == "" {
+		fmt.Println("--bucket is required")
+		os.Exit(1)
+	}
+	fromTime, err := parseTime(*from)
+	if err != nil {
+		fmt.Printf("Invalid --from: %v\n", err)
+		os.Exit(1)
+	}
+	toTime, err := parseTime(*to)
+	if err != nil {
+		fmt.Printf("Invalid --to: %v\n", err)
+		os.Exit(1)
+	}
+	cfg.DryRun = *dryRun
+
+	// Initialize logger
+	logger, err := observability.NewLogger(cfg.LogLevel)
+	if err != nil {
+		fmt.Printf("Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	if err := cfg.MergeWithSecrets(ctx); err != nil {
+		logger.Error("Failed to load secrets from AWS Secrets Manager", zap.Error(err))
+	}
+
+	awsCfg, err := cfg.LoadAWSConfig(ctx)
+	if err != nil {
+		logger.Error("Failed to load AWS config", zap.Error(err))
+		os.Exit(1)
+	}
+
+	metrics := observability.NewMetrics()
+	inventoryClient, err := client.NewInventoryClientFromConfig(cfg, client.WithMetrics(metrics))
+	if err != nil {
+		logger.Error("Failed to initialize inventory client", zap.Error(err))
+		os.Exit(1)
+	}
+	reservationClient := client.NewReservationClientFromConfig(cfg, logger.Logger, client.WithMetrics(metrics))
+
+	// Events go through the same dispatcher, retries and handlers as polled messages
+	dispatcher := worker.NewDispatcher(cfg, inventoryClient, reservationClient, logger, metrics)
+	if err := dispatcher.Start(ctx); err != nil {
+		logger.Error("Failed to start dispatcher", zap.Error(err))
+		os.Exit(1)
+	}
+
+	replayer := replay.NewReplayer(s3.NewFromConfig(awsCfg), dispatcher, replay.Options{
+		Bucket:        *bucket,
+		Prefix:        *prefix,
+		From:          fromTime,
+		To:            toTime,
+		RatePerSecond: *rate,
+		MaxEvents:     *maxEvents,
+	}, logger.Logger)
+
+	logger.Info("Starting S3 replay",
+		zap.String("bucket", *bucket),
+		zap.String("prefix", *prefix),
+		zap.Time("from", fromTime),
+		zap.Time("to", toTime),
+		zap.Float64("rate", *rate),
+		zap.Bool("dry_run", cfg.DryRun),
+	)
+
+	report, err := replayer.Run(ctx)
+	dispatcher.Stop()
+	logger.Info("S3 replay finished",
+		zap.Int("objects", report.Objects),
+		zap.Int("read", report.Read),
+		zap.Int("matched", report.Matched),
+		zap.Int("skipped", report.Skipped),
+		zap.Int("replayed", report.Replayed),
+		zap.Int("failed", report.Failed),
+	)
+	if err != nil {
+		logger.Error("S3 replay failed", zap.Error(err))
+		os.Exit(1)
+	}
+}
+
+// parseTime parses an RFC 3339 timestamp or a UTC date; empty yields the zero time
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
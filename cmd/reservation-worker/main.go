@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -11,24 +12,38 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/traffic-tacos/reservation-worker/internal/client"
 	workerConfig "github.com/traffic-tacos/reservation-worker/internal/config"
+	"github.com/traffic-tacos/reservation-worker/internal/handler"
+	"github.com/traffic-tacos/reservation-worker/internal/lifecycle"
 	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"github.com/traffic-tacos/reservation-worker/internal/publish"
 	"github.com/traffic-tacos/reservation-worker/internal/server"
 	"github.com/traffic-tacos/reservation-worker/internal/worker"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
+// serviceName identifies the worker in logs, traces and metrics
+const serviceName = "reservation-worker"
+
 func main() {
 	// Load configuration
 	cfg := workerConfig.Load()
 
 	// Initialize logger
-	logger, err := observability.NewLogger(cfg.LogLevel)
+	logger, err := observability.NewSampledLogger(cfg.LogLevel, cfg.LogFormat, observability.LogSampling{
+		Initial:    cfg.LogSamplingInitial,
+		Thereafter: cfg.LogSamplingThereafter,
+	}, observability.WithBaseFields(observability.LogFields{
+		Service:    serviceName,
+		Version:    cfg.ServiceVersion,
+		Env:        cfg.Environment,
+		Region:     cfg.AWSRegion,
+		InstanceID: cfg.InstanceID,
+	}))
 	if err != nil {
 		fmt.Printf("Failed to initialize logger: %v\n", err)
 		os.Exit(1)
@@ -36,8 +51,8 @@ func main() {
 	defer logger.Sync()
 
 	// Initialize context for graceful shutdown
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	ctx, cancelCause := context.WithCancelCause(context.Background())
+	defer cancelCause(nil)
 
 	// Load secrets from AWS Secrets Manager if configured
 	if err := cfg.MergeWithSecrets(ctx); err != nil {
@@ -45,66 +60,110 @@ func main() {
 		// Continue with default configuration
 	}
 
+	// Fail fast on invalid configuration
+	if err := cfg.Validate(); err != nil {
+		logger.Error("Invalid configuration", zap.Error(err))
+		os.Exit(1)
+	}
+
+	// Export decision logs through OTLP if configured
+	var flushLogs func(context.Context) error
+	if cfg.LogExport == observability.LogExportOTLP || cfg.LogExport == observability.LogExportBoth {
+		lp, err := observability.InitLogExport(ctx, observability.LogExportConfig{
+			ServiceName:      serviceName,
+			ServiceVersion:   cfg.ServiceVersion,
+			Environment:      cfg.Environment,
+			ExporterEndpoint: cfg.OTELExporterEndpoint,
+		})
+		if err != nil {
+			logger.Error("Failed to initialize OTLP log export", zap.Error(err))
+		} else {
+			logger = logger.WithLogExport(cfg.LogExport, lp)
+			flushLogs = lp.Shutdown
+		}
+	}
+
+	// Hold back the info lines of events not sampled by the dispatcher
+	if cfg.LogSuccessSampleRate < 1 {
+		logger = logger.WithSuccessLogSampling()
+	}
+
+	// Mask sensitive fields in every log output
+	logger = logger.WithRedaction(cfg.RedactFields)
+
+	// Audit lines go to stdout, apart from the operational log on stderr, and carry log_type=audit
+	if cfg.AuditLogEnabled {
+		logger.Audit = observability.NewAuditLogger(zapcore.Lock(os.Stdout))
+	}
+
+	for _, warning := range cfg.Warnings {
+		logger.Warn("Configuration adjusted", zap.String("warning", warning))
+	}
+
 	logger.Info("Starting reservation worker",
 		zap.String("queue_url", cfg.SQSQueueURL),
 		zap.Int("concurrency", cfg.WorkerConcurrency),
 		zap.Int("max_retries", cfg.MaxRetries),
 		zap.String("aws_profile", cfg.AWSProfile),
 		zap.Bool("use_secret_manager", cfg.UseSecretManager),
+		zap.String("log_export", cfg.LogExport),
+		zap.Bool("dry_run", cfg.DryRun),
+		zap.String("metrics_backend", cfg.MetricsBackend),
 	)
 
+	// OTLP settings shared by tracing and metric export
+	otlpConfig := observability.TracingConfig{
+		ServiceName:      serviceName,
+		ServiceVersion:   cfg.ServiceVersion,
+		Environment:      cfg.Environment,
+		ExporterEndpoint: cfg.OTELExporterEndpoint,
+		Sampler:          cfg.OTELTracesSampler,
+		SamplerArg:       cfg.OTELTracesSamplerArg,
+		Insecure:         cfg.OTELExporterInsecure,
+		CAFile:           cfg.OTELExporterCAFile,
+		Protocol:         cfg.OTELExporterProtocol,
+	}
+
 	// Initialize OpenTelemetry tracing (disabled for local development)
 	// TODO: Fix schema conflict and re-enable
+	var flushTracer func(context.Context) error
 	/*
-		tracingConfig := observability.TracingConfig{
-			ServiceName:      "reservation-worker",
-			ServiceVersion:   "1.0.0",
-			Environment:      "production", // TODO: make configurable
-			ExporterEndpoint: cfg.OTELExporterEndpoint,
-		}
-
-		tp, err := observability.InitTracing(ctx, tracingConfig)
+		tp, err := observability.InitTracing(ctx, otlpConfig)
 		if err != nil {
 			logger.Error("Failed to initialize tracing", zap.Error(err))
 			os.Exit(1)
 		}
-		defer func() {
-			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-			if err := tp.Shutdown(shutdownCtx); err != nil {
-				logger.Error("Failed to shutdown tracer provider", zap.Error(err))
-			}
-		}()
+		flushTracer = tp.Shutdown
 	*/
 
-	// Initialize Prometheus metrics
-	metrics := observability.NewMetrics()
+	// Initialize Prometheus metrics, optionally mirrored to the OTLP collector
+	metrics := observability.NewMetrics(observability.WithInstanceID(cfg.InstanceID))
 
-	// Initialize AWS SDK
-	awsOpts := []func(*config.LoadOptions) error{
-		config.WithRegion(cfg.SQSRegion),
+	var flushMetrics func(context.Context) error
+	if cfg.MetricsBackend != observability.MetricsBackendPrometheus {
+		mp, err := observability.InitMetricExport(ctx, otlpConfig)
+		if err != nil {
+			logger.Error("Failed to initialize OTel metric export", zap.Error(err))
+			os.Exit(1)
+		}
+		if err := metrics.SetBackend(cfg.MetricsBackend, mp.Meter("reservation-worker")); err != nil {
+			logger.Error("Failed to configure metrics backend", zap.Error(err))
+			os.Exit(1)
+		}
+		flushMetrics = mp.Shutdown
 	}
 
-	// Determine authentication method
-	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
-	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
-
-	if accessKey != "" && secretKey != "" {
-		// Method 1: Static credentials from environment variables
+	// Initialize AWS SDK
+	switch cfg.AWSCredentialSource() {
+	case workerConfig.CredentialSourceStatic:
 		logger.Info("Using AWS static credentials from environment variables")
-		awsOpts = append(awsOpts, config.WithCredentialsProvider(
-			credentials.NewStaticCredentialsProvider(accessKey, secretKey, ""),
-		))
-	} else if cfg.AWSProfile != "" {
-		// Method 2: Named profile from ~/.aws/credentials
+	case workerConfig.CredentialSourceProfile:
 		logger.Info("Using AWS profile", zap.String("profile", cfg.AWSProfile))
-		awsOpts = append(awsOpts, config.WithSharedConfigProfile(cfg.AWSProfile))
-	} else {
-		// Method 3: Default credential chain (IRSA, Instance Profile, etc.)
+	default:
 		logger.Info("Using AWS default credential chain (IRSA/Instance Profile)")
 	}
 
-	awsCfg, err := config.LoadDefaultConfig(ctx, awsOpts...)
+	awsCfg, err := cfg.LoadAWSConfig(ctx)
 	if err != nil {
 		logger.Error("Failed to load AWS config", zap.Error(err))
 		os.Exit(1)
@@ -112,15 +171,45 @@ func main() {
 
 	sqsClient := sqs.NewFromConfig(awsCfg)
 
+	if cfg.SQSAutoCreateQueue {
+		endpoint := aws.ToString(awsCfg.BaseEndpoint)
+		if !worker.IsLocalStackEndpoint(endpoint) && !cfg.SQSAutoCreateForce {
+			logger.Error("Refusing to auto-create SQS queue outside LocalStack; set SQS_AUTO_CREATE_QUEUE_FORCE to override",
+				zap.String("endpoint", endpoint))
+			os.Exit(1)
+		}
+		queueURL, created, err := worker.EnsureQueue(ctx, sqsClient, cfg.SQSQueueName, cfg.SQSMaxReceiveCount)
+		if err != nil {
+			logger.Error("Failed to auto-create SQS queue", zap.Error(err))
+			os.Exit(1)
+		}
+		if created {
+			logger.Warn("Created SQS queue", zap.String("queue_name", cfg.SQSQueueName), zap.String("queue_url", queueURL))
+		}
+	}
+
+	// A full queue URL wins over a queue name
+	if cfg.SQSQueueURL == "" {
+		queueURL, err := worker.ResolveQueueURL(ctx, sqsClient, cfg.SQSQueueName, cfg.SQSQueueAccount)
+		if err != nil {
+			logger.Error("Failed to resolve SQS queue URL", zap.Error(err))
+			os.Exit(1)
+		}
+		cfg.SQSQueueURL = queueURL
+		logger.Info("Resolved SQS queue URL", zap.String("queue_name", cfg.SQSQueueName), zap.String("queue_url", queueURL))
+	}
+
 	// Initialize external service clients
-	inventoryClient, err := client.NewInventoryClient(cfg.InventoryGRPCAddr)
+	inventoryClient, err := client.NewInventoryClientFromConfig(cfg, client.WithMetrics(metrics), client.WithLogger(logger.Logger))
 	if err != nil {
 		logger.Error("Failed to initialize inventory client", zap.Error(err))
 		os.Exit(1)
 	}
-	defer inventoryClient.Close()
 
-	reservationClient := client.NewReservationClient(cfg.ReservationAPIBase)
+	if cfg.LogHTTPBodies && cfg.LogLevel != "debug" {
+		logger.Warn("LOG_HTTP_BODIES has no effect unless LOG_LEVEL is debug", zap.String("log_level", cfg.LogLevel))
+	}
+	reservationClient := client.NewReservationClientFromConfig(cfg, logger.Logger, client.WithMetrics(metrics))
 
 	// Initialize dispatcher with worker pool
 	dispatcher := worker.NewDispatcher(
@@ -130,6 +219,10 @@ func main() {
 		logger,
 		metrics,
 	)
+	if cfg.OutboundEventsQueueURL != "" {
+		// Approvals dropped after their reservation ended are refunded
+		dispatcher.SetCompensator(handler.NewRefundCompensator(publish.NewEventPublisher(sqsClient, cfg.OutboundEventsQueueURL)))
+	}
 
 	// Initialize SQS poller
 	poller := worker.NewSQSPoller(
@@ -139,13 +232,48 @@ func main() {
 		metrics,
 		dispatcher.GetEventsChan(),
 	)
+	if cfg.SQSPrefetchByWorkers {
+		poller.SetWorkerCapacity(dispatcher)
+	}
+	if cfg.StartupWaitInventory {
+		poller.SetReadinessGate(inventoryClient)
+	}
+	if cfg.PriorityAttribute != "" {
+		poller.SetHighPriorityChan(dispatcher.GetHighPriorityChan())
+	}
+	if cfg.SQSKMSDecryptEnabled {
+		poller.SetKMSDecrypter(client.NewKMSClient(awsCfg))
+		logger.Info("KMS decryption of SQS message bodies enabled")
+	}
 
 	// Start HTTP server for health checks and metrics
 	var wg sync.WaitGroup
+	scrapes := server.NewScrapeWaiter()
+	httpOpts := server.HTTPOptions{
+		EnablePprof: cfg.EnablePprof,
+		Scrapes:     scrapes,
+		Pauser:      poller,
+		EnableAdmin: cfg.EnableAdmin,
+	}
+	if cfg.TestEndpointAllowed() {
+		httpOpts.TestEvents = dispatcher
+		logger.Warn("Self-test endpoint enabled", zap.Bool("dry_run", cfg.DryRun))
+	} else if cfg.EnableTestEndpoint {
+		logger.Warn("Self-test endpoint requires DRY_RUN or ALLOW_LIVE_TEST_EVENTS; not mounting it")
+	}
+	if cfg.ReplayEndpointToken != "" {
+		httpOpts.Replay = dispatcher
+		httpOpts.ReplayToken = cfg.ReplayEndpointToken
+		logger.Info("Event replay endpoint enabled")
+	}
+	httpServer := server.NewHTTPServer(cfg.ServerPort, httpOpts)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		startHTTPServer(cfg.ServerPort, logger)
+		logger.Info("Starting HTTP server", zap.String("port", cfg.ServerPort))
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("HTTP server failed", zap.Error(err))
+		}
 	}()
 
 	// Start gRPC server for debugging (grpcui support)
@@ -155,7 +283,11 @@ func main() {
 		os.Exit(1)
 	}
 
-	grpcServer := server.NewGRPCServer(grpcPort, logger)
+	grpcServer, err := server.NewGRPCServer(grpcPort, logger, &server.DebugOptions{Config: cfg, Inflight: dispatcher})
+	if err != nil {
+		logger.Error("Failed to create gRPC debug server", zap.Error(err))
+		os.Exit(1)
+	}
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -164,12 +296,19 @@ func main() {
 		}
 	}()
 
+	// Restart the dispatch loop and the poller if they exit while running; supervision ends when
+	// shutdown begins. A subsystem out of restarts shuts the process down for the orchestrator to reschedule.
+	superviseCtx, stopSupervising := context.WithCancel(ctx)
+	supervisor := lifecycle.NewSupervisor(cfg.SupervisorMaxRestarts, time.Duration(cfg.SupervisorBackoffMS)*time.Millisecond, logger, metrics)
+	fatal := make(chan error, 2)
+
 	// Start dispatcher
+	dispatcher.StartWorkers(ctx)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		if err := dispatcher.Start(ctx); err != nil {
-			logger.Error("Dispatcher failed", zap.Error(err))
+		if err := supervisor.Supervise(superviseCtx, "dispatcher", func() error { return dispatcher.Run(ctx) }); err != nil {
+			fatal <- err
 		}
 	}()
 
@@ -177,29 +316,119 @@ func main() {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		if err := poller.Start(ctx); err != nil && err != context.Canceled {
-			logger.Error("SQS poller failed", zap.Error(err))
+		if err := supervisor.Supervise(superviseCtx, "poller", func() error { return poller.Start(ctx) }); err != nil {
+			fatal <- err
 		}
 	}()
 
+	// Report worker_healthy from the poller and dispatcher heartbeats
+	if cfg.HeartbeatTimeoutSec > 0 {
+		timeout := time.Duration(cfg.HeartbeatTimeoutSec) * time.Second
+		monitor := worker.NewHealthMonitor(timeout, metrics, poller, dispatcher)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			monitor.Run(ctx, min(timeout/4, 15*time.Second))
+		}()
+	} else {
+		// Nothing watches the heartbeats, so worker_healthy must not read as a stalled worker
+		metrics.SetWorkerHealthy(true)
+	}
+
 	logger.Info("Reservation worker started successfully")
 
 	// Wait for shutdown signal
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	<-sigChan
-	logger.Info("Received shutdown signal, shutting down gracefully...")
+	var fatalErr error
+	select {
+	case <-sigChan:
+		logger.Info("Received shutdown signal, shutting down gracefully...")
+	case fatalErr = <-fatal:
+		logger.Error("Subsystem keeps failing, shutting down", zap.Error(fatalErr))
+	}
+	stopSupervising()
+
+	// After shutting down for a failed subsystem, exit non-zero so the orchestrator reschedules the pod
+	if fatalErr != nil {
+		defer func() {
+			logger.Sync()
+			os.Exit(1)
+		}()
+	}
+
+	// Stop intake first, let in-flight work finish, then release connections
+	drain := lifecycle.NewOrchestrator(logger)
+	drain.Add("stop SQS poller", func(ctx context.Context) error {
+		poller.Stop()
+		return poller.Wait(ctx)
+	})
+	drain.Add("drain dispatcher", func(ctx context.Context) error {
+		stopped := make(chan struct{})
+		go func() {
+			dispatcher.Stop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+			return nil
+		case <-ctx.Done():
+			// Abandon the rest of the drain, so Stop still records its summary for the final scrape
+			cancelCause(observability.CancelCause(observability.CancelReasonShutdown))
+			return ctx.Err()
+		}
+	})
+	drain.Add("stop gRPC debug server", func(ctx context.Context) error {
+		grpcServer.Stop()
+		return nil
+	})
+	drain.Add("close inventory client", func(ctx context.Context) error {
+		return inventoryClient.Close()
+	})
+	drain.Add("close reservation client", func(ctx context.Context) error {
+		reservationClient.Close()
+		return nil
+	})
+
+	// Then flush telemetry. Final metric values, including the shutdown summary, go out before /metrics closes.
+	var finalScrape time.Duration
+	shutdown := lifecycle.NewOrchestrator(logger)
+	if flushMetrics != nil {
+		shutdown.Add("flush metrics", flushMetrics)
+	}
+	if cfg.MetricsFinalScrapeSec > 0 && cfg.MetricsBackend != observability.MetricsBackendOTel {
+		finalScrape = time.Duration(cfg.MetricsFinalScrapeSec) * time.Second
+		shutdown.Add("await final metrics scrape", func(ctx context.Context) error {
+			ctx, cancel := context.WithTimeout(ctx, finalScrape)
+			defer cancel()
+			if err := scrapes.Wait(ctx); err != nil {
+				logger.Warn("No metrics scrape before shutdown; final values may be lost", zap.Error(err))
+			}
+			return nil
+		})
+	}
+	shutdown.Add("stop HTTP server", httpServer.Shutdown)
+	if flushTracer != nil {
+		shutdown.Add("flush tracer", flushTracer)
+	}
+	if flushLogs != nil {
+		shutdown.Add("flush log export", flushLogs)
+	}
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), workerConfig.ShutdownTimeout)
+	defer shutdownCancel()
 
-	// Cancel context to signal shutdown
-	cancel()
+	// The final scrape wait is reserved out of the shutdown budget, so a slow drain cannot use it up
+	drainCtx, drainCancel := context.WithTimeout(shutdownCtx, workerConfig.ShutdownTimeout-finalScrape)
+	defer drainCancel()
 
-	// Stop components
-	poller.Stop()
-	dispatcher.Stop()
-	grpcServer.Stop()
+	err = errors.Join(drain.Shutdown(drainCtx), shutdown.Shutdown(shutdownCtx))
 
-	// Wait for all goroutines to finish with timeout
+	// Cancel context to release anything still running; calls it aborts log the shutdown as their cause
+	cancelCause(observability.CancelCause(observability.CancelReasonShutdown))
+
+	// Wait for all goroutines to finish within the remaining deadline
 	done := make(chan struct{})
 	go func() {
 		wg.Wait()
@@ -208,39 +437,14 @@ func main() {
 
 	select {
 	case <-done:
-		logger.Info("Graceful shutdown completed")
-	case <-time.After(30 * time.Second):
+	case <-shutdownCtx.Done():
 		logger.Warn("Shutdown timeout exceeded, forcing exit")
+		return
 	}
-}
-
-// startHTTPServer starts HTTP server for health checks and metrics
-func startHTTPServer(port string, logger *observability.Logger) {
-	mux := http.NewServeMux()
-
-	// Health check endpoint
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	})
-
-	// Readiness check endpoint
-	mux.HandleFunc("/ready", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("READY"))
-	})
-
-	// Prometheus metrics endpoint
-	mux.Handle("/metrics", promhttp.Handler())
-
-	server := &http.Server{
-		Addr:    fmt.Sprintf(":%s", port),
-		Handler: mux,
-	}
-
-	logger.Info("Starting HTTP server", zap.String("port", port))
 
-	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		logger.Error("HTTP server failed", zap.Error(err))
+	if err != nil {
+		logger.Error("Shutdown completed with errors", zap.Error(err))
+		return
 	}
+	logger.Info("Graceful shutdown completed")
 }
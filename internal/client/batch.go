@@ -0,0 +1,117 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrBatchUnsupported is returned for every request of a batch when the reservation API
+// has no bulk status endpoint; callers send the updates one by one instead
+var ErrBatchUnsupported = errors.New("reservation API has no bulk status endpoint")
+
+// batchStatusItem is one update in a bulk status request
+type batchStatusItem struct {
+	ReservationID   string `json:"reservation_id"`
+	Status          string `json:"status"`
+	OrderID         string `json:"order_id,omitempty"`
+	ExpectedStatus  string `json:"expected_status,omitempty"`
+	ExpectedVersion string `json:"expected_version,omitempty"`
+}
+
+// batchStatusResult is the outcome of one update, in request order
+type batchStatusResult struct {
+	ReservationID string `json:"reservation_id"`
+	StatusCode    int    `json:"status_code"`
+	Error         string `json:"error,omitempty"`
+}
+
+// UpdateReservationStatusBatch updates several reservations in one call to the bulk endpoint.
+// It returns one error per request, in order. If the API has no bulk endpoint (404), this
+// and every later batch fails with ErrBatchUnsupported without sending anything, so each
+// update can be sent with UpdateReservationStatus under its own caller's context.
+func (c *ReservationClient) UpdateReservationStatusBatch(ctx context.Context, reqs []UpdateStatusRequest) []error {
+	if c.batchUnsupported.Load() {
+		return repeatError(ErrBatchUnsupported, len(reqs))
+	}
+
+	errs, err := c.updateBatch(ctx, reqs)
+	if err == nil {
+		return errs
+	}
+
+	var httpErr *HTTPStatusError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+		c.batchUnsupported.Store(true)
+		err = ErrBatchUnsupported
+	}
+	return repeatError(err, len(reqs))
+}
+
+// updateBatch sends reqs to the bulk endpoint; the error is set when the call as a whole failed
+func (c *ReservationClient) updateBatch(ctx context.Context, reqs []UpdateStatusRequest) ([]error, error) {
+	url := fmt.Sprintf("%s/internal/reservations/batch-status", c.baseURL)
+
+	items := make([]batchStatusItem, len(reqs))
+	for i, req := range reqs {
+		items[i] = batchStatusItem{
+			ReservationID:   req.ReservationID,
+			Status:          req.Status,
+			OrderID:         req.OrderID,
+			ExpectedStatus:  req.ExpectedStatus,
+			ExpectedVersion: req.ExpectedVersion,
+		}
+	}
+
+	jsonData, err := json.Marshal(map[string]interface{}{"updates": items})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	if err := waitRateLimit(ctx, c.limiter); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.send(httpReq, operationUpdateStatusBatch)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var decoded struct {
+		Results []batchStatusResult `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(decoded.Results) != len(reqs) {
+		return nil, fmt.Errorf("batch response has %d results for %d updates", len(decoded.Results), len(reqs))
+	}
+
+	errs := make([]error, len(reqs))
+	for i, result := range decoded.Results {
+		if result.StatusCode < 200 || result.StatusCode >= 300 {
+			errs[i] = &HTTPStatusError{StatusCode: result.StatusCode, Body: result.Error}
+		}
+	}
+	return errs, nil
+}
+
+// repeatError returns n copies of err
+func repeatError(err error, n int) []error {
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
@@ -0,0 +1,98 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/traffic-tacos/reservation-worker/internal/client"
+)
+
+func TestReservationClient_UpdateReservationStatusBatch(t *testing.T) {
+	var bulkCalls, patchCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/batch-status") {
+			patchCalls.Add(1)
+			return
+		}
+		bulkCalls.Add(1)
+
+		var body struct {
+			Updates []struct {
+				ReservationID string `json:"reservation_id"`
+			} `json:"updates"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		results := make([]map[string]interface{}, len(body.Updates))
+		for i, u := range body.Updates {
+			code := http.StatusOK
+			if u.ReservationID == "rsv-conflict" {
+				code = http.StatusConflict
+			}
+			results[i] = map[string]interface{}{"reservation_id": u.ReservationID, "status_code": code}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"results": results})
+	}))
+	t.Cleanup(server.Close)
+
+	c := client.NewReservationClient(server.URL, 0)
+	errs := c.UpdateReservationStatusBatch(context.Background(), []client.UpdateStatusRequest{
+		{ReservationID: "rsv-1", Status: client.StatusExpired},
+		{ReservationID: "rsv-conflict", Status: client.StatusExpired},
+		{ReservationID: "rsv-2", Status: client.StatusExpired},
+	})
+
+	if len(errs) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(errs))
+	}
+	if errs[0] != nil || errs[2] != nil {
+		t.Errorf("Expected rsv-1 and rsv-2 to succeed, got %v and %v", errs[0], errs[2])
+	}
+	if !client.IsConflict(errs[1]) {
+		t.Errorf("Expected a conflict for rsv-conflict, got %v", errs[1])
+	}
+	if bulkCalls.Load() != 1 || patchCalls.Load() != 0 {
+		t.Errorf("Expected a single bulk call, got %d bulk and %d per-item", bulkCalls.Load(), patchCalls.Load())
+	}
+}
+
+func TestReservationClient_UpdateReservationStatusBatchFallback(t *testing.T) {
+	var bulkCalls, patchCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/batch-status") {
+			bulkCalls.Add(1)
+			http.NotFound(w, r)
+			return
+		}
+		patchCalls.Add(1)
+	}))
+	t.Cleanup(server.Close)
+
+	c := client.NewReservationClient(server.URL, 0)
+	reqs := []client.UpdateStatusRequest{
+		{ReservationID: "rsv-1", Status: client.StatusExpired},
+		{ReservationID: "rsv-2", Status: client.StatusExpired},
+	}
+
+	// The second batch fails without trying the bulk endpoint again
+	for i := 0; i < 2; i++ {
+		for _, err := range c.UpdateReservationStatusBatch(context.Background(), reqs) {
+			if !errors.Is(err, client.ErrBatchUnsupported) {
+				t.Fatalf("UpdateReservationStatusBatch() error = %v, want ErrBatchUnsupported", err)
+			}
+		}
+	}
+
+	if got := bulkCalls.Load(); got != 1 {
+		t.Errorf("Expected the bulk endpoint to be tried once, got %d", got)
+	}
+	if got := patchCalls.Load(); got != 0 {
+		t.Errorf("Expected per-item updates to be left to the callers, got %d", got)
+	}
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"regexp"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// MaxLoggedBodyBytes caps how much of a body is written to the debug log
+const MaxLoggedBodyBytes = 4096
+
+// redactedFields are JSON string fields whose values never reach the log
+var redactedFields = regexp.MustCompile(`"(payment_intent_id|order_id|user_id|email|phone|card_number|token|authorization)"(\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+// RedactBody masks known sensitive JSON fields in body and truncates it to MaxLoggedBodyBytes.
+// Redaction runs on the whole body first so truncation cannot expose a partial value.
+func RedactBody(body []byte) (redacted string, truncated bool) {
+	masked := redactedFields.ReplaceAll(body, []byte(`"$1"$2"[REDACTED]"`))
+	if len(masked) > MaxLoggedBodyBytes {
+		return string(masked[:MaxLoggedBodyBytes]), true
+	}
+	return string(masked), false
+}
+
+// bodyLogTransport logs request and response bodies while the logger is at debug level
+type bodyLogTransport struct {
+	base   http.RoundTripper
+	logger *zap.Logger
+}
+
+// RoundTrip logs the outbound body and the full response body around the base transport
+func (t *bodyLogTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.logger.Core().Enabled(zapcore.DebugLevel) {
+		return t.base.RoundTrip(req)
+	}
+
+	if req.Body != nil && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			t.logBody("HTTP request body", req, body)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.Body == nil {
+		return resp, err
+	}
+
+	// Buffer the response so the caller can still read it
+	raw, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(raw))
+	if readErr != nil {
+		return resp, nil
+	}
+
+	redacted, truncated := RedactBody(raw)
+	t.logger.Debug("HTTP response body",
+		zap.String("method", req.Method),
+		zap.String("url", req.URL.String()),
+		zap.Int("status_code", resp.StatusCode),
+		zap.String("body", redacted),
+		zap.Bool("truncated", truncated),
+	)
+	return resp, nil
+}
+
+func (t *bodyLogTransport) logBody(msg string, req *http.Request, body io.ReadCloser) {
+	defer body.Close()
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return
+	}
+
+	redacted, truncated := RedactBody(raw)
+	t.logger.Debug(msg,
+		zap.String("method", req.Method),
+		zap.String("url", req.URL.String()),
+		zap.String("body", redacted),
+		zap.Bool("truncated", truncated),
+	)
+}
@@ -0,0 +1,124 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/traffic-tacos/reservation-worker/internal/client"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRedactBody(t *testing.T) {
+	long := `{"reservation_id":"rsv-1","note":"` + strings.Repeat("x", client.MaxLoggedBodyBytes) + `"}`
+	// Places the secret so a naive cut would keep its first four bytes
+	secretAtCap := `{"note":"` + strings.Repeat("x", client.MaxLoggedBodyBytes-36) + `","payment_intent_id":"SECRET"}`
+
+	tests := []struct {
+		name          string
+		body          string
+		want          string
+		wantTruncated bool
+		mustNotLeak   string
+	}{
+		{
+			name: "payment intent redacted",
+			body: `{"reservation_id":"rsv-1","payment_intent_id":"pi_123"}`,
+			want: `{"reservation_id":"rsv-1","payment_intent_id":"[REDACTED]"}`,
+		},
+		{
+			name: "spacing and escapes",
+			body: `{"order_id" : "ord \"7\"", "status": "CONFIRMED"}`,
+			want: `{"order_id" : "[REDACTED]", "status": "CONFIRMED"}`,
+		},
+		{
+			name: "non-JSON body untouched",
+			body: "upstream connect error",
+			want: "upstream connect error",
+		},
+		{
+			name:          "size cap",
+			body:          long,
+			want:          long[:client.MaxLoggedBodyBytes],
+			wantTruncated: true,
+		},
+		{
+			name:          "redacted before truncation",
+			body:          secretAtCap,
+			wantTruncated: true,
+			mustNotLeak:   "SECR",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, truncated := client.RedactBody([]byte(tt.body))
+			if tt.want != "" && got != tt.want {
+				t.Errorf("RedactBody() = %q, want %q", got, tt.want)
+			}
+			if truncated != tt.wantTruncated {
+				t.Errorf("truncated = %v, want %v", truncated, tt.wantTruncated)
+			}
+			if len(got) > client.MaxLoggedBodyBytes {
+				t.Errorf("len = %d, exceeds cap %d", len(got), client.MaxLoggedBodyBytes)
+			}
+			if tt.mustNotLeak != "" && strings.Contains(got, tt.mustNotLeak) {
+				t.Errorf("RedactBody() leaked %q", tt.mustNotLeak)
+			}
+		})
+	}
+}
+
+func TestReservationClient_BodyLogging(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"reservation_id":"rsv-1","status":"HOLD","user_id":"user-42"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		name        string
+		level       zapcore.Level
+		wantEntries int
+	}{
+		{"debug logs both bodies", zapcore.DebugLevel, 2},
+		{"info logs nothing", zapcore.InfoLevel, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(tt.level)
+			c := client.NewReservationClient(server.URL, 0, client.WithBodyLogging(zap.New(core)))
+
+			if err := c.UpdateReservationStatus(context.Background(), &client.UpdateStatusRequest{
+				ReservationID: "rsv-1",
+				Status:        client.StatusConfirmed,
+				OrderID:       "ord-9",
+			}); err != nil {
+				t.Fatalf("UpdateReservationStatus() error = %v", err)
+			}
+
+			if got := logs.Len(); got != tt.wantEntries {
+				t.Fatalf("Expected %d log entries, got %d", tt.wantEntries, got)
+			}
+			for _, entry := range logs.All() {
+				body := entry.ContextMap()["body"].(string)
+				if strings.Contains(body, "ord-9") || strings.Contains(body, "user-42") {
+					t.Errorf("%s leaked a sensitive value: %s", entry.Message, body)
+				}
+			}
+
+			// The logged response must still be readable by the client
+			details, err := c.GetReservation(context.Background(), "rsv-1")
+			if err != nil {
+				t.Fatalf("GetReservation() error = %v", err)
+			}
+			if details.Status != client.StatusHold {
+				t.Errorf("Status = %s, want %s", details.Status, client.StatusHold)
+			}
+		})
+	}
+}
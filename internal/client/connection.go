@@ -0,0 +1,86 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/keepalive"
+)
+
+// Connection defaults reconnect quickly when a connection is reset. The keepalive
+// time matches the 5m minimum a stock grpc-go server enforces on client pings.
+const (
+	DefaultKeepaliveTime    = 5 * time.Minute
+	DefaultKeepaliveTimeout = 20 * time.Second
+	DefaultReconnectBase    = 100 * time.Millisecond
+	DefaultReconnectMax     = 5 * time.Second
+)
+
+// Client-side load balancing policies
+const (
+	LBPolicyRoundRobin = "round_robin"
+	LBPolicyPickFirst  = "pick_first"
+)
+
+// ConnectionConfig tunes keepalive and reconnect behaviour of the inventory connection.
+// Zero values fall back to the defaults.
+type ConnectionConfig struct {
+	KeepaliveTime    time.Duration // Ping after this long without activity
+	KeepaliveTimeout time.Duration // Close the connection if a ping is not acked in time
+	ReconnectBase    time.Duration // First reconnect delay
+	ReconnectMax     time.Duration // Upper bound for reconnect delays
+	LBPolicy         string        // round_robin (default) or pick_first
+
+	// Ping connections without active RPCs too, e.g. to outlive load balancer idle timeouts.
+	// The server's keepalive EnforcementPolicy must set PermitWithoutStream and a MinTime
+	// no longer than KeepaliveTime, or it closes the connection with GOAWAY too_many_pings.
+	KeepaliveWithoutStream bool
+}
+
+// KeepaliveParams returns the client keepalive parameters
+func (c ConnectionConfig) KeepaliveParams() keepalive.ClientParameters {
+	return keepalive.ClientParameters{
+		Time:                orDefault(c.KeepaliveTime, DefaultKeepaliveTime),
+		Timeout:             orDefault(c.KeepaliveTimeout, DefaultKeepaliveTimeout),
+		PermitWithoutStream: c.KeepaliveWithoutStream,
+	}
+}
+
+// ConnectParams returns the reconnect backoff policy
+func (c ConnectionConfig) ConnectParams() grpc.ConnectParams {
+	policy := backoff.DefaultConfig
+	policy.BaseDelay = orDefault(c.ReconnectBase, DefaultReconnectBase)
+	policy.MaxDelay = orDefault(c.ReconnectMax, DefaultReconnectMax)
+	return grpc.ConnectParams{
+		Backoff:           policy,
+		MinConnectTimeout: 5 * time.Second,
+	}
+}
+
+// ServiceConfig returns the default service config selecting the load balancing policy
+func (c ConnectionConfig) ServiceConfig() string {
+	policy := c.LBPolicy
+	if policy == "" {
+		policy = LBPolicyRoundRobin
+	}
+	return fmt.Sprintf(`{"loadBalancingConfig": [{%q: {}}]}`, policy)
+}
+
+// Target resolves addr through DNS unless it already names a resolver scheme,
+// so every backend behind a headless service becomes a balancing candidate
+func (c ConnectionConfig) Target(addr string) string {
+	if strings.Contains(addr, ":///") {
+		return addr
+	}
+	return "dns:///" + addr
+}
+
+func orDefault(d, fallback time.Duration) time.Duration {
+	if d <= 0 {
+		return fallback
+	}
+	return d
+}
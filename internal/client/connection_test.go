@@ -0,0 +1,85 @@
+package client_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/traffic-tacos/reservation-worker/internal/client"
+)
+
+func TestConnectionConfig(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           client.ConnectionConfig
+		wantTime      time.Duration
+		wantTimeout   time.Duration
+		wantIdlePings bool
+		wantBaseDelay time.Duration
+		wantMaxDelay  time.Duration
+	}{
+		{"defaults", client.ConnectionConfig{}, client.DefaultKeepaliveTime, client.DefaultKeepaliveTimeout, false, client.DefaultReconnectBase, client.DefaultReconnectMax},
+		{"configured", client.ConnectionConfig{
+			KeepaliveTime:          30 * time.Second,
+			KeepaliveTimeout:       5 * time.Second,
+			KeepaliveWithoutStream: true,
+			ReconnectBase:          50 * time.Millisecond,
+			ReconnectMax:           2 * time.Second,
+		}, 30 * time.Second, 5 * time.Second, true, 50 * time.Millisecond, 2 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kp := tt.cfg.KeepaliveParams()
+			if kp.Time != tt.wantTime || kp.Timeout != tt.wantTimeout {
+				t.Errorf("Expected keepalive %v/%v, got %v/%v", tt.wantTime, tt.wantTimeout, kp.Time, kp.Timeout)
+			}
+			if kp.PermitWithoutStream != tt.wantIdlePings {
+				t.Errorf("Expected keepalive pings without streams = %v, got %v", tt.wantIdlePings, kp.PermitWithoutStream)
+			}
+
+			cp := tt.cfg.ConnectParams()
+			if cp.Backoff.BaseDelay != tt.wantBaseDelay || cp.Backoff.MaxDelay != tt.wantMaxDelay {
+				t.Errorf("Expected backoff %v..%v, got %v..%v", tt.wantBaseDelay, tt.wantMaxDelay, cp.Backoff.BaseDelay, cp.Backoff.MaxDelay)
+			}
+		})
+	}
+}
+
+func TestConnectionConfig_LoadBalancing(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     string
+		addr       string
+		wantPolicy string
+		wantTarget string
+	}{
+		{"default round robin over DNS", "", "inventory-svc:8021", client.LBPolicyRoundRobin, "dns:///inventory-svc:8021"},
+		{"pick first", client.LBPolicyPickFirst, "inventory-svc:8021", client.LBPolicyPickFirst, "dns:///inventory-svc:8021"},
+		{"explicit scheme kept", "", "dns:///inventory-headless.default.svc:8021", client.LBPolicyRoundRobin, "dns:///inventory-headless.default.svc:8021"},
+		{"passthrough kept", "", "passthrough:///10.0.0.1:8021", client.LBPolicyRoundRobin, "passthrough:///10.0.0.1:8021"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := client.ConnectionConfig{LBPolicy: tt.policy}
+
+			var sc struct {
+				LoadBalancingConfig []map[string]json.RawMessage `json:"loadBalancingConfig"`
+			}
+			if err := json.Unmarshal([]byte(cfg.ServiceConfig()), &sc); err != nil {
+				t.Fatalf("ServiceConfig() is not valid JSON: %v", err)
+			}
+			if len(sc.LoadBalancingConfig) != 1 {
+				t.Fatalf("Expected one load balancing config, got %v", sc.LoadBalancingConfig)
+			}
+			if _, ok := sc.LoadBalancingConfig[0][tt.wantPolicy]; !ok {
+				t.Errorf("Expected policy %s, got %v", tt.wantPolicy, cfg.ServiceConfig())
+			}
+
+			if got := cfg.Target(tt.addr); got != tt.wantTarget {
+				t.Errorf("Expected target %s, got %s", tt.wantTarget, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"context"
+
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/connectivity"
+)
+
+// ConnStateSource is the part of a gRPC client connection WatchConnState observes
+type ConnStateSource interface {
+	GetState() connectivity.State
+	WaitForStateChange(ctx context.Context, sourceState connectivity.State) bool
+}
+
+// WatchConnState logs each state transition of conn and reports it in
+// inventory_grpc_conn_state until ctx ends. logger and metrics may be nil.
+func WatchConnState(ctx context.Context, conn ConnStateSource, logger *zap.Logger, metrics *observability.Metrics) {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	state := conn.GetState()
+	if metrics != nil {
+		metrics.SetInventoryConnState(state.String())
+	}
+	for {
+		previous := state
+		if !conn.WaitForStateChange(ctx, previous) {
+			return
+		}
+		state = conn.GetState()
+
+		if metrics != nil {
+			metrics.SetInventoryConnState(state.String())
+		}
+		fields := []zap.Field{zap.String("from", previous.String()), zap.String("to", state.String())}
+		if state == connectivity.TransientFailure {
+			logger.Warn("Inventory gRPC connection failed", fields...)
+		} else {
+			logger.Info("Inventory gRPC connection state changed", fields...)
+		}
+	}
+}
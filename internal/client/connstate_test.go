@@ -0,0 +1,92 @@
+package client_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/traffic-tacos/reservation-worker/internal/client"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"google.golang.org/grpc/connectivity"
+)
+
+// fakeConn is a connection whose state the test sets
+type fakeConn struct {
+	mu      sync.Mutex
+	state   connectivity.State
+	changed chan struct{} // Closed and replaced on every state change
+}
+
+func newFakeConn(state connectivity.State) *fakeConn {
+	return &fakeConn{state: state, changed: make(chan struct{})}
+}
+
+func (c *fakeConn) set(state connectivity.State) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.state = state
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+func (c *fakeConn) GetState() connectivity.State {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+func (c *fakeConn) WaitForStateChange(ctx context.Context, source connectivity.State) bool {
+	for {
+		c.mu.Lock()
+		state, changed := c.state, c.changed
+		c.mu.Unlock()
+		if state != source {
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-changed:
+		}
+	}
+}
+
+func TestWatchConnState(t *testing.T) {
+	metrics := observability.NewMetrics(observability.WithRegistry(prometheus.NewRegistry()))
+	defer metrics.Unregister()
+
+	conn := newFakeConn(connectivity.Idle)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go client.WatchConnState(ctx, conn, nil, metrics)
+
+	states := []connectivity.State{
+		connectivity.Idle,
+		connectivity.Connecting,
+		connectivity.Ready,
+		connectivity.TransientFailure,
+		connectivity.Connecting,
+		connectivity.Ready,
+	}
+	for i, want := range states {
+		if i > 0 {
+			conn.set(want)
+		}
+
+		deadline := time.Now().Add(time.Second)
+		for testutil.ToFloat64(metrics.InventoryConnState.WithLabelValues(want.String())) != 1 {
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected inventory_grpc_conn_state{state=%q} to become 1", want)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		for _, other := range states {
+			if other != want && testutil.ToFloat64(metrics.InventoryConnState.WithLabelValues(other.String())) != 0 {
+				t.Errorf("After moving to %s, expected state %s to be 0", want, other)
+			}
+		}
+	}
+}
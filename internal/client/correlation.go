@@ -0,0 +1,34 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// correlationTransport sets the correlation header from the request context's processing ID
+type correlationTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip adds the correlation header when a processing ID is present
+func (t *correlationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if id := observability.ProcessingID(req.Context()); id != "" {
+		// RoundTrippers must not modify the caller's request
+		req = req.Clone(req.Context())
+		req.Header.Set(observability.CorrelationHeader, id)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// correlationUnaryInterceptor adds the processing ID to outgoing gRPC metadata
+func correlationUnaryInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if id := observability.ProcessingID(ctx); id != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, strings.ToLower(observability.CorrelationHeader), id)
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
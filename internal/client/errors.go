@@ -0,0 +1,167 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorCategory classifies a downstream error for retry decisions and metrics
+type ErrorCategory string
+
+// Error categories, used as metric label values.
+// A nil error is classified as observability.CategoryNone.
+const (
+	CategoryNotFound           ErrorCategory = "not_found"
+	CategoryInvalidArgument    ErrorCategory = "invalid_argument"
+	CategoryFailedPrecondition ErrorCategory = "failed_precondition"
+	CategoryAlreadyExists      ErrorCategory = "already_exists"
+	CategoryPermissionDenied   ErrorCategory = "permission_denied"
+	CategoryUnauthenticated    ErrorCategory = "unauthenticated"
+	CategoryUnimplemented      ErrorCategory = "unimplemented"
+	CategoryUnavailable        ErrorCategory = "unavailable"
+	CategoryDeadlineExceeded   ErrorCategory = "deadline_exceeded"
+	CategoryResourceExhausted  ErrorCategory = "resource_exhausted"
+	CategoryAborted            ErrorCategory = "aborted"
+	CategoryCanceled           ErrorCategory = "canceled"
+	CategoryInternal           ErrorCategory = "internal"
+	CategoryUnknown            ErrorCategory = "unknown"
+	CategoryIllegalTransition  ErrorCategory = "illegal_transition"
+)
+
+// Retryable reports whether an error of this category may succeed on retry
+func (c ErrorCategory) Retryable() bool {
+	switch c {
+	case CategoryNotFound, CategoryInvalidArgument, CategoryFailedPrecondition, CategoryAlreadyExists,
+		CategoryPermissionDenied, CategoryUnauthenticated, CategoryUnimplemented, CategoryIllegalTransition:
+		return false
+	default:
+		return true
+	}
+}
+
+// HTTPStatusError is returned by the reservation client for non-2xx responses
+type HTTPStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d: %s", e.StatusCode, e.Body)
+}
+
+// IsConflict reports whether err is a rejected conditional update (HTTP 409 or 412)
+func IsConflict(err error) bool {
+	var httpErr *HTTPStatusError
+	if !errors.As(err, &httpErr) {
+		return false
+	}
+	return httpErr.StatusCode == http.StatusConflict || httpErr.StatusCode == http.StatusPreconditionFailed
+}
+
+// Classify maps an error from a downstream call to its category.
+// Errors without a gRPC or HTTP status are treated as unknown and retried.
+func Classify(err error) ErrorCategory {
+	if err == nil {
+		return ErrorCategory(observability.CategoryNone)
+	}
+
+	var transitionErr *IllegalTransitionError
+	if errors.As(err, &transitionErr) {
+		return CategoryIllegalTransition
+	}
+
+	var httpErr *HTTPStatusError
+	if errors.As(err, &httpErr) {
+		return classifyHTTPStatus(httpErr.StatusCode)
+	}
+
+	if st, ok := status.FromError(err); ok {
+		return classifyGRPCCode(st.Code())
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return CategoryDeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		return CategoryCanceled
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return CategoryDeadlineExceeded
+		}
+		return CategoryUnavailable
+	}
+
+	return CategoryUnknown
+}
+
+func classifyGRPCCode(code codes.Code) ErrorCategory {
+	switch code {
+	case codes.OK:
+		return ErrorCategory(observability.CategoryNone)
+	case codes.NotFound:
+		return CategoryNotFound
+	case codes.InvalidArgument, codes.OutOfRange:
+		return CategoryInvalidArgument
+	case codes.FailedPrecondition:
+		return CategoryFailedPrecondition
+	case codes.AlreadyExists:
+		return CategoryAlreadyExists
+	case codes.PermissionDenied:
+		return CategoryPermissionDenied
+	case codes.Unauthenticated:
+		return CategoryUnauthenticated
+	case codes.Unimplemented:
+		return CategoryUnimplemented
+	case codes.Unavailable:
+		return CategoryUnavailable
+	case codes.DeadlineExceeded:
+		return CategoryDeadlineExceeded
+	case codes.ResourceExhausted:
+		return CategoryResourceExhausted
+	case codes.Aborted:
+		return CategoryAborted
+	case codes.Canceled:
+		return CategoryCanceled
+	case codes.Internal, codes.DataLoss:
+		return CategoryInternal
+	default:
+		return CategoryUnknown
+	}
+}
+
+func classifyHTTPStatus(code int) ErrorCategory {
+	switch {
+	case code == http.StatusNotFound:
+		return CategoryNotFound
+	case code == http.StatusUnauthorized:
+		return CategoryUnauthenticated
+	case code == http.StatusForbidden:
+		return CategoryPermissionDenied
+	case code == http.StatusConflict, code == http.StatusPreconditionFailed:
+		return CategoryFailedPrecondition
+	case code == http.StatusTooManyRequests:
+		return CategoryResourceExhausted
+	case code == http.StatusRequestTimeout, code == http.StatusGatewayTimeout:
+		return CategoryDeadlineExceeded
+	case code == http.StatusBadGateway, code == http.StatusServiceUnavailable:
+		return CategoryUnavailable
+	case code == http.StatusNotImplemented:
+		return CategoryUnimplemented
+	case code >= 500:
+		return CategoryInternal
+	case code >= 400:
+		return CategoryInvalidArgument
+	default:
+		return CategoryUnknown
+	}
+}
@@ -0,0 +1,56 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/traffic-tacos/reservation-worker/internal/client"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantCategory  client.ErrorCategory
+		wantRetryable bool
+	}{
+		{"nil", nil, client.ErrorCategory(observability.CategoryNone), true},
+		{"gRPC NotFound", status.Error(codes.NotFound, "hold not found"), client.CategoryNotFound, false},
+		{"wrapped gRPC NotFound", fmt.Errorf("failed to release hold: %w", status.Error(codes.NotFound, "gone")), client.CategoryNotFound, false},
+		{"gRPC Unavailable", status.Error(codes.Unavailable, "transport is closing"), client.CategoryUnavailable, true},
+		{"gRPC InvalidArgument", status.Error(codes.InvalidArgument, "bad seat"), client.CategoryInvalidArgument, false},
+		{"gRPC DeadlineExceeded", status.Error(codes.DeadlineExceeded, "timeout"), client.CategoryDeadlineExceeded, true},
+		{"gRPC FailedPrecondition", status.Error(codes.FailedPrecondition, "already sold"), client.CategoryFailedPrecondition, false},
+		{"gRPC ResourceExhausted", status.Error(codes.ResourceExhausted, "slow down"), client.CategoryResourceExhausted, true},
+		{"gRPC Internal", status.Error(codes.Internal, "boom"), client.CategoryInternal, true},
+		{"HTTP 404", &client.HTTPStatusError{StatusCode: http.StatusNotFound}, client.CategoryNotFound, false},
+		{"wrapped HTTP 400", fmt.Errorf("failed to update: %w", &client.HTTPStatusError{StatusCode: http.StatusBadRequest}), client.CategoryInvalidArgument, false},
+		{"HTTP 409", &client.HTTPStatusError{StatusCode: http.StatusConflict}, client.CategoryFailedPrecondition, false},
+		{"HTTP 429", &client.HTTPStatusError{StatusCode: http.StatusTooManyRequests}, client.CategoryResourceExhausted, true},
+		{"HTTP 503", &client.HTTPStatusError{StatusCode: http.StatusServiceUnavailable}, client.CategoryUnavailable, true},
+		{"HTTP 500", &client.HTTPStatusError{StatusCode: http.StatusInternalServerError}, client.CategoryInternal, true},
+		{"context deadline", fmt.Errorf("rate limiter wait: %w", context.DeadlineExceeded), client.CategoryDeadlineExceeded, true},
+		{"connection refused", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, client.CategoryUnavailable, true},
+		{"illegal transition", fmt.Errorf("guard: %w", client.CheckTransition("rsv_1", client.StatusExpired, client.StatusConfirmed)), client.CategoryIllegalTransition, false},
+		{"plain error", errors.New("failed to parse event detail"), client.CategoryUnknown, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := client.Classify(tt.err)
+			if got != tt.wantCategory {
+				t.Errorf("Classify() = %s, want %s", got, tt.wantCategory)
+			}
+			if got.Retryable() != tt.wantRetryable {
+				t.Errorf("%s.Retryable() = %v, want %v", got, got.Retryable(), tt.wantRetryable)
+			}
+		})
+	}
+}
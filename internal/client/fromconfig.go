@@ -0,0 +1,48 @@
+package client
+
+import (
+	"time"
+
+	"github.com/traffic-tacos/reservation-worker/internal/config"
+	"go.uber.org/zap"
+)
+
+// NewInventoryClientFromConfig creates the inventory client described by cfg, with extra options applied last
+func NewInventoryClientFromConfig(cfg *config.Config, extra ...Option) (*InventoryClient, error) {
+	opts := []Option{
+		WithHeaders(cfg.OutboundHeaders),
+		WithTLS(TLSConfig{
+			Enabled:    cfg.InventoryTLSEnabled,
+			CertFile:   cfg.InventoryTLSCertFile,
+			KeyFile:    cfg.InventoryTLSKeyFile,
+			CAFile:     cfg.InventoryTLSCAFile,
+			ServerName: cfg.InventoryTLSServerName,
+		}),
+		WithConnection(ConnectionConfig{
+			KeepaliveTime:          time.Duration(cfg.InventoryKeepaliveTimeSec) * time.Second,
+			KeepaliveTimeout:       time.Duration(cfg.InventoryKeepaliveTimeoutSec) * time.Second,
+			KeepaliveWithoutStream: cfg.InventoryKeepaliveWithoutStream,
+			ReconnectBase:          time.Duration(cfg.InventoryReconnectBaseMS) * time.Millisecond,
+			ReconnectMax:           time.Duration(cfg.InventoryReconnectMaxMS) * time.Millisecond,
+			LBPolicy:               cfg.InventoryLBPolicy,
+		}),
+	}
+	return NewInventoryClient(cfg.InventoryGRPCAddr, cfg.InventoryRPS, append(opts, extra...)...)
+}
+
+// NewReservationClientFromConfig creates the reservation API client described by cfg.
+// logger receives request and response bodies when cfg.LogHTTPBodies is set; extra options are applied last.
+func NewReservationClientFromConfig(cfg *config.Config, logger *zap.Logger, extra ...Option) *ReservationClient {
+	opts := []Option{
+		WithHeaders(cfg.OutboundHeaders),
+		WithPool(PoolConfig{
+			MaxIdleConns:        cfg.ReservationMaxIdleConns,
+			MaxIdleConnsPerHost: cfg.ReservationMaxIdleConnsPerHost,
+			IdleConnTimeout:     time.Duration(cfg.ReservationIdleConnTimeoutSec) * time.Second,
+		}),
+	}
+	if cfg.LogHTTPBodies {
+		opts = append(opts, WithBodyLogging(logger))
+	}
+	return NewReservationClient(cfg.ReservationAPIBase, cfg.ReservationRPS, append(opts, extra...)...)
+}
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// headerTransport sets the configured outbound headers on each HTTP request
+type headerTransport struct {
+	base    http.RoundTripper
+	options *clientOptions
+}
+
+// RoundTrip adds the outbound headers before delegating to the base transport
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	headers := t.options.outboundHeaders(req.Context())
+	if len(headers) == 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// headerUnaryInterceptor sends the configured outbound headers as gRPC metadata
+func headerUnaryInterceptor(options *clientOptions) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		headers := options.outboundHeaders(ctx)
+		if len(headers) > 0 {
+			pairs := make([]string, 0, len(headers)*2)
+			for k, v := range headers {
+				pairs = append(pairs, strings.ToLower(k), v)
+			}
+			ctx = metadata.AppendToOutgoingContext(ctx, pairs...)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
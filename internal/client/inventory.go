@@ -6,23 +6,50 @@ import (
 	"time"
 
 	"github.com/traffic-tacos/proto-contracts/gen/go/reservation/v1"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/metadata"
 )
 
+// idempotencyKeyMetadata repeats a request's idempotency key as gRPC metadata for inventory
+// deployments that dedupe on it instead of the request field
+const idempotencyKeyMetadata = "idempotency-key"
+
 // InventoryClient wraps gRPC client for inventory service
 type InventoryClient struct {
-	client reservationv1.InventoryServiceClient
-	conn   *grpc.ClientConn
+	client  reservationv1.InventoryServiceClient
+	conn    *grpc.ClientConn
+	limiter *rate.Limiter
+	metrics *observability.Metrics
+
+	// stopWatch ends the connection state watcher, if running
+	stopWatch context.CancelFunc
 }
 
-// NewInventoryClient creates a new inventory service client
-func NewInventoryClient(addr string) (*InventoryClient, error) {
+// NewInventoryClient creates a new inventory service client.
+// rps caps outbound calls per second; 0 disables the limit.
+func NewInventoryClient(addr string, rps int, opts ...Option) (*InventoryClient, error) {
+	options := newClientOptions(opts)
+
+	creds, err := TransportCredentials(options.tls)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure inventory TLS: %w", err)
+	}
+
 	// Create gRPC connection with OpenTelemetry instrumentation
-	conn, err := grpc.NewClient(addr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	conn, err := grpc.NewClient(options.conn.Target(addr),
+		grpc.WithTransportCredentials(creds),
+		grpc.WithKeepaliveParams(options.conn.KeepaliveParams()),
+		grpc.WithConnectParams(options.conn.ConnectParams()),
+		grpc.WithDefaultServiceConfig(options.conn.ServiceConfig()),
 		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+		grpc.WithChainUnaryInterceptor(
+			correlationUnaryInterceptor,
+			headerUnaryInterceptor(options),
+		),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to inventory service: %w", err)
@@ -30,24 +57,55 @@ func NewInventoryClient(addr string) (*InventoryClient, error) {
 
 	client := reservationv1.NewInventoryServiceClient(conn)
 
-	return &InventoryClient{
-		client: client,
-		conn:   conn,
-	}, nil
+	c := &InventoryClient{
+		client:    client,
+		conn:      conn,
+		limiter:   newRateLimiter(rps),
+		metrics:   options.metrics,
+		stopWatch: func() {},
+	}
+	if options.logger != nil || options.metrics != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		c.stopWatch = cancel
+		go WatchConnState(ctx, conn, options.logger, options.metrics)
+	}
+	return c, nil
 }
 
 // Close closes the gRPC connection
 func (c *InventoryClient) Close() error {
+	c.stopWatch()
 	return c.conn.Close()
 }
 
+// WaitReady blocks until the connection to the inventory service is ready or ctx ends.
+// The connection is otherwise established lazily on the first call.
+func (c *InventoryClient) WaitReady(ctx context.Context) error {
+	c.conn.Connect()
+	for {
+		state := c.conn.GetState()
+		if state == connectivity.Ready {
+			return nil
+		}
+		if !c.conn.WaitForStateChange(ctx, state) {
+			return fmt.Errorf("inventory connection %s: %w", state, ctx.Err())
+		}
+	}
+}
+
 // ReleaseHold releases held seats/inventory back to available pool
 func (c *InventoryClient) ReleaseHold(ctx context.Context, req *reservationv1.ReleaseHoldRequest) error {
+	if err := waitRateLimit(ctx, c.limiter); err != nil {
+		return err
+	}
+
 	// Set timeout for gRPC call
 	ctx, cancel := context.WithTimeout(ctx, 250*time.Millisecond)
 	defer cancel()
 
+	start := time.Now()
 	_, err := c.client.ReleaseHold(ctx, req)
+	observeCall(c.metrics, serviceInventory, operationReleaseHold, start, err)
 	if err != nil {
 		return fmt.Errorf("failed to release hold: %w", err)
 	}
@@ -55,13 +113,43 @@ func (c *InventoryClient) ReleaseHold(ctx context.Context, req *reservationv1.Re
 	return nil
 }
 
-// CommitReservation commits a reservation, marking seats as sold
+// ReserveSeat holds seats for a reservation
+func (c *InventoryClient) ReserveSeat(ctx context.Context, req *reservationv1.ReserveSeatRequest) error {
+	if err := waitRateLimit(ctx, c.limiter); err != nil {
+		return err
+	}
+
+	// Set timeout for gRPC call
+	ctx, cancel := context.WithTimeout(ctx, 250*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.client.ReserveSeat(ctx, req)
+	observeCall(c.metrics, serviceInventory, operationReserveSeat, start, err)
+	if err != nil {
+		return fmt.Errorf("failed to reserve seats: %w", err)
+	}
+
+	return nil
+}
+
+// CommitReservation commits a reservation, marking seats as sold. A set IdempotencyKey is
+// also sent as metadata so the inventory service can dedupe a retried commit.
 func (c *InventoryClient) CommitReservation(ctx context.Context, req *reservationv1.CommitReservationRequest) error {
+	if err := waitRateLimit(ctx, c.limiter); err != nil {
+		return err
+	}
+	if req.IdempotencyKey != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, idempotencyKeyMetadata, req.IdempotencyKey)
+	}
+
 	// Set timeout for gRPC call
 	ctx, cancel := context.WithTimeout(ctx, 250*time.Millisecond)
 	defer cancel()
 
+	start := time.Now()
 	_, err := c.client.CommitReservation(ctx, req)
+	observeCall(c.metrics, serviceInventory, operationCommitReservation, start, err)
 	if err != nil {
 		return fmt.Errorf("failed to commit reservation: %w", err)
 	}
@@ -0,0 +1,164 @@
+package client_test
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/traffic-tacos/proto-contracts/gen/go/reservation/v1"
+	"github.com/traffic-tacos/reservation-worker/internal/client"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// inventoryServer records the incoming metadata of each call
+type inventoryServer struct {
+	reservationv1.UnimplementedInventoryServiceServer
+	metadata chan metadata.MD
+}
+
+func (s *inventoryServer) ReleaseHold(ctx context.Context, _ *reservationv1.ReleaseHoldRequest) (*reservationv1.ReleaseHoldResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.metadata <- md
+	return &reservationv1.ReleaseHoldResponse{}, nil
+}
+
+func (s *inventoryServer) CommitReservation(ctx context.Context, _ *reservationv1.CommitReservationRequest) (*reservationv1.CommitReservationResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.metadata <- md
+	return &reservationv1.CommitReservationResponse{}, nil
+}
+
+// newInventoryServer starts a recording inventory server on a loopback port
+func newInventoryServer(t *testing.T) (*inventoryServer, string) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+
+	srv := &inventoryServer{metadata: make(chan metadata.MD, 1)}
+	grpcServer := grpc.NewServer()
+	reservationv1.RegisterInventoryServiceServer(grpcServer, srv)
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	return srv, lis.Addr().String()
+}
+
+func TestInventoryClient_CorrelationMetadata(t *testing.T) {
+	srv, addr := newInventoryServer(t)
+
+	c, err := client.NewInventoryClient(addr, 0)
+	if err != nil {
+		t.Fatalf("NewInventoryClient() error = %v", err)
+	}
+	defer c.Close()
+
+	ctx := observability.WithProcessingID(context.Background(), "proc-123")
+	if err := c.ReleaseHold(ctx, &reservationv1.ReleaseHoldRequest{ReservationId: "rsv-1"}); err != nil {
+		t.Fatalf("ReleaseHold() error = %v", err)
+	}
+
+	if got := (<-srv.metadata).Get(observability.CorrelationHeader); len(got) != 1 || got[0] != "proc-123" {
+		t.Errorf("Expected correlation metadata [proc-123], got %v", got)
+	}
+}
+
+func TestInventoryClient_OutboundMetadata(t *testing.T) {
+	srv, addr := newInventoryServer(t)
+
+	c, err := client.NewInventoryClient(addr, 0,
+		client.WithHeaders(map[string]string{"X-Internal-Auth": "secret"}),
+		client.WithRequestDecorator(func(ctx context.Context) map[string]string {
+			return map[string]string{"X-Tenant-ID": "acme"}
+		}),
+	)
+	if err != nil {
+		t.Fatalf("NewInventoryClient() error = %v", err)
+	}
+	defer c.Close()
+
+	if err := c.ReleaseHold(context.Background(), &reservationv1.ReleaseHoldRequest{ReservationId: "rsv-1"}); err != nil {
+		t.Fatalf("ReleaseHold() error = %v", err)
+	}
+
+	md := <-srv.metadata
+	for key, want := range map[string]string{"x-internal-auth": "secret", "x-tenant-id": "acme"} {
+		if got := md.Get(key); len(got) != 1 || got[0] != want {
+			t.Errorf("Expected metadata %s = [%s], got %v", key, want, got)
+		}
+	}
+}
+
+func TestInventoryClient_CommitIdempotencyKey(t *testing.T) {
+	srv, addr := newInventoryServer(t)
+
+	c, err := client.NewInventoryClient(addr, 0)
+	if err != nil {
+		t.Fatalf("NewInventoryClient() error = %v", err)
+	}
+	defer c.Close()
+
+	tests := []struct {
+		name string
+		key  string
+		want []string
+	}{
+		{"key sent as metadata", "commit:rsv-1:pay-1", []string{"commit:rsv-1:pay-1"}},
+		{"no key, no metadata", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &reservationv1.CommitReservationRequest{ReservationId: "rsv-1", IdempotencyKey: tt.key}
+			if err := c.CommitReservation(context.Background(), req); err != nil {
+				t.Fatalf("CommitReservation() error = %v", err)
+			}
+			if got := (<-srv.metadata).Get("idempotency-key"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected idempotency-key metadata %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestInventoryClient_RecordsDownstreamDuration(t *testing.T) {
+	srv, addr := newInventoryServer(t)
+
+	metrics := observability.NewMetrics(observability.WithRegistry(prometheus.NewRegistry()))
+	defer metrics.Unregister()
+	c, err := client.NewInventoryClient(addr, 0, client.WithMetrics(metrics))
+	if err != nil {
+		t.Fatalf("NewInventoryClient() error = %v", err)
+	}
+	defer c.Close()
+
+	if err := c.ReleaseHold(context.Background(), &reservationv1.ReleaseHoldRequest{ReservationId: "rsv-1"}); err != nil {
+		t.Fatalf("ReleaseHold() error = %v", err)
+	}
+	<-srv.metadata
+
+	if got := downstreamSamples(t, metrics, "inventory", "release_hold", "success"); got != 1 {
+		t.Errorf("Expected one release_hold sample, got %d", got)
+	}
+}
+
+func TestInventoryClient_WaitReady(t *testing.T) {
+	_, addr := newInventoryServer(t)
+
+	c, err := client.NewInventoryClient(addr, 0)
+	if err != nil {
+		t.Fatalf("NewInventoryClient() error = %v", err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.WaitReady(ctx); err != nil {
+		t.Errorf("WaitReady() error = %v", err)
+	}
+}
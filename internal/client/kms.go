@@ -0,0 +1,88 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// KMSClient calls the KMS Decrypt API over its JSON protocol, signing requests
+// with the credentials of the AWS config
+type KMSClient struct {
+	endpoint    string
+	region      string
+	credentials aws.CredentialsProvider
+	signer      *v4.Signer
+	httpClient  *http.Client
+}
+
+// NewKMSClient creates a KMS client for the region and credentials of cfg
+func NewKMSClient(cfg aws.Config) *KMSClient {
+	endpoint := fmt.Sprintf("https://kms.%s.amazonaws.com", cfg.Region)
+	if cfg.BaseEndpoint != nil {
+		endpoint = *cfg.BaseEndpoint
+	}
+
+	return &KMSClient{
+		endpoint:    endpoint,
+		region:      cfg.Region,
+		credentials: cfg.Credentials,
+		signer:      v4.NewSigner(),
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Decrypt decrypts ciphertext that was encrypted under keyID
+func (c *KMSClient) Decrypt(ctx context.Context, keyID string, ciphertext []byte) ([]byte, error) {
+	payload, err := json.Marshal(struct {
+		CiphertextBlob []byte
+		KeyId          string
+	}{ciphertext, keyID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.endpoint+"/", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	httpReq.Header.Set("X-Amz-Target", "TrentService.Decrypt")
+
+	creds, err := c.credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+	payloadHash := sha256.Sum256(payload)
+	if err := c.signer.SignHTTP(ctx, creds, httpReq, hex.EncodeToString(payloadHash[:]), "kms", c.region, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &HTTPStatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var result struct {
+		Plaintext []byte
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return result.Plaintext, nil
+}
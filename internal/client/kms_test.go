@@ -0,0 +1,58 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/traffic-tacos/reservation-worker/internal/client"
+)
+
+func TestKMSClient_Decrypt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Amz-Target"); got != "TrentService.Decrypt" {
+			t.Errorf("Expected X-Amz-Target TrentService.Decrypt, got %q", got)
+		}
+		if got := r.Header.Get("Authorization"); !strings.Contains(got, "/ap-northeast-2/kms/aws4_request") {
+			t.Errorf("Expected a SigV4 signature for kms, got %q", got)
+		}
+
+		var req struct {
+			CiphertextBlob []byte
+			KeyId          string
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		if req.KeyId != "alias/events" || string(req.CiphertextBlob) != "sealed" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"InvalidCiphertextException"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string][]byte{"Plaintext": []byte("opened")})
+	}))
+	defer server.Close()
+
+	c := client.NewKMSClient(aws.Config{
+		Region:       "ap-northeast-2",
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		BaseEndpoint: aws.String(server.URL),
+	})
+
+	plaintext, err := c.Decrypt(context.Background(), "alias/events", []byte("sealed"))
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	if string(plaintext) != "opened" {
+		t.Errorf("Expected plaintext %q, got %q", "opened", plaintext)
+	}
+
+	if _, err := c.Decrypt(context.Background(), "alias/events", []byte("tampered")); err == nil {
+		t.Error("Expected an error for a rejected ciphertext")
+	}
+}
@@ -0,0 +1,33 @@
+package client
+
+import (
+	"time"
+
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+)
+
+// Downstream services and their operations, as metric label values
+const (
+	serviceInventory   = "inventory"
+	serviceReservation = "reservation"
+
+	operationReleaseHold       = "release_hold"
+	operationCommitReservation = "commit_reservation"
+	operationReserveSeat       = "reserve_seat"
+	operationUpdateStatus      = "update_status"
+	operationUpdateStatusBatch = "update_status_batch"
+	operationGetReservation    = "get_reservation"
+)
+
+// observeCall records the latency of a downstream call that started at start.
+// The outcome is success or the category of err.
+func observeCall(metrics *observability.Metrics, service, operation string, start time.Time, err error) {
+	if metrics == nil {
+		return
+	}
+	outcome := observability.OutcomeSuccess
+	if err != nil {
+		outcome = string(Classify(err))
+	}
+	metrics.RecordDownstreamDuration(service, operation, outcome, time.Since(start).Seconds())
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"go.uber.org/zap"
+)
+
+// RequestDecorator returns extra headers to send with a single outbound call.
+// Reservation calls send them as HTTP headers, inventory calls as gRPC metadata.
+type RequestDecorator func(ctx context.Context) map[string]string
+
+// Option configures a downstream client
+type Option func(*clientOptions)
+
+type clientOptions struct {
+	headers   map[string]string
+	decorator RequestDecorator
+	tls       TLSConfig
+	conn      ConnectionConfig
+	pool      PoolConfig
+	bodyLog   *zap.Logger
+	metrics   *observability.Metrics
+	logger    *zap.Logger
+}
+
+// WithHeaders sends the given headers on every call
+func WithHeaders(headers map[string]string) Option {
+	return func(o *clientOptions) {
+		o.headers = headers
+	}
+}
+
+// WithRequestDecorator adds per-call headers computed from the request context
+func WithRequestDecorator(decorator RequestDecorator) Option {
+	return func(o *clientOptions) {
+		o.decorator = decorator
+	}
+}
+
+// WithTLS secures the inventory gRPC connection
+func WithTLS(cfg TLSConfig) Option {
+	return func(o *clientOptions) {
+		o.tls = cfg
+	}
+}
+
+// WithConnection tunes keepalive and reconnect backoff of the inventory connection
+func WithConnection(cfg ConnectionConfig) Option {
+	return func(o *clientOptions) {
+		o.conn = cfg
+	}
+}
+
+// WithPool sizes the reservation client's HTTP connection pool
+func WithPool(cfg PoolConfig) Option {
+	return func(o *clientOptions) {
+		o.pool = cfg
+	}
+}
+
+// WithBodyLogging logs reservation request and response bodies, redacted, while logger is at debug level
+func WithBodyLogging(logger *zap.Logger) Option {
+	return func(o *clientOptions) {
+		o.bodyLog = logger
+	}
+}
+
+// WithMetrics records the latency of every call in worker_downstream_duration_seconds
+// and the inventory connection state in inventory_grpc_conn_state
+func WithMetrics(metrics *observability.Metrics) Option {
+	return func(o *clientOptions) {
+		o.metrics = metrics
+	}
+}
+
+// WithLogger logs inventory connection state transitions
+func WithLogger(logger *zap.Logger) Option {
+	return func(o *clientOptions) {
+		o.logger = logger
+	}
+}
+
+func newClientOptions(opts []Option) *clientOptions {
+	o := &clientOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// outboundHeaders merges static and decorated headers; decorated values win
+func (o *clientOptions) outboundHeaders(ctx context.Context) map[string]string {
+	if o.decorator == nil {
+		return o.headers
+	}
+
+	extra := o.decorator(ctx)
+	if len(o.headers) == 0 {
+		return extra
+	}
+
+	merged := make(map[string]string, len(o.headers)+len(extra))
+	for k, v := range o.headers {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	return merged
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"net/http"
+	"time"
+)
+
+// Pool defaults keep enough idle connections to the reservation API that a
+// full worker pool reuses them instead of dialing per request
+const (
+	DefaultMaxIdleConns        = 100
+	DefaultMaxIdleConnsPerHost = 100
+	DefaultIdleConnTimeout     = 90 * time.Second
+)
+
+// PoolConfig sizes the reservation client's HTTP connection pool.
+// Zero values fall back to the defaults.
+type PoolConfig struct {
+	MaxIdleConns        int           // Idle connections kept across all hosts
+	MaxIdleConnsPerHost int           // Idle connections kept per host
+	IdleConnTimeout     time.Duration // Close idle connections after this long
+}
+
+// Transport returns an HTTP transport with the default transport's settings and this pool size
+func (c PoolConfig) Transport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = orDefaultInt(c.MaxIdleConns, DefaultMaxIdleConns)
+	transport.MaxIdleConnsPerHost = orDefaultInt(c.MaxIdleConnsPerHost, DefaultMaxIdleConnsPerHost)
+	transport.IdleConnTimeout = orDefault(c.IdleConnTimeout, DefaultIdleConnTimeout)
+	return transport
+}
+
+func orDefaultInt(n, fallback int) int {
+	if n <= 0 {
+		return fallback
+	}
+	return n
+}
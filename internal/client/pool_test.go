@@ -0,0 +1,43 @@
+package client_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/traffic-tacos/reservation-worker/internal/client"
+)
+
+func TestPoolConfig_Transport(t *testing.T) {
+	tests := []struct {
+		name            string
+		cfg             client.PoolConfig
+		wantIdle        int
+		wantIdlePerHost int
+		wantTimeout     time.Duration
+	}{
+		{"defaults", client.PoolConfig{}, client.DefaultMaxIdleConns, client.DefaultMaxIdleConnsPerHost, client.DefaultIdleConnTimeout},
+		{"configured", client.PoolConfig{MaxIdleConns: 256, MaxIdleConnsPerHost: 64, IdleConnTimeout: 30 * time.Second}, 256, 64, 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := tt.cfg.Transport()
+			if transport.MaxIdleConns != tt.wantIdle {
+				t.Errorf("MaxIdleConns = %d, want %d", transport.MaxIdleConns, tt.wantIdle)
+			}
+			if transport.MaxIdleConnsPerHost != tt.wantIdlePerHost {
+				t.Errorf("MaxIdleConnsPerHost = %d, want %d", transport.MaxIdleConnsPerHost, tt.wantIdlePerHost)
+			}
+			if transport.IdleConnTimeout != tt.wantTimeout {
+				t.Errorf("IdleConnTimeout = %v, want %v", transport.IdleConnTimeout, tt.wantTimeout)
+			}
+			if transport.Proxy == nil {
+				t.Error("Expected proxy settings to be inherited from the default transport")
+			}
+			if transport == http.DefaultTransport {
+				t.Error("Expected a dedicated transport, got http.DefaultTransport")
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/time/rate"
+)
+
+// newRateLimiter returns a token bucket allowing rps calls per second, or nil for no limit
+func newRateLimiter(rps int) *rate.Limiter {
+	if rps <= 0 {
+		return nil
+	}
+	// A burst of one paces calls evenly instead of letting a backlog through at once
+	return rate.NewLimiter(rate.Limit(rps), 1)
+}
+
+// waitRateLimit blocks until the limiter admits a call or the context is done
+func waitRateLimit(ctx context.Context, limiter *rate.Limiter) error {
+	if limiter == nil {
+		return nil
+	}
+	if err := limiter.Wait(ctx); err != nil {
+		return fmt.Errorf("rate limiter wait: %w", err)
+	}
+	return nil
+}
@@ -7,28 +7,54 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"golang.org/x/time/rate"
 )
 
 // ReservationClient wraps HTTP client for reservation API
 type ReservationClient struct {
 	baseURL    string
 	httpClient *http.Client
+	limiter    *rate.Limiter
+	metrics    *observability.Metrics
+
+	batchUnsupported atomic.Bool // Set once the bulk status endpoint answered 404
 }
 
-// NewReservationClient creates a new reservation API client
-func NewReservationClient(baseURL string) *ReservationClient {
+// NewReservationClient creates a new reservation API client.
+// rps caps outbound calls per second; 0 disables the limit.
+func NewReservationClient(baseURL string, rps int, opts ...Option) *ReservationClient {
+	options := newClientOptions(opts)
+
+	var transport http.RoundTripper = options.pool.Transport()
+	if options.bodyLog != nil {
+		transport = &bodyLogTransport{base: transport, logger: options.bodyLog}
+	}
+	transport = &correlationTransport{base: transport}
+	transport = &headerTransport{base: transport, options: options}
+
 	return &ReservationClient{
 		baseURL: baseURL,
+		limiter: newRateLimiter(rps),
+		metrics: options.metrics,
 		httpClient: &http.Client{
 			Timeout:   30 * time.Second,
-			Transport: otelhttp.NewTransport(http.DefaultTransport),
+			Transport: otelhttp.NewTransport(transport),
 		},
 	}
 }
 
+// Close releases idle keep-alive connections
+func (c *ReservationClient) Close() {
+	c.httpClient.CloseIdleConnections()
+}
+
 // UpdateReservationStatus updates the status of a reservation
 func (c *ReservationClient) UpdateReservationStatus(ctx context.Context, req *UpdateStatusRequest) error {
 	url := fmt.Sprintf("%s/internal/reservations/%s", c.baseURL, req.ReservationID)
@@ -41,6 +67,10 @@ func (c *ReservationClient) UpdateReservationStatus(ctx context.Context, req *Up
 		payload["order_id"] = req.OrderID
 	}
 
+	if req.ExpectedStatus != "" {
+		payload["expected_status"] = req.ExpectedStatus
+	}
+
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("failed to marshal request: %w", err)
@@ -52,17 +82,19 @@ func (c *ReservationClient) UpdateReservationStatus(ctx context.Context, req *Up
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
+	if req.ExpectedVersion != "" {
+		httpReq.Header.Set("If-Match", entityTag(req.ExpectedVersion))
+	}
 
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return fmt.Errorf("failed to execute request: %w", err)
+	if err := waitRateLimit(ctx, c.limiter); err != nil {
+		return err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+	resp, err := c.send(httpReq, operationUpdateStatus)
+	if err != nil {
+		return err
 	}
+	resp.Body.Close()
 
 	return nil
 }
@@ -76,30 +108,61 @@ func (c *ReservationClient) GetReservation(ctx context.Context, reservationID st
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+	if err := waitRateLimit(ctx, c.limiter); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+	resp, err := c.send(httpReq, operationGetReservation)
+	if err != nil {
+		return nil, err
 	}
+	defer resp.Body.Close()
 
 	var details ReservationDetails
 	if err := json.NewDecoder(resp.Body).Decode(&details); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
+	if details.Version == "" {
+		details.Version = resp.Header.Get("ETag")
+	}
+
 	return &details, nil
 }
 
+// send executes httpReq and records its latency under operation.
+// Non-2xx responses are returned as *HTTPStatusError, with their body consumed.
+func (c *ReservationClient) send(httpReq *http.Request, operation string) (*http.Response, error) {
+	start := time.Now()
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		err = fmt.Errorf("failed to execute request: %w", err)
+	} else if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp, err = nil, &HTTPStatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	observeCall(c.metrics, serviceReservation, operation, start, err)
+	return resp, err
+}
+
+// entityTag quotes a version for use in If-Match unless it already is an entity tag
+func entityTag(version string) string {
+	if strings.HasPrefix(version, `"`) || strings.HasPrefix(version, `W/"`) {
+		return version
+	}
+	return strconv.Quote(version)
+}
+
 // UpdateStatusRequest represents a request to update reservation status
 type UpdateStatusRequest struct {
 	ReservationID string
 	Status        string // CONFIRMED, CANCELLED, EXPIRED
 	OrderID       string // Optional, for CONFIRMED status
+
+	// Optimistic concurrency: the API rejects the update with 409/412 if the reservation moved on
+	ExpectedStatus  string // Sent as expected_status
+	ExpectedVersion string // Sent as If-Match
 }
 
 // ReservationDetails represents reservation information
@@ -108,6 +171,7 @@ type ReservationDetails struct {
 	EventID       string    `json:"event_id"`
 	UserID        string    `json:"user_id"`
 	Status        string    `json:"status"`
+	Version       string    `json:"version,omitempty"` // Falls back to the ETag header
 	SeatIDs       []string  `json:"seat_ids"`
 	Quantity      int       `json:"quantity"`
 	TotalPrice    int64     `json:"total_price"`
@@ -0,0 +1,262 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/traffic-tacos/reservation-worker/internal/client"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+)
+
+func newReservationServer(t *testing.T, calls *atomic.Int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.Method == http.MethodGet {
+			json.NewEncoder(w).Encode(client.ReservationDetails{ID: "rsv-1", Status: client.StatusHold})
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestReservationClient_RateLimit(t *testing.T) {
+	var calls atomic.Int32
+	server := newReservationServer(t, &calls)
+
+	const rps = 20
+	c := client.NewReservationClient(server.URL, rps)
+
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		if err := c.UpdateReservationStatus(context.Background(), &client.UpdateStatusRequest{
+			ReservationID: "rsv-1",
+			Status:        client.StatusConfirmed,
+		}); err != nil {
+			t.Fatalf("UpdateReservationStatus() error = %v", err)
+		}
+	}
+	elapsed := time.Since(start)
+
+	// The first call is admitted immediately, the remaining nine wait 50ms each
+	if min := 9 * time.Second / rps; elapsed < min-10*time.Millisecond {
+		t.Errorf("Expected burst paced to %d rps (>= %v), took %v", rps, min, elapsed)
+	}
+	if got := calls.Load(); got != 10 {
+		t.Errorf("Expected 10 calls to reach the server, got %d", got)
+	}
+}
+
+func TestReservationClient_NoRateLimit(t *testing.T) {
+	var calls atomic.Int32
+	server := newReservationServer(t, &calls)
+
+	c := client.NewReservationClient(server.URL, 0)
+
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		if _, err := c.GetReservation(context.Background(), "rsv-1"); err != nil {
+			t.Fatalf("GetReservation() error = %v", err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Errorf("Expected unlimited client to not pace calls, took %v", elapsed)
+	}
+}
+
+func TestReservationClient_RateLimitRespectsContext(t *testing.T) {
+	var calls atomic.Int32
+	server := newReservationServer(t, &calls)
+
+	c := client.NewReservationClient(server.URL, 1)
+
+	// Use up the only token
+	if _, err := c.GetReservation(context.Background(), "rsv-1"); err != nil {
+		t.Fatalf("GetReservation() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := c.GetReservation(ctx, "rsv-1")
+	if err == nil {
+		t.Fatal("Expected error when context expires while waiting on the limiter")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("Expected blocked call to never reach the server, got %d calls", got)
+	}
+}
+
+func TestReservationClient_CorrelationHeader(t *testing.T) {
+	var got atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.Store(r.Header.Get(observability.CorrelationHeader))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c := client.NewReservationClient(server.URL, 0)
+
+	ctx := observability.WithProcessingID(context.Background(), "proc-123")
+	if err := c.UpdateReservationStatus(ctx, &client.UpdateStatusRequest{
+		ReservationID: "rsv-1",
+		Status:        client.StatusConfirmed,
+	}); err != nil {
+		t.Fatalf("UpdateReservationStatus() error = %v", err)
+	}
+
+	if got.Load() != "proc-123" {
+		t.Errorf("Expected %s header proc-123, got %v", observability.CorrelationHeader, got.Load())
+	}
+}
+
+func TestReservationClient_OutboundHeaders(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Clone()
+		json.NewEncoder(w).Encode(client.ReservationDetails{ID: "rsv-1", Status: client.StatusHold})
+	}))
+	defer server.Close()
+
+	c := client.NewReservationClient(server.URL, 0,
+		client.WithHeaders(map[string]string{"X-Internal-Auth": "secret", "X-Tenant-ID": "default"}),
+		client.WithRequestDecorator(func(ctx context.Context) map[string]string {
+			return map[string]string{"X-Tenant-ID": "acme"}
+		}),
+	)
+
+	if _, err := c.GetReservation(context.Background(), "rsv-1"); err != nil {
+		t.Fatalf("GetReservation() error = %v", err)
+	}
+
+	got := <-headers
+	if v := got.Get("X-Internal-Auth"); v != "secret" {
+		t.Errorf("Expected static X-Internal-Auth header, got %q", v)
+	}
+	if v := got.Get("X-Tenant-ID"); v != "acme" {
+		t.Errorf("Expected decorator to override X-Tenant-ID, got %q", v)
+	}
+}
+
+func TestReservationClient_ConditionalUpdate(t *testing.T) {
+	var gotIfMatch atomic.Value
+	var gotExpected atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Header().Set("ETag", `"v2"`)
+			json.NewEncoder(w).Encode(client.ReservationDetails{ID: "rsv-1", Status: client.StatusHold})
+			return
+		}
+
+		var payload map[string]string
+		json.NewDecoder(r.Body).Decode(&payload)
+		gotIfMatch.Store(r.Header.Get("If-Match"))
+		gotExpected.Store(payload["expected_status"])
+
+		if match := r.Header.Get("If-Match"); match != "" && match != `"v2"` {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	c := client.NewReservationClient(server.URL, 0)
+
+	details, err := c.GetReservation(context.Background(), "rsv-1")
+	if err != nil {
+		t.Fatalf("GetReservation() error = %v", err)
+	}
+	if details.Version != `"v2"` {
+		t.Errorf("Version = %q, want the ETag %q", details.Version, `"v2"`)
+	}
+
+	tests := []struct {
+		name         string
+		version      string
+		wantIfMatch  string
+		wantConflict bool
+	}{
+		{"unconditional", "", "", false},
+		{"bare version is quoted", "v2", `"v2"`, false},
+		{"entity tag is sent as is", `"v2"`, `"v2"`, false},
+		{"stale version", "v1", `"v1"`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.UpdateReservationStatus(context.Background(), &client.UpdateStatusRequest{
+				ReservationID:   "rsv-1",
+				Status:          client.StatusConfirmed,
+				ExpectedStatus:  client.StatusHold,
+				ExpectedVersion: tt.version,
+			})
+			if client.IsConflict(err) != tt.wantConflict {
+				t.Fatalf("UpdateReservationStatus() error = %v, wantConflict %v", err, tt.wantConflict)
+			}
+			if !tt.wantConflict && err != nil {
+				t.Fatalf("UpdateReservationStatus() error = %v", err)
+			}
+			if got := gotIfMatch.Load(); got != tt.wantIfMatch {
+				t.Errorf("If-Match = %q, want %q", got, tt.wantIfMatch)
+			}
+			if got := gotExpected.Load(); got != client.StatusHold {
+				t.Errorf("expected_status = %q, want %q", got, client.StatusHold)
+			}
+		})
+	}
+}
+
+// downstreamSamples returns how many calls metrics recorded for the service, operation and outcome
+func downstreamSamples(t *testing.T, metrics *observability.Metrics, service, operation, outcome string) uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := metrics.DownstreamDuration.WithLabelValues(service, operation, outcome).(prometheus.Histogram).Write(&m); err != nil {
+		t.Fatalf("failed to read histogram: %v", err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestReservationClient_RecordsDownstreamDuration(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		wantOutcome string
+	}{
+		{"success", http.StatusOK, "success"},
+		{"not found", http.StatusNotFound, string(client.CategoryNotFound)},
+		{"server error", http.StatusServiceUnavailable, string(client.CategoryUnavailable)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			metrics := observability.NewMetrics(observability.WithRegistry(prometheus.NewRegistry()))
+			defer metrics.Unregister()
+			c := client.NewReservationClient(server.URL, 0, client.WithMetrics(metrics))
+
+			c.UpdateReservationStatus(context.Background(), &client.UpdateStatusRequest{
+				ReservationID: "rsv-1",
+				Status:        client.StatusConfirmed,
+			})
+
+			if got := downstreamSamples(t, metrics, "reservation", "update_status", tt.wantOutcome); got != 1 {
+				t.Errorf("Expected one update_status sample with outcome %s, got %d", tt.wantOutcome, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package client_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/traffic-tacos/reservation-worker/internal/client"
+)
+
+// writeTestCert writes a self-signed certificate and key, returning their paths
+func writeTestCert(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "reservation-worker"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return certFile, keyFile
+}
+
+func TestTransportCredentials(t *testing.T) {
+	certFile, keyFile := writeTestCert(t)
+
+	tests := []struct {
+		name         string
+		cfg          client.TLSConfig
+		wantProtocol string
+		wantServer   string
+		wantErr      bool
+	}{
+		{"disabled is insecure", client.TLSConfig{CertFile: certFile, KeyFile: keyFile}, "insecure", "", false},
+		{"server TLS with system roots", client.TLSConfig{Enabled: true}, "tls", "", false},
+		{"mTLS with CA and server name", client.TLSConfig{
+			Enabled: true, CertFile: certFile, KeyFile: keyFile, CAFile: certFile, ServerName: "inventory.mesh.local",
+		}, "tls", "inventory.mesh.local", false},
+		{"cert without key", client.TLSConfig{Enabled: true, CertFile: certFile}, "", "", true},
+		{"missing CA file", client.TLSConfig{Enabled: true, CAFile: filepath.Join(t.TempDir(), "missing.pem")}, "", "", true},
+		{"CA file without certificates", client.TLSConfig{Enabled: true, CAFile: keyFile}, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creds, err := client.TransportCredentials(tt.cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("TransportCredentials() error = %v", err)
+			}
+
+			info := creds.Info()
+			if info.SecurityProtocol != tt.wantProtocol {
+				t.Errorf("Expected security protocol %q, got %q", tt.wantProtocol, info.SecurityProtocol)
+			}
+			if info.ServerName != tt.wantServer {
+				t.Errorf("Expected server name %q, got %q", tt.wantServer, info.ServerName)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package client
+
+import "fmt"
+
+// transitions lists the statuses each reservation status may move to.
+// Statuses not listed here are unknown to the worker and are not guarded.
+var transitions = map[string][]string{
+	StatusHold:      {StatusConfirmed, StatusCancelled, StatusExpired},
+	StatusConfirmed: {},
+	StatusCancelled: {},
+	StatusExpired:   {},
+}
+
+// CanTransition reports whether a reservation in status from may be moved to status to.
+// Moving to the current status is always allowed so redeliveries stay idempotent.
+func CanTransition(from, to string) bool {
+	if from == to {
+		return true
+	}
+	next, known := transitions[from]
+	if !known {
+		return true
+	}
+	for _, s := range next {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IllegalTransitionError is returned when a status update would break the reservation state machine
+type IllegalTransitionError struct {
+	ReservationID string
+	From          string
+	To            string
+}
+
+func (e *IllegalTransitionError) Error() string {
+	return fmt.Sprintf("illegal status transition for reservation %s: %s -> %s", e.ReservationID, e.From, e.To)
+}
+
+// CheckTransition returns an *IllegalTransitionError when moving from to to is not allowed
+func CheckTransition(reservationID, from, to string) error {
+	if CanTransition(from, to) {
+		return nil
+	}
+	return &IllegalTransitionError{ReservationID: reservationID, From: from, To: to}
+}
@@ -0,0 +1,46 @@
+package client_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/traffic-tacos/reservation-worker/internal/client"
+)
+
+func TestCheckTransition(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		wantErr bool
+	}{
+		{"hold to confirmed", client.StatusHold, client.StatusConfirmed, false},
+		{"hold to cancelled", client.StatusHold, client.StatusCancelled, false},
+		{"hold to expired", client.StatusHold, client.StatusExpired, false},
+		{"expired to expired", client.StatusExpired, client.StatusExpired, false},
+		{"confirmed to confirmed", client.StatusConfirmed, client.StatusConfirmed, false},
+		{"unknown status is not guarded", "PENDING_PAYMENT", client.StatusConfirmed, false},
+		{"expired to confirmed", client.StatusExpired, client.StatusConfirmed, true},
+		{"cancelled to confirmed", client.StatusCancelled, client.StatusConfirmed, true},
+		{"confirmed to expired", client.StatusConfirmed, client.StatusExpired, true},
+		{"confirmed to cancelled", client.StatusConfirmed, client.StatusCancelled, true},
+		{"expired to hold", client.StatusExpired, client.StatusHold, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := client.CheckTransition("rsv_123", tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckTransition(%s, %s) error = %v, wantErr %v", tt.from, tt.to, err, tt.wantErr)
+			}
+			if client.CanTransition(tt.from, tt.to) == tt.wantErr {
+				t.Errorf("CanTransition(%s, %s) disagrees with CheckTransition", tt.from, tt.to)
+			}
+
+			var transitionErr *client.IllegalTransitionError
+			if tt.wantErr && !errors.As(err, &transitionErr) {
+				t.Errorf("error %T is not an *IllegalTransitionError", err)
+			}
+		})
+	}
+}
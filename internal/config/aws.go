@@ -0,0 +1,67 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+// AWS credential sources
+const (
+	CredentialSourceStatic  = "static"
+	CredentialSourceProfile = "profile"
+	CredentialSourceDefault = "default"
+)
+
+// AWSCredentialSource returns which credential source LoadAWSConfig will use
+func (c *Config) AWSCredentialSource() string {
+	if os.Getenv("AWS_ACCESS_KEY_ID") != "" && os.Getenv("AWS_SECRET_ACCESS_KEY") != "" {
+		return CredentialSourceStatic
+	}
+	if c.AWSProfile != "" {
+		return CredentialSourceProfile
+	}
+	return CredentialSourceDefault
+}
+
+// LoadAWSConfig loads the AWS SDK configuration for the configured region and credentials
+func (c *Config) LoadAWSConfig(ctx context.Context) (aws.Config, error) {
+	opts := []func(*config.LoadOptions) error{
+		config.WithRegion(c.SQSRegion),
+	}
+
+	switch c.AWSCredentialSource() {
+	case CredentialSourceStatic:
+		// Method 1: Static credentials from environment variables
+		opts = append(opts, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"), ""),
+		))
+	case CredentialSourceProfile:
+		// Method 2: Named profile from ~/.aws/credentials
+		opts = append(opts, config.WithSharedConfigProfile(c.AWSProfile))
+	default:
+		// Method 3: Default credential chain (IRSA, Instance Profile, etc.)
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	setBaseEndpoint(&awsCfg, c.AWSEndpointURL)
+
+	return awsCfg, nil
+}
+
+// setBaseEndpoint points every client created from cfg at endpoint, leaving the
+// SDK's endpoint resolution in place when it is empty. BaseEndpoint replaces the
+// deprecated EndpointResolver options.
+func setBaseEndpoint(cfg *aws.Config, endpoint string) {
+	if endpoint != "" {
+		cfg.BaseEndpoint = aws.String(endpoint)
+	}
+}
@@ -1,8 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -11,65 +14,600 @@ type Config struct {
 	// AWS Configuration
 	AWSProfile       string
 	AWSRegion        string
+	AWSEndpointURL   string // Overrides the endpoint of every AWS client, e.g. LocalStack
 	UseSecretManager bool
 	SecretName       string
 
 	// SQS Configuration
-	SQSQueueURL string
-	SQSWaitTime int
-	SQSRegion   string
+	SQSQueueURL        string
+	SQSQueueName       string // Resolved to SQSQueueURL at startup when no URL is set
+	SQSQueueAccount    string // Account owning SQSQueueName (empty = the caller's)
+	SQSWaitTime        int
+	SQSRegion          string
+	SQSDLQURL          string // Optional DLQ for messages removed by the worker
+	SQSMaxReceiveCount int    // Messages received more often are treated as poison (0 = disabled)
+	SQSMaxMessages     int    // Messages requested per ReceiveMessage call (1-10)
+	SQSPollerCount     int    // Receive loops running concurrently, sharing the events channel
+	DeletePolicy       string // When messages are deleted: on-success or on-receive
+	SQSDeleteBatch     bool   // Delete a receive's succeeded messages together once all of them concluded or the visibility deadline passed
+	ParseErrorPolicy   string // What happens to messages that cannot be parsed: dlq, retry or drop
+	EventDecoder       string // How message bodies are decoded into events: std or fast
+
+	// Retries of a failed DeleteMessage, SQSDeleteBackoffMS apart and doubling, so a
+	// transient error doesn't leave a handled message to be redelivered
+	SQSDeleteRetries   int
+	SQSDeleteBackoffMS int
+
+	// Visibility timeout requested with each receive (0 = the queue's default). Handlers then get
+	// a deadline this long after the receive, less SQSVisibilityMarginSec, so they abort before
+	// the message can be delivered again. The worker does not extend message visibility, so
+	// the deadline is fixed at receive time and long handlers cannot push it out.
+	SQSVisibilityTimeoutSec int
+	SQSVisibilityMarginSec  int
+
+	// Per-event-type DLQs, falling back to SQSDLQURL. DLQURLsByType (event type -> URL)
+	// takes precedence over the expiry and payment DLQs.
+	DLQExpiredURL string
+	DLQPaymentURL string
+	DLQURLsByType map[string]string
+
+	// Decrypt bodies of messages carrying the kms-key-id attribute with KMS
+	SQSKMSDecryptEnabled bool
+
+	// Size receives to the workers free to start on them, plus SQSPrefetchExtra,
+	// instead of to the room left in the events buffer
+	SQSPrefetchByWorkers bool
+	SQSPrefetchExtra     int
+
+	// Before the first receive, wait StartupDelayMS and then, when StartupWaitInventory
+	// is set, up to StartupReadyTimeoutMS for the inventory connection to become ready
+	StartupDelayMS        int
+	StartupWaitInventory  bool
+	StartupReadyTimeoutMS int
+
+	// Local development: create SQSQueueName and its DLQ at startup when missing.
+	// Refused outside LocalStack unless SQSAutoCreateForce is set.
+	SQSAutoCreateQueue bool
+	SQSAutoCreateForce bool
+
+	// Per-event-type override of DeletePolicy: event type -> at-least-once or at-most-once
+	ProcessingGuarantees map[string]string
+
+	// Event types owned by other consumers of a shared queue; acked without being handled
+	IgnoredEventTypes []string
+
+	// Expected producers per event type: event type, or a prefix pattern such as payment.*,
+	// -> sources separated by |. Events from any other source are diverted to the DLQ.
+	EventSources map[string]string
+
+	// Message attribute carrying an event's priority (empty = no priorities). Events whose
+	// value is one of PriorityHighValues (default "high") are dispatched ahead of the rest;
+	// after PriorityHighBurst of them in a row, a waiting normal event goes next.
+	PriorityAttribute  string
+	PriorityHighValues []string
+	PriorityHighBurst  int
+
+	// Events older than this are acked without being handled (0 = never).
+	// Per-type overrides: event type -> seconds; payment.approved is never dropped unless overridden.
+	MaxEventAgeSeconds int
+	MaxEventAgeByType  map[string]string
 
 	// Worker Configuration
 	WorkerConcurrency int
 	MaxRetries        int
 	BackoffBaseMS     int
+	RampUpSec         int  // Window over which workers are brought online
+	EventsBufferSize  int  // Events received but not yet picked up by a worker (0 = 2x WorkerConcurrency)
+	RetryQueueSize    int  // Retries waiting out their backoff off-worker (0 = back off on the worker)
+	DedupWindowSec    int  // Skip events whose ID succeeded within this window (0 = disabled)
+	StepStateTTLSec   int  // Remember a failing event's completed steps this long so retries skip them (0 = disabled)
+	BatchWindowMS     int  // Coalesce status updates arriving within this window (0 = disabled)
+	BatchMaxSize      int  // Flush a status batch early once it holds this many updates
+	DryRun            bool // Log downstream calls instead of performing them
+	DryRunKeepMessage bool // In dry-run mode, leave messages in the queue
+	HandlerTimeoutMS  int  // Abort a handler attempt running longer than this (0 = only the visibility deadline)
+
+	// Hold received events in one queue per event type and dispatch round-robin across
+	// types, so a backlog of one type does not hold up the others
+	DispatchFairness bool
+
+	// Restart the poller or dispatcher when it exits unexpectedly, waiting SupervisorBackoffMS
+	// and doubling; exit the process once SupervisorMaxRestarts restarts are used up. A run of
+	// five minutes starts the count over.
+	SupervisorMaxRestarts int
+	SupervisorBackoffMS   int
+
+	// Per-event-type concurrency caps (0 = limited only by WorkerConcurrency)
+	ConcurrencyExpired  int
+	ConcurrencyApproved int
+	ConcurrencyFailed   int
+
+	// Handlers running at once for the same reservation (0 = unlimited)
+	ConcurrencyPerReservation int
+
+	// Handlers calling downstream services at once across all event types (0 = WORKER_CONCURRENCY)
+	MaxConcurrentDownstream int
 
 	// External Services
 	InventoryGRPCAddr  string
 	ReservationAPIBase string
+	InventoryRPS       int               // Max inventory calls per second (0 = unlimited)
+	ReservationRPS     int               // Max reservation API calls per second (0 = unlimited)
+	OutboundHeaders    map[string]string // Headers/metadata sent on every downstream call
+
+	GuardStatusTransitions bool // Fetch the current status and refuse illegal transitions before acting
+
+	// Fetch the reservation before releasing an expired hold and skip the release when it left
+	// HOLD or its hold runs past now. Holds expiring within VerifyExpiryAllowanceMS from now count
+	// as expired, allowing for clock differences with the reservation API.
+	VerifyBeforeRelease     bool
+	VerifyExpiryAllowanceMS int
+
+	// Order of the expired handler's two downstream steps: release-first or status-first. When
+	// release-first releases the hold but the status update fails, the seats are free until the
+	// retry; with RestoredHoldTTLSec they are held again for that long, which may take seats another
+	// buyer already got (0 = not restored). Status-first has nothing to undo: an expired
+	// reservation whose release fails keeps its hold until the retry releases it.
+	ExpiredStepOrder   string
+	RestoredHoldTTLSec int
+
+	// Currency payment events are expected in; others are logged and counted, not rejected.
+	// Empty accepts any ISO 4217 code, for multi-currency deployments.
+	ExpectedCurrency string
+
+	// What happens to a payment.approved whose reservation already expired or was cancelled: fail or drop
+	ApprovedAfterTerminal string
+
+	// SQS queue receiving events the worker publishes, such as payment.refund_required
+	// for approvals dropped under APPROVED_AFTER_TERMINAL=drop (empty = none published)
+	OutboundEventsQueueURL string
+
+	// Reservation API HTTP connection pool
+	ReservationMaxIdleConns        int // Idle connections kept across all hosts
+	ReservationMaxIdleConnsPerHost int // Idle connections kept to the reservation API
+	ReservationIdleConnTimeoutSec  int // Close idle connections after this many seconds
+
+	// Inventory gRPC transport security (insecure unless enabled)
+	InventoryTLSEnabled    bool
+	InventoryTLSCertFile   string // Client certificate for mTLS
+	InventoryTLSKeyFile    string // Client private key for mTLS
+	InventoryTLSCAFile     string // CA bundle for the server (system roots if empty)
+	InventoryTLSServerName string // SNI override
+
+	// Inventory gRPC connection tuning
+	InventoryKeepaliveTimeSec       int    // Ping after this many seconds without activity
+	InventoryKeepaliveTimeoutSec    int    // Drop the connection if a ping is not acked in time
+	InventoryKeepaliveWithoutStream bool   // Ping connections without active RPCs; the server must permit it
+	InventoryReconnectBaseMS        int    // First reconnect delay
+	InventoryReconnectMaxMS         int    // Upper bound for reconnect delays
+	InventoryLBPolicy               string // round_robin or pick_first
 
 	// Observability
-	OTELExporterEndpoint string
-	LogLevel             string
+	ServiceVersion        string // Reported in log lines and OTLP resources
+	Environment           string // Deployment environment, e.g. production or staging
+	OTELExporterEndpoint  string
+	OTELTracesSampler     string  // always, never, ratio
+	OTELTracesSamplerArg  float64 // Sampling ratio for the ratio sampler
+	OTELExporterInsecure  bool    // Export without TLS when the endpoint has no scheme
+	OTELExporterCAFile    string  // CA bundle for TLS export (system roots if empty)
+	OTELExporterProtocol  string  // http or grpc
+	LogLevel              string
+	LogFormat             string // json or console
+	LogHTTPBodies         bool   // Log redacted reservation API bodies; only takes effect at debug level
+	AuditLogEnabled       bool   // Write an audit line to stdout for every reservation change
+	LogSamplingInitial    int    // Identical messages logged per second before sampling (0 = off)
+	LogSamplingThereafter int    // Then log every Nth identical message
+	LogExport             string // stdout, otlp, both
+	MetricsBackend        string // prometheus, otel, both
+	MetricsFinalScrapeSec int    // Wait up to this long on shutdown for a last /metrics scrape (0 = don't wait), at most half of ShutdownTimeout
+	HeartbeatTimeoutSec   int    // worker_healthy drops to 0 once a poller or dispatcher heartbeat is older (0 = off, stays 1)
+	InstanceID            string // instance_id label on every metric; defaults to the hostname
+
+	// Log field names whose values are replaced by a hash, at any nesting depth
+	RedactFields []string
+
+	// Fraction of successful events logging their info lines; failures always log in full
+	LogSuccessSampleRate float64
 
 	// Server Configuration
 	ServerPort    string // HTTP server for health/metrics
 	GRPCDebugPort string // gRPC server for debugging
+	EnablePprof   bool   // Mount net/http/pprof under /debug/pprof
+	EnableAdmin   bool   // Mount POST /api/v1/pause and /api/v1/resume
+
+	// Self-test endpoint; with live clients it also requires AllowLiveTestEvents
+	EnableTestEndpoint  bool
+	AllowLiveTestEvents bool
+
+	// Bearer token required by POST /api/v1/events/replay; the endpoint is mounted only when set
+	ReplayEndpointToken string
+
+	// Warnings collected while loading, logged once the logger is ready
+	Warnings []string
 }
 
+// Message deletion policies
+const (
+	DeletePolicyOnSuccess = "on-success" // Delete once the handler succeeds; failures are redelivered
+	DeletePolicyOnReceive = "on-receive" // Delete as soon as the message is received, before it is handled
+)
+
+// Handling of messages whose body cannot be parsed
+const (
+	ParseErrorPolicyDLQ   = "dlq"   // Move to SQS_DLQ_URL right away
+	ParseErrorPolicyRetry = "retry" // Leave in the queue for MAX_RECEIVE_COUNT or the queue's redrive policy to divert
+	ParseErrorPolicyDrop  = "drop"  // Delete without keeping a copy
+)
+
+// Decoders for the event envelope of a message body
+const (
+	EventDecoderStd  = "std"  // encoding/json
+	EventDecoderFast = "fast" // Hand-written scanner without reflection; decodes the same events
+)
+
+// Orders of the expired handler's steps. Whichever runs second may fail after the first succeeded:
+//   - release-first frees the seats even while the reservation API is down, but a failed status update
+//     leaves a HOLD reservation without seats that a late payment.approved could still confirm; the
+//     hold is restored until the retry.
+//   - status-first never leaves a confirmable reservation without seats, but a failed release keeps the
+//     seats of the EXPIRED reservation held until the retry releases them. EXPIRED is terminal, so
+//     there is nothing to undo.
+const (
+	ExpiredStepOrderReleaseFirst = "release-first"
+	ExpiredStepOrderStatusFirst  = "status-first"
+)
+
+// Handling of a payment.approved arriving after its reservation expired or was cancelled
+const (
+	ApprovedAfterTerminalFail = "fail" // Attempt the confirmation; GUARD_STATUS_TRANSITIONS refuses it as illegal
+	ApprovedAfterTerminalDrop = "drop" // Check the status first and ack without confirming, compensating if configured
+)
+
+// Processing guarantees, selecting whether a message is deleted after or before its handler runs
+const (
+	GuaranteeAtLeastOnce = "at-least-once" // Never lost, may be handled again after a crash
+	GuaranteeAtMostOnce  = "at-most-once"  // Never handled twice, lost if the worker dies mid-handler
+)
+
+// SQS allows between 1 and 10 messages per ReceiveMessage call
+const (
+	minSQSMaxMessages = 1
+	maxSQSMaxMessages = 10
+)
+
+// maxSQSVisibilityTimeoutSec is the longest visibility timeout SQS accepts (12 hours)
+const maxSQSVisibilityTimeoutSec = 43200
+
+// ShutdownTimeout bounds graceful shutdown; the final metrics scrape wait is part of it
+const ShutdownTimeout = 30 * time.Second
+
 // Load loads configuration from environment variables
 func Load() *Config {
-	return &Config{
+	cfg := &Config{
 		// AWS Configuration
 		// AWS_PROFILE: 빈 문자열 기본값 (EKS IRSA 자동 인증)
 		// 로컬 개발 시 .env.local에서 명시적으로 설정
 		AWSProfile:       getEnv("AWS_PROFILE", ""),
 		AWSRegion:        getEnv("AWS_REGION", "ap-northeast-2"),
+		AWSEndpointURL:   getEnv("AWS_ENDPOINT_URL", ""),
 		UseSecretManager: getEnvBool("USE_SECRET_MANAGER", false),
 		SecretName:       getEnv("SECRET_NAME", "traffictacos/reservation-worker"),
 
 		// SQS Configuration
-		SQSQueueURL: getEnv("SQS_QUEUE_URL", "https://sqs.ap-northeast-2.amazonaws.com/123/reservation-events"),
-		SQSWaitTime: getEnvInt("SQS_WAIT_TIME", 20),
-		SQSRegion:   getEnv("AWS_REGION", "ap-northeast-2"),
+		SQSQueueURL:        getEnv("SQS_QUEUE_URL", defaultQueueURL()),
+		SQSQueueName:       getEnv("SQS_QUEUE_NAME", ""),
+		SQSQueueAccount:    getEnv("SQS_QUEUE_ACCOUNT", ""),
+		SQSWaitTime:        getEnvInt("SQS_WAIT_TIME", 20),
+		SQSRegion:          getEnv("AWS_REGION", "ap-northeast-2"),
+		SQSDLQURL:          getEnv("SQS_DLQ_URL", ""),
+		SQSMaxReceiveCount: getEnvInt("MAX_RECEIVE_COUNT", 0),
+		SQSMaxMessages:     getEnvInt("SQS_MAX_MESSAGES", maxSQSMaxMessages),
+		SQSPollerCount:     getEnvInt("SQS_POLLER_COUNT", 1),
+		DeletePolicy:       getEnv("DELETE_POLICY", DeletePolicyOnSuccess),
+		SQSDeleteBatch:     getEnvBool("SQS_DELETE_BATCH", false),
+		ParseErrorPolicy:   getEnv("PARSE_ERROR_POLICY", ParseErrorPolicyRetry),
+		EventDecoder:       getEnv("EVENT_DECODER", EventDecoderStd),
+
+		SQSDeleteRetries:   getEnvInt("SQS_DELETE_RETRIES", 3),
+		SQSDeleteBackoffMS: getEnvInt("SQS_DELETE_BACKOFF_MS", 100),
+
+		SQSVisibilityTimeoutSec: getEnvInt("SQS_VISIBILITY_TIMEOUT_SEC", 0),
+		SQSVisibilityMarginSec:  getEnvInt("SQS_VISIBILITY_MARGIN_SEC", 5),
+
+		DLQExpiredURL: getEnv("DLQ_EXPIRED_URL", ""),
+		DLQPaymentURL: getEnv("DLQ_PAYMENT_URL", ""),
+		DLQURLsByType: getEnvMap("DLQ_URLS"),
+
+		SQSKMSDecryptEnabled: getEnvBool("SQS_KMS_DECRYPT_ENABLED", false),
+
+		SQSPrefetchByWorkers: getEnvBool("SQS_PREFETCH_BY_WORKERS", true),
+		SQSPrefetchExtra:     getEnvInt("SQS_PREFETCH_EXTRA", 0),
+
+		StartupDelayMS:        getEnvInt("STARTUP_DELAY_MS", 0),
+		StartupWaitInventory:  getEnvBool("STARTUP_WAIT_INVENTORY_READY", false),
+		StartupReadyTimeoutMS: getEnvInt("STARTUP_READY_TIMEOUT_MS", 30000),
+
+		SQSAutoCreateQueue: getEnvBool("SQS_AUTO_CREATE_QUEUE", false),
+		SQSAutoCreateForce: getEnvBool("SQS_AUTO_CREATE_QUEUE_FORCE", false),
+
+		ProcessingGuarantees: getEnvMap("PROCESSING_GUARANTEES"),
+
+		IgnoredEventTypes: getEnvList("IGNORED_EVENT_TYPES"),
+
+		EventSources: getEnvMap("EVENT_SOURCES"),
+
+		PriorityAttribute:  getEnv("PRIORITY_ATTRIBUTE", ""),
+		PriorityHighValues: getEnvList("PRIORITY_HIGH_VALUES"),
+		PriorityHighBurst:  getEnvInt("PRIORITY_HIGH_BURST", 4),
+
+		MaxEventAgeSeconds: getEnvInt("MAX_EVENT_AGE_SECONDS", 0),
+		MaxEventAgeByType:  getEnvMap("MAX_EVENT_AGE_BY_TYPE"),
 
 		// Worker Configuration
 		WorkerConcurrency: getEnvInt("WORKER_CONCURRENCY", 20),
 		MaxRetries:        getEnvInt("MAX_RETRIES", 5),
 		BackoffBaseMS:     getEnvInt("BACKOFF_BASE_MS", 1000),
+		RampUpSec:         getEnvInt("RAMP_UP_SEC", 0),
+		EventsBufferSize:  getEnvInt("EVENTS_BUFFER_SIZE", 0),
+		RetryQueueSize:    getEnvInt("RETRY_QUEUE_SIZE", 1000),
+		DedupWindowSec:    getEnvInt("DEDUP_WINDOW_SEC", 0),
+		StepStateTTLSec:   getEnvInt("STEP_STATE_TTL_SEC", 900),
+		BatchWindowMS:     getEnvInt("BATCH_WINDOW_MS", 0),
+		BatchMaxSize:      getEnvInt("BATCH_MAX_SIZE", 50),
+		DryRun:            getEnvBool("DRY_RUN", false),
+		DryRunKeepMessage: getEnvBool("DRY_RUN_KEEP_MESSAGES", false),
+		HandlerTimeoutMS:  getEnvInt("HANDLER_TIMEOUT_MS", 0),
+
+		DispatchFairness: getEnvBool("DISPATCH_FAIRNESS", false),
+
+		SupervisorMaxRestarts: getEnvInt("SUPERVISOR_MAX_RESTARTS", 5),
+		SupervisorBackoffMS:   getEnvInt("SUPERVISOR_BACKOFF_MS", 1000),
+
+		ConcurrencyExpired:  getEnvInt("CONCURRENCY_EXPIRED", 0),
+		ConcurrencyApproved: getEnvInt("CONCURRENCY_APPROVED", 0),
+		ConcurrencyFailed:   getEnvInt("CONCURRENCY_FAILED", 0),
+
+		ConcurrencyPerReservation: getEnvInt("CONCURRENCY_PER_RESERVATION", 1),
+
+		MaxConcurrentDownstream: getEnvInt("MAX_CONCURRENT_DOWNSTREAM", 0),
 
 		// External Services
 		InventoryGRPCAddr:  getEnv("INVENTORY_GRPC_ADDR", "inventory-svc:8021"),
 		ReservationAPIBase: getEnv("RESERVATION_API_BASE", "http://reservation-api:8010"),
+		InventoryRPS:       getEnvInt("INVENTORY_RPS", 0),
+		ReservationRPS:     getEnvInt("RESERVATION_RPS", 0),
+		OutboundHeaders:    getEnvMap("OUTBOUND_HEADERS"),
+
+		GuardStatusTransitions: getEnvBool("GUARD_STATUS_TRANSITIONS", false),
+
+		VerifyBeforeRelease:     getEnvBool("VERIFY_BEFORE_RELEASE", false),
+		VerifyExpiryAllowanceMS: getEnvInt("VERIFY_EXPIRY_ALLOWANCE_MS", 2000),
+
+		ExpiredStepOrder:   getEnv("EXPIRED_STEP_ORDER", ExpiredStepOrderReleaseFirst),
+		RestoredHoldTTLSec: getEnvInt("RESTORED_HOLD_TTL_SEC", 0),
+
+		ExpectedCurrency: strings.ToUpper(getEnvOrEmpty("EXPECTED_CURRENCY", "KRW")),
+
+		ApprovedAfterTerminal: getEnv("APPROVED_AFTER_TERMINAL", ApprovedAfterTerminalFail),
+
+		OutboundEventsQueueURL: getEnv("OUTBOUND_EVENTS_QUEUE_URL", ""),
+
+		ReservationMaxIdleConns:        getEnvInt("RESERVATION_MAX_IDLE_CONNS", 100),
+		ReservationMaxIdleConnsPerHost: getEnvInt("RESERVATION_MAX_IDLE_CONNS_PER_HOST", 100),
+		ReservationIdleConnTimeoutSec:  getEnvInt("RESERVATION_IDLE_CONN_TIMEOUT_SEC", 90),
+
+		InventoryTLSEnabled:    getEnvBool("INVENTORY_TLS_ENABLED", false),
+		InventoryTLSCertFile:   getEnv("INVENTORY_TLS_CERT_FILE", ""),
+		InventoryTLSKeyFile:    getEnv("INVENTORY_TLS_KEY_FILE", ""),
+		InventoryTLSCAFile:     getEnv("INVENTORY_TLS_CA_FILE", ""),
+		InventoryTLSServerName: getEnv("INVENTORY_TLS_SERVER_NAME", ""),
+
+		InventoryKeepaliveTimeSec:       getEnvInt("INVENTORY_KEEPALIVE_TIME_SEC", 300),
+		InventoryKeepaliveTimeoutSec:    getEnvInt("INVENTORY_KEEPALIVE_TIMEOUT_SEC", 20),
+		InventoryKeepaliveWithoutStream: getEnvBool("INVENTORY_KEEPALIVE_WITHOUT_STREAM", false),
+		InventoryReconnectBaseMS:        getEnvInt("INVENTORY_RECONNECT_BASE_MS", 100),
+		InventoryReconnectMaxMS:         getEnvInt("INVENTORY_RECONNECT_MAX_MS", 5000),
+		InventoryLBPolicy:               getEnv("INVENTORY_LB_POLICY", "round_robin"),
 
 		// Observability
-		OTELExporterEndpoint: getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://otel-collector:4317"),
-		LogLevel:             getEnv("LOG_LEVEL", "info"),
+		ServiceVersion:        getEnv("SERVICE_VERSION", "1.0.0"),
+		Environment:           getEnv("ENVIRONMENT", "production"),
+		OTELExporterEndpoint:  getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://otel-collector:4317"),
+		OTELTracesSampler:     getEnv("OTEL_TRACES_SAMPLER", "always"),
+		OTELTracesSamplerArg:  getEnvFloat("OTEL_TRACES_SAMPLER_ARG", 1.0),
+		OTELExporterInsecure:  getEnvBool("OTEL_EXPORTER_INSECURE", true),
+		OTELExporterCAFile:    getEnv("OTEL_EXPORTER_CA_FILE", ""),
+		OTELExporterProtocol:  getEnv("OTEL_EXPORTER_OTLP_PROTOCOL", "http"),
+		LogLevel:              getEnv("LOG_LEVEL", "info"),
+		LogFormat:             getEnv("LOG_FORMAT", "json"),
+		LogHTTPBodies:         getEnvBool("LOG_HTTP_BODIES", false),
+		AuditLogEnabled:       getEnvBool("AUDIT_LOG_ENABLED", false),
+		LogSamplingInitial:    getEnvInt("LOG_SAMPLING_INITIAL", 100),
+		LogSamplingThereafter: getEnvInt("LOG_SAMPLING_THEREAFTER", 100),
+		LogExport:             getEnv("LOG_EXPORT", "stdout"),
+		MetricsBackend:        getEnv("METRICS_BACKEND", "prometheus"),
+		MetricsFinalScrapeSec: getEnvInt("METRICS_FINAL_SCRAPE_SEC", 0),
+		HeartbeatTimeoutSec:   getEnvInt("HEARTBEAT_TIMEOUT_SEC", 120),
+		InstanceID:            getEnv("INSTANCE_ID", hostname()),
+
+		RedactFields: getEnvList("REDACT_FIELDS"),
+
+		LogSuccessSampleRate: getEnvFloat("LOG_SUCCESS_SAMPLE_RATE", 1.0),
 
 		// Server Configuration
 		ServerPort:    getEnv("SERVER_PORT", "8040"),
 		GRPCDebugPort: getEnv("GRPC_DEBUG_PORT", "8041"),
+		EnablePprof:   getEnvBool("ENABLE_PPROF", false),
+		EnableAdmin:   getEnvBool("ENABLE_ADMIN_ENDPOINTS", false),
+
+		EnableTestEndpoint:  getEnvBool("ENABLE_TEST_ENDPOINT", false),
+		AllowLiveTestEvents: getEnvBool("ALLOW_LIVE_TEST_EVENTS", false),
+
+		ReplayEndpointToken: getEnv("REPLAY_ENDPOINT_TOKEN", ""),
+	}
+
+	cfg.clampSQSMaxMessages()
+
+	return cfg
+}
+
+// clampSQSMaxMessages keeps SQSMaxMessages within the range SQS accepts
+func (c *Config) clampSQSMaxMessages() {
+	clamped := c.SQSMaxMessages
+	if clamped < minSQSMaxMessages {
+		clamped = minSQSMaxMessages
+	} else if clamped > maxSQSMaxMessages {
+		clamped = maxSQSMaxMessages
+	}
+
+	if clamped != c.SQSMaxMessages {
+		c.Warnings = append(c.Warnings, fmt.Sprintf("SQS_MAX_MESSAGES=%d is outside %d-%d, using %d",
+			c.SQSMaxMessages, minSQSMaxMessages, maxSQSMaxMessages, clamped))
+		c.SQSMaxMessages = clamped
+	}
+}
+
+// TestEndpointAllowed reports whether the self-test endpoint may be mounted.
+// Test events mutate real inventory and reservations unless running dry, so
+// live mode needs an explicit opt-in.
+func (c *Config) TestEndpointAllowed() bool {
+	return c.EnableTestEndpoint && (c.DryRun || c.AllowLiveTestEvents)
+}
+
+// EventsBuffer returns the capacity of the channel between the poller and the workers.
+// A larger buffer absorbs bursts, but its messages sit received and invisible while
+// they wait, and the poller only backs off once it is full. A smaller one backs off
+// sooner and can leave workers idle between receives.
+func (c *Config) EventsBuffer() int {
+	if c.EventsBufferSize > 0 {
+		return c.EventsBufferSize
+	}
+	return c.WorkerConcurrency * 2
+}
+
+// ProcessingGuarantee returns the guarantee for eventType, falling back to the one implied by DeletePolicy
+func (c *Config) ProcessingGuarantee(eventType string) string {
+	if guarantee, ok := c.ProcessingGuarantees[eventType]; ok {
+		return guarantee
+	}
+	if c.DeletePolicy == DeletePolicyOnReceive {
+		return GuaranteeAtMostOnce
+	}
+	return GuaranteeAtLeastOnce
+}
+
+// dlqGroups maps event types to the DLQ of the team owning them
+var dlqGroups = map[string]func(c *Config) string{
+	"reservation.expired":      func(c *Config) string { return c.DLQExpiredURL },
+	"reservation.hold.expired": func(c *Config) string { return c.DLQExpiredURL },
+	"payment.approved":         func(c *Config) string { return c.DLQPaymentURL },
+	"payment.failed":           func(c *Config) string { return c.DLQPaymentURL },
+}
+
+// DLQURL returns the dead-letter queue for messages of eventType, or "" when there is none
+func (c *Config) DLQURL(eventType string) string {
+	if url := c.DLQURLsByType[eventType]; url != "" {
+		return url
+	}
+	if group, ok := dlqGroups[eventType]; ok {
+		if url := group(c); url != "" {
+			return url
+		}
+	}
+	return c.SQSDLQURL
+}
+
+// DLQURLs returns the distinct dead-letter queues messages of eventTypes are diverted to,
+// or every configured one when eventTypes is empty
+func (c *Config) DLQURLs(eventTypes []string) []string {
+	var urls []string
+	if len(eventTypes) > 0 {
+		for _, eventType := range eventTypes {
+			urls = append(urls, c.DLQURL(eventType))
+		}
+	} else {
+		urls = append(urls, c.SQSDLQURL, c.DLQExpiredURL, c.DLQPaymentURL)
+		for _, url := range c.DLQURLsByType {
+			urls = append(urls, url)
+		}
+	}
+
+	slices.Sort(urls)
+	urls = slices.Compact(urls)
+	return slices.DeleteFunc(urls, func(url string) bool { return url == "" })
+}
+
+// SourceAllowed reports whether events of eventType may come from source. An exact
+// EventSources entry wins over the longest matching prefix pattern; event types
+// matching neither are accepted from any source.
+func (c *Config) SourceAllowed(eventType, source string) bool {
+	allowed, ok := c.EventSources[eventType]
+	if !ok {
+		matched := ""
+		for pattern, sources := range c.EventSources {
+			prefix, isPattern := strings.CutSuffix(pattern, "*")
+			if isPattern && strings.HasPrefix(eventType, prefix) && len(prefix) >= len(matched) {
+				matched, allowed, ok = prefix, sources, true
+			}
+		}
+	}
+	if !ok {
+		return true
 	}
+
+	for _, s := range strings.Split(allowed, "|") {
+		if strings.TrimSpace(s) == source {
+			return true
+		}
+	}
+	return false
+}
+
+// HighPriority reports whether a PriorityAttribute value marks an event as high priority
+func (c *Config) HighPriority(value string) bool {
+	if len(c.PriorityHighValues) == 0 {
+		return value == "high"
+	}
+	for _, v := range c.PriorityHighValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// neverStaleEventTypes are exempt from MaxEventAgeSeconds unless MaxEventAgeByType names them.
+// An approval means the customer paid, so it is handled however late it arrives.
+var neverStaleEventTypes = map[string]bool{
+	"payment.approved": true,
+}
+
+// MaxEventAge returns how old an event of eventType may be before it is dropped, or 0 when it never is
+func (c *Config) MaxEventAge(eventType string) time.Duration {
+	seconds := c.MaxEventAgeSeconds
+	if override, ok := c.MaxEventAgeByType[eventType]; ok {
+		seconds, _ = strconv.Atoi(override)
+	} else if neverStaleEventTypes[eventType] {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// defaultQueueURL is the queue used when neither SQS_QUEUE_URL nor SQS_QUEUE_NAME is set
+func defaultQueueURL() string {
+	if os.Getenv("SQS_QUEUE_NAME") != "" {
+		return ""
+	}
+	return "https://sqs.ap-northeast-2.amazonaws.com/123/reservation-events"
+}
+
+// hostname returns the machine's hostname, which is the pod name on Kubernetes
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return name
 }
 
 // getEnv gets environment variable with default value
@@ -80,6 +618,15 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvOrEmpty is getEnv for settings where an explicitly empty value is meaningful:
+// the default applies only when the variable is unset
+func getEnvOrEmpty(key, defaultValue string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return strings.TrimSpace(value)
+	}
+	return defaultValue
+}
+
 // getEnvInt gets environment variable as integer with default value
 func getEnvInt(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
@@ -90,6 +637,41 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvFloat gets environment variable as float with default value
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvMap parses a comma-separated list of key=value pairs, skipping malformed entries
+func getEnvMap(key string) map[string]string {
+	result := make(map[string]string)
+	for _, pair := range strings.Split(os.Getenv(key), ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			continue
+		}
+		result[k] = strings.TrimSpace(v)
+	}
+	return result
+}
+
+// getEnvList parses a comma-separated list, skipping empty entries
+func getEnvList(key string) []string {
+	var result []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
 // getEnvBool gets environment variable as boolean with default value
 func getEnvBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
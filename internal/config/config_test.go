@@ -1,10 +1,18 @@
 package config_test
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/traffic-tacos/reservation-worker/internal/config"
 )
 
@@ -130,4 +138,574 @@ func TestLoadWithDefaults(t *testing.T) {
 	if cfg.ServerPort != "8040" {
 		t.Errorf("Expected default ServerPort to be '8040', got '%s'", cfg.ServerPort)
 	}
-}
\ No newline at end of file
+
+	if cfg.RestoredHoldTTLSec != 0 {
+		t.Errorf("Expected released holds not to be restored by default, got RestoredHoldTTLSec %d", cfg.RestoredHoldTTLSec)
+	}
+
+	if cfg.MetricsFinalScrapeSec != 0 {
+		t.Errorf("Expected no final scrape wait by default, got MetricsFinalScrapeSec %d", cfg.MetricsFinalScrapeSec)
+	}
+}
+
+func TestLoadSQSMaxMessages(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		expected    int
+		wantWarning bool
+	}{
+		{"default", "", 10, false},
+		{"within range", "5", 5, false},
+		{"below minimum", "0", 1, true},
+		{"negative", "-3", 1, true},
+		{"above maximum", "25", 10, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("SQS_MAX_MESSAGES", tt.value)
+			defer os.Unsetenv("SQS_MAX_MESSAGES")
+
+			cfg := config.Load()
+
+			if cfg.SQSMaxMessages != tt.expected {
+				t.Errorf("Expected SQSMaxMessages to be %d, got %d", tt.expected, cfg.SQSMaxMessages)
+			}
+			if hasWarning := len(cfg.Warnings) > 0; hasWarning != tt.wantWarning {
+				t.Errorf("Expected warning = %v, got warnings %v", tt.wantWarning, cfg.Warnings)
+			}
+		})
+	}
+}
+
+func TestLoadEventsBufferSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected int
+	}{
+		{"default is twice the concurrency", "", 40},
+		{"override", "100", 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("WORKER_CONCURRENCY", "20")
+			os.Setenv("EVENTS_BUFFER_SIZE", tt.value)
+			defer os.Unsetenv("WORKER_CONCURRENCY")
+			defer os.Unsetenv("EVENTS_BUFFER_SIZE")
+
+			cfg := config.Load()
+
+			if got := cfg.EventsBuffer(); got != tt.expected {
+				t.Errorf("Expected EventsBuffer() to be %d, got %d", tt.expected, got)
+			}
+			if err := cfg.Validate(); err != nil {
+				t.Errorf("Validate() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadQueueName(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		wantURL string
+	}{
+		{"name alone leaves the URL to be resolved", "", ""},
+		{"URL wins over name", "https://sqs.example.com/123/explicit", "https://sqs.example.com/123/explicit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("SQS_QUEUE_NAME", "reservation-events")
+			defer os.Unsetenv("SQS_QUEUE_NAME")
+			if tt.url != "" {
+				os.Setenv("SQS_QUEUE_URL", tt.url)
+				defer os.Unsetenv("SQS_QUEUE_URL")
+			}
+
+			cfg := config.Load()
+
+			if cfg.SQSQueueURL != tt.wantURL {
+				t.Errorf("Expected SQSQueueURL %q, got %q", tt.wantURL, cfg.SQSQueueURL)
+			}
+			if cfg.SQSQueueName != "reservation-events" {
+				t.Errorf("Expected SQSQueueName reservation-events, got %q", cfg.SQSQueueName)
+			}
+		})
+	}
+}
+
+func TestLoadAWSConfigEndpoint(t *testing.T) {
+	// Stands in for LocalStack
+	var targets []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target := r.Header.Get("X-Amz-Target")
+		targets = append(targets, target)
+		switch target {
+		case "AmazonSQS.GetQueueUrl":
+			json.NewEncoder(w).Encode(map[string]string{"QueueUrl": "http://localhost:4566/000000000000/reservation-events"})
+		case "secretsmanager.GetSecretValue":
+			json.NewEncoder(w).Encode(map[string]string{"SecretString": `{"inventory_grpc_addr":"inventory:8021"}`})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	for key, value := range map[string]string{
+		"AWS_ENDPOINT_URL":      server.URL,
+		"AWS_ACCESS_KEY_ID":     "test",
+		"AWS_SECRET_ACCESS_KEY": "test",
+	} {
+		os.Setenv(key, value)
+		defer os.Unsetenv(key)
+	}
+
+	cfg := config.Load()
+	awsCfg, err := cfg.LoadAWSConfig(context.Background())
+	if err != nil {
+		t.Fatalf("LoadAWSConfig() error = %v", err)
+	}
+
+	sqsClient := sqs.NewFromConfig(awsCfg)
+	if got := aws.ToString(sqsClient.Options().BaseEndpoint); got != server.URL {
+		t.Errorf("Expected SQS client endpoint %q, got %q", server.URL, got)
+	}
+	result, err := sqsClient.GetQueueUrl(context.Background(), &sqs.GetQueueUrlInput{QueueName: aws.String("reservation-events")})
+	if err != nil {
+		t.Fatalf("GetQueueUrl() error = %v", err)
+	}
+	if got := aws.ToString(result.QueueUrl); got != "http://localhost:4566/000000000000/reservation-events" {
+		t.Errorf("Unexpected queue URL %q", got)
+	}
+
+	secrets, err := config.LoadSecretsFromAWS(context.Background(), cfg.AWSRegion, cfg.SecretName, "", cfg.AWSEndpointURL)
+	if err != nil {
+		t.Fatalf("LoadSecretsFromAWS() error = %v", err)
+	}
+	if secrets.InventoryGRPCAddr != "inventory:8021" {
+		t.Errorf("Expected inventory address from the secret, got %q", secrets.InventoryGRPCAddr)
+	}
+
+	if len(targets) != 2 {
+		t.Errorf("Expected both clients to call the overridden endpoint, got %v", targets)
+	}
+}
+
+func TestLoadOutboundHeaders(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected map[string]string
+	}{
+		{"unset", "", map[string]string{}},
+		{"single", "X-Tenant-ID=acme", map[string]string{"X-Tenant-ID": "acme"}},
+		{"multiple with spaces", "X-Tenant-ID = acme, X-Internal-Auth=token", map[string]string{"X-Tenant-ID": "acme", "X-Internal-Auth": "token"}},
+		{"malformed skipped", "X-Tenant-ID=acme,bogus,=value", map[string]string{"X-Tenant-ID": "acme"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("OUTBOUND_HEADERS", tt.value)
+			defer os.Unsetenv("OUTBOUND_HEADERS")
+
+			cfg := config.Load()
+
+			if !reflect.DeepEqual(cfg.OutboundHeaders, tt.expected) {
+				t.Errorf("Expected OutboundHeaders %v, got %v", tt.expected, cfg.OutboundHeaders)
+			}
+		})
+	}
+}
+
+func TestLoadIgnoredEventTypes(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected []string
+	}{
+		{"unset", "", nil},
+		{"multiple with spaces", "order.created, order.shipped", []string{"order.created", "order.shipped"}},
+		{"empty entries skipped", "order.created,, ", []string{"order.created"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("IGNORED_EVENT_TYPES", tt.value)
+			defer os.Unsetenv("IGNORED_EVENT_TYPES")
+
+			cfg := config.Load()
+
+			if !reflect.DeepEqual(cfg.IgnoredEventTypes, tt.expected) {
+				t.Errorf("Expected IgnoredEventTypes %v, got %v", tt.expected, cfg.IgnoredEventTypes)
+			}
+		})
+	}
+}
+
+func TestLoadExpectedCurrency(t *testing.T) {
+	tests := []struct {
+		name     string
+		set      bool
+		value    string
+		expected string
+	}{
+		{"unset defaults to KRW", false, "", "KRW"},
+		{"explicitly empty accepts any currency", true, "", ""},
+		{"lowercase is normalized", true, "usd", "USD"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Unsetenv("EXPECTED_CURRENCY")
+			if tt.set {
+				os.Setenv("EXPECTED_CURRENCY", tt.value)
+			}
+			defer os.Unsetenv("EXPECTED_CURRENCY")
+
+			cfg := config.Load()
+
+			if cfg.ExpectedCurrency != tt.expected {
+				t.Errorf("Expected ExpectedCurrency %q, got %q", tt.expected, cfg.ExpectedCurrency)
+			}
+			if err := cfg.Validate(); err != nil {
+				t.Errorf("Validate() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadParseErrorPolicyDefault(t *testing.T) {
+	tests := []struct {
+		name            string
+		maxReceiveCount string
+		dlqURL          string
+		expected        string
+	}{
+		{"receive cap", "5", "", config.ParseErrorPolicyRetry},
+		{"DLQ without receive cap", "", "https://sqs.ap-northeast-2.amazonaws.com/123/reservation-dlq", config.ParseErrorPolicyRetry},
+		{"neither", "", "", config.ParseErrorPolicyRetry},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("MAX_RECEIVE_COUNT", tt.maxReceiveCount)
+			os.Setenv("SQS_DLQ_URL", tt.dlqURL)
+			defer os.Unsetenv("MAX_RECEIVE_COUNT")
+			defer os.Unsetenv("SQS_DLQ_URL")
+
+			cfg := config.Load()
+
+			if cfg.ParseErrorPolicy != tt.expected {
+				t.Errorf("Expected ParseErrorPolicy %q, got %q", tt.expected, cfg.ParseErrorPolicy)
+			}
+			if err := cfg.Validate(); err != nil {
+				t.Errorf("Validate() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestProcessingGuarantee(t *testing.T) {
+	tests := []struct {
+		name         string
+		deletePolicy string
+		guarantees   map[string]string
+		eventType    string
+		want         string
+	}{
+		{"on-success default", config.DeletePolicyOnSuccess, nil, "reservation.expired", config.GuaranteeAtLeastOnce},
+		{"on-receive default", config.DeletePolicyOnReceive, nil, "reservation.expired", config.GuaranteeAtMostOnce},
+		{"override", config.DeletePolicyOnSuccess, map[string]string{"reservation.expired": config.GuaranteeAtMostOnce}, "reservation.expired", config.GuaranteeAtMostOnce},
+		{"override for another type", config.DeletePolicyOnReceive, map[string]string{"payment.approved": config.GuaranteeAtLeastOnce}, "reservation.expired", config.GuaranteeAtMostOnce},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{DeletePolicy: tt.deletePolicy, ProcessingGuarantees: tt.guarantees}
+			if got := cfg.ProcessingGuarantee(tt.eventType); got != tt.want {
+				t.Errorf("ProcessingGuarantee(%s) = %s, want %s", tt.eventType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSourceAllowed(t *testing.T) {
+	cfg := &config.Config{EventSources: map[string]string{
+		"payment.*":             "payment-sim-api",
+		"payment.approved":      "payment-sim-api|payment-gateway",
+		"reservation.expired":   "reservation-api",
+		"reservation.hold.*":    "reservation-api",
+		"reservation.hold.test": "load-test",
+	}}
+
+	tests := []struct {
+		eventType string
+		source    string
+		want      bool
+	}{
+		{"payment.failed", "payment-sim-api", true},
+		{"payment.failed", "reservation-api", false},
+		{"payment.approved", "payment-gateway", true},
+		{"payment.approved", "", false},
+		{"reservation.expired", "reservation-api", true},
+		{"reservation.expired", "payment-sim-api", false},
+		{"reservation.hold.expired", "reservation-api", true},
+		{"reservation.hold.test", "reservation-api", false},
+		{"reservation.cancelled", "anything", true},
+	}
+
+	for _, tt := range tests {
+		if got := cfg.SourceAllowed(tt.eventType, tt.source); got != tt.want {
+			t.Errorf("SourceAllowed(%s, %q) = %v, want %v", tt.eventType, tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestDLQURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		byType    map[string]string
+		eventType string
+		want      string
+	}{
+		{"expired", nil, "reservation.expired", "https://sqs/expired-dlq"},
+		{"hold expired", nil, "reservation.hold.expired", "https://sqs/expired-dlq"},
+		{"payment", nil, "payment.failed", "https://sqs/payment-dlq"},
+		{"fallback", nil, "reservation.cancelled", "https://sqs/dlq"},
+		{"mapping wins", map[string]string{"payment.failed": "https://sqs/failed-dlq"}, "payment.failed", "https://sqs/failed-dlq"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				SQSDLQURL:     "https://sqs/dlq",
+				DLQExpiredURL: "https://sqs/expired-dlq",
+				DLQPaymentURL: "https://sqs/payment-dlq",
+				DLQURLsByType: tt.byType,
+			}
+			if got := cfg.DLQURL(tt.eventType); got != tt.want {
+				t.Errorf("DLQURL(%s) = %s, want %s", tt.eventType, got, tt.want)
+			}
+		})
+	}
+
+	if got := (&config.Config{DLQExpiredURL: "https://sqs/expired-dlq"}).DLQURL("payment.failed"); got != "" {
+		t.Errorf("Expected no DLQ for a type without one, got %s", got)
+	}
+}
+
+func TestDLQURLs(t *testing.T) {
+	cfg := &config.Config{
+		SQSDLQURL:     "https://sqs/dlq",
+		DLQExpiredURL: "https://sqs/expired-dlq",
+		DLQURLsByType: map[string]string{"payment.failed": "https://sqs/failed-dlq", "payment.approved": "https://sqs/failed-dlq"},
+	}
+
+	tests := []struct {
+		name       string
+		eventTypes []string
+		want       []string
+	}{
+		{"every configured DLQ", nil, []string{"https://sqs/dlq", "https://sqs/expired-dlq", "https://sqs/failed-dlq"}},
+		{"DLQs of the given types", []string{"reservation.expired", "reservation.hold.expired"}, []string{"https://sqs/expired-dlq"}},
+		{"fallback for types without their own", []string{"payment.failed", "reservation.cancelled"}, []string{"https://sqs/dlq", "https://sqs/failed-dlq"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cfg.DLQURLs(tt.eventTypes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DLQURLs(%v) = %v, want %v", tt.eventTypes, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxEventAge(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides map[string]string
+		eventType string
+		want      time.Duration
+	}{
+		{"default", nil, "reservation.expired", time.Hour},
+		{"approvals exempt", nil, "payment.approved", 0},
+		{"override", map[string]string{"reservation.expired": "600"}, "reservation.expired", 10 * time.Minute},
+		{"override disables", map[string]string{"payment.failed": "0"}, "payment.failed", 0},
+		{"approvals opted in", map[string]string{"payment.approved": "86400"}, "payment.approved", 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{MaxEventAgeSeconds: 3600, MaxEventAgeByType: tt.overrides}
+			if got := cfg.MaxEventAge(tt.eventType); got != tt.want {
+				t.Errorf("MaxEventAge(%s) = %v, want %v", tt.eventType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := func() *config.Config {
+		return &config.Config{
+			SQSQueueURL:          "https://sqs.ap-northeast-2.amazonaws.com/123/reservation-events",
+			SQSWaitTime:          20,
+			SQSPollerCount:       1,
+			WorkerConcurrency:    20,
+			MaxRetries:           5,
+			BackoffBaseMS:        1000,
+			InventoryGRPCAddr:    "inventory-svc:8021",
+			ReservationAPIBase:   "http://reservation-api:8010",
+			LogFormat:            "json",
+			LogExport:            "stdout",
+			OTELTracesSampler:    "always",
+			OTELExporterProtocol: "http",
+			MetricsBackend:       "prometheus",
+			InventoryLBPolicy:    "round_robin",
+			DeletePolicy:         config.DeletePolicyOnSuccess,
+			ParseErrorPolicy:     config.ParseErrorPolicyRetry,
+			SQSMaxReceiveCount:   5,
+			EventDecoder:         config.EventDecoderStd,
+			ExpiredStepOrder:     config.ExpiredStepOrderReleaseFirst,
+
+			ApprovedAfterTerminal: config.ApprovedAfterTerminalFail,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(c *config.Config)
+		wantErr string
+	}{
+		{"valid config", func(c *config.Config) {}, ""},
+		{"empty queue URL", func(c *config.Config) { c.SQSQueueURL = "" }, "SQS_QUEUE_URL"},
+		{"queue URL without scheme", func(c *config.Config) { c.SQSQueueURL = "sqs.amazonaws.com/123/q" }, "SQS_QUEUE_URL"},
+		{"queue URL without host", func(c *config.Config) { c.SQSQueueURL = "https:///123/q" }, "SQS_QUEUE_URL"},
+		{"queue name instead of URL", func(c *config.Config) { c.SQSQueueURL = ""; c.SQSQueueName = "reservation-events" }, ""},
+		{"no pollers", func(c *config.Config) { c.SQSPollerCount = 0 }, "SQS_POLLER_COUNT"},
+		{"malformed AWS endpoint", func(c *config.Config) { c.AWSEndpointURL = "localhost:4566" }, "AWS_ENDPOINT_URL"},
+		{"success log sample rate above 1", func(c *config.Config) { c.LogSuccessSampleRate = 1.5 }, "LOG_SUCCESS_SAMPLE_RATE"},
+		{"auto create without queue name", func(c *config.Config) { c.SQSAutoCreateQueue = true }, "SQS_AUTO_CREATE_QUEUE"},
+		{"auto create with queue name", func(c *config.Config) { c.SQSAutoCreateQueue = true; c.SQSQueueName = "reservation-events" }, ""},
+		{"malformed DLQ URL", func(c *config.Config) { c.SQSDLQURL = "not a url" }, "SQS_DLQ_URL"},
+		{"zero concurrency", func(c *config.Config) { c.WorkerConcurrency = 0 }, "WORKER_CONCURRENCY"},
+		{"events buffer below concurrency", func(c *config.Config) { c.EventsBufferSize = 10 }, "EVENTS_BUFFER_SIZE"},
+		{"negative events buffer", func(c *config.Config) { c.EventsBufferSize = -1 }, "EVENTS_BUFFER_SIZE"},
+		{"negative max retries", func(c *config.Config) { c.MaxRetries = -1 }, "MAX_RETRIES"},
+		{"zero backoff", func(c *config.Config) { c.BackoffBaseMS = 0 }, "BACKOFF_BASE_MS"},
+		{"negative backoff", func(c *config.Config) { c.BackoffBaseMS = -5 }, "BACKOFF_BASE_MS"},
+		{"wait time above 20", func(c *config.Config) { c.SQSWaitTime = 21 }, "SQS_WAIT_TIME"},
+		{"negative wait time", func(c *config.Config) { c.SQSWaitTime = -1 }, "SQS_WAIT_TIME"},
+		{"visibility timeout too long", func(c *config.Config) { c.SQSVisibilityTimeoutSec = 43201 }, "SQS_VISIBILITY_TIMEOUT_SEC"},
+		{"visibility margin not below timeout", func(c *config.Config) { c.SQSVisibilityTimeoutSec = 30; c.SQSVisibilityMarginSec = 30 }, "SQS_VISIBILITY_MARGIN_SEC"},
+		{"delete batch without visibility timeout", func(c *config.Config) { c.SQSDeleteBatch = true }, "SQS_DELETE_BATCH"},
+		{"empty inventory address", func(c *config.Config) { c.InventoryGRPCAddr = "" }, "INVENTORY_GRPC_ADDR"},
+		{"empty reservation API base", func(c *config.Config) { c.ReservationAPIBase = "" }, "RESERVATION_API_BASE"},
+		{"unknown log format", func(c *config.Config) { c.LogFormat = "logfmt" }, "LOG_FORMAT"},
+		{"unknown log export", func(c *config.Config) { c.LogExport = "kafka" }, "LOG_EXPORT"},
+		{"unknown sampler", func(c *config.Config) { c.OTELTracesSampler = "sometimes" }, "OTEL_TRACES_SAMPLER"},
+		{"unknown OTLP protocol", func(c *config.Config) { c.OTELExporterProtocol = "thrift" }, "OTEL_EXPORTER_OTLP_PROTOCOL"},
+		{"unknown metrics backend", func(c *config.Config) { c.MetricsBackend = "statsd" }, "METRICS_BACKEND"},
+		{"final scrape wait leaves no time to drain", func(c *config.Config) { c.MetricsFinalScrapeSec = 20 }, "METRICS_FINAL_SCRAPE_SEC"},
+		{"ratio above 1", func(c *config.Config) { c.OTELTracesSampler = "ratio"; c.OTELTracesSamplerArg = 1.5 }, "OTEL_TRACES_SAMPLER_ARG"},
+		{"reconnect max below base", func(c *config.Config) { c.InventoryReconnectBaseMS = 500; c.InventoryReconnectMaxMS = 100 }, "INVENTORY_RECONNECT_MAX_MS"},
+		{"negative keepalive time", func(c *config.Config) { c.InventoryKeepaliveTimeSec = -1 }, "INVENTORY_KEEPALIVE_TIME_SEC"},
+		{"unknown LB policy", func(c *config.Config) { c.InventoryLBPolicy = "random" }, "INVENTORY_LB_POLICY"},
+		{"unknown processing guarantee", func(c *config.Config) {
+			c.ProcessingGuarantees = map[string]string{"payment.approved": "exactly-once"}
+		}, "PROCESSING_GUARANTEES"},
+		{"malformed expired DLQ", func(c *config.Config) { c.DLQExpiredURL = "sqs/expired-dlq" }, "DLQ_EXPIRED_URL"},
+		{"malformed DLQ mapping", func(c *config.Config) {
+			c.DLQURLsByType = map[string]string{"payment.failed": "ftp://dlq"}
+		}, "DLQ_URLS"},
+		{"negative max event age", func(c *config.Config) { c.MaxEventAgeSeconds = -1 }, "MAX_EVENT_AGE_SECONDS"},
+		{"event sources without a source", func(c *config.Config) { c.EventSources = map[string]string{"payment.*": " | "} }, "EVENT_SOURCES"},
+		{"negative startup delay", func(c *config.Config) { c.StartupDelayMS = -1 }, "STARTUP_DELAY_MS"},
+		{"priorities without a high burst", func(c *config.Config) { c.PriorityAttribute = "priority"; c.PriorityHighBurst = 0 }, "PRIORITY_HIGH_BURST"},
+		{"non-numeric max event age override", func(c *config.Config) {
+			c.MaxEventAgeByType = map[string]string{"reservation.expired": "1h"}
+		}, "MAX_EVENT_AGE_BY_TYPE"},
+		{"unknown delete policy", func(c *config.Config) { c.DeletePolicy = "never" }, "DELETE_POLICY"},
+		{"unknown parse error policy", func(c *config.Config) { c.ParseErrorPolicy = "ignore" }, "PARSE_ERROR_POLICY"},
+		{"parse errors retried without receive cap", func(c *config.Config) { c.SQSMaxReceiveCount = 0 }, ""},
+		{"unknown expired step order", func(c *config.Config) { c.ExpiredStepOrder = "parallel" }, "EXPIRED_STEP_ORDER"},
+		{"unknown event decoder", func(c *config.Config) { c.EventDecoder = "jsoniter" }, "EVENT_DECODER"},
+		{"unknown approved-after-terminal policy", func(c *config.Config) { c.ApprovedAfterTerminal = "confirm" }, "APPROVED_AFTER_TERMINAL"},
+		{"malformed expected currency", func(c *config.Config) { c.ExpectedCurrency = "WON!" }, "EXPECTED_CURRENCY"},
+		{"malformed outbound events queue", func(c *config.Config) { c.OutboundEventsQueueURL = "refunds" }, "OUTBOUND_EVENTS_QUEUE_URL"},
+		{"parse errors to DLQ without DLQ", func(c *config.Config) { c.ParseErrorPolicy = config.ParseErrorPolicyDLQ }, "SQS_DLQ_URL"},
+		{"parse errors to DLQ", func(c *config.Config) {
+			c.ParseErrorPolicy = config.ParseErrorPolicyDLQ
+			c.SQSDLQURL = "https://sqs.example.com/123/dlq"
+		}, ""},
+		{"batching without batch size", func(c *config.Config) { c.BatchWindowMS = 20; c.BatchMaxSize = 0 }, "BATCH_MAX_SIZE"},
+		{"inventory TLS cert without key", func(c *config.Config) { c.InventoryTLSEnabled = true; c.InventoryTLSCertFile = "client.pem" }, "INVENTORY_TLS_CERT_FILE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid()
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want mention of %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateAggregatesErrors(t *testing.T) {
+	cfg := &config.Config{LogExport: "stdout", OTELTracesSampler: "always", OTELExporterProtocol: "http", MetricsBackend: "prometheus"}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Expected validation error for empty config")
+	}
+
+	for _, field := range []string{"SQS_QUEUE_URL", "WORKER_CONCURRENCY", "BACKOFF_BASE_MS", "INVENTORY_GRPC_ADDR", "RESERVATION_API_BASE"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("Expected aggregated error to mention %s, got %v", field, err)
+		}
+	}
+}
+
+func TestLoadedDefaultsAreValid(t *testing.T) {
+	if err := config.Load().Validate(); err != nil {
+		t.Errorf("Default configuration should be valid, got %v", err)
+	}
+}
+
+func TestTestEndpointAllowed(t *testing.T) {
+	tests := []struct {
+		name      string
+		enabled   bool
+		dryRun    bool
+		allowLive bool
+		want      bool
+	}{
+		{"disabled", false, true, true, false},
+		{"enabled in dry run", true, true, false, true},
+		{"enabled live without opt-in", true, false, false, false},
+		{"enabled live with opt-in", true, false, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{EnableTestEndpoint: tt.enabled, DryRun: tt.dryRun, AllowLiveTestEvents: tt.allowLive}
+			if got := cfg.TestEndpointAllowed(); got != tt.want {
+				t.Errorf("TestEndpointAllowed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -16,10 +16,13 @@ type SecretConfig struct {
 	InventoryGRPCAddr  string `json:"inventory_grpc_addr"`
 	ReservationAPIBase string `json:"reservation_api_base"`
 	OTELEndpoint       string `json:"otel_endpoint"`
+
+	// Merged over OUTBOUND_HEADERS, e.g. {"X-Internal-Auth": "..."}
+	OutboundHeaders map[string]string `json:"outbound_headers"`
 }
 
 // LoadSecretsFromAWS loads configuration from AWS Secrets Manager
-func LoadSecretsFromAWS(ctx context.Context, region, secretName, profile string) (*SecretConfig, error) {
+func LoadSecretsFromAWS(ctx context.Context, region, secretName, profile, endpoint string) (*SecretConfig, error) {
 	// Create AWS config
 	opts := []func(*config.LoadOptions) error{
 		config.WithRegion(region),
@@ -34,6 +37,7 @@ func LoadSecretsFromAWS(ctx context.Context, region, secretName, profile string)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
+	setBaseEndpoint(&cfg, endpoint)
 
 	// Create Secrets Manager client
 	client := secretsmanager.NewFromConfig(cfg)
@@ -63,7 +67,7 @@ func (c *Config) MergeWithSecrets(ctx context.Context) error {
 		return nil
 	}
 
-	secrets, err := LoadSecretsFromAWS(ctx, c.AWSRegion, c.SecretName, c.AWSProfile)
+	secrets, err := LoadSecretsFromAWS(ctx, c.AWSRegion, c.SecretName, c.AWSProfile, c.AWSEndpointURL)
 	if err != nil {
 		return fmt.Errorf("failed to load secrets: %w", err)
 	}
@@ -81,6 +85,12 @@ func (c *Config) MergeWithSecrets(ctx context.Context) error {
 	if secrets.OTELEndpoint != "" {
 		c.OTELExporterEndpoint = secrets.OTELEndpoint
 	}
+	for k, v := range secrets.OutboundHeaders {
+		if c.OutboundHeaders == nil {
+			c.OutboundHeaders = make(map[string]string)
+		}
+		c.OutboundHeaders[k] = v
+	}
 
 	return nil
 }
\ No newline at end of file
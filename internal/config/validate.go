@@ -0,0 +1,295 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validate checks the loaded configuration and reports every problem found
+func (c *Config) Validate() error {
+	var errs []error
+
+	// A queue name is resolved to its URL at startup, after validation
+	if c.SQSQueueURL != "" || c.SQSQueueName == "" {
+		if err := validateURL(c.SQSQueueURL); err != nil {
+			errs = append(errs, fmt.Errorf("SQS_QUEUE_URL: %w", err))
+		}
+	}
+	if c.AWSEndpointURL != "" {
+		if err := validateURL(c.AWSEndpointURL); err != nil {
+			errs = append(errs, fmt.Errorf("AWS_ENDPOINT_URL: %w", err))
+		}
+	}
+	if c.SQSAutoCreateQueue && c.SQSQueueName == "" {
+		errs = append(errs, errors.New("SQS_AUTO_CREATE_QUEUE: requires SQS_QUEUE_NAME"))
+	}
+	if c.SQSDLQURL != "" {
+		if err := validateURL(c.SQSDLQURL); err != nil {
+			errs = append(errs, fmt.Errorf("SQS_DLQ_URL: %w", err))
+		}
+	}
+	for _, dlq := range []struct {
+		name string
+		url  string
+	}{
+		{"DLQ_EXPIRED_URL", c.DLQExpiredURL},
+		{"DLQ_PAYMENT_URL", c.DLQPaymentURL},
+		{"OUTBOUND_EVENTS_QUEUE_URL", c.OutboundEventsQueueURL},
+	} {
+		if dlq.url == "" {
+			continue
+		}
+		if err := validateURL(dlq.url); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", dlq.name, err))
+		}
+	}
+	for eventType, url := range c.DLQURLsByType {
+		if err := validateURL(url); err != nil {
+			errs = append(errs, fmt.Errorf("DLQ_URLS: %s: %w", eventType, err))
+		}
+	}
+	for eventType, sources := range c.EventSources {
+		if strings.Trim(sources, " |") == "" {
+			errs = append(errs, fmt.Errorf("EVENT_SOURCES: %s: no sources listed", eventType))
+		}
+	}
+	if c.PriorityAttribute != "" && c.PriorityHighBurst < 1 {
+		errs = append(errs, fmt.Errorf("PRIORITY_HIGH_BURST: must be >= 1 so normal events are not starved, got %d", c.PriorityHighBurst))
+	}
+	if c.SQSWaitTime < 0 || c.SQSWaitTime > 20 {
+		errs = append(errs, fmt.Errorf("SQS_WAIT_TIME: must be between 0 and 20, got %d", c.SQSWaitTime))
+	}
+	if c.SQSVisibilityTimeoutSec < 0 || c.SQSVisibilityTimeoutSec > maxSQSVisibilityTimeoutSec {
+		errs = append(errs, fmt.Errorf("SQS_VISIBILITY_TIMEOUT_SEC: must be between 0 and %d, got %d", maxSQSVisibilityTimeoutSec, c.SQSVisibilityTimeoutSec))
+	}
+	if c.SQSVisibilityTimeoutSec > 0 && (c.SQSVisibilityMarginSec < 0 || c.SQSVisibilityMarginSec >= c.SQSVisibilityTimeoutSec) {
+		errs = append(errs, fmt.Errorf("SQS_VISIBILITY_MARGIN_SEC: must be >= 0 and below SQS_VISIBILITY_TIMEOUT_SEC (%d), got %d", c.SQSVisibilityTimeoutSec, c.SQSVisibilityMarginSec))
+	}
+	if c.SQSDeleteBatch && c.SQSVisibilityTimeoutSec == 0 {
+		errs = append(errs, fmt.Errorf("SQS_DELETE_BATCH: requires SQS_VISIBILITY_TIMEOUT_SEC, which bounds how long succeeded messages wait for the rest of their receive"))
+	}
+	if c.SQSMaxReceiveCount < 0 {
+		errs = append(errs, fmt.Errorf("MAX_RECEIVE_COUNT: must be >= 0, got %d", c.SQSMaxReceiveCount))
+	}
+
+	if c.WorkerConcurrency < 1 {
+		errs = append(errs, fmt.Errorf("WORKER_CONCURRENCY: must be >= 1, got %d", c.WorkerConcurrency))
+	}
+	if c.MaxRetries < 0 {
+		errs = append(errs, fmt.Errorf("MAX_RETRIES: must be >= 0, got %d", c.MaxRetries))
+	}
+	if c.BackoffBaseMS <= 0 {
+		errs = append(errs, fmt.Errorf("BACKOFF_BASE_MS: must be > 0, got %d", c.BackoffBaseMS))
+	}
+	if c.EventsBufferSize < 0 || (c.EventsBufferSize > 0 && c.EventsBufferSize < c.WorkerConcurrency) {
+		errs = append(errs, fmt.Errorf("EVENTS_BUFFER_SIZE: must be 0 or >= WORKER_CONCURRENCY (%d), got %d", c.WorkerConcurrency, c.EventsBufferSize))
+	}
+	if c.RampUpSec < 0 {
+		errs = append(errs, fmt.Errorf("RAMP_UP_SEC: must be >= 0, got %d", c.RampUpSec))
+	}
+
+	for _, limit := range []struct {
+		name  string
+		value int
+	}{
+		{"RETRY_QUEUE_SIZE", c.RetryQueueSize},
+		{"DEDUP_WINDOW_SEC", c.DedupWindowSec},
+		{"STEP_STATE_TTL_SEC", c.StepStateTTLSec},
+		{"HANDLER_TIMEOUT_MS", c.HandlerTimeoutMS},
+		{"VERIFY_EXPIRY_ALLOWANCE_MS", c.VerifyExpiryAllowanceMS},
+		{"RESTORED_HOLD_TTL_SEC", c.RestoredHoldTTLSec},
+		{"BATCH_WINDOW_MS", c.BatchWindowMS},
+		{"CONCURRENCY_EXPIRED", c.ConcurrencyExpired},
+		{"CONCURRENCY_APPROVED", c.ConcurrencyApproved},
+		{"CONCURRENCY_FAILED", c.ConcurrencyFailed},
+		{"CONCURRENCY_PER_RESERVATION", c.ConcurrencyPerReservation},
+		{"MAX_CONCURRENT_DOWNSTREAM", c.MaxConcurrentDownstream},
+		{"MAX_EVENT_AGE_SECONDS", c.MaxEventAgeSeconds},
+		{"SQS_PREFETCH_EXTRA", c.SQSPrefetchExtra},
+		{"SQS_DELETE_RETRIES", c.SQSDeleteRetries},
+		{"SQS_DELETE_BACKOFF_MS", c.SQSDeleteBackoffMS},
+		{"STARTUP_DELAY_MS", c.StartupDelayMS},
+		{"STARTUP_READY_TIMEOUT_MS", c.StartupReadyTimeoutMS},
+		{"INVENTORY_RPS", c.InventoryRPS},
+		{"RESERVATION_RPS", c.ReservationRPS},
+		{"RESERVATION_MAX_IDLE_CONNS", c.ReservationMaxIdleConns},
+		{"RESERVATION_MAX_IDLE_CONNS_PER_HOST", c.ReservationMaxIdleConnsPerHost},
+		{"RESERVATION_IDLE_CONN_TIMEOUT_SEC", c.ReservationIdleConnTimeoutSec},
+		{"LOG_SAMPLING_INITIAL", c.LogSamplingInitial},
+		{"LOG_SAMPLING_THEREAFTER", c.LogSamplingThereafter},
+		{"METRICS_FINAL_SCRAPE_SEC", c.MetricsFinalScrapeSec},
+		{"HEARTBEAT_TIMEOUT_SEC", c.HeartbeatTimeoutSec},
+		{"SUPERVISOR_MAX_RESTARTS", c.SupervisorMaxRestarts},
+		{"SUPERVISOR_BACKOFF_MS", c.SupervisorBackoffMS},
+		{"INVENTORY_KEEPALIVE_TIME_SEC", c.InventoryKeepaliveTimeSec},
+		{"INVENTORY_KEEPALIVE_TIMEOUT_SEC", c.InventoryKeepaliveTimeoutSec},
+		{"INVENTORY_RECONNECT_BASE_MS", c.InventoryReconnectBaseMS},
+		{"INVENTORY_RECONNECT_MAX_MS", c.InventoryReconnectMaxMS},
+	} {
+		if limit.value < 0 {
+			errs = append(errs, fmt.Errorf("%s: must be >= 0, got %d", limit.name, limit.value))
+		}
+	}
+
+	if c.SQSPollerCount < 1 {
+		errs = append(errs, fmt.Errorf("SQS_POLLER_COUNT: must be >= 1, got %d", c.SQSPollerCount))
+	}
+	if c.BatchWindowMS > 0 && c.BatchMaxSize < 1 {
+		errs = append(errs, fmt.Errorf("BATCH_MAX_SIZE: must be >= 1 when batching is enabled, got %d", c.BatchMaxSize))
+	}
+
+	if c.InventoryGRPCAddr == "" {
+		errs = append(errs, errors.New("INVENTORY_GRPC_ADDR: must not be empty"))
+	}
+	if c.ReservationAPIBase == "" {
+		errs = append(errs, errors.New("RESERVATION_API_BASE: must not be empty"))
+	}
+	if c.InventoryReconnectMaxMS > 0 && c.InventoryReconnectMaxMS < c.InventoryReconnectBaseMS {
+		errs = append(errs, fmt.Errorf("INVENTORY_RECONNECT_MAX_MS: must be >= INVENTORY_RECONNECT_BASE_MS, got %d", c.InventoryReconnectMaxMS))
+	}
+	if c.InventoryTLSEnabled && (c.InventoryTLSCertFile == "") != (c.InventoryTLSKeyFile == "") {
+		errs = append(errs, errors.New("INVENTORY_TLS_CERT_FILE and INVENTORY_TLS_KEY_FILE: must be set together"))
+	}
+
+	switch c.DeletePolicy {
+	case DeletePolicyOnSuccess, DeletePolicyOnReceive:
+	default:
+		errs = append(errs, fmt.Errorf("DELETE_POLICY: must be one of %s, %s, got %q", DeletePolicyOnSuccess, DeletePolicyOnReceive, c.DeletePolicy))
+	}
+
+	switch c.ParseErrorPolicy {
+	case ParseErrorPolicyDrop, ParseErrorPolicyRetry:
+	case ParseErrorPolicyDLQ:
+		if c.SQSDLQURL == "" {
+			errs = append(errs, errors.New("PARSE_ERROR_POLICY: dlq requires SQS_DLQ_URL"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("PARSE_ERROR_POLICY: must be one of %s, %s, %s, got %q",
+			ParseErrorPolicyDLQ, ParseErrorPolicyRetry, ParseErrorPolicyDrop, c.ParseErrorPolicy))
+	}
+
+	switch c.ExpiredStepOrder {
+	case ExpiredStepOrderReleaseFirst, ExpiredStepOrderStatusFirst:
+	default:
+		errs = append(errs, fmt.Errorf("EXPIRED_STEP_ORDER: must be one of %s, %s, got %q",
+			ExpiredStepOrderReleaseFirst, ExpiredStepOrderStatusFirst, c.ExpiredStepOrder))
+	}
+
+	switch c.EventDecoder {
+	case EventDecoderStd, EventDecoderFast:
+	default:
+		errs = append(errs, fmt.Errorf("EVENT_DECODER: must be one of %s, %s, got %q", EventDecoderStd, EventDecoderFast, c.EventDecoder))
+	}
+
+	if c.ExpectedCurrency != "" && !isCurrencyCode(c.ExpectedCurrency) {
+		errs = append(errs, fmt.Errorf("EXPECTED_CURRENCY: must be a three-letter ISO 4217 code, got %q", c.ExpectedCurrency))
+	}
+
+	switch c.ApprovedAfterTerminal {
+	case ApprovedAfterTerminalFail, ApprovedAfterTerminalDrop:
+	default:
+		errs = append(errs, fmt.Errorf("APPROVED_AFTER_TERMINAL: must be one of %s, %s, got %q",
+			ApprovedAfterTerminalFail, ApprovedAfterTerminalDrop, c.ApprovedAfterTerminal))
+	}
+
+	for eventType, guarantee := range c.ProcessingGuarantees {
+		switch guarantee {
+		case GuaranteeAtLeastOnce, GuaranteeAtMostOnce:
+		default:
+			errs = append(errs, fmt.Errorf("PROCESSING_GUARANTEES: %s must be one of %s, %s, got %q", eventType, GuaranteeAtLeastOnce, GuaranteeAtMostOnce, guarantee))
+		}
+	}
+
+	for eventType, seconds := range c.MaxEventAgeByType {
+		if n, err := strconv.Atoi(seconds); err != nil || n < 0 {
+			errs = append(errs, fmt.Errorf("MAX_EVENT_AGE_BY_TYPE: %s must be a number of seconds >= 0, got %q", eventType, seconds))
+		}
+	}
+
+	switch c.InventoryLBPolicy {
+	case "round_robin", "pick_first":
+	default:
+		errs = append(errs, fmt.Errorf("INVENTORY_LB_POLICY: must be one of round_robin, pick_first, got %q", c.InventoryLBPolicy))
+	}
+
+	switch c.OTELTracesSampler {
+	case "always", "never":
+	case "ratio":
+		if c.OTELTracesSamplerArg < 0 || c.OTELTracesSamplerArg > 1 {
+			errs = append(errs, fmt.Errorf("OTEL_TRACES_SAMPLER_ARG: must be between 0 and 1, got %v", c.OTELTracesSamplerArg))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("OTEL_TRACES_SAMPLER: must be one of always, never, ratio, got %q", c.OTELTracesSampler))
+	}
+
+	switch c.OTELExporterProtocol {
+	case "http", "grpc":
+	default:
+		errs = append(errs, fmt.Errorf("OTEL_EXPORTER_OTLP_PROTOCOL: must be one of http, grpc, got %q", c.OTELExporterProtocol))
+	}
+
+	switch c.LogFormat {
+	case "json", "console":
+	default:
+		errs = append(errs, fmt.Errorf("LOG_FORMAT: must be one of json, console, got %q", c.LogFormat))
+	}
+	if c.LogSuccessSampleRate < 0 || c.LogSuccessSampleRate > 1 {
+		errs = append(errs, fmt.Errorf("LOG_SUCCESS_SAMPLE_RATE: must be between 0 and 1, got %v", c.LogSuccessSampleRate))
+	}
+
+	switch c.LogExport {
+	case "stdout", "otlp", "both":
+	default:
+		errs = append(errs, fmt.Errorf("LOG_EXPORT: must be one of stdout, otlp, both, got %q", c.LogExport))
+	}
+
+	switch c.MetricsBackend {
+	case "prometheus", "otel", "both":
+	default:
+		errs = append(errs, fmt.Errorf("METRICS_BACKEND: must be one of prometheus, otel, both, got %q", c.MetricsBackend))
+	}
+	if time.Duration(c.MetricsFinalScrapeSec)*time.Second*2 > ShutdownTimeout {
+		errs = append(errs, fmt.Errorf("METRICS_FINAL_SCRAPE_SEC: must be at most half the %s shutdown timeout, the rest is left to drain, got %d", ShutdownTimeout, c.MetricsFinalScrapeSec))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// validateURL checks that raw is an absolute http(s) URL
+func validateURL(raw string) error {
+	if raw == "" {
+		return errors.New("must not be empty")
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("malformed URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("must use http or https scheme, got %q", raw)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("missing host in %q", raw)
+	}
+	return nil
+}
+
+// isCurrencyCode reports whether code has the shape of an ISO 4217 code
+func isCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,4 @@
+// Package eventpb holds the protobuf encoding of the events consumed from SQS
+package eventpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative event.proto
@@ -2,34 +2,51 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/traffic-tacos/proto-contracts/gen/go/reservation/v1"
 	"github.com/traffic-tacos/reservation-worker/internal/client"
+	"github.com/traffic-tacos/reservation-worker/internal/config"
 	"github.com/traffic-tacos/reservation-worker/internal/observability"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
 // ApprovedHandler handles payment.approved events
 type ApprovedHandler struct {
-	inventoryClient   *client.InventoryClient
-	reservationClient *client.ReservationClient
+	inventoryClient   InventoryService
+	reservationClient ReservationService
+	config            *config.Config
 	logger            *observability.Logger
 	metrics           *observability.Metrics
+	compensator       Compensator
+}
+
+// Compensator reverses a payment approved after its reservation ended, e.g. by requesting a refund
+type Compensator interface {
+	CompensateApproval(ctx context.Context, event *Event, detail *PaymentApprovedDetail, status string) error
+}
+
+// SetCompensator hands approvals dropped under APPROVED_AFTER_TERMINAL=drop to c
+func (h *ApprovedHandler) SetCompensator(c Compensator) {
+	h.compensator = c
 }
 
 // NewApprovedHandler creates a new approved event handler
 func NewApprovedHandler(
-	inventoryClient *client.InventoryClient,
-	reservationClient *client.ReservationClient,
+	inventoryClient InventoryService,
+	reservationClient ReservationService,
+	config *config.Config,
 	logger *observability.Logger,
 	metrics *observability.Metrics,
 ) *ApprovedHandler {
 	return &ApprovedHandler{
 		inventoryClient:   inventoryClient,
 		reservationClient: reservationClient,
+		config:            config,
 		logger:            logger,
 		metrics:           metrics,
 	}
@@ -58,6 +75,7 @@ func (h *ApprovedHandler) Handle(ctx context.Context, event *Event) error {
 		attribute.String("reservation_id", approvedDetail.ReservationID),
 		attribute.String("payment_intent_id", approvedDetail.PaymentIntentID),
 		attribute.Int64("amount", approvedDetail.Amount),
+		attribute.Int("attempt", observability.Attempt(ctx)),
 	)
 	defer span.End()
 
@@ -65,12 +83,34 @@ func (h *ApprovedHandler) Handle(ctx context.Context, event *Event) error {
 	if event.TraceID != "" {
 		logger = h.logger.WithTrace(event.TraceID)
 	}
+	logger = logger.With(observability.ProcessingIDField(ctx), observability.ContextField(ctx))
 
 	logger.Info("Processing payment approved event",
 		zap.String("reservation_id", approvedDetail.ReservationID),
 		zap.String("payment_intent_id", approvedDetail.PaymentIntentID),
 		zap.Int64("amount", approvedDetail.Amount),
 	)
+	checkCurrency(h.config, h.metrics, logger, event.Type, approvedDetail.Currency)
+
+	// Refuse events that would move the reservation out of a state it cannot leave
+	var current *client.ReservationDetails
+	if h.config != nil && h.config.ApprovedAfterTerminal == config.ApprovedAfterTerminalDrop {
+		current, err = checkTransition(ctx, h.reservationClient, approvedDetail.ReservationID, client.StatusConfirmed)
+		if status, ended := endedBeforeApproval(err); ended {
+			return h.dropAfterTerminal(ctx, span, start, logger, event, approvedDetail, status)
+		}
+	} else {
+		current, err = guardTransition(ctx, h.config, h.reservationClient, approvedDetail.ReservationID, client.StatusConfirmed)
+	}
+	if err != nil {
+		observability.SetSpanError(span, err)
+		recordOutcome(span, h.metrics, "approved", downstreamOutcome(err), time.Since(start))
+		logger.Error("Refusing reservation status transition",
+			zap.Error(err),
+			zap.String("reservation_id", approvedDetail.ReservationID),
+		)
+		return err
+	}
 
 	// Step 1: Update reservation status to CONFIRMED
 	statusReq := &client.UpdateStatusRequest{
@@ -78,10 +118,11 @@ func (h *ApprovedHandler) Handle(ctx context.Context, event *Event) error {
 		Status:        client.StatusConfirmed,
 		// OrderID will be generated by reservation service
 	}
+	expectCurrent(statusReq, current)
 
-	if err := h.reservationClient.UpdateReservationStatus(ctx, statusReq); err != nil {
+	if err := updateStatus(ctx, h.reservationClient, statusReq, logger); err != nil {
 		observability.SetSpanError(span, err)
-		h.metrics.RecordProcessingDuration("approved", observability.OutcomeDownstreamError, time.Since(start).Seconds())
+		recordOutcome(span, h.metrics, "approved", downstreamOutcome(err), time.Since(start))
 		logger.Error("Failed to update reservation status",
 			zap.Error(err),
 			zap.String("reservation_id", approvedDetail.ReservationID),
@@ -92,6 +133,12 @@ func (h *ApprovedHandler) Handle(ctx context.Context, event *Event) error {
 	logger.Info("Successfully updated reservation status to CONFIRMED",
 		zap.String("reservation_id", approvedDetail.ReservationID),
 	)
+	audit(ctx, h.config, h.logger, event, observability.AuditEntry{
+		Action:        observability.AuditActionStatusTransition,
+		ReservationID: approvedDetail.ReservationID,
+		FromStatus:    statusReq.ExpectedStatus,
+		ToStatus:      client.StatusConfirmed,
+	})
 
 	// Step 2: Commit reservation in inventory service (optional - mark seats as SOLD)
 	if approvedDetail.EventID != "" && len(approvedDetail.SeatIDs) > 0 {
@@ -101,9 +148,11 @@ func (h *ApprovedHandler) Handle(ctx context.Context, event *Event) error {
 			Quantity:        int32(approvedDetail.Quantity),
 			SeatIds:         approvedDetail.SeatIDs,
 			PaymentIntentId: approvedDetail.PaymentIntentID,
+			IdempotencyKey:  commitIdempotencyKey(approvedDetail.ReservationID, approvedDetail.PaymentIntentID),
 		}
 
-		if err := h.inventoryClient.CommitReservation(ctx, commitReq); err != nil {
+		err := commitReservation(ctx, h.inventoryClient, commitReq, logger)
+		if err != nil {
 			// Log error but don't fail the entire operation
 			// The reservation is already confirmed, inventory is in a recoverable state
 			logger.Error("Failed to commit reservation in inventory service",
@@ -116,13 +165,17 @@ func (h *ApprovedHandler) Handle(ctx context.Context, event *Event) error {
 			logger.Info("Successfully committed reservation in inventory service",
 				zap.String("reservation_id", approvedDetail.ReservationID),
 			)
+			audit(ctx, h.config, h.logger, event, observability.AuditEntry{
+				Action:        observability.AuditActionCommitReservation,
+				ReservationID: approvedDetail.ReservationID,
+			})
 		}
 	}
 
 	// Success
 	observability.SetSpanSuccess(span)
 	duration := time.Since(start)
-	h.metrics.RecordProcessingDuration("approved", observability.OutcomeSuccess, duration.Seconds())
+	recordOutcome(span, h.metrics, "approved", successOutcome(h.config), duration)
 
 	logger.Info("Successfully processed payment approved event",
 		zap.String("reservation_id", approvedDetail.ReservationID),
@@ -131,4 +184,43 @@ func (h *ApprovedHandler) Handle(ctx context.Context, event *Event) error {
 	)
 
 	return nil
-}
\ No newline at end of file
+}
+
+// endedBeforeApproval reports whether err refuses a confirmation because the
+// reservation already expired or was cancelled, and the status it ended in
+func endedBeforeApproval(err error) (string, bool) {
+	var transitionErr *client.IllegalTransitionError
+	if !errors.As(err, &transitionErr) {
+		return "", false
+	}
+	switch transitionErr.From {
+	case client.StatusExpired, client.StatusCancelled:
+		return transitionErr.From, true
+	}
+	return "", false
+}
+
+// dropAfterTerminal acks an approval whose reservation already ended without confirming
+// it, handing it to the compensator when one is set. A failed compensation is retried.
+func (h *ApprovedHandler) dropAfterTerminal(ctx context.Context, span trace.Span, start time.Time, logger *zap.Logger, event *Event, detail *PaymentApprovedDetail, status string) error {
+	logger.Warn("Payment approved after reservation ended, not confirming",
+		zap.String("reservation_id", detail.ReservationID),
+		zap.String("payment_intent_id", detail.PaymentIntentID),
+		zap.String("status", status),
+	)
+
+	if h.compensator != nil {
+		if err := h.compensator.CompensateApproval(ctx, event, detail, status); err != nil {
+			observability.SetSpanError(span, err)
+			recordOutcome(span, h.metrics, "approved", observability.OutcomeDownstreamError, time.Since(start))
+			logger.Error("Failed to compensate payment approved after reservation ended",
+				zap.Error(err),
+				zap.String("reservation_id", detail.ReservationID),
+			)
+			return fmt.Errorf("failed to compensate approval: %w", err)
+		}
+	}
+
+	recordOutcome(span, h.metrics, "approved", observability.OutcomeApprovedAfterTerminal, time.Since(start))
+	return nil
+}
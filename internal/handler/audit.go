@@ -0,0 +1,18 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/traffic-tacos/reservation-worker/internal/config"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+)
+
+// audit records a change made while handling event; dry runs change nothing and are not audited
+func audit(ctx context.Context, cfg *config.Config, logger *observability.Logger, event *Event, entry observability.AuditEntry) {
+	if cfg != nil && cfg.DryRun {
+		return
+	}
+	entry.EventID = event.ID
+	entry.EventType = event.Type
+	logger.Audit.Record(ctx, entry)
+}
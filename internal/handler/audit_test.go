@@ -0,0 +1,105 @@
+package handler_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/traffic-tacos/reservation-worker/internal/client"
+	"github.com/traffic-tacos/reservation-worker/internal/config"
+	"github.com/traffic-tacos/reservation-worker/internal/handler"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// auditLines decodes the JSON lines written by an audit logger
+func auditLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("audit line is not JSON: %v", err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestHandlers_AuditLog(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+	badRequest := &client.HTTPStatusError{StatusCode: 400, Body: "invalid status"}
+
+	tests := []struct {
+		name        string
+		eventType   string
+		releaseErr  error
+		updateErr   error
+		dryRun      bool
+		wantActions []string
+	}{
+		{name: "expired", eventType: handler.EventTypeReservationExpired,
+			wantActions: []string{observability.AuditActionReleaseHold, observability.AuditActionStatusTransition}},
+		{name: "failed", eventType: handler.EventTypePaymentFailed,
+			wantActions: []string{observability.AuditActionStatusTransition, observability.AuditActionReleaseHold}},
+		{name: "approved", eventType: handler.EventTypePaymentApproved,
+			wantActions: []string{observability.AuditActionStatusTransition, observability.AuditActionCommitReservation}},
+		{name: "cancelled", eventType: handler.EventTypeReservationCancelled,
+			wantActions: []string{observability.AuditActionStatusTransition, observability.AuditActionReleaseHold}},
+		{name: "expired with inventory unavailable", eventType: handler.EventTypeReservationExpired, releaseErr: unavailable},
+		{name: "failed with update rejected", eventType: handler.EventTypePaymentFailed, updateErr: badRequest},
+		{name: "approved with update rejected", eventType: handler.EventTypePaymentApproved, updateErr: badRequest},
+		{name: "dry run", eventType: handler.EventTypeReservationExpired, dryRun: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := &observability.Logger{Logger: zap.NewNop(), Audit: observability.NewAuditLogger(zapcore.AddSync(&buf))}
+			cfg := &config.Config{DryRun: tt.dryRun}
+			inventory := &stubInventory{releaseErr: tt.releaseErr}
+			reservation := &stubReservation{updateErr: tt.updateErr}
+
+			var h handler.EventHandler
+			switch tt.eventType {
+			case handler.EventTypeReservationExpired:
+				h = handler.NewExpiredHandler(inventory, reservation, cfg, logger, testMetrics)
+			case handler.EventTypePaymentFailed:
+				h = handler.NewFailedHandler(inventory, reservation, cfg, logger, testMetrics)
+			case handler.EventTypePaymentApproved:
+				h = handler.NewApprovedHandler(inventory, reservation, cfg, logger, testMetrics)
+			case handler.EventTypeReservationCancelled:
+				h = handler.NewCancelledHandler(inventory, reservation, cfg, logger, testMetrics)
+			}
+
+			ctx := observability.WithProcessingID(context.Background(), "proc-1")
+			h.Handle(ctx, newTestEvent(t, tt.eventType))
+
+			var actions []string
+			for _, line := range auditLines(t, &buf) {
+				actions = append(actions, line["action"].(string))
+				for key, want := range map[string]string{
+					"log_type":       "audit",
+					"reservation_id": "rsv_123",
+					"event_id":       "msg_1",
+					"event_type":     tt.eventType,
+					"correlation_id": "proc-1",
+				} {
+					if line[key] != want {
+						t.Errorf("audit %s = %v, want %q", key, line[key], want)
+					}
+				}
+			}
+			if !reflect.DeepEqual(actions, tt.wantActions) {
+				t.Errorf("Expected audit actions %v, got %v", tt.wantActions, actions)
+			}
+		})
+	}
+}
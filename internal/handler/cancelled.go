@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/traffic-tacos/proto-contracts/gen/go/reservation/v1"
+	"github.com/traffic-tacos/reservation-worker/internal/client"
+	"github.com/traffic-tacos/reservation-worker/internal/config"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// CancelledHandler handles user-initiated reservation.cancelled events
+type CancelledHandler struct {
+	inventoryClient   InventoryService
+	reservationClient ReservationService
+	config            *config.Config
+	logger            *observability.Logger
+	metrics           *observability.Metrics
+}
+
+// NewCancelledHandler creates a new cancelled event handler
+func NewCancelledHandler(
+	inventoryClient InventoryService,
+	reservationClient ReservationService,
+	config *config.Config,
+	logger *observability.Logger,
+	metrics *observability.Metrics,
+) *CancelledHandler {
+	return &CancelledHandler{
+		inventoryClient:   inventoryClient,
+		reservationClient: reservationClient,
+		config:            config,
+		logger:            logger,
+		metrics:           metrics,
+	}
+}
+
+// Handle processes a reservation cancelled event
+func (h *CancelledHandler) Handle(ctx context.Context, event *Event) error {
+	start := time.Now()
+
+	// Parse event detail
+	detail, err := event.ParseEventDetail()
+	if err != nil {
+		h.metrics.RecordProcessingDuration("cancelled", observability.OutcomeInvalidPayload, time.Since(start).Seconds())
+		return fmt.Errorf("failed to parse event detail: %w", err)
+	}
+
+	cancelledDetail, ok := detail.(*ReservationCancelledDetail)
+	if !ok {
+		h.metrics.RecordProcessingDuration("cancelled", observability.OutcomeInvalidPayload, time.Since(start).Seconds())
+		return fmt.Errorf("invalid event detail type for cancelled event")
+	}
+
+	// Start tracing span
+	ctx, span := observability.StartSpan(ctx, "handle_reservation_cancelled")
+	span.SetAttributes(
+		attribute.String("reservation_id", cancelledDetail.ReservationID),
+		attribute.String("event_id", cancelledDetail.EventID),
+		attribute.String("reason", cancelledDetail.Reason),
+		attribute.Int("attempt", observability.Attempt(ctx)),
+	)
+	defer span.End()
+
+	logger := h.logger.WithEvent(event.Type, cancelledDetail.ReservationID, cancelledDetail.EventID)
+	if event.TraceID != "" {
+		logger = h.logger.WithTrace(event.TraceID)
+	}
+	logger = logger.With(observability.ProcessingIDField(ctx), observability.ContextField(ctx))
+
+	logger.Info("Processing reservation cancelled event",
+		zap.String("reservation_id", cancelledDetail.ReservationID),
+		zap.String("user_id", cancelledDetail.UserID),
+		zap.String("reason", cancelledDetail.Reason),
+	)
+
+	// Refuse events that would move the reservation out of a state it cannot leave
+	current, err := guardTransition(ctx, h.config, h.reservationClient, cancelledDetail.ReservationID, client.StatusCancelled)
+	if err != nil {
+		if isReservationGone(err) {
+			return dropNotFound(span, h.metrics, "cancelled", start, logger, cancelledDetail.ReservationID, err)
+		}
+		observability.SetSpanError(span, err)
+		recordOutcome(span, h.metrics, "cancelled", downstreamOutcome(err), time.Since(start))
+		logger.Error("Refusing reservation status transition",
+			zap.Error(err),
+			zap.String("reservation_id", cancelledDetail.ReservationID),
+		)
+		return err
+	}
+
+	// Step 1: Update reservation status to CANCELLED
+	statusReq := &client.UpdateStatusRequest{
+		ReservationID: cancelledDetail.ReservationID,
+		Status:        client.StatusCancelled,
+	}
+	expectCurrent(statusReq, current)
+
+	if err := updateStatus(ctx, h.reservationClient, statusReq, logger); err != nil {
+		if isReservationGone(err) {
+			return dropNotFound(span, h.metrics, "cancelled", start, logger, cancelledDetail.ReservationID, err)
+		}
+		observability.SetSpanError(span, err)
+		recordOutcome(span, h.metrics, "cancelled", downstreamOutcome(err), time.Since(start))
+		logger.Error("Failed to update reservation status",
+			zap.Error(err),
+			zap.String("reservation_id", cancelledDetail.ReservationID),
+		)
+		return fmt.Errorf("failed to update reservation status: %w", err)
+	}
+
+	logger.Info("Successfully updated reservation status to CANCELLED",
+		zap.String("reservation_id", cancelledDetail.ReservationID),
+	)
+	audit(ctx, h.config, h.logger, event, observability.AuditEntry{
+		Action:        observability.AuditActionStatusTransition,
+		ReservationID: cancelledDetail.ReservationID,
+		FromStatus:    statusReq.ExpectedStatus,
+		ToStatus:      client.StatusCancelled,
+		RequestedBy:   cancelledDetail.UserID,
+		Reason:        cancelledDetail.Reason,
+	})
+
+	// Step 2: Release hold in inventory service
+	if cancelledDetail.EventID != "" && len(cancelledDetail.SeatIDs) > 0 {
+		releaseReq := &reservationv1.ReleaseHoldRequest{
+			EventId:        cancelledDetail.EventID,
+			ReservationId:  cancelledDetail.ReservationID,
+			Quantity:       int32(cancelledDetail.Quantity),
+			SeatIds:        cancelledDetail.SeatIDs,
+			IdempotencyKey: releaseIdempotencyKey(cancelledDetail.ReservationID, event.ID),
+		}
+
+		if err := releaseHold(ctx, h.inventoryClient, releaseReq, logger); err != nil {
+			observability.SetSpanError(span, err)
+			recordOutcome(span, h.metrics, "cancelled", observability.OutcomeDownstreamError, time.Since(start))
+			logger.Error("Failed to release hold in inventory service",
+				zap.Error(err),
+				zap.String("reservation_id", cancelledDetail.ReservationID),
+			)
+			return fmt.Errorf("failed to release hold: %w", err)
+		}
+
+		logger.Info("Successfully released hold in inventory service",
+			zap.String("reservation_id", cancelledDetail.ReservationID),
+		)
+		audit(ctx, h.config, h.logger, event, observability.AuditEntry{
+			Action:        observability.AuditActionReleaseHold,
+			ReservationID: cancelledDetail.ReservationID,
+			RequestedBy:   cancelledDetail.UserID,
+			Reason:        cancelledDetail.Reason,
+		})
+	}
+
+	// Success
+	observability.SetSpanSuccess(span)
+	duration := time.Since(start)
+	recordOutcome(span, h.metrics, "cancelled", successOutcome(h.config), duration)
+
+	logger.Info("Successfully processed reservation cancelled event",
+		zap.String("reservation_id", cancelledDetail.ReservationID),
+		zap.Duration("duration", duration),
+	)
+
+	return nil
+}
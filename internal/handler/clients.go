@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/traffic-tacos/proto-contracts/gen/go/reservation/v1"
+	"github.com/traffic-tacos/reservation-worker/internal/client"
+)
+
+// InventoryService is the inventory API used by handlers
+type InventoryService interface {
+	ReleaseHold(ctx context.Context, req *reservationv1.ReleaseHoldRequest) error
+	CommitReservation(ctx context.Context, req *reservationv1.CommitReservationRequest) error
+	ReserveSeat(ctx context.Context, req *reservationv1.ReserveSeatRequest) error
+}
+
+// ReservationService is the reservation API used by handlers
+type ReservationService interface {
+	UpdateReservationStatus(ctx context.Context, req *client.UpdateStatusRequest) error
+	GetReservation(ctx context.Context, reservationID string) (*client.ReservationDetails, error)
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"context"
+	"strings"
+)
+
+// EventTypePaymentRefundRequired asks the payment service to reverse a charge
+const EventTypePaymentRefundRequired = "payment.refund_required"
+
+// PaymentRefundRequiredDetail represents the detail for payment.refund_required events
+type PaymentRefundRequiredDetail struct {
+	ReservationID     string `json:"reservation_id"`
+	PaymentIntentID   string `json:"payment_intent_id"`
+	Amount            int64  `json:"amount"`
+	Currency          string `json:"currency,omitempty"`
+	EventID           string `json:"event_id,omitempty"`
+	UserID            string `json:"user_id,omitempty"`
+	ReservationStatus string `json:"reservation_status"`
+	Reason            string `json:"reason"`
+}
+
+// EventPublisher sends events to other services
+type EventPublisher interface {
+	Publish(ctx context.Context, event *Event) error
+}
+
+// RefundCompensator compensates approvals of ended reservations by publishing payment.refund_required
+type RefundCompensator struct {
+	publisher EventPublisher
+}
+
+// NewRefundCompensator creates a compensator publishing through publisher
+func NewRefundCompensator(publisher EventPublisher) *RefundCompensator {
+	return &RefundCompensator{publisher: publisher}
+}
+
+// CompensateApproval publishes a refund request for the approved payment. The event ID
+// derives from the approval's, so consumers can discard the copy a redelivery publishes.
+func (c *RefundCompensator) CompensateApproval(ctx context.Context, event *Event, detail *PaymentApprovedDetail, status string) error {
+	refund, err := NewEvent("refund-required-"+event.ID, EventTypePaymentRefundRequired, event.TraceID, &PaymentRefundRequiredDetail{
+		ReservationID:     detail.ReservationID,
+		PaymentIntentID:   detail.PaymentIntentID,
+		Amount:            detail.Amount,
+		Currency:          detail.Currency,
+		EventID:           detail.EventID,
+		UserID:            detail.UserID,
+		ReservationStatus: status,
+		Reason:            "reservation_" + strings.ToLower(status),
+	})
+	if err != nil {
+		return err
+	}
+	return c.publisher.Publish(ctx, refund)
+}
@@ -0,0 +1,115 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/traffic-tacos/reservation-worker/internal/client"
+	"github.com/traffic-tacos/reservation-worker/internal/config"
+	"github.com/traffic-tacos/reservation-worker/internal/handler"
+)
+
+// conflictServer is a reservation API that rejects updates whose If-Match is stale.
+// concurrent runs once, after the first read, to simulate another writer.
+type conflictServer struct {
+	mu         sync.Mutex
+	status     string
+	version    int
+	reads      int
+	patches    int
+	concurrent func(s *conflictServer)
+}
+
+func (s *conflictServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Method == http.MethodGet {
+		json.NewEncoder(w).Encode(client.ReservationDetails{
+			ID:      "rsv_123",
+			Status:  s.status,
+			Version: strconv.Itoa(s.version),
+		})
+		s.reads++
+		if s.reads == 1 && s.concurrent != nil {
+			s.concurrent(s)
+		}
+		return
+	}
+
+	s.patches++
+	if r.Header.Get("If-Match") != strconv.Quote(strconv.Itoa(s.version)) {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+	var payload map[string]string
+	json.NewDecoder(r.Body).Decode(&payload)
+	s.status = payload["status"]
+	s.version++
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestApprovedHandler_ConflictingUpdate(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrent  func(s *conflictServer)
+		wantErr     bool
+		wantStatus  string
+		wantPatches int
+	}{
+		{
+			name:        "no concurrent writer",
+			wantStatus:  client.StatusConfirmed,
+			wantPatches: 1,
+		},
+		{
+			name:        "version bumped while still on hold",
+			concurrent:  func(s *conflictServer) { s.version++ },
+			wantStatus:  client.StatusConfirmed,
+			wantPatches: 2,
+		},
+		{
+			name:        "confirmed by another worker",
+			concurrent:  func(s *conflictServer) { s.status, s.version = client.StatusConfirmed, s.version+1 },
+			wantStatus:  client.StatusConfirmed,
+			wantPatches: 1,
+		},
+		{
+			name:        "expired underneath",
+			concurrent:  func(s *conflictServer) { s.status, s.version = client.StatusExpired, s.version+1 },
+			wantErr:     true,
+			wantStatus:  client.StatusExpired,
+			wantPatches: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &conflictServer{status: client.StatusHold, version: 1, concurrent: tt.concurrent}
+			server := httptest.NewServer(api)
+			t.Cleanup(server.Close)
+
+			cfg := &config.Config{GuardStatusTransitions: true}
+			h := handler.NewApprovedHandler(&stubInventory{}, client.NewReservationClient(server.URL, 0), cfg, testLogger(), testMetrics)
+
+			err := h.Handle(context.Background(), newTestEvent(t, handler.EventTypePaymentApproved))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Handle() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && client.Classify(err) != client.CategoryIllegalTransition {
+				t.Errorf("Classify(%v) = %s, want %s", err, client.Classify(err), client.CategoryIllegalTransition)
+			}
+			if api.status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", api.status, tt.wantStatus)
+			}
+			if api.patches != tt.wantPatches {
+				t.Errorf("patches = %d, want %d", api.patches, tt.wantPatches)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/traffic-tacos/reservation-worker/internal/config"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"go.uber.org/zap"
+)
+
+// iso4217 holds the ISO 4217 codes of circulating currencies; fund, metal and test codes are left out
+var iso4217 = func() map[string]bool {
+	codes := map[string]bool{}
+	for _, code := range strings.Fields(`
+		AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BHD BIF BMD BND BOB BRL
+		BSD BTN BWP BYN BZD CAD CDF CHF CLP CNY COP CRC CUP CVE CZK DJF DKK DOP DZD EGP
+		ERN ETB EUR FJD FKP GBP GEL GHS GIP GMD GNF GTQ GYD HKD HNL HTG HUF IDR ILS INR
+		IQD IRR ISK JMD JOD JPY KES KGS KHR KMF KPW KRW KWD KYD KZT LAK LBP LKR LRD LSL
+		LYD MAD MDL MGA MKD MMK MNT MOP MRU MUR MVR MWK MXN MYR MZN NAD NGN NIO NOK NPR
+		NZD OMR PAB PEN PGK PHP PKR PLN PYG QAR RON RSD RUB RWF SAR SBD SCR SDG SEK SGD
+		SHP SLE SLL SOS SRD SSP STN SVC SYP SZL THB TJS TMT TND TOP TRY TTD TWD TZS UAH
+		UGX USD UYU UZS VED VES VND VUV WST XAF XCD XCG XOF XPF YER ZAR ZMW ZWG ZWL`) {
+		codes[code] = true
+	}
+	return codes
+}()
+
+// checkCurrency logs and counts a payment currency that is not an ISO 4217 code or not
+// EXPECTED_CURRENCY. The event is handled either way; a mismatch points at a data bug upstream.
+func checkCurrency(cfg *config.Config, metrics *observability.Metrics, logger *zap.Logger, eventType, currency string) {
+	if currency == "" {
+		return
+	}
+
+	code := strings.ToUpper(currency)
+	reason := ""
+	switch {
+	case !iso4217[code]:
+		reason = observability.CurrencyInvalidCode
+	case cfg != nil && cfg.ExpectedCurrency != "" && code != cfg.ExpectedCurrency:
+		reason = observability.CurrencyUnexpected
+	default:
+		return
+	}
+
+	metrics.RecordCurrencyMismatch(eventType, reason)
+	expected := ""
+	if cfg != nil {
+		expected = cfg.ExpectedCurrency
+	}
+	logger.Warn("Payment currency mismatch",
+		zap.String("currency", currency),
+		zap.String("expected_currency", expected),
+		zap.String("reason", reason),
+	)
+}
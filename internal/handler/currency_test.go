@@ -0,0 +1,64 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/traffic-tacos/reservation-worker/internal/client"
+	"github.com/traffic-tacos/reservation-worker/internal/config"
+	"github.com/traffic-tacos/reservation-worker/internal/handler"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+)
+
+func TestHandlers_CurrencyCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		eventType  string
+		currency   string
+		expected   string
+		wantReason string // "" = no mismatch recorded
+	}{
+		{name: "valid code", eventType: handler.EventTypePaymentApproved, currency: "KRW", expected: "KRW"},
+		{name: "valid lower-case code", eventType: handler.EventTypePaymentFailed, currency: "krw", expected: "KRW"},
+		{name: "invalid code", eventType: handler.EventTypePaymentApproved, currency: "KRX", expected: "KRW", wantReason: observability.CurrencyInvalidCode},
+		{name: "mismatch", eventType: handler.EventTypePaymentFailed, currency: "USD", expected: "KRW", wantReason: observability.CurrencyUnexpected},
+		{name: "multi-currency deployment", eventType: handler.EventTypePaymentApproved, currency: "USD"},
+		{name: "no currency", eventType: handler.EventTypePaymentApproved, expected: "KRW"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{ExpectedCurrency: tt.expected}
+			inventory := &stubInventory{}
+			reservation := &stubReservation{currentStatus: client.StatusHold}
+			metrics := observability.NewMetrics(observability.WithRegistry(prometheus.NewRegistry()))
+			defer metrics.Unregister()
+
+			var h handler.EventHandler = handler.NewApprovedHandler(inventory, reservation, cfg, testLogger(), metrics)
+			if tt.eventType == handler.EventTypePaymentFailed {
+				h = handler.NewFailedHandler(inventory, reservation, cfg, testLogger(), metrics)
+			}
+
+			detail, _ := json.Marshal(map[string]interface{}{
+				"reservation_id": "rsv_123", "payment_intent_id": "pi_1", "amount": 1000, "currency": tt.currency,
+			})
+			// A mismatch is reported, not rejected
+			if err := h.Handle(context.Background(), &handler.Event{ID: "msg_1", Type: tt.eventType, Detail: detail}); err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+
+			for _, reason := range []string{observability.CurrencyInvalidCode, observability.CurrencyUnexpected} {
+				want := 0.0
+				if reason == tt.wantReason {
+					want = 1
+				}
+				if got := testutil.ToFloat64(metrics.CurrencyMismatches.WithLabelValues(tt.eventType, reason)); got != want {
+					t.Errorf("Expected %v %s mismatches, got %v", want, reason, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,435 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/traffic-tacos/reservation-worker/internal/config"
+)
+
+// EventDecoder decodes a message body into an event. The detail is kept raw and
+// decoded later by ParseEventDetail.
+type EventDecoder interface {
+	Decode(data []byte, event *Event) error
+}
+
+// NewEventDecoder returns the decoder selected by EVENT_DECODER, defaulting to encoding/json
+func NewEventDecoder(name string) EventDecoder {
+	if name == config.EventDecoderFast {
+		return FastDecoder{}
+	}
+	return StdDecoder{}
+}
+
+// StdDecoder decodes events with encoding/json
+type StdDecoder struct{}
+
+// Decode implements EventDecoder
+func (StdDecoder) Decode(data []byte, event *Event) error {
+	return json.Unmarshal(data, event)
+}
+
+// FastDecoder decodes events with a hand-written scanner instead of reflection. It accepts
+// the same input as StdDecoder and decodes the same events; escaped strings, times and
+// resources are handed to encoding/json one token at a time.
+type FastDecoder struct{}
+
+// maxNestingDepth matches the nesting limit of encoding/json
+const maxNestingDepth = 10000
+
+// eventFieldNames are the JSON keys of Event, matched case-insensitively like encoding/json does
+var eventFieldNames = []string{"id", "type", "source", "detail", "time", "trace_id", "version", "region", "account", "resources"}
+
+// Decode implements EventDecoder
+func (FastDecoder) Decode(data []byte, event *Event) error {
+	s := &eventScanner{data: data}
+	s.skipSpace()
+	// A top-level null leaves the event unchanged, as with encoding/json
+	if !s.literal("null") {
+		if err := s.object(event); err != nil {
+			return err
+		}
+	}
+	s.skipSpace()
+	if s.pos < len(s.data) {
+		return s.errorf("unexpected data after event")
+	}
+	return nil
+}
+
+// eventScanner walks an event's JSON once, validating it as it goes
+type eventScanner struct {
+	data []byte
+	pos  int
+}
+
+func (s *eventScanner) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid event JSON at offset %d: %s", s.pos, fmt.Sprintf(format, args...))
+}
+
+func (s *eventScanner) skipSpace() {
+	for s.pos < len(s.data) {
+		switch s.data[s.pos] {
+		case ' ', '\t', '\n', '\r':
+			s.pos++
+		default:
+			return
+		}
+	}
+}
+
+// consume skips c if it is next
+func (s *eventScanner) consume(c byte) bool {
+	if s.pos < len(s.data) && s.data[s.pos] == c {
+		s.pos++
+		return true
+	}
+	return false
+}
+
+// literal skips lit if it is next
+func (s *eventScanner) literal(lit string) bool {
+	if len(s.data)-s.pos >= len(lit) && string(s.data[s.pos:s.pos+len(lit)]) == lit {
+		s.pos += len(lit)
+		return true
+	}
+	return false
+}
+
+// object decodes the event's top-level object into event
+func (s *eventScanner) object(event *Event) error {
+	if !s.consume('{') {
+		return s.errorf("expected an object")
+	}
+	s.skipSpace()
+	if s.consume('}') {
+		return nil
+	}
+
+	for {
+		s.skipSpace()
+		key, err := s.keyValue()
+		if err != nil {
+			return err
+		}
+		s.skipSpace()
+		if !s.consume(':') {
+			return s.errorf("expected ':' after object key")
+		}
+		s.skipSpace()
+		if err := s.field(event, key); err != nil {
+			return err
+		}
+
+		s.skipSpace()
+		if s.consume(',') {
+			continue
+		}
+		if s.consume('}') {
+			return nil
+		}
+		return s.errorf("expected ',' or '}' after object value")
+	}
+}
+
+// field decodes the value of key into event, skipping keys Event does not have
+func (s *eventScanner) field(event *Event, key []byte) error {
+	switch string(key) {
+	case "id":
+		return s.stringField(&event.ID)
+	case "type":
+		return s.stringField(&event.Type)
+	case "source":
+		return s.stringField(&event.Source)
+	case "trace_id":
+		return s.stringField(&event.TraceID)
+	case "version":
+		return s.stringField(&event.Version)
+	case "region":
+		return s.stringField(&event.Region)
+	case "account":
+		return s.stringField(&event.Account)
+	case "detail":
+		raw, err := s.rawValue()
+		if err != nil {
+			return err
+		}
+		event.Detail = append(event.Detail[:0], raw...)
+		return nil
+	case "time":
+		raw, err := s.rawValue()
+		if err != nil {
+			return err
+		}
+		return event.Time.UnmarshalJSON(raw)
+	case "resources":
+		start := s.pos
+		if resources, ok := s.stringArray(event.Resources[:0]); ok {
+			event.Resources = resources
+			return nil
+		}
+		s.pos = start
+		raw, err := s.rawValue()
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(raw, &event.Resources)
+	}
+
+	for _, name := range eventFieldNames {
+		if strings.EqualFold(string(key), name) {
+			return s.field(event, []byte(name))
+		}
+	}
+	return s.skipValue(0)
+}
+
+// stringField decodes a string into dst, leaving it unchanged for null
+func (s *eventScanner) stringField(dst *string) error {
+	if s.literal("null") {
+		return nil
+	}
+	if s.pos >= len(s.data) || s.data[s.pos] != '"' {
+		return s.errorf("expected a string")
+	}
+	str, err := s.stringValue()
+	if err != nil {
+		return err
+	}
+	*dst = str
+	return nil
+}
+
+// stringArray appends the elements of an array of strings to dst. It reports false for
+// anything else, including null elements, leaving the position undefined.
+func (s *eventScanner) stringArray(dst []string) ([]string, bool) {
+	if !s.consume('[') {
+		return nil, false
+	}
+	s.skipSpace()
+	if s.consume(']') {
+		return []string{}, true
+	}
+
+	for {
+		s.skipSpace()
+		if s.pos >= len(s.data) || s.data[s.pos] != '"' {
+			return nil, false
+		}
+		str, err := s.stringValue()
+		if err != nil {
+			return nil, false
+		}
+		dst = append(dst, str)
+
+		s.skipSpace()
+		if s.consume(',') {
+			continue
+		}
+		return dst, s.consume(']')
+	}
+}
+
+// keyValue returns an object key, unescaped only when it needs to be
+func (s *eventScanner) keyValue() ([]byte, error) {
+	start := s.pos
+	escaped, err := s.skipString()
+	if err != nil {
+		return nil, err
+	}
+
+	content := s.data[start+1 : s.pos-1]
+	if !escaped && utf8.Valid(content) {
+		return content, nil
+	}
+	var key string
+	if err := json.Unmarshal(s.data[start:s.pos], &key); err != nil {
+		return nil, err
+	}
+	return []byte(key), nil
+}
+
+// stringValue decodes a string, copying it directly unless it needs unescaping or UTF-8 repair
+func (s *eventScanner) stringValue() (string, error) {
+	start := s.pos
+	escaped, err := s.skipString()
+	if err != nil {
+		return "", err
+	}
+
+	content := s.data[start+1 : s.pos-1]
+	if !escaped && utf8.Valid(content) {
+		return string(content), nil
+	}
+	var str string
+	if err := json.Unmarshal(s.data[start:s.pos], &str); err != nil {
+		return "", err
+	}
+	return str, nil
+}
+
+// rawValue skips a value and returns its bytes
+func (s *eventScanner) rawValue() ([]byte, error) {
+	start := s.pos
+	if err := s.skipValue(0); err != nil {
+		return nil, err
+	}
+	return s.data[start:s.pos], nil
+}
+
+// skipString skips a string, reporting whether it contains escapes
+func (s *eventScanner) skipString() (bool, error) {
+	if !s.consume('"') {
+		return false, s.errorf("expected a string")
+	}
+
+	escaped := false
+	for s.pos < len(s.data) {
+		c := s.data[s.pos]
+		switch {
+		case c == '"':
+			s.pos++
+			return escaped, nil
+		case c == '\\':
+			escaped = true
+			if err := s.skipEscape(); err != nil {
+				return false, err
+			}
+		case c < 0x20:
+			return false, s.errorf("control character in string")
+		default:
+			s.pos++
+		}
+	}
+	return false, s.errorf("unterminated string")
+}
+
+// skipEscape skips a backslash escape sequence
+func (s *eventScanner) skipEscape() error {
+	s.pos++ // The backslash
+	if s.pos >= len(s.data) {
+		return s.errorf("unterminated string")
+	}
+
+	switch s.data[s.pos] {
+	case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+		s.pos++
+		return nil
+	case 'u':
+		s.pos++
+		for i := 0; i < 4; i++ {
+			if s.pos >= len(s.data) || !isHexDigit(s.data[s.pos]) {
+				return s.errorf("invalid \\u escape")
+			}
+			s.pos++
+		}
+		return nil
+	default:
+		return s.errorf("invalid escape character %q", s.data[s.pos])
+	}
+}
+
+// skipValue skips and validates any JSON value nested depth levels deep
+func (s *eventScanner) skipValue(depth int) error {
+	if s.pos >= len(s.data) {
+		return s.errorf("unexpected end of input")
+	}
+
+	switch c := s.data[s.pos]; {
+	case c == '"':
+		_, err := s.skipString()
+		return err
+	case c == '{':
+		return s.skipComposite(depth, '}', true)
+	case c == '[':
+		return s.skipComposite(depth, ']', false)
+	case c == '-' || isDigit(c):
+		return s.skipNumber()
+	case s.literal("true"), s.literal("false"), s.literal("null"):
+		return nil
+	default:
+		return s.errorf("invalid character %q looking for a value", c)
+	}
+}
+
+// skipComposite skips an object or array, whose members are key-value pairs when keyed
+func (s *eventScanner) skipComposite(depth int, closing byte, keyed bool) error {
+	if depth+1 > maxNestingDepth {
+		return s.errorf("exceeded max depth")
+	}
+	s.pos++ // The opening bracket
+	s.skipSpace()
+	if s.consume(closing) {
+		return nil
+	}
+
+	for {
+		s.skipSpace()
+		if keyed {
+			if _, err := s.skipString(); err != nil {
+				return err
+			}
+			s.skipSpace()
+			if !s.consume(':') {
+				return s.errorf("expected ':' after object key")
+			}
+			s.skipSpace()
+		}
+		if err := s.skipValue(depth + 1); err != nil {
+			return err
+		}
+
+		s.skipSpace()
+		if s.consume(',') {
+			continue
+		}
+		if s.consume(closing) {
+			return nil
+		}
+		return s.errorf("expected ',' or %q", closing)
+	}
+}
+
+// skipNumber skips a number: -?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?
+func (s *eventScanner) skipNumber() error {
+	s.consume('-')
+	switch {
+	case s.consume('0'):
+	case s.pos < len(s.data) && isDigit(s.data[s.pos]):
+		s.skipDigits()
+	default:
+		return s.errorf("invalid number")
+	}
+
+	if s.consume('.') {
+		if s.skipDigits() == 0 {
+			return s.errorf("invalid number")
+		}
+	}
+	if s.consume('e') || s.consume('E') {
+		if !s.consume('+') {
+			s.consume('-')
+		}
+		if s.skipDigits() == 0 {
+			return s.errorf("invalid number")
+		}
+	}
+	return nil
+}
+
+// skipDigits skips decimal digits and returns how many
+func (s *eventScanner) skipDigits() int {
+	start := s.pos
+	for s.pos < len(s.data) && isDigit(s.data[s.pos]) {
+		s.pos++
+	}
+	return s.pos - start
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
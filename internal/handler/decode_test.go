@@ -0,0 +1,102 @@
+package handler_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/traffic-tacos/reservation-worker/internal/handler"
+)
+
+// Representative message bodies, as published by the reservation and payment services
+var benchmarkPayloads = map[string]string{
+	"reservation.expired": `{"id":"evt-7f3c2a","type":"reservation.expired","source":"reservation-api","version":"2",` +
+		`"time":"2024-01-15T10:30:00Z","region":"ap-northeast-2","account":"123456789012",` +
+		`"detail":{"reservation_id":"rsv_abc123","event_id":"evt_concert_2024","qty":2,` +
+		`"seat_ids":["A-12","A-13"],"expired_at":"2024-01-15T10:30:00Z"}}`,
+	"payment.approved": `{"id":"evt-9d1e4b","type":"payment.approved","source":"payment-sim-api","version":"2",` +
+		`"time":"2024-01-15T10:29:41.123456789+09:00","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736",` +
+		`"resources":["arn:aws:events:ap-northeast-2:123456789012:rule/payments"],` +
+		`"detail":{"reservation_id":"rsv_abc123","payment_intent_id":"pay_xyz789","amount":120000,"currency":"KRW"}}`,
+}
+
+func TestEventDecoders_Identical(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{"empty object", `{}`, false},
+		{"top-level null", `null`, false},
+		{"surrounding whitespace", " \n\t{\"id\" : \"evt-1\" , \"type\":\"reservation.expired\"}\r\n", false},
+		{"escaped strings", `{"id":"evt-\"1\"","source":"api\/v1é😀\n"}`, false},
+		{"non-ASCII strings", `{"id":"예약-1","source":"réservation"}`, false},
+		{"invalid UTF-8 is replaced", "{\"id\":\"evt-\xff\xfe\"}", false},
+		{"escaped key", `{"\u0069d":"evt-1"}`, false},
+		{"case-insensitive keys", `{"ID":"evt-1","Type":"payment.failed","TRACE_ID":"abc","ſource":"api"}`, false},
+		{"duplicate keys keep the last", `{"id":"evt-1","id":"evt-2","detail":{"a":1},"detail":[2]}`, false},
+		{"null fields", `{"id":null,"detail":null,"time":null,"resources":null}`, false},
+		{"unknown fields", `{"extra":{"nested":[1,-2.5e+3,true,false,null,"x",{}],"deep":[[[]]]},"id":"evt-1","n":0}`, false},
+		{"detail keeps its formatting", `{"detail": { "reservation_id" : "rsv_1",` + "\n" + ` "qty": 2 }}`, false},
+		{"empty resources", `{"resources":[]}`, false},
+		{"resources with a null element", `{"resources":["a",null,"b"]}`, false},
+
+		{"empty input", ``, true},
+		{"truncated", `{"id":"evt-1"`, true},
+		{"trailing data", `{"id":"evt-1"}x`, true},
+		{"trailing comma", `{"id":"evt-1",}`, true},
+		{"not an object", `["evt-1"]`, true},
+		{"number for a string field", `{"id":1}`, true},
+		{"malformed time", `{"time":"yesterday"}`, true},
+		{"non-string resources", `{"resources":[1]}`, true},
+		{"invalid escape", `{"id":"evt-\x"}`, true},
+		{"control character in string", "{\"id\":\"evt-\n1\"}", true},
+		{"leading zero in unknown field", `{"n":01}`, true},
+		{"bad literal in detail", `{"detail":{"ok":tru}}`, true},
+		{"unterminated detail", `{"detail":{"reservation_id":"rsv_1"`, true},
+	}
+	for name, body := range benchmarkPayloads {
+		tests = append(tests, struct {
+			name    string
+			body    string
+			wantErr bool
+		}{name, body, false})
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var std, fast handler.Event
+			stdErr := handler.StdDecoder{}.Decode([]byte(tt.body), &std)
+			fastErr := handler.FastDecoder{}.Decode([]byte(tt.body), &fast)
+
+			if (stdErr != nil) != tt.wantErr || (fastErr != nil) != tt.wantErr {
+				t.Fatalf("Decode() errors std = %v, fast = %v, want error = %v", stdErr, fastErr, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(std, fast) {
+				t.Errorf("Decoders disagree:\nstd  = %+v\nfast = %+v", std, fast)
+			}
+		})
+	}
+}
+
+func BenchmarkEventDecoders(b *testing.B) {
+	decoders := map[string]handler.EventDecoder{
+		"std":  handler.StdDecoder{},
+		"fast": handler.FastDecoder{},
+	}
+
+	for payloadName, payload := range benchmarkPayloads {
+		body := []byte(payload)
+		for decoderName, decoder := range decoders {
+			b.Run(payloadName+"/"+decoderName, func(b *testing.B) {
+				b.ReportAllocs()
+				b.SetBytes(int64(len(body)))
+				for i := 0; i < b.N; i++ {
+					var event handler.Event
+					if err := decoder.Decode(body, &event); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/traffic-tacos/proto-contracts/gen/go/reservation/v1"
+	"github.com/traffic-tacos/reservation-worker/internal/client"
+	"github.com/traffic-tacos/reservation-worker/internal/config"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"go.uber.org/zap"
+)
+
+// dryRunInventory logs inventory mutations instead of performing them
+type dryRunInventory struct {
+	logger *observability.Logger
+}
+
+// NewDryRunInventory returns an inventory service that only logs intended calls
+func NewDryRunInventory(logger *observability.Logger) InventoryService {
+	return &dryRunInventory{logger: logger}
+}
+
+// ReleaseHold logs the release that would have been issued
+func (d *dryRunInventory) ReleaseHold(ctx context.Context, req *reservationv1.ReleaseHoldRequest) error {
+	d.logger.Info("Dry run: skipping ReleaseHold",
+		zap.String("reservation_id", req.GetReservationId()),
+		zap.String("event_id", req.GetEventId()),
+		zap.Int32("quantity", req.GetQuantity()),
+		zap.Strings("seat_ids", req.GetSeatIds()),
+	)
+	return nil
+}
+
+// CommitReservation logs the commit that would have been issued
+func (d *dryRunInventory) CommitReservation(ctx context.Context, req *reservationv1.CommitReservationRequest) error {
+	d.logger.Info("Dry run: skipping CommitReservation",
+		zap.String("reservation_id", req.GetReservationId()),
+		zap.String("event_id", req.GetEventId()),
+		zap.Int32("quantity", req.GetQuantity()),
+		zap.Strings("seat_ids", req.GetSeatIds()),
+		zap.String("payment_intent_id", req.GetPaymentIntentId()),
+	)
+	return nil
+}
+
+// ReserveSeat logs the hold that would have been requested
+func (d *dryRunInventory) ReserveSeat(ctx context.Context, req *reservationv1.ReserveSeatRequest) error {
+	d.logger.Info("Dry run: skipping ReserveSeat",
+		zap.String("reservation_id", req.GetReservationId()),
+		zap.String("event_id", req.GetEventId()),
+		zap.Int32("quantity", req.GetQuantity()),
+		zap.Strings("seat_ids", req.GetSeatIds()),
+	)
+	return nil
+}
+
+// dryRunReservation logs status updates instead of performing them.
+// Reads are passed through since they have no side effects.
+type dryRunReservation struct {
+	ReservationService
+	logger *observability.Logger
+}
+
+// NewDryRunReservation returns a reservation service that only logs intended updates
+func NewDryRunReservation(reservationClient ReservationService, logger *observability.Logger) ReservationService {
+	return &dryRunReservation{ReservationService: reservationClient, logger: logger}
+}
+
+// UpdateReservationStatus logs the status update that would have been issued
+func (d *dryRunReservation) UpdateReservationStatus(ctx context.Context, req *client.UpdateStatusRequest) error {
+	d.logger.Info("Dry run: skipping UpdateReservationStatus",
+		zap.String("reservation_id", req.ReservationID),
+		zap.String("status", req.Status),
+	)
+	return nil
+}
+
+// successOutcome returns the outcome recorded when a handler completes
+func successOutcome(cfg *config.Config) string {
+	if cfg != nil && cfg.DryRun {
+		return observability.OutcomeDryRun
+	}
+	return observability.OutcomeSuccess
+}
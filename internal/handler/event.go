@@ -3,6 +3,7 @@ package handler
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 )
 
@@ -18,13 +19,73 @@ type Event struct {
 	Region    string          `json:"region,omitempty"`
 	Account   string          `json:"account,omitempty"`
 	Resources []string        `json:"resources,omitempty"`
+
+	// Bound by the event's source to its message, e.g. an SQS receipt handle
+	ackFunc  func()
+	nackFunc func(retryable bool)
+
+	// When the source may deliver the event again, e.g. its SQS visibility timeout; zero if never
+	visibilityDeadline time.Time
+}
+
+// SetAckFuncs binds the callbacks invoked once processing concludes
+func (e *Event) SetAckFuncs(ack func(), nack func(retryable bool)) {
+	e.ackFunc = ack
+	e.nackFunc = nack
+}
+
+// SetVisibilityDeadline records when the event's source may deliver it again.
+// Nothing extends it once set; sources do not renew message visibility.
+func (e *Event) SetVisibilityDeadline(deadline time.Time) {
+	e.visibilityDeadline = deadline
+}
+
+// VisibilityDeadline returns when the event's source may deliver it again, or the zero time
+func (e *Event) VisibilityDeadline() time.Time {
+	return e.visibilityDeadline
+}
+
+// Ack tells the event's source that processing succeeded
+func (e *Event) Ack() {
+	if e.ackFunc != nil {
+		e.ackFunc()
+	}
+}
+
+// Nack tells the event's source that processing failed for good. retryable reports
+// whether a redelivery could succeed, e.g. once a downstream outage is over.
+func (e *Event) Nack(retryable bool) {
+	if e.nackFunc != nil {
+		e.nackFunc(retryable)
+	}
+}
+
+// ReservationID returns the reservation the event refers to, or "" if its detail cannot be parsed
+func (e *Event) ReservationID() string {
+	detail, err := e.ParseEventDetail()
+	if err != nil {
+		return ""
+	}
+
+	switch d := detail.(type) {
+	case *ReservationExpiredDetail:
+		return d.ReservationID
+	case *PaymentApprovedDetail:
+		return d.ReservationID
+	case *PaymentFailedDetail:
+		return d.ReservationID
+	case *ReservationCancelledDetail:
+		return d.ReservationID
+	default:
+		return ""
+	}
 }
 
 // ReservationExpiredDetail represents the detail for reservation.expired events
 type ReservationExpiredDetail struct {
 	ReservationID string   `json:"reservation_id"`
 	EventID       string   `json:"event_id"`
-	Quantity      int      `json:"qty"`
+	Quantity      int      `json:"quantity"`
 	SeatIDs       []string `json:"seat_ids"`
 	UserID        string   `json:"user_id,omitempty"`
 	ExpiresAt     string   `json:"expires_at,omitempty"`
@@ -39,7 +100,7 @@ type PaymentApprovedDetail struct {
 	EventID         string   `json:"event_id,omitempty"`
 	UserID          string   `json:"user_id,omitempty"`
 	SeatIDs         []string `json:"seat_ids,omitempty"`
-	Quantity        int      `json:"qty,omitempty"`
+	Quantity        int      `json:"quantity,omitempty"`
 }
 
 // PaymentFailedDetail represents the detail for payment.failed events
@@ -53,40 +114,79 @@ type PaymentFailedDetail struct {
 	EventID         string   `json:"event_id,omitempty"`
 	UserID          string   `json:"user_id,omitempty"`
 	SeatIDs         []string `json:"seat_ids,omitempty"`
-	Quantity        int      `json:"qty,omitempty"`
+	Quantity        int      `json:"quantity,omitempty"`
+}
+
+// ReservationCancelledDetail represents the detail for user-initiated reservation.cancelled events
+type ReservationCancelledDetail struct {
+	ReservationID string   `json:"reservation_id"`
+	EventID       string   `json:"event_id,omitempty"`
+	Quantity      int      `json:"quantity,omitempty"`
+	SeatIDs       []string `json:"seat_ids,omitempty"`
+	UserID        string   `json:"user_id,omitempty"`
+	Reason        string   `json:"reason,omitempty"`
+	CancelledAt   string   `json:"cancelled_at,omitempty"`
 }
 
 // Event type constants
 const (
-	EventTypeReservationExpired = "reservation.expired"
-	EventTypePaymentApproved    = "payment.approved"
-	EventTypePaymentFailed      = "payment.failed"
+	EventTypeReservationExpired   = "reservation.expired"
+	EventTypeReservationCancelled = "reservation.cancelled"
+	EventTypePaymentApproved      = "payment.approved"
+	EventTypePaymentFailed        = "payment.failed"
 
 	// Legacy event types for compatibility
 	EventTypeReservationHoldCreated = "reservation.hold.created"
 	EventTypeReservationHoldExpired = "reservation.hold.expired"
 )
 
-// ParseEventDetail parses the event detail based on event type
+// EventSource is the source of events the worker publishes
+const EventSource = "reservation-worker"
+
+// NewEvent builds an event published by the worker, with detail encoded at CurrentEventVersion
+func NewEvent(id, eventType, traceID string, detail interface{}) (*Event, error) {
+	raw, err := json.Marshal(detail)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s detail: %w", eventType, err)
+	}
+	return &Event{
+		ID:      id,
+		Type:    eventType,
+		Source:  EventSource,
+		Detail:  raw,
+		Time:    time.Now().UTC(),
+		TraceID: traceID,
+		Version: strconv.Itoa(CurrentEventVersion),
+	}, nil
+}
+
+// ParseEventDetail parses the event detail based on event type and schema version
 func (e *Event) ParseEventDetail() (interface{}, error) {
 	switch e.Type {
 	case EventTypeReservationExpired, EventTypeReservationHoldExpired:
 		var detail ReservationExpiredDetail
-		if err := json.Unmarshal(e.Detail, &detail); err != nil {
+		if err := e.decodeDetail(&detail); err != nil {
 			return nil, err
 		}
 		return &detail, nil
 
 	case EventTypePaymentApproved:
 		var detail PaymentApprovedDetail
-		if err := json.Unmarshal(e.Detail, &detail); err != nil {
+		if err := e.decodeDetail(&detail); err != nil {
 			return nil, err
 		}
 		return &detail, nil
 
 	case EventTypePaymentFailed:
 		var detail PaymentFailedDetail
-		if err := json.Unmarshal(e.Detail, &detail); err != nil {
+		if err := e.decodeDetail(&detail); err != nil {
+			return nil, err
+		}
+		return &detail, nil
+
+	case EventTypeReservationCancelled:
+		var detail ReservationCancelledDetail
+		if err := e.decodeDetail(&detail); err != nil {
 			return nil, err
 		}
 		return &detail, nil
@@ -2,6 +2,7 @@ package handler_test
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
@@ -62,6 +63,24 @@ func TestEvent_ParseEventDetail(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "parse reservation cancelled event",
+			event: handler.Event{
+				ID:     "evt_555",
+				Type:   handler.EventTypeReservationCancelled,
+				Source: "reservation-api",
+				Time:   time.Now(),
+				Detail: json.RawMessage(`{
+					"reservation_id": "rsv_333",
+					"event_id": "evt_456",
+					"quantity": 1,
+					"seat_ids": ["A-1"],
+					"user_id": "user_1",
+					"reason": "changed_plans"
+				}`),
+			},
+			wantErr: false,
+		},
 		{
 			name: "unknown event type returns error",
 			event: handler.Event{
@@ -94,6 +113,28 @@ func TestEvent_ParseEventDetail(t *testing.T) {
 	}
 }
 
+func TestEvent_ParseCancelledDetail(t *testing.T) {
+	event := handler.Event{
+		Type:   handler.EventTypeReservationCancelled,
+		Detail: json.RawMessage(`{"reservation_id":"rsv_333","user_id":"user_1","reason":"changed_plans"}`),
+	}
+
+	detail, err := event.ParseEventDetail()
+	if err != nil {
+		t.Fatalf("ParseEventDetail() error = %v", err)
+	}
+	cancelled, ok := detail.(*handler.ReservationCancelledDetail)
+	if !ok {
+		t.Fatalf("ParseEventDetail() returned %T, want *ReservationCancelledDetail", detail)
+	}
+	if cancelled.Reason != "changed_plans" || cancelled.UserID != "user_1" {
+		t.Errorf("Unexpected detail %+v", cancelled)
+	}
+	if got := event.ReservationID(); got != "rsv_333" {
+		t.Errorf("ReservationID() = %q, want rsv_333", got)
+	}
+}
+
 func TestValidateEventType(t *testing.T) {
 	tests := []struct {
 		eventType string
@@ -103,6 +144,7 @@ func TestValidateEventType(t *testing.T) {
 		{handler.EventTypeReservationHoldExpired, true},
 		{handler.EventTypePaymentApproved, true},
 		{handler.EventTypePaymentFailed, true},
+		{handler.EventTypeReservationCancelled, true},
 		{"invalid.event", false},
 		{"", false},
 	}
@@ -125,3 +167,94 @@ func TestValidateEventType(t *testing.T) {
 		})
 	}
 }
+
+func TestEvent_UnmarshalSQSBody(t *testing.T) {
+	// SQS message body as published by reservation-api (EventBridge envelope)
+	body := `{
+		"id": "evt_uuid_v4",
+		"type": "reservation.expired",
+		"source": "reservation-api",
+		"detail": {
+			"reservation_id": "rsv_456",
+			"event_id": "evt_789",
+			"qty": 2,
+			"seat_ids": ["A1", "A2"]
+		},
+		"time": "2025-01-23T10:00:00Z",
+		"trace_id": "trace_abc",
+		"version": "1.0",
+		"region": "ap-northeast-2",
+		"account": "137406935518"
+	}`
+
+	var event handler.Event
+	if err := json.Unmarshal([]byte(body), &event); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if event.ID != "evt_uuid_v4" || event.Type != handler.EventTypeReservationExpired || event.Source != "reservation-api" {
+		t.Errorf("Unexpected envelope fields: %+v", event)
+	}
+	if event.TraceID != "trace_abc" || event.Version != "1.0" || event.Region != "ap-northeast-2" {
+		t.Errorf("Unexpected metadata fields: %+v", event)
+	}
+	if want := time.Date(2025, 1, 23, 10, 0, 0, 0, time.UTC); !event.Time.Equal(want) {
+		t.Errorf("Time = %v, want %v", event.Time, want)
+	}
+
+	detail, err := event.ParseEventDetail()
+	if err != nil {
+		t.Fatalf("ParseEventDetail() error = %v", err)
+	}
+	expired, ok := detail.(*handler.ReservationExpiredDetail)
+	if !ok {
+		t.Fatalf("ParseEventDetail() returned %T, want *ReservationExpiredDetail", detail)
+	}
+	if expired.ReservationID != "rsv_456" || expired.Quantity != 2 || len(expired.SeatIDs) != 2 {
+		t.Errorf("Unexpected detail: %+v", expired)
+	}
+}
+
+func TestEvent_ParseEventDetailVersions(t *testing.T) {
+	tests := []struct {
+		name         string
+		version      string
+		detail       string
+		wantQuantity int
+		wantErr      error
+	}{
+		{"unversioned payload uses qty", "", `{"reservation_id":"rsv_1","qty":2}`, 2, nil},
+		{"v1 payload uses qty", "1.0", `{"reservation_id":"rsv_1","qty":3}`, 3, nil},
+		{"v2 payload uses quantity", "2.0", `{"reservation_id":"rsv_1","quantity":4}`, 4, nil},
+		{"v-prefixed version", "v2", `{"reservation_id":"rsv_1","quantity":5}`, 5, nil},
+		{"v2 ignores legacy qty", "2", `{"reservation_id":"rsv_1","qty":6}`, 0, nil},
+		{"future version is rejected", "3.0", `{"reservation_id":"rsv_1","quantity":1}`, 0, handler.ErrUnsupportedVersion},
+		{"malformed version is rejected", "latest", `{"reservation_id":"rsv_1","qty":1}`, 0, handler.ErrUnsupportedVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := handler.Event{
+				Type:    handler.EventTypeReservationExpired,
+				Version: tt.version,
+				Detail:  json.RawMessage(tt.detail),
+			}
+
+			got, err := event.ParseEventDetail()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("ParseEventDetail() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseEventDetail() error = %v", err)
+			}
+
+			detail := got.(*handler.ReservationExpiredDetail)
+			if detail.ReservationID != "rsv_1" || detail.Quantity != tt.wantQuantity {
+				t.Errorf("ParseEventDetail() = %+v, want quantity %d", detail, tt.wantQuantity)
+			}
+		})
+	}
+}
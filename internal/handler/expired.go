@@ -3,33 +3,39 @@ package handler
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/traffic-tacos/proto-contracts/gen/go/reservation/v1"
 	"github.com/traffic-tacos/reservation-worker/internal/client"
+	"github.com/traffic-tacos/reservation-worker/internal/config"
 	"github.com/traffic-tacos/reservation-worker/internal/observability"
 	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // ExpiredHandler handles reservation.expired events
 type ExpiredHandler struct {
-	inventoryClient   *client.InventoryClient
-	reservationClient *client.ReservationClient
+	inventoryClient   InventoryService
+	reservationClient ReservationService
+	config            *config.Config
 	logger            *observability.Logger
 	metrics           *observability.Metrics
 }
 
 // NewExpiredHandler creates a new expired event handler
 func NewExpiredHandler(
-	inventoryClient *client.InventoryClient,
-	reservationClient *client.ReservationClient,
+	inventoryClient InventoryService,
+	reservationClient ReservationService,
+	config *config.Config,
 	logger *observability.Logger,
 	metrics *observability.Metrics,
 ) *ExpiredHandler {
 	return &ExpiredHandler{
 		inventoryClient:   inventoryClient,
 		reservationClient: reservationClient,
+		config:            config,
 		logger:            logger,
 		metrics:           metrics,
 	}
@@ -58,6 +64,7 @@ func (h *ExpiredHandler) Handle(ctx context.Context, event *Event) error {
 		attribute.String("reservation_id", expiredDetail.ReservationID),
 		attribute.String("event_id", expiredDetail.EventID),
 		attribute.Int("quantity", expiredDetail.Quantity),
+		attribute.Int("attempt", observability.Attempt(ctx)),
 	)
 	defer span.End()
 
@@ -65,6 +72,7 @@ func (h *ExpiredHandler) Handle(ctx context.Context, event *Event) error {
 	if event.TraceID != "" {
 		logger = h.logger.WithTrace(event.TraceID)
 	}
+	logger = logger.With(observability.ProcessingIDField(ctx), observability.ContextField(ctx))
 
 	logger.Info("Processing reservation expired event",
 		zap.String("reservation_id", expiredDetail.ReservationID),
@@ -73,48 +81,115 @@ func (h *ExpiredHandler) Handle(ctx context.Context, event *Event) error {
 		zap.Strings("seat_ids", expiredDetail.SeatIDs),
 	)
 
-	// Step 1: Release hold in inventory service
-	releaseReq := &reservationv1.ReleaseHoldRequest{
-		EventId:       expiredDetail.EventID,
-		ReservationId: expiredDetail.ReservationID,
-		Quantity:      int32(expiredDetail.Quantity),
-		SeatIds:       expiredDetail.SeatIDs,
-	}
-
-	if err := h.inventoryClient.ReleaseHold(ctx, releaseReq); err != nil {
+	// Refuse events that would move the reservation out of a state it cannot leave
+	current, err := guardTransition(ctx, h.config, h.reservationClient, expiredDetail.ReservationID, client.StatusExpired)
+	if err != nil {
+		if isReservationGone(err) {
+			return dropNotFound(span, h.metrics, "expired", start, logger, expiredDetail.ReservationID, err)
+		}
 		observability.SetSpanError(span, err)
-		h.metrics.RecordProcessingDuration("expired", observability.OutcomeDownstreamError, time.Since(start).Seconds())
-		logger.Error("Failed to release hold in inventory service",
+		recordOutcome(span, h.metrics, "expired", downstreamOutcome(err), time.Since(start))
+		logger.Error("Refusing reservation status transition",
 			zap.Error(err),
 			zap.String("reservation_id", expiredDetail.ReservationID),
 		)
-		return fmt.Errorf("failed to release hold: %w", err)
+		return err
 	}
 
-	logger.Info("Successfully released hold in inventory service",
-		zap.String("reservation_id", expiredDetail.ReservationID),
-	)
+	// Re-check that the hold really ended, so a renewed hold is not released
+	if h.config.VerifyBeforeRelease {
+		if current == nil {
+			current, err = h.reservationClient.GetReservation(ctx, expiredDetail.ReservationID)
+			if err != nil {
+				if isReservationGone(err) {
+					return dropNotFound(span, h.metrics, "expired", start, logger, expiredDetail.ReservationID, err)
+				}
+				observability.SetSpanError(span, err)
+				recordOutcome(span, h.metrics, "expired", observability.OutcomeDownstreamError, time.Since(start))
+				logger.Error("Failed to verify the reservation expired",
+					zap.Error(err),
+					zap.String("reservation_id", expiredDetail.ReservationID),
+				)
+				return fmt.Errorf("failed to get reservation: %w", err)
+			}
+		}
+		if reason := notExpiredReason(h.config, current, time.Now()); reason != "" {
+			recordOutcome(span, h.metrics, "expired", observability.OutcomeNotExpiredSkipped, time.Since(start))
+			logger.Warn("Reservation has not expired, skipping hold release",
+				zap.String("reservation_id", expiredDetail.ReservationID),
+				zap.String("status", current.Status),
+				zap.Time("hold_expires_at", current.HoldExpiresAt),
+				zap.String("reason", reason),
+			)
+			return nil
+		}
+		if !h.config.GuardStatusTransitions {
+			// Only a guarded update is made conditional on the fetched state
+			current = nil
+		}
+	}
 
-	// Step 2: Update reservation status to EXPIRED
+	releaseReq := &reservationv1.ReleaseHoldRequest{
+		EventId:        expiredDetail.EventID,
+		ReservationId:  expiredDetail.ReservationID,
+		Quantity:       int32(expiredDetail.Quantity),
+		SeatIds:        expiredDetail.SeatIDs,
+		IdempotencyKey: releaseIdempotencyKey(expiredDetail.ReservationID, event.ID),
+	}
 	statusReq := &client.UpdateStatusRequest{
 		ReservationID: expiredDetail.ReservationID,
 		Status:        client.StatusExpired,
 	}
+	expectCurrent(statusReq, current)
 
-	if err := h.reservationClient.UpdateReservationStatus(ctx, statusReq); err != nil {
+	fail := func(err error) error {
 		observability.SetSpanError(span, err)
-		h.metrics.RecordProcessingDuration("expired", observability.OutcomeDownstreamError, time.Since(start).Seconds())
-		logger.Error("Failed to update reservation status",
-			zap.Error(err),
-			zap.String("reservation_id", expiredDetail.ReservationID),
-		)
-		return fmt.Errorf("failed to update reservation status: %w", err)
+		recordOutcome(span, h.metrics, "expired", downstreamOutcome(err), time.Since(start))
+		return err
+	}
+
+	if h.config.ExpiredStepOrder == config.ExpiredStepOrderStatusFirst {
+		// Step 1: Update reservation status to EXPIRED
+		statusErr := h.expire(ctx, event, statusReq, logger)
+		gone := statusErr != nil && isReservationGone(statusErr)
+		if statusErr != nil && !gone {
+			return fail(fmt.Errorf("failed to update reservation status: %w", statusErr))
+		}
+
+		// Step 2: Release hold in inventory service, also for a reservation that is gone.
+		// Nothing is compensated: the hold only lasts longer, until the retry releases it.
+		if err := h.release(ctx, event, releaseReq, logger); err != nil {
+			if statusErr == nil {
+				logger.Error("Reservation expired but its hold is kept until the retry releases it",
+					zap.String("reservation_id", expiredDetail.ReservationID),
+				)
+			}
+			return fail(fmt.Errorf("failed to release hold: %w", err))
+		}
+		if gone {
+			return dropNotFound(span, h.metrics, "expired", start, logger, expiredDetail.ReservationID, statusErr)
+		}
+	} else {
+		// Step 1: Release hold in inventory service
+		if err := h.release(ctx, event, releaseReq, logger); err != nil {
+			return fail(fmt.Errorf("failed to release hold: %w", err))
+		}
+
+		// Step 2: Update reservation status to EXPIRED
+		if err := h.expire(ctx, event, statusReq, logger); err != nil {
+			if isReservationGone(err) {
+				return dropNotFound(span, h.metrics, "expired", start, logger, expiredDetail.ReservationID, err)
+			}
+			// The reservation is still on HOLD; with RESTORED_HOLD_TTL_SEC keep its seats until the retry
+			h.restoreHold(ctx, event, expiredDetail, logger)
+			return fail(fmt.Errorf("failed to update reservation status: %w", err))
+		}
 	}
 
 	// Success
 	observability.SetSpanSuccess(span)
 	duration := time.Since(start)
-	h.metrics.RecordProcessingDuration("expired", observability.OutcomeSuccess, duration.Seconds())
+	recordOutcome(span, h.metrics, "expired", successOutcome(h.config), duration)
 
 	logger.Info("Successfully processed reservation expired event",
 		zap.String("reservation_id", expiredDetail.ReservationID),
@@ -122,4 +197,128 @@ func (h *ExpiredHandler) Handle(ctx context.Context, event *Event) error {
 	)
 
 	return nil
-}
\ No newline at end of file
+}
+
+// release releases the expired hold
+func (h *ExpiredHandler) release(ctx context.Context, event *Event, req *reservationv1.ReleaseHoldRequest, logger *zap.Logger) error {
+	if stepCompleted(ctx, stepReleaseHold) {
+		logger.Info("Hold released by an earlier attempt, skipping",
+			zap.String("reservation_id", req.ReservationId),
+		)
+		return nil
+	}
+
+	if err := releaseHold(ctx, h.inventoryClient, req, logger); err != nil {
+		logger.Error("Failed to release hold in inventory service",
+			zap.Error(err),
+			zap.String("reservation_id", req.ReservationId),
+		)
+		return err
+	}
+	completeStep(ctx, stepReleaseHold)
+
+	logger.Info("Successfully released hold in inventory service",
+		zap.String("reservation_id", req.ReservationId),
+	)
+	audit(ctx, h.config, h.logger, event, observability.AuditEntry{
+		Action:        observability.AuditActionReleaseHold,
+		ReservationID: req.ReservationId,
+	})
+	return nil
+}
+
+// expire moves the reservation to EXPIRED
+func (h *ExpiredHandler) expire(ctx context.Context, event *Event, req *client.UpdateStatusRequest, logger *zap.Logger) error {
+	if stepCompleted(ctx, stepUpdateStatus) {
+		logger.Info("Reservation expired by an earlier attempt, skipping status update",
+			zap.String("reservation_id", req.ReservationID),
+		)
+		return nil
+	}
+
+	if err := updateStatus(ctx, h.reservationClient, req, logger); err != nil {
+		if !isReservationGone(err) {
+			logger.Error("Failed to update reservation status",
+				zap.Error(err),
+				zap.String("reservation_id", req.ReservationID),
+			)
+		}
+		return err
+	}
+	completeStep(ctx, stepUpdateStatus)
+
+	audit(ctx, h.config, h.logger, event, observability.AuditEntry{
+		Action:        observability.AuditActionStatusTransition,
+		ReservationID: req.ReservationID,
+		FromStatus:    req.ExpectedStatus,
+		ToStatus:      client.StatusExpired,
+	})
+	return nil
+}
+
+// restoreHold holds the seats released for a reservation whose status update then failed, for
+// RESTORED_HOLD_TTL_SEC. The retry may release under the same idempotency key, which the inventory
+// service can ignore, so the restored hold must expire on its own.
+func (h *ExpiredHandler) restoreHold(ctx context.Context, event *Event, detail *ReservationExpiredDetail, logger *zap.Logger) {
+	if h.config.RestoredHoldTTLSec <= 0 {
+		logger.Warn("Hold released for a reservation still on HOLD, not restoring it",
+			zap.String("reservation_id", detail.ReservationID),
+		)
+		return
+	}
+
+	req := &reservationv1.ReserveSeatRequest{
+		ReservationId:  detail.ReservationID,
+		EventId:        detail.EventID,
+		SeatIds:        detail.SeatIDs,
+		Quantity:       int32(detail.Quantity),
+		UserId:         detail.UserID,
+		HoldExpiresAt:  timestamppb.New(time.Now().Add(time.Duration(h.config.RestoredHoldTTLSec) * time.Second)),
+		IdempotencyKey: restoreIdempotencyKey(detail.ReservationID, event.ID, observability.Attempt(ctx)),
+	}
+
+	// Restore even when the attempt was cancelled, which may be why the update failed
+	ctx = context.WithoutCancel(ctx)
+	if err := traceStep(ctx, stepRestoreHold, func() error { return h.inventoryClient.ReserveSeat(ctx, req) }); err != nil {
+		logger.Error("Failed to restore released hold",
+			zap.Error(err),
+			zap.String("reservation_id", detail.ReservationID),
+		)
+		return
+	}
+	// The hold is back, so the retry must release it again
+	undoStep(ctx, stepReleaseHold)
+
+	logger.Warn("Restored released hold until the retry",
+		zap.String("reservation_id", detail.ReservationID),
+		zap.Time("hold_expires_at", req.HoldExpiresAt.AsTime()),
+	)
+	audit(ctx, h.config, h.logger, event, observability.AuditEntry{
+		Action:        observability.AuditActionRestoreHold,
+		ReservationID: detail.ReservationID,
+	})
+}
+
+// restoreIdempotencyKey identifies one attempt's restore of a released hold
+func restoreIdempotencyKey(reservationID, eventID string, attempt int) string {
+	return "restore:" + reservationID + ":" + eventID + ":" + strconv.Itoa(attempt)
+}
+
+// notExpiredReason reports why the hold of reservation must not be released, or "" when it
+// expired: the reservation left HOLD for another status than EXPIRED, or its hold runs past now
+// plus VERIFY_EXPIRY_ALLOWANCE_MS. An EXPIRED reservation may still have its hold, when the
+// status-first order failed to release it. A reservation without a hold expiry is judged by its
+// status alone.
+func notExpiredReason(cfg *config.Config, reservation *client.ReservationDetails, now time.Time) string {
+	if reservation.Status == client.StatusExpired {
+		return ""
+	}
+	if reservation.Status != client.StatusHold {
+		return "status is " + reservation.Status
+	}
+	allowance := time.Duration(cfg.VerifyExpiryAllowanceMS) * time.Millisecond
+	if !reservation.HoldExpiresAt.IsZero() && reservation.HoldExpiresAt.After(now.Add(allowance)) {
+		return "hold expires in " + reservation.HoldExpiresAt.Sub(now).Round(time.Second).String()
+	}
+	return ""
+}
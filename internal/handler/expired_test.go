@@ -0,0 +1,229 @@
+package handler_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/traffic-tacos/proto-contracts/gen/go/reservation/v1"
+	"github.com/traffic-tacos/reservation-worker/internal/client"
+	"github.com/traffic-tacos/reservation-worker/internal/config"
+	"github.com/traffic-tacos/reservation-worker/internal/handler"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+)
+
+func TestExpiredHandler_VerifyBeforeRelease(t *testing.T) {
+	tests := []struct {
+		name          string
+		verify        bool
+		guard         bool // GUARD_STATUS_TRANSITIONS, whose lookup the verification reuses
+		currentStatus string
+		expiresIn     time.Duration // Hold expiry relative to now
+		wantReleased  bool
+		wantLookups   int
+	}{
+		{"expired hold is released", true, false, client.StatusHold, -time.Minute, true, 1},
+		{"renewed hold is skipped", true, false, client.StatusHold, 5 * time.Minute, false, 1},
+		{"hold expiring within the allowance is released", true, false, client.StatusHold, time.Second, true, 1},
+		{"reservation no longer on hold is skipped", true, false, client.StatusConfirmed, -time.Minute, false, 1},
+		{"expired reservation is released", true, false, client.StatusExpired, -time.Minute, true, 1},
+		{"guarded lookup is reused", true, true, client.StatusHold, -time.Minute, true, 1},
+		{"not verified when disabled", false, false, client.StatusHold, 5 * time.Minute, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{VerifyBeforeRelease: tt.verify, VerifyExpiryAllowanceMS: 2000, GuardStatusTransitions: tt.guard}
+			inventory := &stubInventory{}
+			reservation := &stubReservation{currentStatus: tt.currentStatus, holdExpiresAt: time.Now().Add(tt.expiresIn)}
+			metrics := observability.NewMetrics(observability.WithRegistry(prometheus.NewRegistry()))
+			defer metrics.Unregister()
+
+			h := handler.NewExpiredHandler(inventory, reservation, cfg, testLogger(), metrics)
+			if err := h.Handle(context.Background(), newTestEvent(t, handler.EventTypeReservationExpired)); err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+
+			if released := inventory.releases == 1 && reservation.updates == 1; released != tt.wantReleased {
+				t.Errorf("Expected released = %v, got %d releases and %d updates", tt.wantReleased, inventory.releases, reservation.updates)
+			}
+			if reservation.lookups != tt.wantLookups {
+				t.Errorf("Expected %d lookups, got %d", tt.wantLookups, reservation.lookups)
+			}
+
+			var m dto.Metric
+			metrics.ProcessingDuration.WithLabelValues("expired", observability.OutcomeNotExpiredSkipped).(prometheus.Histogram).Write(&m)
+			wantSkipped := uint64(0)
+			if !tt.wantReleased {
+				wantSkipped = 1
+			}
+			if got := m.GetHistogram().GetSampleCount(); got != wantSkipped {
+				t.Errorf("Expected %d not_expired_skipped outcomes, got %d", wantSkipped, got)
+			}
+		})
+	}
+}
+
+// orderedClients records the order of the expired handler's downstream calls
+type orderedClients struct {
+	*stubInventory
+	*stubReservation
+	calls []string
+}
+
+func (o *orderedClients) ReleaseHold(ctx context.Context, req *reservationv1.ReleaseHoldRequest) error {
+	o.calls = append(o.calls, "release")
+	return o.stubInventory.ReleaseHold(ctx, req)
+}
+
+func (o *orderedClients) UpdateReservationStatus(ctx context.Context, req *client.UpdateStatusRequest) error {
+	o.calls = append(o.calls, "update")
+	return o.stubReservation.UpdateReservationStatus(ctx, req)
+}
+
+func (o *orderedClients) ReserveSeat(ctx context.Context, req *reservationv1.ReserveSeatRequest) error {
+	o.calls = append(o.calls, "restore")
+	return o.stubInventory.ReserveSeat(ctx, req)
+}
+
+func TestExpiredHandler_StepOrder(t *testing.T) {
+	unavailable := &client.HTTPStatusError{StatusCode: 503, Body: "service unavailable"}
+	releaseFailed := errors.New("inventory unavailable")
+
+	tests := []struct {
+		name       string
+		order      string
+		ttlSec     int
+		releaseErr error
+		updateErr  error
+		reserveErr error
+		wantCalls  []string
+		wantErr    bool
+	}{
+		{name: "release first", order: config.ExpiredStepOrderReleaseFirst, ttlSec: 60,
+			wantCalls: []string{"release", "update"}},
+		{name: "status first", order: config.ExpiredStepOrderStatusFirst, ttlSec: 60,
+			wantCalls: []string{"update", "release"}},
+		{name: "release first stops when the release fails", order: config.ExpiredStepOrderReleaseFirst, ttlSec: 60,
+			releaseErr: releaseFailed, wantCalls: []string{"release"}, wantErr: true},
+		{name: "release first restores the hold when the update fails", order: config.ExpiredStepOrderReleaseFirst, ttlSec: 60,
+			updateErr: unavailable, wantCalls: []string{"release", "update", "restore"}, wantErr: true},
+		{name: "release first fails when the restore fails", order: config.ExpiredStepOrderReleaseFirst, ttlSec: 60,
+			updateErr: unavailable, reserveErr: releaseFailed, wantCalls: []string{"release", "update", "restore"}, wantErr: true},
+		{name: "release first without a restored hold TTL", order: config.ExpiredStepOrderReleaseFirst,
+			updateErr: unavailable, wantCalls: []string{"release", "update"}, wantErr: true},
+		{name: "status first stops when the update fails", order: config.ExpiredStepOrderStatusFirst, ttlSec: 60,
+			updateErr: unavailable, wantCalls: []string{"update"}, wantErr: true},
+		{name: "status first keeps the status when the release fails", order: config.ExpiredStepOrderStatusFirst, ttlSec: 60,
+			releaseErr: releaseFailed, wantCalls: []string{"update", "release"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{ExpiredStepOrder: tt.order, RestoredHoldTTLSec: tt.ttlSec}
+			clients := &orderedClients{
+				stubInventory:   &stubInventory{releaseErr: tt.releaseErr, reserveErr: tt.reserveErr},
+				stubReservation: &stubReservation{updateErr: tt.updateErr, currentStatus: client.StatusHold},
+			}
+
+			h := handler.NewExpiredHandler(clients, clients, cfg, testLogger(), testMetrics)
+			err := h.Handle(context.Background(), newTestEvent(t, handler.EventTypeReservationExpired))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Handle() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(clients.calls, tt.wantCalls) {
+				t.Errorf("Expected calls %v, got %v", tt.wantCalls, clients.calls)
+			}
+		})
+	}
+}
+
+func TestExpiredHandler_RestoredHold(t *testing.T) {
+	cfg := &config.Config{ExpiredStepOrder: config.ExpiredStepOrderReleaseFirst, RestoredHoldTTLSec: 60}
+	inventory := &stubInventory{}
+	reservation := &stubReservation{updateErr: &client.HTTPStatusError{StatusCode: 503}, currentStatus: client.StatusHold}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // The restore must go out even for a cancelled attempt
+
+	before := time.Now()
+	h := handler.NewExpiredHandler(inventory, reservation, cfg, testLogger(), testMetrics)
+	if err := h.Handle(ctx, newTestEvent(t, handler.EventTypeReservationExpired)); err == nil {
+		t.Fatal("Expected the failed status update to fail the handler")
+	}
+
+	if len(inventory.reserves) != 1 {
+		t.Fatalf("Expected 1 restored hold, got %d", len(inventory.reserves))
+	}
+	req := inventory.reserves[0]
+	if req.GetReservationId() != "rsv_123" || req.GetEventId() != "evt_456" || req.GetQuantity() != 2 ||
+		!reflect.DeepEqual(req.GetSeatIds(), []string{"A-1", "A-2"}) {
+		t.Errorf("Restored hold does not match the released one: %v", req)
+	}
+	if expires := req.GetHoldExpiresAt().AsTime(); expires.Before(before.Add(time.Minute)) || expires.After(time.Now().Add(time.Minute)) {
+		t.Errorf("Expected the restored hold to expire in 60s, got %v", expires)
+	}
+	if req.GetIdempotencyKey() == "" || req.GetIdempotencyKey() == inventory.keys[0] {
+		t.Errorf("Expected a restore idempotency key distinct from the release's, got %q", req.GetIdempotencyKey())
+	}
+}
+
+func TestExpiredHandler_RetrySkipsCompletedSteps(t *testing.T) {
+	unavailable := &client.HTTPStatusError{StatusCode: 503, Body: "service unavailable"}
+	releaseFailed := errors.New("inventory unavailable")
+
+	tests := []struct {
+		name       string
+		order      string
+		ttlSec     int
+		releaseErr error // Fails the first attempt only
+		updateErr  error // Fails the first attempt only
+		wantCalls  []string
+	}{
+		{name: "release first retries only the status update", order: config.ExpiredStepOrderReleaseFirst,
+			updateErr: unavailable, wantCalls: []string{"release", "update", "update"}},
+		{name: "release first releases a restored hold again", order: config.ExpiredStepOrderReleaseFirst, ttlSec: 60,
+			updateErr: unavailable, wantCalls: []string{"release", "update", "restore", "release", "update"}},
+		{name: "status first retries only the release", order: config.ExpiredStepOrderStatusFirst,
+			releaseErr: releaseFailed, wantCalls: []string{"update", "release", "release"}},
+		{name: "failed first step is retried", order: config.ExpiredStepOrderReleaseFirst,
+			releaseErr: releaseFailed, wantCalls: []string{"release", "release", "update"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{ExpiredStepOrder: tt.order, RestoredHoldTTLSec: tt.ttlSec}
+			clients := &orderedClients{
+				stubInventory:   &stubInventory{releaseErr: tt.releaseErr},
+				stubReservation: &stubReservation{updateErr: tt.updateErr, currentStatus: client.StatusHold},
+			}
+			h := handler.Chain(handler.NewExpiredHandler(clients, clients, cfg, testLogger(), testMetrics),
+				handler.StepTracking(time.Minute))
+			event := newTestEvent(t, handler.EventTypeReservationExpired)
+
+			if err := h(context.Background(), event); err == nil {
+				t.Fatal("Expected the first attempt to fail")
+			}
+			clients.releaseErr, clients.updateErr = nil, nil
+			if err := h(context.Background(), event); err != nil {
+				t.Fatalf("Retry error = %v", err)
+			}
+			if !reflect.DeepEqual(clients.calls, tt.wantCalls) {
+				t.Errorf("Expected calls %v, got %v", tt.wantCalls, clients.calls)
+			}
+
+			// The state is dropped once the event succeeds, so a later redelivery runs every step
+			clients.calls = nil
+			if err := h(context.Background(), event); err != nil {
+				t.Fatalf("Redelivery error = %v", err)
+			}
+			if len(clients.calls) != 2 {
+				t.Errorf("Expected the redelivery to run both steps, got calls %v", clients.calls)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/traffic-tacos/proto-contracts/gen/go/reservation/v1"
 	"github.com/traffic-tacos/reservation-worker/internal/client"
+	"github.com/traffic-tacos/reservation-worker/internal/config"
 	"github.com/traffic-tacos/reservation-worker/internal/observability"
 	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
@@ -14,22 +15,25 @@ import (
 
 // FailedHandler handles payment.failed events
 type FailedHandler struct {
-	inventoryClient   *client.InventoryClient
-	reservationClient *client.ReservationClient
+	inventoryClient   InventoryService
+	reservationClient ReservationService
+	config            *config.Config
 	logger            *observability.Logger
 	metrics           *observability.Metrics
 }
 
 // NewFailedHandler creates a new failed event handler
 func NewFailedHandler(
-	inventoryClient *client.InventoryClient,
-	reservationClient *client.ReservationClient,
+	inventoryClient InventoryService,
+	reservationClient ReservationService,
+	config *config.Config,
 	logger *observability.Logger,
 	metrics *observability.Metrics,
 ) *FailedHandler {
 	return &FailedHandler{
 		inventoryClient:   inventoryClient,
 		reservationClient: reservationClient,
+		config:            config,
 		logger:            logger,
 		metrics:           metrics,
 	}
@@ -59,6 +63,7 @@ func (h *FailedHandler) Handle(ctx context.Context, event *Event) error {
 		attribute.String("payment_intent_id", failedDetail.PaymentIntentID),
 		attribute.Int64("amount", failedDetail.Amount),
 		attribute.String("error_code", failedDetail.ErrorCode),
+		attribute.Int("attempt", observability.Attempt(ctx)),
 	)
 	defer span.End()
 
@@ -66,6 +71,7 @@ func (h *FailedHandler) Handle(ctx context.Context, event *Event) error {
 	if event.TraceID != "" {
 		logger = h.logger.WithTrace(event.TraceID)
 	}
+	logger = logger.With(observability.ProcessingIDField(ctx), observability.ContextField(ctx))
 
 	logger.Info("Processing payment failed event",
 		zap.String("reservation_id", failedDetail.ReservationID),
@@ -74,16 +80,36 @@ func (h *FailedHandler) Handle(ctx context.Context, event *Event) error {
 		zap.String("error_code", failedDetail.ErrorCode),
 		zap.String("error_message", failedDetail.ErrorMessage),
 	)
+	checkCurrency(h.config, h.metrics, logger, event.Type, failedDetail.Currency)
+
+	// Refuse events that would move the reservation out of a state it cannot leave
+	current, err := guardTransition(ctx, h.config, h.reservationClient, failedDetail.ReservationID, client.StatusCancelled)
+	if err != nil {
+		if isReservationGone(err) {
+			return dropNotFound(span, h.metrics, "failed", start, logger, failedDetail.ReservationID, err)
+		}
+		observability.SetSpanError(span, err)
+		recordOutcome(span, h.metrics, "failed", downstreamOutcome(err), time.Since(start))
+		logger.Error("Refusing reservation status transition",
+			zap.Error(err),
+			zap.String("reservation_id", failedDetail.ReservationID),
+		)
+		return err
+	}
 
 	// Step 1: Update reservation status to CANCELLED
 	statusReq := &client.UpdateStatusRequest{
 		ReservationID: failedDetail.ReservationID,
 		Status:        client.StatusCancelled,
 	}
+	expectCurrent(statusReq, current)
 
-	if err := h.reservationClient.UpdateReservationStatus(ctx, statusReq); err != nil {
+	if err := updateStatus(ctx, h.reservationClient, statusReq, logger); err != nil {
+		if isReservationGone(err) {
+			return dropNotFound(span, h.metrics, "failed", start, logger, failedDetail.ReservationID, err)
+		}
 		observability.SetSpanError(span, err)
-		h.metrics.RecordProcessingDuration("failed", observability.OutcomeDownstreamError, time.Since(start).Seconds())
+		recordOutcome(span, h.metrics, "failed", downstreamOutcome(err), time.Since(start))
 		logger.Error("Failed to update reservation status",
 			zap.Error(err),
 			zap.String("reservation_id", failedDetail.ReservationID),
@@ -94,19 +120,26 @@ func (h *FailedHandler) Handle(ctx context.Context, event *Event) error {
 	logger.Info("Successfully updated reservation status to CANCELLED",
 		zap.String("reservation_id", failedDetail.ReservationID),
 	)
+	audit(ctx, h.config, h.logger, event, observability.AuditEntry{
+		Action:        observability.AuditActionStatusTransition,
+		ReservationID: failedDetail.ReservationID,
+		FromStatus:    statusReq.ExpectedStatus,
+		ToStatus:      client.StatusCancelled,
+	})
 
 	// Step 2: Release hold in inventory service
 	if failedDetail.EventID != "" && len(failedDetail.SeatIDs) > 0 {
 		releaseReq := &reservationv1.ReleaseHoldRequest{
-			EventId:       failedDetail.EventID,
-			ReservationId: failedDetail.ReservationID,
-			Quantity:      int32(failedDetail.Quantity),
-			SeatIds:       failedDetail.SeatIDs,
+			EventId:        failedDetail.EventID,
+			ReservationId:  failedDetail.ReservationID,
+			Quantity:       int32(failedDetail.Quantity),
+			SeatIds:        failedDetail.SeatIDs,
+			IdempotencyKey: releaseIdempotencyKey(failedDetail.ReservationID, event.ID),
 		}
 
-		if err := h.inventoryClient.ReleaseHold(ctx, releaseReq); err != nil {
+		if err := releaseHold(ctx, h.inventoryClient, releaseReq, logger); err != nil {
 			observability.SetSpanError(span, err)
-			h.metrics.RecordProcessingDuration("failed", observability.OutcomeDownstreamError, time.Since(start).Seconds())
+			recordOutcome(span, h.metrics, "failed", observability.OutcomeDownstreamError, time.Since(start))
 			logger.Error("Failed to release hold in inventory service",
 				zap.Error(err),
 				zap.String("reservation_id", failedDetail.ReservationID),
@@ -117,12 +150,16 @@ func (h *FailedHandler) Handle(ctx context.Context, event *Event) error {
 		logger.Info("Successfully released hold in inventory service",
 			zap.String("reservation_id", failedDetail.ReservationID),
 		)
+		audit(ctx, h.config, h.logger, event, observability.AuditEntry{
+			Action:        observability.AuditActionReleaseHold,
+			ReservationID: failedDetail.ReservationID,
+		})
 	}
 
 	// Success
 	observability.SetSpanSuccess(span)
 	duration := time.Since(start)
-	h.metrics.RecordProcessingDuration("failed", observability.OutcomeSuccess, duration.Seconds())
+	recordOutcome(span, h.metrics, "failed", successOutcome(h.config), duration)
 
 	logger.Info("Successfully processed payment failed event",
 		zap.String("reservation_id", failedDetail.ReservationID),
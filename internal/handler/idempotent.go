@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/traffic-tacos/proto-contracts/gen/go/reservation/v1"
+	"github.com/traffic-tacos/reservation-worker/internal/client"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// releaseHold releases a hold, treating a hold the inventory service no longer has as released
+func releaseHold(ctx context.Context, inventory InventoryService, req *reservationv1.ReleaseHoldRequest, logger *zap.Logger) error {
+	err := traceStep(ctx, stepReleaseHold, func() error { return inventory.ReleaseHold(ctx, req) })
+	if err == nil || !isAlreadyReleased(err) {
+		return err
+	}
+
+	logger.Info("Hold already released, treating as success",
+		zap.String("reservation_id", req.ReservationId),
+		zap.Error(err),
+	)
+	return nil
+}
+
+// releaseIdempotencyKey identifies one event's release of a reservation's hold, so
+// the inventory service can ignore a redelivery instead of freeing the seats twice
+func releaseIdempotencyKey(reservationID, eventID string) string {
+	return "release:" + reservationID + ":" + eventID
+}
+
+// commitReservation commits a reservation, treating one the inventory service already committed as committed
+func commitReservation(ctx context.Context, inventory InventoryService, req *reservationv1.CommitReservationRequest, logger *zap.Logger) error {
+	err := traceStep(ctx, stepCommitReservation, func() error { return inventory.CommitReservation(ctx, req) })
+	if err == nil || !isAlreadyCommitted(err) {
+		return err
+	}
+
+	logger.Info("Reservation already committed, treating as success",
+		zap.String("reservation_id", req.ReservationId),
+		zap.String("payment_intent_id", req.PaymentIntentId),
+		zap.Error(err),
+	)
+	return nil
+}
+
+// commitIdempotencyKey identifies the commit of a reservation paid by one payment intent, so the
+// inventory service can ignore a retry whose first attempt succeeded instead of selling the seats twice
+func commitIdempotencyKey(reservationID, paymentIntentID string) string {
+	return "commit:" + reservationID + ":" + paymentIntentID
+}
+
+// isAlreadyCommitted reports whether a CommitReservation error means an earlier attempt already committed it
+func isAlreadyCommitted(err error) bool {
+	return client.Classify(err) == client.CategoryAlreadyExists ||
+		strings.Contains(strings.ToLower(err.Error()), "already committed")
+}
+
+// isAlreadyReleased reports whether a ReleaseHold error means there is nothing left to release
+func isAlreadyReleased(err error) bool {
+	return client.Classify(err) == client.CategoryNotFound ||
+		strings.Contains(strings.ToLower(err.Error()), "already released")
+}
+
+// isReservationGone reports whether err means the reservation was deleted or finalized
+// out from under the event, which neither a retry nor a redelivery can change
+func isReservationGone(err error) bool {
+	return client.Classify(err) == client.CategoryNotFound
+}
+
+// dropNotFound records an event whose reservation no longer exists and reports
+// success, so the event is acked instead of retried
+func dropNotFound(span trace.Span, metrics *observability.Metrics, handlerName string, start time.Time, logger *zap.Logger, reservationID string, err error) error {
+	recordOutcome(span, metrics, handlerName, observability.OutcomeNotFound, time.Since(start))
+	logger.Warn("Reservation not found, dropping event",
+		zap.String("reservation_id", reservationID),
+		zap.Error(err),
+	)
+	return nil
+}
+
+// maxConflictRetries bounds how often a conflicting update is re-evaluated and resent
+const maxConflictRetries = 3
+
+// updateStatus updates a reservation's status, treating a reservation
+// that is already in the target status as updated. When a conditional
+// update conflicts, the reservation is re-fetched and the update is resent
+// against the fresh state if the transition is still legal.
+func updateStatus(ctx context.Context, reservation ReservationService, req *client.UpdateStatusRequest, logger *zap.Logger) error {
+	for conflicts := 0; ; conflicts++ {
+		err := traceStep(ctx, stepUpdateStatus, func() error { return reservation.UpdateReservationStatus(ctx, req) })
+		if err == nil {
+			return nil
+		}
+
+		// Only a rejected update can mean the status was already applied
+		if client.Classify(err).Retryable() {
+			return err
+		}
+
+		current, getErr := reservation.GetReservation(ctx, req.ReservationID)
+		if getErr != nil {
+			return err
+		}
+		if current.Status == req.Status {
+			logger.Info("Reservation already in target status, treating as success",
+				zap.String("reservation_id", req.ReservationID),
+				zap.String("status", req.Status),
+				zap.Error(err),
+			)
+			return nil
+		}
+
+		if !client.IsConflict(err) || conflicts >= maxConflictRetries {
+			return err
+		}
+		if transitionErr := client.CheckTransition(req.ReservationID, current.Status, req.Status); transitionErr != nil {
+			return transitionErr
+		}
+
+		logger.Info("Reservation changed during update, retrying against current state",
+			zap.String("reservation_id", req.ReservationID),
+			zap.String("current_status", current.Status),
+			zap.String("current_version", current.Version),
+		)
+		observability.AddSpanEvent(trace.SpanFromContext(ctx), stepUpdateStatus+".conflict_retry",
+			trace.WithAttributes(attribute.Int("retry", conflicts+1), attribute.String("current_status", current.Status)))
+		next := *req
+		expectCurrent(&next, current)
+		req = &next
+	}
+}
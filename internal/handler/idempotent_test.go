@@ -0,0 +1,308 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/traffic-tacos/proto-contracts/gen/go/reservation/v1"
+	"github.com/traffic-tacos/reservation-worker/internal/client"
+	"github.com/traffic-tacos/reservation-worker/internal/config"
+	"github.com/traffic-tacos/reservation-worker/internal/handler"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var testMetrics = observability.NewMetrics()
+
+type stubInventory struct {
+	releaseErr error
+	commitErrs []error // Returned by successive CommitReservation calls, then nil
+	reserveErr error
+	releases   int
+	commits    int
+	reserves   []*reservationv1.ReserveSeatRequest
+	keys       []string // Idempotency keys of ReleaseHold calls
+	commitKeys []string // Idempotency keys of CommitReservation calls
+}
+
+func (s *stubInventory) ReleaseHold(ctx context.Context, req *reservationv1.ReleaseHoldRequest) error {
+	s.releases++
+	s.keys = append(s.keys, req.IdempotencyKey)
+	return s.releaseErr
+}
+
+func (s *stubInventory) CommitReservation(ctx context.Context, req *reservationv1.CommitReservationRequest) error {
+	s.commitKeys = append(s.commitKeys, req.IdempotencyKey)
+	var err error
+	if s.commits < len(s.commitErrs) {
+		err = s.commitErrs[s.commits]
+	}
+	s.commits++
+	return err
+}
+
+// stubReservation fails updates with updateErr and reports currentStatus and holdExpiresAt on lookup
+type stubReservation struct {
+	mu            sync.Mutex
+	updateErr     error
+	getErr        error
+	currentStatus string
+	holdExpiresAt time.Time
+	lookups       int
+	updates       int
+}
+
+func (s *stubReservation) UpdateReservationStatus(ctx context.Context, req *client.UpdateStatusRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updates++
+	return s.updateErr
+}
+
+func (s *stubReservation) GetReservation(ctx context.Context, reservationID string) (*client.ReservationDetails, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lookups++
+	if s.getErr != nil {
+		return nil, s.getErr
+	}
+	return &client.ReservationDetails{ID: reservationID, Status: s.currentStatus, HoldExpiresAt: s.holdExpiresAt}, nil
+}
+
+func (s *stubInventory) ReserveSeat(ctx context.Context, req *reservationv1.ReserveSeatRequest) error {
+	s.reserves = append(s.reserves, req)
+	return s.reserveErr
+}
+
+func newTestEvent(t *testing.T, eventType string) *handler.Event {
+	t.Helper()
+	detail, err := json.Marshal(map[string]interface{}{
+		"reservation_id": "rsv_123",
+		"event_id":       "evt_456",
+		"quantity":       2,
+		"seat_ids":       []string{"A-1", "A-2"},
+	})
+	if err != nil {
+		t.Fatalf("marshal detail: %v", err)
+	}
+	return &handler.Event{ID: "msg_1", Type: eventType, Detail: detail}
+}
+
+func TestHandlers_IdempotentDownstream(t *testing.T) {
+	notFound := status.Error(codes.NotFound, "hold not found")
+	conflict := &client.HTTPStatusError{StatusCode: 409, Body: "status unchanged"}
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+
+	tests := []struct {
+		name          string
+		eventType     string
+		releaseErr    error
+		updateErr     error
+		currentStatus string
+		wantErr       bool
+	}{
+		{name: "expired with hold not found", eventType: handler.EventTypeReservationExpired, releaseErr: notFound},
+		{name: "expired with hold already released", eventType: handler.EventTypeReservationExpired, releaseErr: errors.New("failed to release hold: already released")},
+		{name: "expired with status already expired", eventType: handler.EventTypeReservationExpired, updateErr: conflict, currentStatus: client.StatusExpired},
+		{name: "expired with status in another state", eventType: handler.EventTypeReservationExpired, updateErr: conflict, currentStatus: client.StatusConfirmed, wantErr: true},
+		{name: "expired with inventory unavailable", eventType: handler.EventTypeReservationExpired, releaseErr: unavailable, wantErr: true},
+		{name: "failed with hold not found", eventType: handler.EventTypePaymentFailed, releaseErr: notFound},
+		{name: "failed with status already cancelled", eventType: handler.EventTypePaymentFailed, updateErr: conflict, currentStatus: client.StatusCancelled},
+		{name: "approved with status already confirmed", eventType: handler.EventTypePaymentApproved, updateErr: conflict, currentStatus: client.StatusConfirmed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			inventory := &stubInventory{releaseErr: tt.releaseErr}
+			reservation := &stubReservation{updateErr: tt.updateErr, currentStatus: tt.currentStatus}
+
+			var h handler.EventHandler
+			switch tt.eventType {
+			case handler.EventTypeReservationExpired:
+				h = handler.NewExpiredHandler(inventory, reservation, cfg, testLogger(), testMetrics)
+			case handler.EventTypePaymentFailed:
+				h = handler.NewFailedHandler(inventory, reservation, cfg, testLogger(), testMetrics)
+			case handler.EventTypePaymentApproved:
+				h = handler.NewApprovedHandler(inventory, reservation, cfg, testLogger(), testMetrics)
+			}
+
+			err := h.Handle(context.Background(), newTestEvent(t, tt.eventType))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Handle() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHandlers_ReleaseIdempotencyKey(t *testing.T) {
+	for _, eventType := range []string{handler.EventTypeReservationExpired, handler.EventTypePaymentFailed} {
+		t.Run(eventType, func(t *testing.T) {
+			inventory := &stubInventory{}
+			var h handler.EventHandler
+			if eventType == handler.EventTypeReservationExpired {
+				h = handler.NewExpiredHandler(inventory, &stubReservation{}, &config.Config{}, testLogger(), testMetrics)
+			} else {
+				h = handler.NewFailedHandler(inventory, &stubReservation{}, &config.Config{}, testLogger(), testMetrics)
+			}
+
+			// A retry and a redelivery of the same event, then a different event
+			event := newTestEvent(t, eventType)
+			for i := 0; i < 2; i++ {
+				if err := h.Handle(context.Background(), event); err != nil {
+					t.Fatalf("Handle() error = %v", err)
+				}
+			}
+			other := newTestEvent(t, eventType)
+			other.ID = "msg_2"
+			if err := h.Handle(context.Background(), other); err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+
+			if len(inventory.keys) != 3 {
+				t.Fatalf("Expected 3 releases, got %d", len(inventory.keys))
+			}
+			if inventory.keys[0] == "" || inventory.keys[0] != inventory.keys[1] {
+				t.Errorf("Expected the same non-empty key for one event, got %q and %q", inventory.keys[0], inventory.keys[1])
+			}
+			if inventory.keys[2] == inventory.keys[0] {
+				t.Errorf("Expected a different key for another event, got %q for both", inventory.keys[2])
+			}
+		})
+	}
+}
+
+func TestApprovedHandler_CommitRetrySafe(t *testing.T) {
+	alreadyExists := status.Error(codes.AlreadyExists, "reservation rsv_123 committed")
+	responseLost := status.Error(codes.Unavailable, "connection reset by peer")
+
+	tests := []struct {
+		name          string
+		commitErrs    []error // One delivery of the event per error
+		wantCommitted int     // Commits audited as done
+	}{
+		{"redelivery after a successful commit", []error{nil, alreadyExists}, 2},
+		{"retry after a lost response", []error{responseLost, alreadyExists}, 1},
+		{"already committed in the message", []error{errors.New("failed to commit reservation: reservation already committed")}, 1},
+		{"commit conflict is not success", []error{status.Error(codes.FailedPrecondition, "seats sold to another reservation")}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := &observability.Logger{Logger: zap.NewNop(), Audit: observability.NewAuditLogger(zapcore.AddSync(&buf))}
+			inventory := &stubInventory{commitErrs: tt.commitErrs}
+			h := handler.NewApprovedHandler(inventory, &stubReservation{}, &config.Config{}, logger, testMetrics)
+
+			event := newTestEvent(t, handler.EventTypePaymentApproved)
+			event.Detail = json.RawMessage(`{"reservation_id":"rsv_123","payment_intent_id":"pay_1","event_id":"evt_456","quantity":2,"seat_ids":["A-1","A-2"]}`)
+			for range tt.commitErrs {
+				if err := h.Handle(context.Background(), event); err != nil {
+					t.Fatalf("Handle() error = %v", err)
+				}
+			}
+
+			for i, key := range inventory.commitKeys {
+				if key != "commit:rsv_123:pay_1" {
+					t.Errorf("Commit %d sent idempotency key %q, want one per reservation and payment intent", i+1, key)
+				}
+			}
+			committed := 0
+			for _, line := range auditLines(t, &buf) {
+				if line["action"] == observability.AuditActionCommitReservation {
+					committed++
+				}
+			}
+			if committed != tt.wantCommitted {
+				t.Errorf("Expected %d commits audited, got %d", tt.wantCommitted, committed)
+			}
+		})
+	}
+}
+
+func TestHandlers_RetryableUpdateErrorSkipsLookup(t *testing.T) {
+	reservation := &stubReservation{
+		updateErr:     &client.HTTPStatusError{StatusCode: 503},
+		currentStatus: client.StatusExpired,
+	}
+	h := handler.NewExpiredHandler(&stubInventory{}, reservation, &config.Config{}, testLogger(), testMetrics)
+
+	if err := h.Handle(context.Background(), newTestEvent(t, handler.EventTypeReservationExpired)); err == nil {
+		t.Fatal("Handle() error = nil, want the retryable update error")
+	}
+	if reservation.lookups != 0 {
+		t.Errorf("GetReservation called %d times, want 0", reservation.lookups)
+	}
+}
+
+func TestHandlers_ReservationNotFound(t *testing.T) {
+	httpNotFound := &client.HTTPStatusError{StatusCode: 404, Body: "reservation not found"}
+	grpcNotFound := status.Error(codes.NotFound, "reservation not found")
+
+	tests := []struct {
+		name         string
+		eventType    string
+		guard        bool
+		updateErr    error
+		getErr       error
+		wantErr      bool
+		wantReleases int
+	}{
+		{name: "expired with status update 404", eventType: handler.EventTypeReservationExpired, updateErr: httpNotFound, getErr: httpNotFound, wantReleases: 1},
+		{name: "expired with guard lookup 404", eventType: handler.EventTypeReservationExpired, guard: true, getErr: httpNotFound},
+		{name: "failed with status update 404", eventType: handler.EventTypePaymentFailed, updateErr: httpNotFound, getErr: httpNotFound},
+		{name: "failed with guard lookup NotFound", eventType: handler.EventTypePaymentFailed, guard: true, getErr: grpcNotFound},
+		{name: "approved with status update 404 still fails", eventType: handler.EventTypePaymentApproved, updateErr: httpNotFound, getErr: httpNotFound, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{GuardStatusTransitions: tt.guard}
+			inventory := &stubInventory{}
+			reservation := &stubReservation{updateErr: tt.updateErr, getErr: tt.getErr}
+			metrics := observability.NewMetrics(observability.WithRegistry(prometheus.NewRegistry()))
+			defer metrics.Unregister()
+
+			var (
+				h    handler.EventHandler
+				name string
+			)
+			switch tt.eventType {
+			case handler.EventTypeReservationExpired:
+				h, name = handler.NewExpiredHandler(inventory, reservation, cfg, testLogger(), metrics), "expired"
+			case handler.EventTypePaymentFailed:
+				h, name = handler.NewFailedHandler(inventory, reservation, cfg, testLogger(), metrics), "failed"
+			case handler.EventTypePaymentApproved:
+				h, name = handler.NewApprovedHandler(inventory, reservation, cfg, testLogger(), metrics), "approved"
+			}
+
+			err := h.Handle(context.Background(), newTestEvent(t, tt.eventType))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Handle() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if inventory.releases != tt.wantReleases {
+				t.Errorf("Expected %d releases, got %d", tt.wantReleases, inventory.releases)
+			}
+
+			var m dto.Metric
+			metrics.ProcessingDuration.WithLabelValues(name, observability.OutcomeNotFound).(prometheus.Histogram).Write(&m)
+			wantDropped := uint64(1)
+			if tt.wantErr {
+				wantDropped = 0
+			}
+			if got := m.GetHistogram().GetSampleCount(); got != wantDropped {
+				t.Errorf("Expected %d not_found outcomes, got %d", wantDropped, got)
+			}
+		})
+	}
+}
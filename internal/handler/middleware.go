@@ -0,0 +1,260 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"go.uber.org/zap"
+	"golang.org/x/sync/semaphore"
+)
+
+// ErrHandlerPanic marks errors recovered from a panicking handler; they are retried like any other error
+var ErrHandlerPanic = errors.New("handler panicked")
+
+// EventHandler handles a single event
+type EventHandler interface {
+	Handle(ctx context.Context, event *Event) error
+}
+
+// HandlerFunc adapts a function to EventHandler
+type HandlerFunc func(ctx context.Context, event *Event) error
+
+// Handle calls f(ctx, event)
+func (f HandlerFunc) Handle(ctx context.Context, event *Event) error {
+	return f(ctx, event)
+}
+
+// Middleware decorates a handler with cross-cutting behavior
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Chain wraps h with the middlewares; the first middleware runs outermost
+func Chain(h EventHandler, middlewares ...Middleware) HandlerFunc {
+	wrapped := HandlerFunc(h.Handle)
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		wrapped = middlewares[i](wrapped)
+	}
+	return wrapped
+}
+
+// Recovery converts handler panics into errors wrapping ErrHandlerPanic
+func Recovery(logger *observability.Logger) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, event *Event) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Error("Recovered from handler panic",
+						zap.String("event_type", event.Type),
+						zap.String("event_id", event.ID),
+						zap.Any("panic", r),
+						zap.ByteString("stack", debug.Stack()),
+					)
+					err = fmt.Errorf("%w: %v", ErrHandlerPanic, r)
+				}
+			}()
+			return next(ctx, event)
+		}
+	}
+}
+
+// Timing logs how long the wrapped handler took
+func Timing(logger *observability.Logger) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, event *Event) error {
+			start := time.Now()
+			err := next(ctx, event)
+			logger.Debug("Handler finished",
+				zap.String("event_type", event.Type),
+				zap.String("event_id", event.ID),
+				zap.Duration("duration", time.Since(start)),
+				zap.Bool("success", err == nil),
+			)
+			return err
+		}
+	}
+}
+
+// Dedup skips events whose ID was handled successfully within window.
+// Concurrent deliveries of the same event may both run; this only absorbs redeliveries.
+func Dedup(window time.Duration, logger *observability.Logger) Middleware {
+	seen := newSeenSet(window)
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, event *Event) error {
+			if event.ID != "" && seen.contains(event.ID) {
+				logger.Info("Skipping duplicate event",
+					zap.String("event_type", event.Type),
+					zap.String("event_id", event.ID),
+				)
+				return nil
+			}
+
+			if err := next(ctx, event); err != nil {
+				return err
+			}
+			if event.ID != "" {
+				seen.add(event.ID)
+			}
+			return nil
+		}
+	}
+}
+
+// StepTracking remembers which downstream steps an attempt at an event completed, for window
+// after its last attempt, so a retry skips them instead of calling the service again. The
+// state is dropped once the event succeeds.
+func StepTracking(window time.Duration) Middleware {
+	steps := newStepStore(window)
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, event *Event) error {
+			if event.ID == "" {
+				return next(ctx, event)
+			}
+
+			if err := next(withStepState(ctx, steps.get(event.ID)), event); err != nil {
+				return err
+			}
+			steps.forget(event.ID)
+			return nil
+		}
+	}
+}
+
+// ReservationLimit lets at most limit handlers run at once for the same reservation,
+// so events racing on one reservation's status run one after another.
+// Events whose reservation cannot be determined are not limited.
+func ReservationLimit(limit int) Middleware {
+	slots := newKeyedSlots(limit)
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, event *Event) error {
+			reservationID := event.ReservationID()
+			if reservationID == "" {
+				return next(ctx, event)
+			}
+
+			slot, err := slots.acquire(ctx, reservationID)
+			if err != nil {
+				return err
+			}
+			// Deferred so a panicking handler cannot leave the reservation locked
+			defer slots.release(reservationID, slot)
+
+			return next(ctx, event)
+		}
+	}
+}
+
+// DownstreamLimit lets at most limit handlers wrapped by the returned middleware run at
+// once, capping calls to downstream services shared by all event types.
+// Waiting for a slot ends with ctx.
+func DownstreamLimit(limit int) Middleware {
+	sem := semaphore.NewWeighted(int64(limit))
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, event *Event) error {
+			if err := sem.Acquire(ctx, 1); err != nil {
+				return err
+			}
+			defer sem.Release(1)
+
+			return next(ctx, event)
+		}
+	}
+}
+
+// keyedSlots holds a semaphore per key, created on first use and removed once unused
+type keyedSlots struct {
+	mu    sync.Mutex
+	limit int
+	keys  map[string]*keySlot
+}
+
+// keySlot is the semaphore of one key
+type keySlot struct {
+	sem  chan struct{}
+	refs int // Holders and waiters
+}
+
+func newKeyedSlots(limit int) *keyedSlots {
+	return &keyedSlots{
+		limit: limit,
+		keys:  make(map[string]*keySlot),
+	}
+}
+
+// acquire waits for one of key's slots or until ctx is done
+func (s *keyedSlots) acquire(ctx context.Context, key string) (*keySlot, error) {
+	s.mu.Lock()
+	slot, ok := s.keys[key]
+	if !ok {
+		slot = &keySlot{sem: make(chan struct{}, s.limit)}
+		s.keys[key] = slot
+	}
+	slot.refs++
+	s.mu.Unlock()
+
+	select {
+	case slot.sem <- struct{}{}:
+		return slot, nil
+	case <-ctx.Done():
+		s.unref(key, slot)
+		return nil, ctx.Err()
+	}
+}
+
+func (s *keyedSlots) release(key string, slot *keySlot) {
+	<-slot.sem
+	s.unref(key, slot)
+}
+
+func (s *keyedSlots) unref(key string, slot *keySlot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	slot.refs--
+	if slot.refs == 0 {
+		delete(s.keys, key)
+	}
+}
+
+// seenSet remembers IDs for a fixed window
+type seenSet struct {
+	mu        sync.Mutex
+	window    time.Duration
+	expires   map[string]time.Time
+	lastSweep time.Time
+}
+
+func newSeenSet(window time.Duration) *seenSet {
+	return &seenSet{
+		window:    window,
+		expires:   make(map[string]time.Time),
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *seenSet) contains(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expiry, ok := s.expires[id]
+	return ok && time.Now().Before(expiry)
+}
+
+func (s *seenSet) add(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.expires[id] = now.Add(s.window)
+
+	// Drop expired IDs about once per window so the set stays bounded by traffic
+	if now.Sub(s.lastSweep) >= s.window {
+		for k, expiry := range s.expires {
+			if !now.Before(expiry) {
+				delete(s.expires, k)
+			}
+		}
+		s.lastSweep = now
+	}
+}
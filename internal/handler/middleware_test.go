@@ -0,0 +1,157 @@
+package handler_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/traffic-tacos/reservation-worker/internal/handler"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"go.uber.org/zap"
+)
+
+func testLogger() *observability.Logger {
+	return &observability.Logger{Logger: zap.NewNop()}
+}
+
+// recordingMiddleware appends name to calls before and after next runs
+func recordingMiddleware(name string, calls *[]string) handler.Middleware {
+	return func(next handler.HandlerFunc) handler.HandlerFunc {
+		return func(ctx context.Context, event *handler.Event) error {
+			*calls = append(*calls, name+" before")
+			err := next(ctx, event)
+			*calls = append(*calls, name+" after")
+			return err
+		}
+	}
+}
+
+func TestChain_Order(t *testing.T) {
+	var calls []string
+	h := handler.HandlerFunc(func(ctx context.Context, event *handler.Event) error {
+		calls = append(calls, "handler")
+		return nil
+	})
+
+	chained := handler.Chain(h, recordingMiddleware("outer", &calls), recordingMiddleware("inner", &calls))
+	if err := chained(context.Background(), &handler.Event{ID: "evt-1"}); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	want := []string{"outer before", "inner before", "handler", "inner after", "outer after"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("Expected call order %v, got %v", want, calls)
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	tests := []struct {
+		name    string
+		handle  handler.HandlerFunc
+		wantErr error
+	}{
+		{"passes success through", func(context.Context, *handler.Event) error { return nil }, nil},
+		{"passes errors through", func(context.Context, *handler.Event) error { return context.DeadlineExceeded }, context.DeadlineExceeded},
+		{"converts panic", func(context.Context, *handler.Event) error { panic("nil detail") }, handler.ErrHandlerPanic},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chained := handler.Chain(tt.handle, handler.Recovery(testLogger()))
+
+			err := chained(context.Background(), &handler.Event{ID: "evt-1", Type: handler.EventTypePaymentApproved})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDedup(t *testing.T) {
+	calls := 0
+	failNext := false
+	h := handler.HandlerFunc(func(context.Context, *handler.Event) error {
+		calls++
+		if failNext {
+			failNext = false
+			return errors.New("downstream unavailable")
+		}
+		return nil
+	})
+	chained := handler.Chain(h, handler.Dedup(50*time.Millisecond, testLogger()))
+	ctx := context.Background()
+
+	// A failed attempt is not remembered, so its retry still runs
+	failNext = true
+	if err := chained(ctx, &handler.Event{ID: "evt-1"}); err == nil {
+		t.Fatal("Expected first attempt to fail")
+	}
+	if err := chained(ctx, &handler.Event{ID: "evt-1"}); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	// A redelivery after success is skipped
+	if err := chained(ctx, &handler.Event{ID: "evt-1"}); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected duplicate to be skipped, handler ran %d times", calls)
+	}
+
+	// Other events and redeliveries after the window run again
+	if err := chained(ctx, &handler.Event{ID: "evt-2"}); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if err := chained(ctx, &handler.Event{ID: "evt-1"}); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if calls != 4 {
+		t.Errorf("Expected 4 handler runs, got %d", calls)
+	}
+}
+
+func TestReservationLimit_ReleasesOnPanic(t *testing.T) {
+	h := handler.HandlerFunc(func(context.Context, *handler.Event) error { panic("nil detail") })
+	chained := handler.Chain(h, handler.Recovery(testLogger()), handler.ReservationLimit(1))
+	event := newTestEvent(t, handler.EventTypePaymentApproved)
+
+	if err := chained(context.Background(), event); !errors.Is(err, handler.ErrHandlerPanic) {
+		t.Fatalf("Expected a recovered panic, got %v", err)
+	}
+
+	// The second delivery would block forever if the panic left the reservation locked
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := chained(ctx, event); !errors.Is(err, handler.ErrHandlerPanic) {
+		t.Errorf("Expected the reservation to be unlocked after a panic, got %v", err)
+	}
+}
+
+func TestDownstreamLimit_WaitEndsWithContext(t *testing.T) {
+	release := make(chan struct{})
+	h := handler.HandlerFunc(func(context.Context, *handler.Event) error {
+		<-release
+		return nil
+	})
+	chained := handler.Chain(h, handler.DownstreamLimit(1))
+	event := newTestEvent(t, handler.EventTypePaymentApproved)
+
+	done := make(chan error, 1)
+	go func() { done <- chained(context.Background(), event) }()
+
+	// The only slot is held, so the second call waits until its context is done
+	time.Sleep(10 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := chained(ctx, event); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the wait to end with the context, got %v", err)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("Expected the first call to succeed, got %v", err)
+	}
+}
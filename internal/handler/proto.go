@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/traffic-tacos/reservation-worker/internal/eventpb"
+	"google.golang.org/protobuf/proto"
+)
+
+// ProtoDecoder decodes events published as eventpb.Event. The detail is re-encoded as
+// JSON so handlers parse it like that of a JSON event.
+type ProtoDecoder struct{}
+
+// Decode implements EventDecoder
+func (ProtoDecoder) Decode(data []byte, event *Event) error {
+	var msg eventpb.Event
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	return eventFromProto(&msg, event)
+}
+
+// eventFromProto maps msg onto event
+func eventFromProto(msg *eventpb.Event, event *Event) error {
+	event.ID = msg.GetId()
+	event.Type = msg.GetType()
+	event.Source = msg.GetSource()
+	event.TraceID = msg.GetTraceId()
+	event.Region = msg.GetRegion()
+	event.Account = msg.GetAccount()
+	event.Resources = msg.GetResources()
+
+	// The detail is re-encoded in the current schema whatever version the producer stamped,
+	// so it must not be migrated again. Only a version this worker cannot read is kept, to be rejected.
+	event.Version = msg.GetVersion()
+	if _, err := event.SchemaVersion(); err == nil {
+		event.Version = strconv.Itoa(CurrentEventVersion)
+	}
+
+	if msg.Time != nil {
+		if err := msg.Time.CheckValid(); err != nil {
+			return fmt.Errorf("invalid event time: %w", err)
+		}
+		event.Time = msg.Time.AsTime()
+	}
+
+	detail := detailFromProto(msg)
+	if detail == nil {
+		return nil
+	}
+	raw, err := json.Marshal(detail)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s detail: %w", event.Type, err)
+	}
+	event.Detail = raw
+	return nil
+}
+
+// detailFromProto returns the detail struct matching msg's detail, or nil if it has none
+func detailFromProto(msg *eventpb.Event) interface{} {
+	switch d := msg.GetDetail().(type) {
+	case *eventpb.Event_ReservationExpired:
+		return &ReservationExpiredDetail{
+			ReservationID: d.ReservationExpired.GetReservationId(),
+			EventID:       d.ReservationExpired.GetEventId(),
+			Quantity:      int(d.ReservationExpired.GetQuantity()),
+			SeatIDs:       d.ReservationExpired.GetSeatIds(),
+			UserID:        d.ReservationExpired.GetUserId(),
+			ExpiresAt:     d.ReservationExpired.GetExpiresAt(),
+		}
+	case *eventpb.Event_PaymentApproved:
+		return &PaymentApprovedDetail{
+			ReservationID:   d.PaymentApproved.GetReservationId(),
+			PaymentIntentID: d.PaymentApproved.GetPaymentIntentId(),
+			Amount:          d.PaymentApproved.GetAmount(),
+			Currency:        d.PaymentApproved.GetCurrency(),
+			EventID:         d.PaymentApproved.GetEventId(),
+			UserID:          d.PaymentApproved.GetUserId(),
+			SeatIDs:         d.PaymentApproved.GetSeatIds(),
+			Quantity:        int(d.PaymentApproved.GetQuantity()),
+		}
+	case *eventpb.Event_PaymentFailed:
+		return &PaymentFailedDetail{
+			ReservationID:   d.PaymentFailed.GetReservationId(),
+			PaymentIntentID: d.PaymentFailed.GetPaymentIntentId(),
+			Amount:          d.PaymentFailed.GetAmount(),
+			Currency:        d.PaymentFailed.GetCurrency(),
+			ErrorCode:       d.PaymentFailed.GetErrorCode(),
+			ErrorMessage:    d.PaymentFailed.GetErrorMessage(),
+			EventID:         d.PaymentFailed.GetEventId(),
+			UserID:          d.PaymentFailed.GetUserId(),
+			SeatIDs:         d.PaymentFailed.GetSeatIds(),
+			Quantity:        int(d.PaymentFailed.GetQuantity()),
+		}
+	case *eventpb.Event_ReservationCancelled:
+		return &ReservationCancelledDetail{
+			ReservationID: d.ReservationCancelled.GetReservationId(),
+			EventID:       d.ReservationCancelled.GetEventId(),
+			Quantity:      int(d.ReservationCancelled.GetQuantity()),
+			SeatIDs:       d.ReservationCancelled.GetSeatIds(),
+			UserID:        d.ReservationCancelled.GetUserId(),
+			Reason:        d.ReservationCancelled.GetReason(),
+			CancelledAt:   d.ReservationCancelled.GetCancelledAt(),
+		}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,185 @@
+package handler_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/traffic-tacos/reservation-worker/internal/eventpb"
+	"github.com/traffic-tacos/reservation-worker/internal/handler"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestProtoDecoder_MatchesJSON(t *testing.T) {
+	eventTime := time.Date(2025, 1, 23, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		json string
+		msg  *eventpb.Event
+	}{
+		{
+			name: "reservation expired",
+			json: `{"id":"evt-1","type":"reservation.expired","source":"reservation-api","version":"2",
+				"time":"2025-01-23T10:00:00Z","trace_id":"trace-1","region":"ap-northeast-2","account":"137406935518",
+				"detail":{"reservation_id":"rsv-1","event_id":"concert-1","quantity":2,"seat_ids":["A1","A2"],"expires_at":"2025-01-23T10:00:00Z"}}`,
+			msg: &eventpb.Event{
+				Id: "evt-1", Type: "reservation.expired", Source: "reservation-api", Version: "2",
+				Time: timestamppb.New(eventTime), TraceId: "trace-1", Region: "ap-northeast-2", Account: "137406935518",
+				Detail: &eventpb.Event_ReservationExpired{ReservationExpired: &eventpb.ReservationExpired{
+					ReservationId: "rsv-1", EventId: "concert-1", Quantity: 2, SeatIds: []string{"A1", "A2"}, ExpiresAt: "2025-01-23T10:00:00Z",
+				}},
+			},
+		},
+		{
+			name: "payment approved without a version",
+			json: `{"id":"evt-2","type":"payment.approved","source":"payment-sim-api","version":"2","time":"2025-01-23T10:00:00Z",
+				"resources":["arn:aws:events:rule/payments"],
+				"detail":{"reservation_id":"rsv-1","payment_intent_id":"pay-1","amount":120000,"currency":"KRW","user_id":"user-1"}}`,
+			msg: &eventpb.Event{
+				Id: "evt-2", Type: "payment.approved", Source: "payment-sim-api", Time: timestamppb.New(eventTime),
+				Resources: []string{"arn:aws:events:rule/payments"},
+				Detail: &eventpb.Event_PaymentApproved{PaymentApproved: &eventpb.PaymentApproved{
+					ReservationId: "rsv-1", PaymentIntentId: "pay-1", Amount: 120000, Currency: "KRW", UserId: "user-1",
+				}},
+			},
+		},
+		{
+			name: "payment failed",
+			json: `{"id":"evt-3","type":"payment.failed","version":"2","time":"2025-01-23T10:00:00Z",
+				"detail":{"reservation_id":"rsv-1","payment_intent_id":"pay-1","amount":5000,"error_code":"CARD_DECLINED","quantity":1}}`,
+			msg: &eventpb.Event{
+				Id: "evt-3", Type: "payment.failed", Version: "2", Time: timestamppb.New(eventTime),
+				Detail: &eventpb.Event_PaymentFailed{PaymentFailed: &eventpb.PaymentFailed{
+					ReservationId: "rsv-1", PaymentIntentId: "pay-1", Amount: 5000, ErrorCode: "CARD_DECLINED", Quantity: 1,
+				}},
+			},
+		},
+		{
+			name: "reservation cancelled",
+			json: `{"id":"evt-4","type":"reservation.cancelled","version":"2","time":"2025-01-23T10:00:00Z",
+				"detail":{"reservation_id":"rsv-1","seat_ids":["B7"],"reason":"user_request","cancelled_at":"2025-01-23T09:59:00Z"}}`,
+			msg: &eventpb.Event{
+				Id: "evt-4", Type: "reservation.cancelled", Version: "2", Time: timestamppb.New(eventTime),
+				Detail: &eventpb.Event_ReservationCancelled{ReservationCancelled: &eventpb.ReservationCancelled{
+					ReservationId: "rsv-1", SeatIds: []string{"B7"}, Reason: "user_request", CancelledAt: "2025-01-23T09:59:00Z",
+				}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fromJSON handler.Event
+			if err := (handler.StdDecoder{}).Decode([]byte(tt.json), &fromJSON); err != nil {
+				t.Fatalf("JSON Decode() error = %v", err)
+			}
+
+			body, err := proto.Marshal(tt.msg)
+			if err != nil {
+				t.Fatalf("proto.Marshal() error = %v", err)
+			}
+			var fromProto handler.Event
+			if err := (handler.ProtoDecoder{}).Decode(body, &fromProto); err != nil {
+				t.Fatalf("proto Decode() error = %v", err)
+			}
+
+			if fromProto.ID != fromJSON.ID || fromProto.Type != fromJSON.Type || fromProto.Source != fromJSON.Source ||
+				fromProto.TraceID != fromJSON.TraceID || fromProto.Version != fromJSON.Version ||
+				fromProto.Region != fromJSON.Region || fromProto.Account != fromJSON.Account ||
+				!reflect.DeepEqual(fromProto.Resources, fromJSON.Resources) {
+				t.Errorf("Envelopes differ:\nproto = %+v\njson  = %+v", fromProto, fromJSON)
+			}
+			if !fromProto.Time.Equal(fromJSON.Time) {
+				t.Errorf("Time = %v, want %v", fromProto.Time, fromJSON.Time)
+			}
+
+			protoDetail, err := fromProto.ParseEventDetail()
+			if err != nil {
+				t.Fatalf("ParseEventDetail() of proto event error = %v", err)
+			}
+			jsonDetail, err := fromJSON.ParseEventDetail()
+			if err != nil {
+				t.Fatalf("ParseEventDetail() of JSON event error = %v", err)
+			}
+			if !reflect.DeepEqual(protoDetail, jsonDetail) {
+				t.Errorf("Details differ:\nproto = %+v\njson  = %+v", protoDetail, jsonDetail)
+			}
+		})
+	}
+}
+
+func TestProtoDecoder_Version(t *testing.T) {
+	tests := []struct {
+		name            string
+		version         string
+		wantVersion     string
+		wantUnsupported bool
+	}{
+		{"current", "2", "2", false},
+		{"unversioned", "", "2", false},
+		{"older producer", "1", "2", false},
+		{"newer producer", "3", "3", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := proto.Marshal(&eventpb.Event{
+				Id: "evt-1", Type: "reservation.expired", Version: tt.version,
+				Detail: &eventpb.Event_ReservationExpired{ReservationExpired: &eventpb.ReservationExpired{
+					ReservationId: "rsv-1", EventId: "concert-1", Quantity: 2, SeatIds: []string{"A1", "A2"},
+				}},
+			})
+			if err != nil {
+				t.Fatalf("proto.Marshal() error = %v", err)
+			}
+			var event handler.Event
+			if err := (handler.ProtoDecoder{}).Decode(body, &event); err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+
+			if event.Version != tt.wantVersion {
+				t.Errorf("Version = %q, want %q", event.Version, tt.wantVersion)
+			}
+			detail, err := event.ParseEventDetail()
+			if tt.wantUnsupported {
+				if !errors.Is(err, handler.ErrUnsupportedVersion) {
+					t.Errorf("ParseEventDetail() error = %v, want ErrUnsupportedVersion", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseEventDetail() error = %v", err)
+			}
+			if got := detail.(*handler.ReservationExpiredDetail).Quantity; got != 2 {
+				t.Errorf("Quantity = %d, want 2", got)
+			}
+		})
+	}
+}
+
+func TestProtoDecoder_Malformed(t *testing.T) {
+	invalidTime, err := proto.Marshal(&eventpb.Event{Id: "evt-1", Time: &timestamppb.Timestamp{Nanos: -1}})
+	if err != nil {
+		t.Fatalf("proto.Marshal() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		body []byte
+	}{
+		{"JSON body", []byte(`{"id":"evt-1"}`)},
+		{"invalid timestamp", invalidTime},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var event handler.Event
+			if err := (handler.ProtoDecoder{}).Decode(tt.body, &event); err == nil {
+				t.Errorf("Expected an error decoding %q, got event %+v", tt.body, event)
+			}
+		})
+	}
+}
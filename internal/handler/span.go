@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Downstream steps traced on handler spans
+const (
+	stepUpdateStatus      = "update_status"
+	stepReleaseHold       = "release_hold"
+	stepCommitReservation = "commit_reservation"
+	stepRestoreHold       = "restore_hold"
+)
+
+// traceStep runs the downstream call fn between <step>.start and <step>.end events on
+// the span in ctx, and records its latency as the <step>.latency_ms span attribute
+func traceStep(ctx context.Context, step string, fn func() error) error {
+	span := trace.SpanFromContext(ctx)
+	observability.AddSpanEvent(span, step+".start")
+
+	start := time.Now()
+	err := fn()
+	latencyMS := float64(time.Since(start).Microseconds()) / 1000
+
+	attrs := []attribute.KeyValue{attribute.Float64("latency_ms", latencyMS)}
+	if err != nil {
+		attrs = append(attrs, attribute.String("error", err.Error()))
+	}
+	observability.AddSpanEvent(span, step+".end", trace.WithAttributes(attrs...))
+	span.SetAttributes(attribute.Float64(step+".latency_ms", latencyMS))
+	return err
+}
+
+// recordOutcome records a handler's outcome on its span and in the processing duration metric
+func recordOutcome(span trace.Span, metrics *observability.Metrics, handlerName, outcome string, duration time.Duration) {
+	span.SetAttributes(attribute.String("outcome", outcome))
+	metrics.RecordProcessingDuration(handlerName, outcome, duration.Seconds())
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// stepState records the downstream steps completed by earlier attempts at one event
+type stepState struct {
+	mu   sync.Mutex
+	done map[string]bool
+}
+
+type stepStateKey struct{}
+
+// withStepState returns a context carrying the step state of the event being handled
+func withStepState(ctx context.Context, state *stepState) context.Context {
+	return context.WithValue(ctx, stepStateKey{}, state)
+}
+
+// stepCompleted reports whether an earlier attempt at the event in ctx completed step.
+// Without StepTracking nothing is recorded and every step runs.
+func stepCompleted(ctx context.Context, step string) bool {
+	state, ok := ctx.Value(stepStateKey{}).(*stepState)
+	if !ok {
+		return false
+	}
+	state.mu.Lock()
+	done := state.done[step]
+	state.mu.Unlock()
+	if done {
+		observability.AddSpanEvent(trace.SpanFromContext(ctx), step+".skipped")
+	}
+	return done
+}
+
+// completeStep records that step completed, so a retry of the event skips it
+func completeStep(ctx context.Context, step string) {
+	if state, ok := ctx.Value(stepStateKey{}).(*stepState); ok {
+		state.mu.Lock()
+		state.done[step] = true
+		state.mu.Unlock()
+	}
+}
+
+// undoStep records that a completed step was compensated, so a retry runs it again
+func undoStep(ctx context.Context, step string) {
+	if state, ok := ctx.Value(stepStateKey{}).(*stepState); ok {
+		state.mu.Lock()
+		delete(state.done, step)
+		state.mu.Unlock()
+	}
+}
+
+// stepStore keeps the step state of events that have not succeeded yet, for a window after their last attempt
+type stepStore struct {
+	mu        sync.Mutex
+	window    time.Duration
+	events    map[string]*stepEntry
+	lastSweep time.Time
+}
+
+type stepEntry struct {
+	state   *stepState
+	expires time.Time
+}
+
+func newStepStore(window time.Duration) *stepStore {
+	return &stepStore{
+		window:    window,
+		events:    make(map[string]*stepEntry),
+		lastSweep: time.Now(),
+	}
+}
+
+// get returns the step state of event id, starting an empty one if it has none
+func (s *stepStore) get(id string) *stepState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	entry, ok := s.events[id]
+	if !ok || !now.Before(entry.expires) {
+		entry = &stepEntry{state: &stepState{done: make(map[string]bool)}}
+		s.events[id] = entry
+	}
+	entry.expires = now.Add(s.window)
+
+	// Drop expired states about once per window so the store stays bounded by failing events
+	if now.Sub(s.lastSweep) >= s.window {
+		for k, e := range s.events {
+			if !now.Before(e.expires) {
+				delete(s.events, k)
+			}
+		}
+		s.lastSweep = now
+	}
+	return entry.state
+}
+
+// forget drops the step state of event id
+func (s *stepStore) forget(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.events, id)
+}
@@ -0,0 +1,92 @@
+package handler_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/traffic-tacos/reservation-worker/internal/client"
+	"github.com/traffic-tacos/reservation-worker/internal/config"
+	"github.com/traffic-tacos/reservation-worker/internal/handler"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestHandlers_SpanEvents(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	tests := []struct {
+		name        string
+		eventType   string
+		updateErr   error
+		wantEvents  []string
+		wantOutcome string
+	}{
+		{name: "expired", eventType: handler.EventTypeReservationExpired,
+			wantEvents:  []string{"release_hold.start", "release_hold.end", "update_status.start", "update_status.end"},
+			wantOutcome: observability.OutcomeSuccess},
+		{name: "approved", eventType: handler.EventTypePaymentApproved,
+			wantEvents:  []string{"update_status.start", "update_status.end", "commit_reservation.start", "commit_reservation.end"},
+			wantOutcome: observability.OutcomeSuccess},
+		{name: "failed with update rejected", eventType: handler.EventTypePaymentFailed,
+			updateErr:   &client.HTTPStatusError{StatusCode: 400, Body: "invalid status"},
+			wantEvents:  []string{"update_status.start", "update_status.end", "exception"},
+			wantOutcome: observability.OutcomeDownstreamError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			inventory := &stubInventory{}
+			reservation := &stubReservation{updateErr: tt.updateErr}
+
+			var h handler.EventHandler
+			switch tt.eventType {
+			case handler.EventTypeReservationExpired:
+				h = handler.NewExpiredHandler(inventory, reservation, cfg, testLogger(), testMetrics)
+			case handler.EventTypePaymentFailed:
+				h = handler.NewFailedHandler(inventory, reservation, cfg, testLogger(), testMetrics)
+			case handler.EventTypePaymentApproved:
+				h = handler.NewApprovedHandler(inventory, reservation, cfg, testLogger(), testMetrics)
+			}
+
+			recorder.Reset()
+			ctx := observability.WithAttempt(context.Background(), 2)
+			h.Handle(ctx, newTestEvent(t, tt.eventType))
+
+			spans := recorder.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("Expected 1 span, got %d", len(spans))
+			}
+			span := spans[0]
+
+			var events []string
+			for _, event := range span.Events() {
+				events = append(events, event.Name)
+			}
+			if !reflect.DeepEqual(events, tt.wantEvents) {
+				t.Errorf("Expected span events %v, got %v", tt.wantEvents, events)
+			}
+
+			attrs := make(map[attribute.Key]attribute.Value)
+			for _, attr := range span.Attributes() {
+				attrs[attr.Key] = attr.Value
+			}
+			if got := attrs["attempt"].AsInt64(); got != 2 {
+				t.Errorf("Expected attempt attribute 2, got %d", got)
+			}
+			if got := attrs["outcome"].AsString(); got != tt.wantOutcome {
+				t.Errorf("Expected outcome attribute %q, got %q", tt.wantOutcome, got)
+			}
+			if _, ok := attrs["update_status.latency_ms"]; !ok {
+				t.Error("Expected an update_status.latency_ms attribute")
+			}
+		})
+	}
+}
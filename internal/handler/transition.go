@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/traffic-tacos/reservation-worker/internal/client"
+	"github.com/traffic-tacos/reservation-worker/internal/config"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+)
+
+// guardTransition fetches the reservation and refuses the event when the state
+// machine does not allow moving it to status to. The fetched reservation is
+// returned so the update can be made conditional on it.
+// It is a no-op returning nil details unless GuardStatusTransitions is enabled.
+func guardTransition(ctx context.Context, cfg *config.Config, reservation ReservationService, reservationID, to string) (*client.ReservationDetails, error) {
+	if cfg == nil || !cfg.GuardStatusTransitions {
+		return nil, nil
+	}
+	return checkTransition(ctx, reservation, reservationID, to)
+}
+
+// checkTransition is guardTransition regardless of GuardStatusTransitions
+func checkTransition(ctx context.Context, reservation ReservationService, reservationID, to string) (*client.ReservationDetails, error) {
+	current, err := reservation.GetReservation(ctx, reservationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current reservation status: %w", err)
+	}
+	if err := client.CheckTransition(reservationID, current.Status, to); err != nil {
+		return nil, err
+	}
+	return current, nil
+}
+
+// expectCurrent makes req conditional on the reservation still matching current
+func expectCurrent(req *client.UpdateStatusRequest, current *client.ReservationDetails) {
+	if current == nil {
+		return
+	}
+	req.ExpectedStatus = current.Status
+	req.ExpectedVersion = current.Version
+}
+
+// downstreamOutcome picks the processing outcome for a failed downstream step
+func downstreamOutcome(err error) string {
+	var transitionErr *client.IllegalTransitionError
+	if errors.As(err, &transitionErr) {
+		return observability.OutcomeIllegalTransition
+	}
+	return observability.OutcomeDownstreamError
+}
@@ -0,0 +1,128 @@
+package handler_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/traffic-tacos/reservation-worker/internal/client"
+	"github.com/traffic-tacos/reservation-worker/internal/config"
+	"github.com/traffic-tacos/reservation-worker/internal/handler"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+)
+
+func TestHandlers_TransitionGuard(t *testing.T) {
+	tests := []struct {
+		name          string
+		eventType     string
+		currentStatus string
+		guard         bool
+		wantIllegal   bool
+	}{
+		{name: "approved on hold", eventType: handler.EventTypePaymentApproved, currentStatus: client.StatusHold, guard: true},
+		{name: "approved after expiry", eventType: handler.EventTypePaymentApproved, currentStatus: client.StatusExpired, guard: true, wantIllegal: true},
+		{name: "expired after confirmation", eventType: handler.EventTypeReservationExpired, currentStatus: client.StatusConfirmed, guard: true, wantIllegal: true},
+		{name: "failed after confirmation", eventType: handler.EventTypePaymentFailed, currentStatus: client.StatusConfirmed, guard: true, wantIllegal: true},
+		{name: "approved after expiry without guard", eventType: handler.EventTypePaymentApproved, currentStatus: client.StatusExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{GuardStatusTransitions: tt.guard}
+			inventory := &stubInventory{}
+			reservation := &stubReservation{currentStatus: tt.currentStatus}
+
+			var h handler.EventHandler
+			switch tt.eventType {
+			case handler.EventTypeReservationExpired:
+				h = handler.NewExpiredHandler(inventory, reservation, cfg, testLogger(), testMetrics)
+			case handler.EventTypePaymentFailed:
+				h = handler.NewFailedHandler(inventory, reservation, cfg, testLogger(), testMetrics)
+			case handler.EventTypePaymentApproved:
+				h = handler.NewApprovedHandler(inventory, reservation, cfg, testLogger(), testMetrics)
+			}
+
+			err := h.Handle(context.Background(), newTestEvent(t, tt.eventType))
+			if !tt.wantIllegal {
+				if err != nil {
+					t.Fatalf("Handle() error = %v, want nil", err)
+				}
+				return
+			}
+
+			if got := client.Classify(err); got != client.CategoryIllegalTransition {
+				t.Fatalf("Classify(%v) = %s, want %s", err, got, client.CategoryIllegalTransition)
+			}
+			if client.Classify(err).Retryable() {
+				t.Error("illegal transition must not be retried")
+			}
+			if reservation.updates != 0 || inventory.releases != 0 {
+				t.Errorf("downstream mutated after refusal: %d updates, %d releases", reservation.updates, inventory.releases)
+			}
+		})
+	}
+}
+
+// stubCompensator records the approvals handed to it
+type stubCompensator struct {
+	err      error
+	statuses []string
+}
+
+func (s *stubCompensator) CompensateApproval(ctx context.Context, event *handler.Event, detail *handler.PaymentApprovedDetail, status string) error {
+	s.statuses = append(s.statuses, status)
+	return s.err
+}
+
+func TestApprovedHandler_AfterTerminal(t *testing.T) {
+	tests := []struct {
+		name           string
+		currentStatus  string
+		compensateErr  error
+		wantErr        bool
+		wantConfirmed  bool
+		wantCompensate bool
+	}{
+		{name: "approved after expiry", currentStatus: client.StatusExpired, wantCompensate: true},
+		{name: "approved after cancellation", currentStatus: client.StatusCancelled, wantCompensate: true},
+		{name: "approved on hold", currentStatus: client.StatusHold, wantConfirmed: true},
+		{name: "failed compensation is retried", currentStatus: client.StatusExpired, compensateErr: errors.New("queue unavailable"), wantErr: true, wantCompensate: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{ApprovedAfterTerminal: config.ApprovedAfterTerminalDrop}
+			inventory := &stubInventory{}
+			reservation := &stubReservation{currentStatus: tt.currentStatus}
+			compensator := &stubCompensator{err: tt.compensateErr}
+			metrics := observability.NewMetrics(observability.WithRegistry(prometheus.NewRegistry()))
+			defer metrics.Unregister()
+
+			h := handler.NewApprovedHandler(inventory, reservation, cfg, testLogger(), metrics)
+			h.SetCompensator(compensator)
+
+			err := h.Handle(context.Background(), newTestEvent(t, handler.EventTypePaymentApproved))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Handle() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if confirmed := reservation.updates == 1 && inventory.commits == 1; confirmed != tt.wantConfirmed {
+				t.Errorf("Expected confirmed = %v, got %d updates and %d commits", tt.wantConfirmed, reservation.updates, inventory.commits)
+			}
+			if compensated := len(compensator.statuses) == 1 && compensator.statuses[0] == tt.currentStatus; compensated != tt.wantCompensate {
+				t.Errorf("Expected compensation = %v, got %v", tt.wantCompensate, compensator.statuses)
+			}
+
+			var m dto.Metric
+			metrics.ProcessingDuration.WithLabelValues("approved", observability.OutcomeApprovedAfterTerminal).(prometheus.Histogram).Write(&m)
+			wantDropped := uint64(0)
+			if tt.wantCompensate && !tt.wantErr {
+				wantDropped = 1
+			}
+			if got := m.GetHistogram().GetSampleCount(); got != wantDropped {
+				t.Errorf("Expected %d approved_after_terminal outcomes, got %d", wantDropped, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CurrentEventVersion is the detail schema version the handler structs decode
+const CurrentEventVersion = 2
+
+// ErrUnsupportedVersion is returned for payload versions newer than this worker understands
+var ErrUnsupportedVersion = errors.New("unsupported event version")
+
+// detailMigrations upgrade a detail payload from the keyed version to the next one
+var detailMigrations = map[int]func(fields map[string]json.RawMessage){
+	1: migrateDetailV1,
+}
+
+// SchemaVersion returns the major schema version of the event.
+// Events without a version predate versioning and are treated as v1.
+func (e *Event) SchemaVersion() (int, error) {
+	v := strings.TrimPrefix(strings.TrimSpace(e.Version), "v")
+	if v == "" {
+		return 1, nil
+	}
+
+	major, _, _ := strings.Cut(v, ".")
+	version, err := strconv.Atoi(major)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("%w: %q", ErrUnsupportedVersion, e.Version)
+	}
+	if version > CurrentEventVersion {
+		return 0, fmt.Errorf("%w: %q (current %d)", ErrUnsupportedVersion, e.Version, CurrentEventVersion)
+	}
+	return version, nil
+}
+
+// decodeDetail decodes the event detail into out, upgrading older payloads first
+func (e *Event) decodeDetail(out interface{}) error {
+	version, err := e.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	if version == CurrentEventVersion {
+		return json.Unmarshal(e.Detail, out)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(e.Detail, &fields); err != nil {
+		return err
+	}
+	for v := version; v < CurrentEventVersion; v++ {
+		detailMigrations[v](fields)
+	}
+
+	migrated, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("failed to re-encode migrated detail: %w", err)
+	}
+	return json.Unmarshal(migrated, out)
+}
+
+// migrateDetailV1 renames qty to quantity
+func migrateDetailV1(fields map[string]json.RawMessage) {
+	qty, ok := fields["qty"]
+	if !ok {
+		return
+	}
+	if _, exists := fields["quantity"]; !exists {
+		fields["quantity"] = qty
+	}
+	delete(fields, "qty")
+}
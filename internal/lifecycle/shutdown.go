@@ -0,0 +1,76 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"go.uber.org/zap"
+)
+
+// step is a named shutdown action
+type step struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Orchestrator runs shutdown steps in registration order under a shared deadline
+type Orchestrator struct {
+	steps  []step
+	logger *observability.Logger
+}
+
+// NewOrchestrator creates an empty shutdown orchestrator
+func NewOrchestrator(logger *observability.Logger) *Orchestrator {
+	return &Orchestrator{logger: logger}
+}
+
+// Add registers a shutdown step. Steps run in the order they are added.
+func (o *Orchestrator) Add(name string, fn func(ctx context.Context) error) {
+	o.steps = append(o.steps, step{name: name, fn: fn})
+}
+
+// Shutdown runs every step in order and returns their aggregated errors.
+// Once ctx is done the running step is abandoned and the remaining steps are skipped.
+func (o *Orchestrator) Shutdown(ctx context.Context) error {
+	var errs []error
+
+	for i, s := range o.steps {
+		if err := ctx.Err(); err != nil {
+			for _, skipped := range o.steps[i:] {
+				errs = append(errs, fmt.Errorf("%s: skipped: %w", skipped.name, err))
+			}
+			break
+		}
+
+		start := time.Now()
+		if err := o.runStep(ctx, s); err != nil {
+			o.logger.Error("Shutdown step failed", zap.String("step", s.name), zap.Error(err))
+			errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+			continue
+		}
+		o.logger.Info("Shutdown step completed",
+			zap.String("step", s.name),
+			zap.Duration("duration", time.Since(start)),
+		)
+	}
+
+	return errors.Join(errs...)
+}
+
+// runStep runs a single step, giving up when ctx is done even if the step ignores ctx
+func (o *Orchestrator) runStep(ctx context.Context, s step) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- s.fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,92 @@
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/traffic-tacos/reservation-worker/internal/lifecycle"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"go.uber.org/zap"
+)
+
+func testLogger() *observability.Logger {
+	return &observability.Logger{Logger: zap.NewNop()}
+}
+
+func TestOrchestrator_RunsStepsInOrder(t *testing.T) {
+	o := lifecycle.NewOrchestrator(testLogger())
+
+	var order []string
+	for _, name := range []string{"poller", "dispatcher", "inventory", "tracer"} {
+		name := name
+		o.Add(name, func(ctx context.Context) error {
+			order = append(order, name)
+			return nil
+		})
+	}
+
+	if err := o.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	want := []string{"poller", "dispatcher", "inventory", "tracer"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("Shutdown order = %v, want %v", order, want)
+	}
+}
+
+func TestOrchestrator_AggregatesErrors(t *testing.T) {
+	o := lifecycle.NewOrchestrator(testLogger())
+
+	errInventory := errors.New("close failed")
+	errTracer := errors.New("flush failed")
+	ran := false
+
+	o.Add("inventory", func(ctx context.Context) error { return errInventory })
+	o.Add("reservation", func(ctx context.Context) error { ran = true; return nil })
+	o.Add("tracer", func(ctx context.Context) error { return errTracer })
+
+	err := o.Shutdown(context.Background())
+	if !errors.Is(err, errInventory) || !errors.Is(err, errTracer) {
+		t.Errorf("Expected aggregated error to wrap both failures, got %v", err)
+	}
+	if !ran {
+		t.Error("Expected steps after a failed step to still run")
+	}
+}
+
+func TestOrchestrator_RespectsDeadline(t *testing.T) {
+	o := lifecycle.NewOrchestrator(testLogger())
+
+	block := make(chan struct{})
+	defer close(block)
+	ranAfter := false
+
+	// A step that ignores its context must not hold up shutdown
+	o.Add("dispatcher", func(ctx context.Context) error { <-block; return nil })
+	o.Add("tracer", func(ctx context.Context) error { ranAfter = true; return nil })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := o.Shutdown(ctx)
+	elapsed := time.Since(start)
+
+	if elapsed > 500*time.Millisecond {
+		t.Errorf("Expected Shutdown to return at the deadline, took %v", elapsed)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "tracer: skipped") {
+		t.Errorf("Expected remaining steps to be reported as skipped, got %v", err)
+	}
+	if ranAfter {
+		t.Error("Expected steps after the deadline to be skipped")
+	}
+}
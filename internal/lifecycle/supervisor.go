@@ -0,0 +1,106 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"go.uber.org/zap"
+)
+
+// ErrRestartLimit is returned by Supervise once a subsystem exited more often than it may be restarted
+var ErrRestartLimit = errors.New("restart limit exceeded")
+
+// errExitedEarly stands in for the error of a subsystem that returned nil while it should be running
+var errExitedEarly = errors.New("exited unexpectedly")
+
+// maxRestartBackoff caps the doubling wait between restarts
+const maxRestartBackoff = 30 * time.Second
+
+// defaultHealthyRun is how long a subsystem must run before its earlier restarts are forgotten
+const defaultHealthyRun = 5 * time.Minute
+
+// Supervisor restarts subsystems that exit or panic while the process should still be running
+type Supervisor struct {
+	maxRestarts int
+	backoff     time.Duration
+	healthyRun  time.Duration
+	logger      *observability.Logger
+	metrics     *observability.Metrics
+}
+
+// NewSupervisor creates a supervisor that restarts a subsystem up to maxRestarts times,
+// waiting backoff before the first restart and doubling it for each further one
+func NewSupervisor(maxRestarts int, backoff time.Duration, logger *observability.Logger, metrics *observability.Metrics) *Supervisor {
+	return &Supervisor{maxRestarts: maxRestarts, backoff: backoff, healthyRun: defaultHealthyRun, logger: logger, metrics: metrics}
+}
+
+// SetHealthyRun sets how long a subsystem must run before an exit starts its restart count
+// and backoff over, so occasional crashes over a long uptime don't use up maxRestarts
+func (s *Supervisor) SetHealthyRun(d time.Duration) {
+	s.healthyRun = d
+}
+
+// Supervise calls run and calls it again whenever it returns or panics before ctx is done.
+// Cancelling ctx stops supervision without stopping run; stop the subsystem itself for that.
+// It returns nil once run returns after ctx is done, or an error wrapping ErrRestartLimit
+// when run exits again after maxRestarts restarts without a healthy run in between.
+func (s *Supervisor) Supervise(ctx context.Context, name string, run func() error) error {
+	backoff := s.backoff
+	for restarts := 0; ; restarts++ {
+		started := time.Now()
+		err := s.runRecovered(name, run)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if time.Since(started) >= s.healthyRun {
+			restarts = 0
+			backoff = s.backoff
+		}
+
+		if restarts >= s.maxRestarts {
+			s.logger.Error("Subsystem exited too often, giving up",
+				zap.String("subsystem", name),
+				zap.Int("restarts", restarts),
+				zap.Error(err),
+			)
+			return fmt.Errorf("%s: %w after %d restarts: %w", name, ErrRestartLimit, restarts, err)
+		}
+
+		s.logger.Error("Subsystem exited unexpectedly, restarting",
+			zap.String("subsystem", name),
+			zap.Int("restart", restarts+1),
+			zap.Duration("backoff", backoff),
+			zap.Error(err),
+		)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxRestartBackoff)
+		s.metrics.RecordSubsystemRestart(name)
+	}
+}
+
+// runRecovered calls run, turning a panic into an error and a nil return into errExitedEarly
+func (s *Supervisor) runRecovered(name string, run func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error("Recovered from subsystem panic",
+				zap.String("subsystem", name),
+				zap.Any("panic", r),
+				zap.ByteString("stack", debug.Stack()),
+			)
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	if err := run(); err != nil {
+		return err
+	}
+	return errExitedEarly
+}
@@ -0,0 +1,137 @@
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/traffic-tacos/reservation-worker/internal/lifecycle"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+)
+
+func TestSupervisor_Restarts(t *testing.T) {
+	errCrash := errors.New("dispatch loop failed")
+
+	tests := []struct {
+		name         string
+		crashes      int // Calls exiting early before run blocks until supervision ends
+		crash        func() error
+		maxRestarts  int
+		wantCalls    int32
+		wantRestarts float64
+		wantLimit    bool
+	}{
+		{"error is restarted", 1, func() error { return errCrash }, 3, 2, 1, false},
+		{"early nil return is restarted", 2, func() error { return nil }, 3, 3, 2, false},
+		{"panic is restarted", 1, func() error { panic("nil map") }, 3, 2, 1, false},
+		{"gives up after the restart cap", 10, func() error { return errCrash }, 2, 3, 2, true},
+		{"no restarts allowed", 1, func() error { return errCrash }, 0, 1, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := observability.NewMetrics(observability.WithRegistry(prometheus.NewRegistry()))
+			defer metrics.Unregister()
+			supervisor := lifecycle.NewSupervisor(tt.maxRestarts, time.Millisecond, testLogger(), metrics)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var calls atomic.Int32
+			running := make(chan struct{})
+			run := func() error {
+				if int(calls.Add(1)) <= tt.crashes {
+					return tt.crash()
+				}
+				close(running)
+				<-ctx.Done()
+				return ctx.Err()
+			}
+
+			result := make(chan error, 1)
+			go func() { result <- supervisor.Supervise(ctx, "dispatcher", run) }()
+
+			var err error
+			select {
+			case <-running:
+				// Back up after the crashes; shutting down ends supervision
+				cancel()
+				err = <-result
+			case err = <-result:
+			case <-time.After(time.Second):
+				t.Fatal("Timed out waiting for the subsystem to be restarted")
+			}
+
+			if errors.Is(err, lifecycle.ErrRestartLimit) != tt.wantLimit {
+				t.Errorf("Supervise() error = %v, want restart limit = %v", err, tt.wantLimit)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("Expected %d runs, got %d", tt.wantCalls, got)
+			}
+			if got := testutil.ToFloat64(metrics.SubsystemRestarts.WithLabelValues("dispatcher")); got != tt.wantRestarts {
+				t.Errorf("Expected %v restarts counted, got %v", tt.wantRestarts, got)
+			}
+		})
+	}
+}
+
+func TestSupervisor_HealthyRunResetsRestarts(t *testing.T) {
+	metrics := observability.NewMetrics(observability.WithRegistry(prometheus.NewRegistry()))
+	defer metrics.Unregister()
+	supervisor := lifecycle.NewSupervisor(1, time.Millisecond, testLogger(), metrics)
+	supervisor.SetHealthyRun(20 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Every run crashes; only those that first ran a while are forgiven
+	var calls atomic.Int32
+	run := func() error {
+		if calls.Add(1) <= 3 {
+			time.Sleep(30 * time.Millisecond)
+		}
+		return errors.New("dispatch loop failed")
+	}
+
+	result := make(chan error, 1)
+	go func() { result <- supervisor.Supervise(ctx, "dispatcher", run) }()
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, lifecycle.ErrRestartLimit) {
+			t.Errorf("Supervise() error = %v, want restart limit", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for supervision to give up")
+	}
+	// Each healthy run's crash gets the one restart again; the quick fourth run's crash has none left
+	if got := calls.Load(); got != 4 {
+		t.Errorf("Expected 4 runs, got %d", got)
+	}
+}
+
+func TestSupervisor_NoRestartAfterShutdown(t *testing.T) {
+	metrics := observability.NewMetrics(observability.WithRegistry(prometheus.NewRegistry()))
+	defer metrics.Unregister()
+	supervisor := lifecycle.NewSupervisor(3, time.Millisecond, testLogger(), metrics)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls atomic.Int32
+	run := func() error {
+		calls.Add(1)
+		// Stopping the subsystem during shutdown returns it after supervision ended
+		cancel()
+		return nil
+	}
+
+	if err := supervisor.Supervise(ctx, "poller", run); err != nil {
+		t.Errorf("Supervise() error = %v", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("Expected a subsystem stopped for shutdown not to be restarted, got %d runs", got)
+	}
+}
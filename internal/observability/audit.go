@@ -0,0 +1,81 @@
+package observability
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Audited actions
+const (
+	AuditActionStatusTransition  = "status_transition"
+	AuditActionReleaseHold       = "release_hold"
+	AuditActionCommitReservation = "commit_reservation"
+	AuditActionRestoreHold       = "restore_hold"
+)
+
+// auditActor identifies this worker as the party making the change
+const auditActor = "reservation-worker"
+
+// AuditEntry describes one change the worker made to a reservation or its inventory
+type AuditEntry struct {
+	Action        string
+	ReservationID string
+	EventID       string // ID of the event that caused the change
+	EventType     string
+	FromStatus    string // Empty when the previous status was not looked up
+	ToStatus      string // Set for status transitions
+	RequestedBy   string // User who asked for the change, for user-initiated events
+	Reason        string // Why the change was requested, when the event gives one
+}
+
+// AuditLogger writes one JSON line per audited change. It is separate from the
+// operational logger so entries are never sampled or dropped by LOG_LEVEL.
+// A nil AuditLogger records nothing.
+type AuditLogger struct {
+	logger *zap.Logger
+}
+
+// NewAuditLogger creates an audit logger writing to out
+func NewAuditLogger(out zapcore.WriteSyncer) *AuditLogger {
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.TimeKey = "ts"
+	encoderConfig.MessageKey = "msg"
+	encoderConfig.LevelKey = ""
+	encoderConfig.CallerKey = ""
+	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(encoderConfig), out, zapcore.InfoLevel)
+	return &AuditLogger{logger: zap.New(core).With(zap.String("log_type", "audit"))}
+}
+
+// Record writes entry along with the correlation ID carried by ctx
+func (a *AuditLogger) Record(ctx context.Context, entry AuditEntry) {
+	if a == nil {
+		return
+	}
+
+	fields := []zap.Field{
+		zap.String("action", entry.Action),
+		zap.String("actor", auditActor),
+		zap.String("reservation_id", entry.ReservationID),
+		zap.String("event_id", entry.EventID),
+		zap.String("event_type", entry.EventType),
+		zap.String("correlation_id", ProcessingID(ctx)),
+	}
+	if entry.FromStatus != "" {
+		fields = append(fields, zap.String("from_status", entry.FromStatus))
+	}
+	if entry.ToStatus != "" {
+		fields = append(fields, zap.String("to_status", entry.ToStatus))
+	}
+	if entry.RequestedBy != "" {
+		fields = append(fields, zap.String("requested_by", entry.RequestedBy))
+	}
+	if entry.Reason != "" {
+		fields = append(fields, zap.String("reason", entry.Reason))
+	}
+
+	a.logger.Info("audit", fields...)
+}
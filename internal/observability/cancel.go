@@ -0,0 +1,60 @@
+package observability
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+)
+
+// Reasons a context was cancelled, as reported by CancelReason
+const (
+	CancelReasonShutdown           = "shutdown"            // The process is shutting down
+	CancelReasonHandlerTimeout     = "handler_timeout"     // HANDLER_TIMEOUT_MS elapsed
+	CancelReasonVisibilityDeadline = "visibility_deadline" // The message was about to become visible again
+	CancelReasonRequestTimeout     = "request_timeout"     // An HTTP endpoint's own timeout elapsed
+
+	// Contexts ended without a reason tag, e.g. by an upstream deadline or a client going away
+	CancelReasonDeadlineExceeded = "deadline_exceeded"
+	CancelReasonCanceled         = "canceled"
+)
+
+// cancelCause is a context cancellation cause tagged with a reason
+type cancelCause struct {
+	reason string
+}
+
+func (c *cancelCause) Error() string { return "context ended: " + c.reason }
+
+// CancelCause returns a cause for context.WithCancelCause, WithTimeoutCause or WithDeadlineCause
+// that CancelReason reports as reason. The context's Err is unaffected.
+func CancelCause(reason string) error {
+	return &cancelCause{reason: reason}
+}
+
+// CancelReason reports why ctx was cancelled: the reason of its CancelCause, or for contexts
+// ended without one CancelReasonDeadlineExceeded or CancelReasonCanceled. It returns "" while
+// ctx is not done.
+func CancelReason(ctx context.Context) string {
+	if ctx.Err() == nil {
+		return ""
+	}
+
+	var cause *cancelCause
+	if errors.As(context.Cause(ctx), &cause) {
+		return cause.reason
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return CancelReasonDeadlineExceeded
+	}
+	return CancelReasonCanceled
+}
+
+// CancelReasonField returns the CancelReason of ctx as a log field, or no field while ctx is not done
+func CancelReasonField(ctx context.Context) zap.Field {
+	reason := CancelReason(ctx)
+	if reason == "" {
+		return zap.Skip()
+	}
+	return zap.String("cancel_reason", reason)
+}
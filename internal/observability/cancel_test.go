@@ -0,0 +1,54 @@
+package observability_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+)
+
+func TestCancelReason(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  func(t *testing.T) context.Context
+		want string
+	}{
+		{"live context", func(*testing.T) context.Context { return context.Background() }, ""},
+		{"cancelled with a reason", func(t *testing.T) context.Context {
+			ctx, cancel := context.WithCancelCause(context.Background())
+			cancel(observability.CancelCause(observability.CancelReasonShutdown))
+			return ctx
+		}, observability.CancelReasonShutdown},
+		{"child of a context cancelled with a reason", func(t *testing.T) context.Context {
+			parent, cancel := context.WithCancelCause(context.Background())
+			ctx, cancelChild := context.WithTimeout(parent, time.Hour)
+			t.Cleanup(cancelChild)
+			cancel(observability.CancelCause(observability.CancelReasonShutdown))
+			return ctx
+		}, observability.CancelReasonShutdown},
+		{"timed out with a reason", func(t *testing.T) context.Context {
+			ctx, cancel := context.WithTimeoutCause(context.Background(), 0, observability.CancelCause(observability.CancelReasonHandlerTimeout))
+			t.Cleanup(cancel)
+			return ctx
+		}, observability.CancelReasonHandlerTimeout},
+		{"timed out without a reason", func(t *testing.T) context.Context {
+			ctx, cancel := context.WithTimeout(context.Background(), 0)
+			t.Cleanup(cancel)
+			return ctx
+		}, observability.CancelReasonDeadlineExceeded},
+		{"cancelled without a reason", func(t *testing.T) context.Context {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			return ctx
+		}, observability.CancelReasonCanceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := observability.CancelReason(tt.ctx(t)); got != tt.want {
+				t.Errorf("CancelReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
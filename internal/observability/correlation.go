@@ -0,0 +1,47 @@
+package observability
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// CorrelationHeader carries the processing ID on outbound HTTP calls and, lowercased, in gRPC metadata
+const CorrelationHeader = "X-Correlation-ID"
+
+type processingIDKey struct{}
+
+// NewProcessingID returns a new identifier for one logical unit of event processing
+func NewProcessingID() string {
+	return uuid.NewString()
+}
+
+// WithProcessingID returns a context carrying the processing ID
+func WithProcessingID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, processingIDKey{}, id)
+}
+
+// ProcessingID returns the processing ID carried by ctx, or ""
+func ProcessingID(ctx context.Context) string {
+	id, _ := ctx.Value(processingIDKey{}).(string)
+	return id
+}
+
+type attemptKey struct{}
+
+// WithAttempt returns a context carrying the delivery attempt of the event being handled
+func WithAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+// Attempt returns the attempt carried by ctx, or 0
+func Attempt(ctx context.Context) int {
+	attempt, _ := ctx.Value(attemptKey{}).(int)
+	return attempt
+}
+
+// ProcessingIDField returns the processing ID in ctx as a log field
+func ProcessingIDField(ctx context.Context) zap.Field {
+	return zap.String("processing_id", ProcessingID(ctx))
+}
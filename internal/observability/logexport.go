@@ -0,0 +1,99 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/contrib/bridges/otelzap"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Log export modes
+const (
+	LogExportStdout = "stdout"
+	LogExportOTLP   = "otlp"
+	LogExportBoth   = "both"
+)
+
+// LogExportConfig holds OpenTelemetry log export configuration
+type LogExportConfig struct {
+	ServiceName      string
+	ServiceVersion   string
+	Environment      string
+	ExporterEndpoint string
+}
+
+// InitLogExport initializes an OTLP log provider for exporting decision logs
+func InitLogExport(ctx context.Context, config LogExportConfig) (*sdklog.LoggerProvider, error) {
+	// Accept both "host:port" and "http(s)://host:port" endpoints
+	var endpointOpts []otlploghttp.Option
+	if strings.Contains(config.ExporterEndpoint, "://") {
+		endpointOpts = append(endpointOpts, otlploghttp.WithEndpointURL(config.ExporterEndpoint))
+	} else {
+		endpointOpts = append(endpointOpts,
+			otlploghttp.WithEndpoint(config.ExporterEndpoint),
+			otlploghttp.WithInsecure(),
+		)
+	}
+
+	exporter, err := otlploghttp.New(ctx, endpointOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP log exporter: %w", err)
+	}
+
+	res := resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(config.ServiceName),
+		semconv.ServiceVersion(config.ServiceVersion),
+		semconv.DeploymentEnvironment(config.Environment),
+	)
+
+	return sdklog.NewLoggerProvider(
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter)),
+		sdklog.WithResource(res),
+	), nil
+}
+
+// WithLogExport returns a logger that writes to stdout, the OTLP provider, or both
+func (l *Logger) WithLogExport(mode string, provider otellog.LoggerProvider) *Logger {
+	if provider == nil {
+		return l
+	}
+
+	otelCore := otelzap.NewCore("reservation-worker", otelzap.WithLoggerProvider(provider))
+
+	switch mode {
+	case LogExportOTLP:
+		return &Logger{Audit: l.Audit, Logger: l.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			// Keep the configured level; the OTLP core itself accepts every level
+			leveled, err := zapcore.NewIncreaseLevelCore(otelCore, zapcore.LevelOf(core))
+			if err != nil {
+				return otelCore
+			}
+			return leveled
+		}))}
+	case LogExportBoth:
+		return &Logger{Audit: l.Audit, Logger: l.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			leveled, err := zapcore.NewIncreaseLevelCore(otelCore, zapcore.LevelOf(core))
+			if err != nil {
+				return zapcore.NewTee(core, otelCore)
+			}
+			return zapcore.NewTee(core, leveled)
+		}))}
+	default:
+		return l
+	}
+}
+
+// ContextField carries ctx so exported log records are correlated with the active span.
+// The stdout encoder skips it.
+func ContextField(ctx context.Context) zap.Field {
+	return zap.Field{Key: "ctx", Type: zapcore.SkipType, Interface: ctx}
+}
@@ -0,0 +1,94 @@
+package observability_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// recordingExporter keeps exported log records in memory
+type recordingExporter struct {
+	mu      sync.Mutex
+	records []sdklog.Record
+}
+
+func (e *recordingExporter) Export(_ context.Context, records []sdklog.Record) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, r := range records {
+		e.records = append(e.records, r.Clone())
+	}
+	return nil
+}
+
+func (e *recordingExporter) Shutdown(context.Context) error   { return nil }
+func (e *recordingExporter) ForceFlush(context.Context) error { return nil }
+
+func TestWithLogExport_TraceContextAndSeverity(t *testing.T) {
+	exporter := &recordingExporter{}
+	provider := sdklog.NewLoggerProvider(sdklog.WithProcessor(sdklog.NewSimpleProcessor(exporter)))
+
+	base, err := observability.NewLogger("info")
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	logger := base.WithLogExport(observability.LogExportOTLP, provider)
+
+	traceID := trace.TraceID{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10}
+	spanID := trace.SpanID{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	logger.With(observability.ContextField(ctx)).Info("Event processed successfully", zap.String("event_id", "evt_1"))
+	logger.With(observability.ContextField(ctx)).Error("Event processing failed after max retries")
+	logger.Debug("Below configured level")
+
+	if len(exporter.records) != 2 {
+		t.Fatalf("Expected 2 exported records, got %d", len(exporter.records))
+	}
+
+	tests := []struct {
+		record   sdklog.Record
+		severity otellog.Severity
+	}{
+		{exporter.records[0], otellog.SeverityInfo},
+		{exporter.records[1], otellog.SeverityError},
+	}
+
+	for _, tt := range tests {
+		if tt.record.TraceID() != traceID {
+			t.Errorf("TraceID = %s, want %s", tt.record.TraceID(), traceID)
+		}
+		if tt.record.SpanID() != spanID {
+			t.Errorf("SpanID = %s, want %s", tt.record.SpanID(), spanID)
+		}
+		if tt.record.Severity() != tt.severity {
+			t.Errorf("Severity = %v, want %v", tt.record.Severity(), tt.severity)
+		}
+	}
+}
+
+func TestWithLogExport_StdoutSkipsProvider(t *testing.T) {
+	exporter := &recordingExporter{}
+	provider := sdklog.NewLoggerProvider(sdklog.WithProcessor(sdklog.NewSimpleProcessor(exporter)))
+
+	base, err := observability.NewLogger("info")
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	logger := base.WithLogExport(observability.LogExportStdout, provider)
+	logger.Info("Stdout only")
+
+	if len(exporter.records) != 0 {
+		t.Errorf("Expected no exported records in stdout mode, got %d", len(exporter.records))
+	}
+}
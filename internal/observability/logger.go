@@ -1,6 +1,8 @@
 package observability
 
 import (
+	"os"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -8,10 +10,71 @@ import (
 // Logger wraps zap logger with structured logging for reservation worker
 type Logger struct {
 	*zap.Logger
+
+	Audit *AuditLogger // Records reservation changes; nil when auditing is disabled
+}
+
+// LogSampling throttles repeated identical log lines: per second, the first
+// Initial occurrences are logged and then every Thereafter-th one.
+// Initial <= 0 disables sampling.
+type LogSampling struct {
+	Initial    int
+	Thereafter int
+}
+
+// DefaultLogSampling matches zap's production defaults
+var DefaultLogSampling = LogSampling{Initial: 100, Thereafter: 100}
+
+// LogFields is deployment metadata attached to every log line
+type LogFields struct {
+	Service    string
+	Version    string
+	Env        string
+	Region     string
+	InstanceID string
+}
+
+// WithBaseFields attaches fields to every entry of the logger; empty values are left out
+func WithBaseFields(fields LogFields) zap.Option {
+	var zapFields []zap.Field
+	for _, f := range []struct{ key, value string }{
+		{"service", fields.Service},
+		{"version", fields.Version},
+		{"env", fields.Env},
+		{"region", fields.Region},
+		{"instance_id", fields.InstanceID},
+	} {
+		if f.value != "" {
+			zapFields = append(zapFields, zap.String(f.key, f.value))
+		}
+	}
+	return zap.Fields(zapFields...)
 }
 
+// Log encodings
+const (
+	LogFormatJSON    = "json"
+	LogFormatConsole = "console" // Human-readable, for local development
+)
+
 // NewLogger creates a new structured logger
-func NewLogger(level string) (*Logger, error) {
+func NewLogger(level string, opts ...zap.Option) (*Logger, error) {
+	return NewSampledLogger(level, LogFormatJSON, DefaultLogSampling, opts...)
+}
+
+// NewSampledLogger creates a structured logger with the given encoding and sampling
+func NewSampledLogger(level, format string, sampling LogSampling, opts ...zap.Option) (*Logger, error) {
+	logger, err := LoggerConfig(level, format, sampling).Build(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Logger{Logger: logger}, nil
+}
+
+// LoggerConfig returns the zap configuration of a logger writing to stderr.
+// Sampling is never applied at debug level so nothing is hidden while debugging.
+func LoggerConfig(level, format string, sampling LogSampling) zap.Config {
 	config := zap.NewProductionConfig()
 
 	// Set log level
@@ -28,19 +91,37 @@ func NewLogger(level string) (*Logger, error) {
 		config.Level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
 	}
 
-	// JSON output for structured logging
-	config.Encoding = "json"
+	// JSON output for structured logging unless console output was asked for
+	config.Encoding = LogFormatJSON
 	config.EncoderConfig.TimeKey = "ts"
 	config.EncoderConfig.LevelKey = "level"
 	config.EncoderConfig.MessageKey = "msg"
 	config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	if format == LogFormatConsole {
+		config.Encoding = LogFormatConsole
+		config.EncoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+		if isTerminal(os.Stderr) {
+			config.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		}
+	}
 
-	logger, err := config.Build()
-	if err != nil {
-		return nil, err
+	// Throttle floods of identical messages, e.g. retry warnings during an outage
+	if sampling.Initial > 0 && config.Level.Level() > zapcore.DebugLevel {
+		config.Sampling = &zap.SamplingConfig{
+			Initial:    sampling.Initial,
+			Thereafter: sampling.Thereafter,
+		}
+	} else {
+		config.Sampling = nil
 	}
 
-	return &Logger{Logger: logger}, nil
+	return config
+}
+
+// isTerminal reports whether f is attached to a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
 }
 
 // WithEvent adds event-specific fields to logger
@@ -70,4 +151,13 @@ func (l *Logger) WithOutcome(outcome string) *zap.Logger {
 // WithLatency adds processing latency in milliseconds
 func (l *Logger) WithLatency(latencyMS int64) *zap.Logger {
 	return l.With(zap.Int64("latency_ms", latencyMS))
-}
\ No newline at end of file
+}
+
+// delegateCheck adds wrapper to checked when the core it wraps would write entry. Asking
+// the wrapped core keeps its own decisions, such as sampling, in force.
+func delegateCheck(wrapped, wrapper zapcore.Core, entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if wrapped.Check(entry, nil) == nil {
+		return checked
+	}
+	return checked.AddCore(entry, wrapper)
+}
@@ -0,0 +1,131 @@
+package observability_test
+
+import (
+	"testing"
+
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNewSampledLogger(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    string
+		sampling observability.LogSampling
+		want     int
+	}{
+		// Of 200 identical lines: the first 5 plus the 100th after them
+		{"sampling drops excess", "info", observability.LogSampling{Initial: 5, Thereafter: 100}, 6},
+		{"sampling disabled", "info", observability.LogSampling{}, 200},
+		{"debug level is never sampled", "debug", observability.LogSampling{Initial: 5, Thereafter: 100}, 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Hooks only see entries that made it past the sampler
+			written := 0
+			logger, err := observability.NewSampledLogger(tt.level, observability.LogFormatJSON, tt.sampling, zap.Hooks(func(zapcore.Entry) error {
+				written++
+				return nil
+			}))
+			if err != nil {
+				t.Fatalf("NewSampledLogger() error = %v", err)
+			}
+
+			for i := 0; i < 200; i++ {
+				logger.Warn("Event processing failed, retrying")
+			}
+
+			if written != tt.want {
+				t.Errorf("Logged %d of 200 identical messages, want %d", written, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithBaseFields(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger, err := observability.NewLogger("debug",
+		zap.WrapCore(func(zapcore.Core) zapcore.Core { return core }),
+		observability.WithBaseFields(observability.LogFields{
+			Service:    "reservation-worker",
+			Version:    "1.2.3",
+			Env:        "staging",
+			InstanceID: "worker-7",
+		}),
+	)
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+
+	logger.Info("Starting reservation worker")
+	logger.WithEvent("reservation.expired", "rsv-1", "evt-1").Warn("Event processing failed, retrying")
+	logger.Debug("Handler finished", zap.Bool("success", true))
+
+	want := map[string]string{
+		"service":     "reservation-worker",
+		"version":     "1.2.3",
+		"env":         "staging",
+		"instance_id": "worker-7",
+	}
+	for _, entry := range logs.All() {
+		fields := entry.ContextMap()
+		for key, value := range want {
+			if fields[key] != value {
+				t.Errorf("%q: field %s = %v, want %q", entry.Message, key, fields[key], value)
+			}
+		}
+		if _, ok := fields["region"]; ok {
+			t.Errorf("%q: empty region should be left out", entry.Message)
+		}
+	}
+	if logs.Len() != 3 {
+		t.Errorf("Captured %d entries, want 3", logs.Len())
+	}
+}
+
+func TestLoggerConfig_Format(t *testing.T) {
+	tests := []struct {
+		name         string
+		format       string
+		wantEncoding string
+	}{
+		{"json", observability.LogFormatJSON, "json"},
+		{"console", observability.LogFormatConsole, "console"},
+		{"unset defaults to json", "", "json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := observability.LoggerConfig("info", tt.format, observability.DefaultLogSampling)
+			if config.Encoding != tt.wantEncoding {
+				t.Errorf("Encoding = %q, want %q", config.Encoding, tt.wantEncoding)
+			}
+
+			// Tests do not run on a terminal, so console levels are not colorized
+			if tt.wantEncoding == "console" {
+				var level zapcore.PrimitiveArrayEncoder = &stringArrayEncoder{}
+				config.EncoderConfig.EncodeLevel(zapcore.WarnLevel, level)
+				if got := level.(*stringArrayEncoder).values; len(got) != 1 || got[0] != "WARN" {
+					t.Errorf("Console level encoded as %q, want WARN", got)
+				}
+			}
+
+			if _, err := observability.NewSampledLogger("info", tt.format, observability.DefaultLogSampling); err != nil {
+				t.Errorf("NewSampledLogger() error = %v", err)
+			}
+		})
+	}
+}
+
+// stringArrayEncoder records the strings a level encoder appends
+type stringArrayEncoder struct {
+	zapcore.PrimitiveArrayEncoder
+	values []string
+}
+
+func (e *stringArrayEncoder) AppendString(s string) {
+	e.values = append(e.values, s)
+}
@@ -0,0 +1,117 @@
+package observability
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// maxTrailEntries bounds the log lines held back for one unsampled event
+const maxTrailEntries = 64
+
+type logTrailKey struct{}
+
+// SampleSuccessLogs decides whether the event handled under ctx logs its info and debug
+// lines, keeping them for a fraction rate of events. Lines of the other events are held
+// back and only written once the event logs a warning or error, so failures keep their
+// full trail. The decision is made once per context and needs a logger created with
+// WithSuccessLogSampling that is given the context through ContextField.
+func SampleSuccessLogs(ctx context.Context, rate float64) context.Context {
+	if rate >= 1 || ctx.Value(logTrailKey{}) != nil {
+		return ctx
+	}
+	if rand.Float64() < rate {
+		return ctx
+	}
+	return context.WithValue(ctx, logTrailKey{}, &logTrail{})
+}
+
+// WithSuccessLogSampling returns a logger that honors the decisions of SampleSuccessLogs
+func (l *Logger) WithSuccessLogSampling() *Logger {
+	return &Logger{Audit: l.Audit, Logger: l.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &trailCore{Core: core}
+	}))}
+}
+
+// logTrail holds back the lines of an unsampled event until it logs a warning or error
+type logTrail struct {
+	mu       sync.Mutex
+	entries  []trailEntry
+	released bool
+}
+
+// trailEntry is a held back line and the core to write it to
+type trailEntry struct {
+	core   zapcore.Core
+	entry  zapcore.Entry
+	fields []zapcore.Field
+}
+
+func (t *logTrail) write(core zapcore.Core, entry zapcore.Entry, fields []zapcore.Field) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.released {
+		return core.Write(entry, fields)
+	}
+	if entry.Level < zapcore.WarnLevel {
+		if len(t.entries) < maxTrailEntries {
+			t.entries = append(t.entries, trailEntry{core: core, entry: entry, fields: append([]zapcore.Field(nil), fields...)})
+		}
+		return nil
+	}
+
+	// The event is failing; write what it held back, then log everything from now on
+	t.released = true
+	for _, held := range t.entries {
+		held.core.Write(held.entry, held.fields)
+	}
+	t.entries = nil
+	return core.Write(entry, fields)
+}
+
+// trailCore routes lines logged with the context of an unsampled event through its trail
+type trailCore struct {
+	zapcore.Core
+	trail *logTrail
+}
+
+func (c *trailCore) With(fields []zapcore.Field) zapcore.Core {
+	trail := trailFromFields(fields)
+	if trail == nil {
+		trail = c.trail
+	}
+	return &trailCore{Core: c.Core.With(fields), trail: trail}
+}
+
+func (c *trailCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return delegateCheck(c.Core, c, entry, checked)
+}
+
+func (c *trailCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	trail := trailFromFields(fields)
+	if trail == nil {
+		trail = c.trail
+	}
+	if trail == nil {
+		return c.Core.Write(entry, fields)
+	}
+	return trail.write(c.Core, entry, fields)
+}
+
+// trailFromFields returns the trail of the context passed in a ContextField, if any
+func trailFromFields(fields []zapcore.Field) *logTrail {
+	for _, field := range fields {
+		if field.Key != "ctx" || field.Type != zapcore.SkipType {
+			continue
+		}
+		if ctx, ok := field.Interface.(context.Context); ok {
+			trail, _ := ctx.Value(logTrailKey{}).(*logTrail)
+			return trail
+		}
+	}
+	return nil
+}
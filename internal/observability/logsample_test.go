@@ -0,0 +1,38 @@
+package observability_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLogger_WithSuccessLogSamplingKeepsSampling(t *testing.T) {
+	var buf bytes.Buffer
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(&buf), zapcore.DebugLevel)
+	sampled := zapcore.NewSamplerWithOptions(core, time.Minute, 2, 0)
+	logger := (&observability.Logger{Logger: zap.New(sampled)}).WithSuccessLogSampling()
+
+	// An unsampled event holds back its info lines until it fails
+	ctx := observability.SampleSuccessLogs(context.Background(), 0)
+	logger.Info("Processing event", observability.ContextField(ctx))
+	if buf.Len() != 0 {
+		t.Fatalf("Expected the unsampled event's line to be held back, got %s", buf.String())
+	}
+	logger.Warn("Event processing failed, retrying", observability.ContextField(ctx))
+
+	// Identical lines of other events are still throttled by the sampler
+	for i := 0; i < 5; i++ {
+		logger.Info("Event processed successfully")
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Errorf("Expected the held back line, the warning and 2 of 5 sampled lines, got %d:\n%s", len(lines), buf.String())
+	}
+}
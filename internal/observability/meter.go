@@ -0,0 +1,192 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"google.golang.org/grpc/credentials"
+)
+
+// Metrics backends
+const (
+	MetricsBackendPrometheus = "prometheus"
+	MetricsBackendOTel       = "otel"
+	MetricsBackendBoth       = "both"
+)
+
+// InitMetricExport initializes an OTel meter provider that pushes to the OTLP
+// collector using the same endpoint, protocol and TLS settings as tracing
+func InitMetricExport(ctx context.Context, config TracingConfig) (*sdkmetric.MeterProvider, error) {
+	target, err := resolveExporterTarget(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var exporter sdkmetric.Exporter
+	switch config.Protocol {
+	case ProtocolHTTP, "":
+		opts := []otlpmetrichttp.Option{otlpmetrichttp.WithEndpoint(target.endpoint)}
+		if target.urlPath != "" {
+			opts = append(opts, otlpmetrichttp.WithURLPath(target.urlPath))
+		}
+		if target.tlsConfig == nil {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		} else {
+			opts = append(opts, otlpmetrichttp.WithTLSClientConfig(target.tlsConfig))
+		}
+		exporter, err = otlpmetrichttp.New(ctx, opts...)
+
+	case ProtocolGRPC:
+		opts := []otlpmetricgrpc.Option{otlpmetricgrpc.WithEndpoint(target.endpoint)}
+		if target.tlsConfig == nil {
+			opts = append(opts, otlpmetricgrpc.WithInsecure())
+		} else {
+			opts = append(opts, otlpmetricgrpc.WithTLSCredentials(credentials.NewTLS(target.tlsConfig)))
+		}
+		exporter, err = otlpmetricgrpc.New(ctx, opts...)
+
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol %q", config.Protocol)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP metric exporter: %w", err)
+	}
+
+	res := resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(config.ServiceName),
+		semconv.ServiceVersion(config.ServiceVersion),
+		semconv.DeploymentEnvironment(config.Environment),
+	)
+
+	return sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter)),
+		sdkmetric.WithResource(res),
+	), nil
+}
+
+// otelInstruments mirrors the Prometheus metrics as OTel instruments
+type otelInstruments struct {
+	eventsTotal        metric.Int64Counter
+	latency            metric.Float64Histogram
+	sqsPollErrors      metric.Int64Counter
+	activeWorkers      metric.Float64Gauge
+	processingDuration metric.Float64Histogram
+	messageAge         metric.Float64Histogram
+	backoffWait        metric.Float64Histogram
+	retriesTotal       metric.Int64Counter
+	shutdownInflight   metric.Int64Counter
+	shutdownDrained    metric.Int64Counter
+	shutdownDropped    metric.Int64Counter
+	clockSkewDetected  metric.Int64Counter
+	inflightEvents     metric.Int64UpDownCounter
+	pollerBackpressure metric.Int64Counter
+	credentialErrors   metric.Int64Counter
+	sqsThrottled       metric.Int64Counter
+	downstreamDuration metric.Float64Histogram
+	inventoryConnState metric.Float64Gauge
+	currencyMismatches metric.Int64Counter
+	workerHealthy      metric.Float64Gauge
+	subsystemRestarts  metric.Int64Counter
+}
+
+// newOTelInstruments creates the OTel instruments under the Prometheus metric names
+func newOTelInstruments(meter metric.Meter) (*otelInstruments, error) {
+	var (
+		inst otelInstruments
+		err  error
+	)
+
+	if inst.eventsTotal, err = meter.Int64Counter("worker_events_total",
+		metric.WithDescription("Total number of events processed by type, outcome and error category")); err != nil {
+		return nil, err
+	}
+	if inst.latency, err = meter.Float64Histogram("worker_latency_seconds",
+		metric.WithDescription("Event processing latency in seconds"), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if inst.sqsPollErrors, err = meter.Int64Counter("sqs_poll_errors_total",
+		metric.WithDescription("Total number of SQS polling errors")); err != nil {
+		return nil, err
+	}
+	if inst.activeWorkers, err = meter.Float64Gauge("worker_active_goroutines",
+		metric.WithDescription("Current number of active worker goroutines")); err != nil {
+		return nil, err
+	}
+	if inst.processingDuration, err = meter.Float64Histogram("worker_processing_duration_seconds",
+		metric.WithDescription("Time spent processing events by handler type"), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if inst.messageAge, err = meter.Float64Histogram("sqs_message_age_seconds",
+		metric.WithDescription("Time messages spent in SQS between enqueue and processing"), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if inst.backoffWait, err = meter.Float64Histogram("worker_backoff_wait_seconds",
+		metric.WithDescription("Time events spent waiting out retry backoff"), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if inst.retriesTotal, err = meter.Int64Counter("worker_retries_total",
+		metric.WithDescription("Total number of retries scheduled by event type")); err != nil {
+		return nil, err
+	}
+	if inst.shutdownInflight, err = meter.Int64Counter("worker_shutdown_inflight",
+		metric.WithDescription("Events buffered, in flight or waiting for retry when shutdown began")); err != nil {
+		return nil, err
+	}
+	if inst.shutdownDrained, err = meter.Int64Counter("worker_shutdown_drained",
+		metric.WithDescription("Events that reached a final outcome while the dispatcher drained")); err != nil {
+		return nil, err
+	}
+	if inst.shutdownDropped, err = meter.Int64Counter("worker_shutdown_dropped",
+		metric.WithDescription("Events abandoned during shutdown without a final outcome")); err != nil {
+		return nil, err
+	}
+	if inst.clockSkewDetected, err = meter.Int64Counter("clock_skew_detected_total",
+		metric.WithDescription("Timestamps found to lie in the future, clamped to an age of zero")); err != nil {
+		return nil, err
+	}
+	if inst.inflightEvents, err = meter.Int64UpDownCounter("worker_inflight_events",
+		metric.WithDescription("Events accepted by the dispatcher that have not reached a final outcome")); err != nil {
+		return nil, err
+	}
+	if inst.pollerBackpressure, err = meter.Int64Counter("sqs_poller_backpressure_total",
+		metric.WithDescription("Receives paused or shrunk because the events channel was full")); err != nil {
+		return nil, err
+	}
+	if inst.credentialErrors, err = meter.Int64Counter("credential_error_total",
+		metric.WithDescription("SQS calls rejected because the AWS credentials were expired or invalid")); err != nil {
+		return nil, err
+	}
+	if inst.sqsThrottled, err = meter.Int64Counter("sqs_throttled_total",
+		metric.WithDescription("SQS receives rejected for exceeding a request rate limit")); err != nil {
+		return nil, err
+	}
+	if inst.downstreamDuration, err = meter.Float64Histogram("worker_downstream_duration_seconds",
+		metric.WithDescription("Latency of inventory and reservation API calls"), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if inst.inventoryConnState, err = meter.Float64Gauge("inventory_grpc_conn_state",
+		metric.WithDescription("State of the inventory gRPC connection: 1 for the current state, 0 for the others")); err != nil {
+		return nil, err
+	}
+	if inst.currencyMismatches, err = meter.Int64Counter("worker_currency_mismatches_total",
+		metric.WithDescription("Payment events whose currency is not an ISO 4217 code or not the expected one")); err != nil {
+		return nil, err
+	}
+	if inst.workerHealthy, err = meter.Float64Gauge("worker_healthy",
+		metric.WithDescription("1 while the SQS poller and dispatcher heartbeats are fresh, 0 once either goes stale")); err != nil {
+		return nil, err
+	}
+	if inst.subsystemRestarts, err = meter.Int64Counter("worker_subsystem_restarts_total",
+		metric.WithDescription("Restarts of the SQS poller or dispatcher after they exited unexpectedly")); err != nil {
+		return nil, err
+	}
+
+	return &inst, nil
+}
@@ -1,8 +1,14 @@
 package observability
 
 import (
+	"context"
+	"fmt"
+	"sync"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 )
 
 // Metrics holds all Prometheus metrics for the reservation worker
@@ -12,20 +18,76 @@ type Metrics struct {
 	SQSPollErrors       prometheus.Counter
 	ActiveWorkers       prometheus.Gauge
 	ProcessingDuration  *prometheus.HistogramVec
+	MessageAge          prometheus.Histogram
+	BackoffWait         *prometheus.HistogramVec
+	RetriesTotal        *prometheus.CounterVec
+	ShutdownInflight    prometheus.Counter
+	ShutdownDrained     prometheus.Counter
+	ShutdownDropped     prometheus.Counter
+	ClockSkewDetected   prometheus.Counter
+	InflightEvents      prometheus.Gauge
+	PollerBackpressure  *prometheus.CounterVec
+	CredentialErrors    prometheus.Counter
+	SQSThrottled        prometheus.Counter
+	DownstreamDuration  *prometheus.HistogramVec
+	InventoryConnState  *prometheus.GaugeVec
+	CurrencyMismatches  *prometheus.CounterVec
+	WorkerHealthy       prometheus.Gauge
+	SubsystemRestarts   *prometheus.CounterVec
+
+	// Backends; Prometheus only unless SetBackend is called
+	prometheusDisabled bool
+	otel               *otelInstruments
+
+	registry *trackingRegisterer
+}
+
+// MetricsOption configures NewMetrics
+type MetricsOption func(*metricsOptions)
+
+type metricsOptions struct {
+	registerer prometheus.Registerer
+	instanceID string
+}
+
+// WithRegistry registers the metrics with reg instead of the global Prometheus registry
+func WithRegistry(reg prometheus.Registerer) MetricsOption {
+	return func(o *metricsOptions) {
+		o.registerer = reg
+	}
+}
+
+// WithInstanceID adds an instance_id constant label to every metric
+func WithInstanceID(id string) MetricsOption {
+	return func(o *metricsOptions) {
+		o.instanceID = id
+	}
 }
 
 // NewMetrics creates and registers all Prometheus metrics
-func NewMetrics() *Metrics {
+func NewMetrics(opts ...MetricsOption) *Metrics {
+	options := metricsOptions{registerer: prometheus.DefaultRegisterer}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.instanceID != "" {
+		options.registerer = prometheus.WrapRegistererWith(prometheus.Labels{"instance_id": options.instanceID}, options.registerer)
+	}
+	registry := &trackingRegisterer{Registerer: options.registerer}
+	factory := promauto.With(registry)
+
 	return &Metrics{
-		EventsTotal: promauto.NewCounterVec(
+		registry: registry,
+
+		EventsTotal: factory.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "worker_events_total",
-				Help: "Total number of events processed by type and outcome",
+				Help: "Total number of events processed by type, outcome and error category",
 			},
-			[]string{"type", "outcome"},
+			[]string{"type", "outcome", "category"},
 		),
 
-		LatencyHistogram: promauto.NewHistogramVec(
+		LatencyHistogram: factory.NewHistogramVec(
 			prometheus.HistogramOpts{
 				Name:    "worker_latency_seconds",
 				Help:    "Event processing latency in seconds",
@@ -34,21 +96,21 @@ func NewMetrics() *Metrics {
 			[]string{"type"},
 		),
 
-		SQSPollErrors: promauto.NewCounter(
+		SQSPollErrors: factory.NewCounter(
 			prometheus.CounterOpts{
 				Name: "sqs_poll_errors_total",
 				Help: "Total number of SQS polling errors",
 			},
 		),
 
-		ActiveWorkers: promauto.NewGauge(
+		ActiveWorkers: factory.NewGauge(
 			prometheus.GaugeOpts{
 				Name: "worker_active_goroutines",
 				Help: "Current number of active worker goroutines",
 			},
 		),
 
-		ProcessingDuration: promauto.NewHistogramVec(
+		ProcessingDuration: factory.NewHistogramVec(
 			prometheus.HistogramOpts{
 				Name:    "worker_processing_duration_seconds",
 				Help:    "Time spent processing events by handler type",
@@ -56,40 +118,464 @@ func NewMetrics() *Metrics {
 			},
 			[]string{"handler", "outcome"},
 		),
+
+		MessageAge: factory.NewHistogram(
+			prometheus.HistogramOpts{
+				Name:    "sqs_message_age_seconds",
+				Help:    "Time messages spent in SQS between enqueue and processing",
+				Buckets: []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 300, 900, 3600},
+			},
+		),
+
+		BackoffWait: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "worker_backoff_wait_seconds",
+				Help:    "Time events spent waiting out retry backoff",
+				Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 2, 4, 8, 16, 32},
+			},
+			[]string{"type"},
+		),
+
+		RetriesTotal: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "worker_retries_total",
+				Help: "Total number of retries scheduled by event type",
+			},
+			[]string{"type"},
+		),
+
+		ShutdownInflight: factory.NewCounter(
+			prometheus.CounterOpts{
+				Name: "worker_shutdown_inflight",
+				Help: "Events buffered, in flight or waiting for retry when shutdown began",
+			},
+		),
+
+		ShutdownDrained: factory.NewCounter(
+			prometheus.CounterOpts{
+				Name: "worker_shutdown_drained",
+				Help: "Events that reached a final outcome while the dispatcher drained",
+			},
+		),
+
+		ShutdownDropped: factory.NewCounter(
+			prometheus.CounterOpts{
+				Name: "worker_shutdown_dropped",
+				Help: "Events abandoned during shutdown without a final outcome",
+			},
+		),
+
+		ClockSkewDetected: factory.NewCounter(
+			prometheus.CounterOpts{
+				Name: "clock_skew_detected_total",
+				Help: "Timestamps found to lie in the future, clamped to an age of zero",
+			},
+		),
+
+		InflightEvents: factory.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "worker_inflight_events",
+				Help: "Events accepted by the dispatcher that have not reached a final outcome",
+			},
+		),
+
+		PollerBackpressure: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "sqs_poller_backpressure_total",
+				Help: "Receives paused or shrunk because the events channel was full",
+			},
+			[]string{"action"},
+		),
+
+		CredentialErrors: factory.NewCounter(
+			prometheus.CounterOpts{
+				Name: "credential_error_total",
+				Help: "SQS calls rejected because the AWS credentials were expired or invalid",
+			},
+		),
+
+		SQSThrottled: factory.NewCounter(
+			prometheus.CounterOpts{
+				Name: "sqs_throttled_total",
+				Help: "SQS receives rejected for exceeding a request rate limit",
+			},
+		),
+
+		DownstreamDuration: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "worker_downstream_duration_seconds",
+				Help:    "Latency of inventory and reservation API calls",
+				Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1.0, 2.5},
+			},
+			[]string{"service", "operation", "outcome"},
+		),
+
+		InventoryConnState: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "inventory_grpc_conn_state",
+				Help: "State of the inventory gRPC connection: 1 for the current state, 0 for the others",
+			},
+			[]string{"state"},
+		),
+
+		CurrencyMismatches: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "worker_currency_mismatches_total",
+				Help: "Payment events whose currency is not an ISO 4217 code or not the expected one",
+			},
+			[]string{"type", "reason"},
+		),
+
+		WorkerHealthy: factory.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "worker_healthy",
+				Help: "1 while the SQS poller and dispatcher heartbeats are fresh, 0 once either goes stale",
+			},
+		),
+
+		SubsystemRestarts: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "worker_subsystem_restarts_total",
+				Help: "Restarts of the SQS poller or dispatcher after they exited unexpectedly",
+			},
+			[]string{"subsystem"},
+		),
+	}
+}
+
+// Unregister removes all collectors from the registry they were registered with,
+// so metrics can be created again. Calling it more than once is a no-op.
+func (m *Metrics) Unregister() {
+	m.registry.unregisterAll()
+}
+
+// trackingRegisterer remembers the collectors registered through it
+type trackingRegisterer struct {
+	prometheus.Registerer
+
+	mu         sync.Mutex
+	collectors []prometheus.Collector
+}
+
+func (r *trackingRegisterer) Register(c prometheus.Collector) error {
+	if err := r.Registerer.Register(c); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.collectors = append(r.collectors, c)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *trackingRegisterer) MustRegister(cs ...prometheus.Collector) {
+	r.Registerer.MustRegister(cs...)
+	r.mu.Lock()
+	r.collectors = append(r.collectors, cs...)
+	r.mu.Unlock()
+}
+
+func (r *trackingRegisterer) unregisterAll() {
+	r.mu.Lock()
+	collectors := r.collectors
+	r.collectors = nil
+	r.mu.Unlock()
+
+	for _, c := range collectors {
+		r.Registerer.Unregister(c)
+	}
+}
+
+// SetBackend selects where metrics are recorded: prometheus, otel or both.
+// meter is required for otel and both. Call before any metrics are recorded.
+func (m *Metrics) SetBackend(backend string, meter metric.Meter) error {
+	switch backend {
+	case MetricsBackendPrometheus, "":
+		m.prometheusDisabled = false
+		m.otel = nil
+		return nil
+	case MetricsBackendOTel, MetricsBackendBoth:
+		if meter == nil {
+			return fmt.Errorf("metrics backend %q requires a meter", backend)
+		}
+		inst, err := newOTelInstruments(meter)
+		if err != nil {
+			return fmt.Errorf("failed to create OTel instruments: %w", err)
+		}
+		m.prometheusDisabled = backend == MetricsBackendOTel
+		m.otel = inst
+		return nil
+	default:
+		return fmt.Errorf("unknown metrics backend %q", backend)
 	}
 }
 
 // RecordEventProcessed records a processed event with outcome
 func (m *Metrics) RecordEventProcessed(eventType, outcome string) {
-	m.EventsTotal.WithLabelValues(eventType, outcome).Inc()
+	m.RecordEventError(eventType, outcome, CategoryNone)
+}
+
+// RecordEventError records a processed event whose outcome was caused by an error of the given category
+func (m *Metrics) RecordEventError(eventType, outcome, category string) {
+	if !m.prometheusDisabled {
+		m.EventsTotal.WithLabelValues(eventType, outcome, category).Inc()
+	}
+	if m.otel != nil {
+		m.otel.eventsTotal.Add(context.Background(), 1, metric.WithAttributes(
+			attribute.String("type", eventType),
+			attribute.String("outcome", outcome),
+			attribute.String("category", category),
+		))
+	}
 }
 
 // RecordEventLatency records event processing latency
 func (m *Metrics) RecordEventLatency(eventType string, seconds float64) {
-	m.LatencyHistogram.WithLabelValues(eventType).Observe(seconds)
+	if !m.prometheusDisabled {
+		m.LatencyHistogram.WithLabelValues(eventType).Observe(seconds)
+	}
+	if m.otel != nil {
+		m.otel.latency.Record(context.Background(), seconds, metric.WithAttributes(
+			attribute.String("type", eventType),
+		))
+	}
 }
 
 // RecordSQSPollError increments SQS polling error counter
 func (m *Metrics) RecordSQSPollError() {
-	m.SQSPollErrors.Inc()
+	if !m.prometheusDisabled {
+		m.SQSPollErrors.Inc()
+	}
+	if m.otel != nil {
+		m.otel.sqsPollErrors.Add(context.Background(), 1)
+	}
 }
 
 // SetActiveWorkers sets the current number of active workers
 func (m *Metrics) SetActiveWorkers(count float64) {
-	m.ActiveWorkers.Set(count)
+	if !m.prometheusDisabled {
+		m.ActiveWorkers.Set(count)
+	}
+	if m.otel != nil {
+		m.otel.activeWorkers.Record(context.Background(), count)
+	}
 }
 
 // RecordProcessingDuration records handler processing duration
 func (m *Metrics) RecordProcessingDuration(handler, outcome string, seconds float64) {
-	m.ProcessingDuration.WithLabelValues(handler, outcome).Observe(seconds)
+	if !m.prometheusDisabled {
+		m.ProcessingDuration.WithLabelValues(handler, outcome).Observe(seconds)
+	}
+	if m.otel != nil {
+		m.otel.processingDuration.Record(context.Background(), seconds, metric.WithAttributes(
+			attribute.String("handler", handler),
+			attribute.String("outcome", outcome),
+		))
+	}
+}
+
+// RecordDownstreamDuration records the latency of one call to a downstream service
+func (m *Metrics) RecordDownstreamDuration(service, operation, outcome string, seconds float64) {
+	if !m.prometheusDisabled {
+		m.DownstreamDuration.WithLabelValues(service, operation, outcome).Observe(seconds)
+	}
+	if m.otel != nil {
+		m.otel.downstreamDuration.Record(context.Background(), seconds, metric.WithAttributes(
+			attribute.String("service", service),
+			attribute.String("operation", operation),
+			attribute.String("outcome", outcome),
+		))
+	}
+}
+
+// SetWorkerHealthy reports whether the poller and dispatcher are alive
+func (m *Metrics) SetWorkerHealthy(healthy bool) {
+	value := 0.0
+	if healthy {
+		value = 1
+	}
+	if !m.prometheusDisabled {
+		m.WorkerHealthy.Set(value)
+	}
+	if m.otel != nil {
+		m.otel.workerHealthy.Record(context.Background(), value)
+	}
+}
+
+// inventoryConnStates are the gRPC connectivity states reported by inventory_grpc_conn_state
+var inventoryConnStates = []string{"IDLE", "CONNECTING", "READY", "TRANSIENT_FAILURE", "SHUTDOWN"}
+
+// SetInventoryConnState marks state as the current state of the inventory gRPC connection
+func (m *Metrics) SetInventoryConnState(state string) {
+	for _, s := range inventoryConnStates {
+		value := 0.0
+		if s == state {
+			value = 1
+		}
+		if !m.prometheusDisabled {
+			m.InventoryConnState.WithLabelValues(s).Set(value)
+		}
+		if m.otel != nil {
+			m.otel.inventoryConnState.Record(context.Background(), value, metric.WithAttributes(attribute.String("state", s)))
+		}
+	}
 }
 
+// Reasons of worker_currency_mismatches_total
+const (
+	CurrencyInvalidCode = "invalid_code"
+	CurrencyUnexpected  = "unexpected_currency"
+)
+
+// RecordCurrencyMismatch records a payment event whose currency failed validation for reason
+func (m *Metrics) RecordCurrencyMismatch(eventType, reason string) {
+	if !m.prometheusDisabled {
+		m.CurrencyMismatches.WithLabelValues(eventType, reason).Inc()
+	}
+	if m.otel != nil {
+		m.otel.currencyMismatches.Add(context.Background(), 1, metric.WithAttributes(
+			attribute.String("type", eventType),
+			attribute.String("reason", reason),
+		))
+	}
+}
+
+// RecordSubsystemRestart records a restart of subsystem after it exited unexpectedly
+func (m *Metrics) RecordSubsystemRestart(subsystem string) {
+	if !m.prometheusDisabled {
+		m.SubsystemRestarts.WithLabelValues(subsystem).Inc()
+	}
+	if m.otel != nil {
+		m.otel.subsystemRestarts.Add(context.Background(), 1, metric.WithAttributes(attribute.String("subsystem", subsystem)))
+	}
+}
+
+// RecordMessageAge records the enqueue-to-process age of an SQS message
+func (m *Metrics) RecordMessageAge(seconds float64) {
+	if !m.prometheusDisabled {
+		m.MessageAge.Observe(seconds)
+	}
+	if m.otel != nil {
+		m.otel.messageAge.Record(context.Background(), seconds)
+	}
+}
+
+// RecordBackoffWait records how long a retry waited before running again
+func (m *Metrics) RecordBackoffWait(eventType string, seconds float64) {
+	if !m.prometheusDisabled {
+		m.BackoffWait.WithLabelValues(eventType).Observe(seconds)
+	}
+	if m.otel != nil {
+		m.otel.backoffWait.Record(context.Background(), seconds, metric.WithAttributes(
+			attribute.String("type", eventType),
+		))
+	}
+}
+
+// RecordRetry counts a scheduled retry
+func (m *Metrics) RecordRetry(eventType string) {
+	if !m.prometheusDisabled {
+		m.RetriesTotal.WithLabelValues(eventType).Inc()
+	}
+	if m.otel != nil {
+		m.otel.retriesTotal.Add(context.Background(), 1, metric.WithAttributes(
+			attribute.String("type", eventType),
+		))
+	}
+}
+
+// RecordShutdown records how the events outstanding at shutdown were resolved
+func (m *Metrics) RecordShutdown(inflight, drained, dropped int64) {
+	if !m.prometheusDisabled {
+		m.ShutdownInflight.Add(float64(inflight))
+		m.ShutdownDrained.Add(float64(drained))
+		m.ShutdownDropped.Add(float64(dropped))
+	}
+	if m.otel != nil {
+		ctx := context.Background()
+		m.otel.shutdownInflight.Add(ctx, inflight)
+		m.otel.shutdownDrained.Add(ctx, drained)
+		m.otel.shutdownDropped.Add(ctx, dropped)
+	}
+}
+
+// RecordClockSkew counts a timestamp that was ahead of the local clock
+func (m *Metrics) RecordClockSkew() {
+	if !m.prometheusDisabled {
+		m.ClockSkewDetected.Inc()
+	}
+	if m.otel != nil {
+		m.otel.clockSkewDetected.Add(context.Background(), 1)
+	}
+}
+
+// AddInflightEvents adjusts the number of events the dispatcher is working on by delta
+func (m *Metrics) AddInflightEvents(delta int64) {
+	if !m.prometheusDisabled {
+		m.InflightEvents.Add(float64(delta))
+	}
+	if m.otel != nil {
+		m.otel.inflightEvents.Add(context.Background(), delta)
+	}
+}
+
+// RecordBackpressure records the poller holding back a receive; action is paused or reduced
+func (m *Metrics) RecordBackpressure(action string) {
+	if !m.prometheusDisabled {
+		m.PollerBackpressure.WithLabelValues(action).Inc()
+	}
+	if m.otel != nil {
+		m.otel.pollerBackpressure.Add(context.Background(), 1, metric.WithAttributes(
+			attribute.String("action", action),
+		))
+	}
+}
+
+// RecordCredentialError increments the AWS credential error counter
+func (m *Metrics) RecordCredentialError() {
+	if !m.prometheusDisabled {
+		m.CredentialErrors.Inc()
+	}
+	if m.otel != nil {
+		m.otel.credentialErrors.Add(context.Background(), 1)
+	}
+}
+
+// RecordSQSThrottled increments the throttled SQS receive counter
+func (m *Metrics) RecordSQSThrottled() {
+	if !m.prometheusDisabled {
+		m.SQSThrottled.Inc()
+	}
+	if m.otel != nil {
+		m.otel.sqsThrottled.Add(context.Background(), 1)
+	}
+}
+
+// Backpressure actions
+const (
+	BackpressurePaused  = "paused"  // Receiving stopped until the channel had room
+	BackpressureReduced = "reduced" // Fewer messages requested than configured
+)
+
+// CategoryNone is the category label of events that did not fail
+const CategoryNone = "none"
+
 // Outcome constants for metrics
 const (
-	OutcomeSuccess         = "success"
-	OutcomeRetried         = "retried"
-	OutcomeFailed          = "failed"
-	OutcomeDropped         = "dropped"
-	OutcomeInvalidPayload  = "invalid_payload"
-	OutcomeDownstreamError = "downstream_error"
+	OutcomeSuccess               = "success"
+	OutcomeRetried               = "retried"
+	OutcomeFailed                = "failed"
+	OutcomeDropped               = "dropped"
+	OutcomeInvalidPayload        = "invalid_payload"
+	OutcomeDownstreamError       = "downstream_error"
+	OutcomePoison                = "poison"
+	OutcomeDryRun                = "dry_run"
+	OutcomeUnsupportedVersion    = "unsupported_version"
+	OutcomeIllegalTransition     = "illegal_transition"
+	OutcomeReturned              = "returned"
+	OutcomeIgnored               = "ignored"
+	OutcomeStaleDropped          = "stale_dropped"
+	OutcomeNotFound              = "not_found"
+	OutcomeSourceMismatch        = "source_mismatch"
+	OutcomeApprovedAfterTerminal = "approved_after_terminal"
+	OutcomeNotExpiredSkipped     = "not_expired_skipped"
 )
\ No newline at end of file
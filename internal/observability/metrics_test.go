@@ -0,0 +1,154 @@
+package observability_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// testMetrics is shared because metrics register against the global Prometheus registry
+var testMetrics = observability.NewMetrics()
+
+// otelCounterValue sums an OTel counter's data points matching the attribute value
+func otelCounterValue(t *testing.T, reader *sdkmetric.ManualReader, name, key, value string) int64 {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	var total int64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != name {
+				continue
+			}
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			if !ok {
+				t.Fatalf("%s has data type %T, want Sum[int64]", name, m.Data)
+			}
+			for _, dp := range sum.DataPoints {
+				if v, ok := dp.Attributes.Value(attribute.Key(key)); ok && v.AsString() == value {
+					total += dp.Value
+				}
+			}
+		}
+	}
+	return total
+}
+
+func TestMetrics_SetBackend(t *testing.T) {
+	defer testMetrics.SetBackend(observability.MetricsBackendPrometheus, nil)
+
+	tests := []struct {
+		backend        string
+		wantPrometheus float64
+		wantOTel       int64
+	}{
+		{backend: observability.MetricsBackendPrometheus, wantPrometheus: 1, wantOTel: 0},
+		{backend: observability.MetricsBackendOTel, wantPrometheus: 0, wantOTel: 1},
+		{backend: observability.MetricsBackendBoth, wantPrometheus: 1, wantOTel: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.backend, func(t *testing.T) {
+			reader := sdkmetric.NewManualReader()
+			provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+			defer provider.Shutdown(context.Background())
+
+			if err := testMetrics.SetBackend(tt.backend, provider.Meter("test")); err != nil {
+				t.Fatalf("SetBackend() error = %v", err)
+			}
+
+			// Use a distinct event type per case so Prometheus counts don't carry over
+			eventType := "test." + tt.backend
+			before := testutil.ToFloat64(testMetrics.EventsTotal.WithLabelValues(eventType, observability.OutcomeSuccess, observability.CategoryNone))
+
+			testMetrics.RecordEventProcessed(eventType, observability.OutcomeSuccess)
+
+			promDelta := testutil.ToFloat64(testMetrics.EventsTotal.WithLabelValues(eventType, observability.OutcomeSuccess, observability.CategoryNone)) - before
+			if promDelta != tt.wantPrometheus {
+				t.Errorf("Prometheus worker_events_total delta = %v, want %v", promDelta, tt.wantPrometheus)
+			}
+			if got := otelCounterValue(t, reader, "worker_events_total", "type", eventType); got != tt.wantOTel {
+				t.Errorf("OTel worker_events_total = %d, want %d", got, tt.wantOTel)
+			}
+		})
+	}
+}
+
+func TestMetrics_SetBackendErrors(t *testing.T) {
+	defer testMetrics.SetBackend(observability.MetricsBackendPrometheus, nil)
+
+	if err := testMetrics.SetBackend(observability.MetricsBackendOTel, nil); err == nil {
+		t.Error("Expected error when otel backend has no meter")
+	}
+	if err := testMetrics.SetBackend("statsd", nil); err == nil {
+		t.Error("Expected error for unknown backend")
+	}
+}
+
+func TestNewMetrics_WithRegistry(t *testing.T) {
+	first := prometheus.NewRegistry()
+	second := prometheus.NewRegistry()
+
+	a := observability.NewMetrics(observability.WithRegistry(first))
+	b := observability.NewMetrics(observability.WithRegistry(second))
+
+	a.RecordSQSPollError()
+	b.RecordSQSPollError()
+	b.RecordSQSPollError()
+
+	if got := testutil.ToFloat64(a.SQSPollErrors); got != 1 {
+		t.Errorf("First registry sqs_poll_errors_total = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(b.SQSPollErrors); got != 2 {
+		t.Errorf("Second registry sqs_poll_errors_total = %v, want 2", got)
+	}
+	if n, err := testutil.GatherAndCount(first, "sqs_poll_errors_total"); err != nil || n != 1 {
+		t.Errorf("GatherAndCount() = %d, %v; want 1 series registered with the first registry", n, err)
+	}
+}
+
+func TestMetrics_Unregister(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	for i := 0; i < 3; i++ {
+		metrics := observability.NewMetrics(observability.WithRegistry(registry))
+		metrics.RecordSQSPollError()
+		if n, err := testutil.GatherAndCount(registry, "sqs_poll_errors_total"); err != nil || n != 1 {
+			t.Fatalf("Round %d: GatherAndCount() = %d, %v; want 1", i, n, err)
+		}
+
+		metrics.Unregister()
+		metrics.Unregister()
+		if n, err := testutil.GatherAndCount(registry); err != nil || n != 0 {
+			t.Fatalf("Round %d: %d series still registered after Unregister, err = %v", i, n, err)
+		}
+	}
+}
+
+func TestNewMetrics_WithInstanceID(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	metrics := observability.NewMetrics(observability.WithRegistry(registry), observability.WithInstanceID("worker-abc"))
+	defer metrics.Unregister()
+
+	metrics.RecordSQSPollError()
+
+	expected := `
+# HELP sqs_poll_errors_total Total number of SQS polling errors
+# TYPE sqs_poll_errors_total counter
+sqs_poll_errors_total{instance_id="worker-abc"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "sqs_poll_errors_total"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,133 @@
+package observability
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// WithRedaction returns a logger that replaces the values of the named fields, at any
+// nesting depth, with a hash of the value. Equal values hash alike, so entries stay
+// correlatable. Apply it last so it covers every output, including OTLP export.
+func (l *Logger) WithRedaction(fieldNames []string) *Logger {
+	if len(fieldNames) == 0 {
+		return l
+	}
+
+	redacted := make(map[string]bool, len(fieldNames))
+	for _, name := range fieldNames {
+		redacted[name] = true
+	}
+	return &Logger{Audit: l.Audit, Logger: l.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &redactCore{Core: core, redacted: redacted}
+	}))}
+}
+
+// redactCore redacts fields before passing them to the wrapped core
+type redactCore struct {
+	zapcore.Core
+	redacted map[string]bool
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.redactFields(fields)), redacted: c.redacted}
+}
+
+func (c *redactCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return delegateCheck(c.Core, c, entry, checked)
+}
+
+func (c *redactCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(entry, c.redactFields(fields))
+}
+
+func (c *redactCore) redactFields(fields []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, field := range fields {
+		replacement, changed := c.redactField(field)
+		if !changed {
+			if out != nil {
+				out = append(out, field)
+			}
+			continue
+		}
+		if out == nil {
+			// Copy on first change; the caller's slice may be shared
+			out = append(make([]zapcore.Field, 0, len(fields)), fields[:i]...)
+		}
+		out = append(out, replacement)
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
+
+// redactField returns the field with redacted values and whether anything was replaced
+func (c *redactCore) redactField(field zapcore.Field) (zapcore.Field, bool) {
+	nested := field.Type == zapcore.ObjectMarshalerType ||
+		field.Type == zapcore.ArrayMarshalerType ||
+		field.Type == zapcore.ReflectType
+	if !c.redacted[field.Key] && !nested {
+		return field, false
+	}
+
+	// Render the field into plain values so nested keys can be inspected
+	enc := zapcore.NewMapObjectEncoder()
+	field.AddTo(enc)
+	value, ok := enc.Fields[field.Key]
+	if !ok {
+		return field, false
+	}
+
+	if c.redacted[field.Key] {
+		return zap.String(field.Key, redactValue(value)), true
+	}
+
+	if field.Type == zapcore.ReflectType {
+		// Reflected values are stored as is; normalize them through JSON
+		raw, err := json.Marshal(value)
+		if err != nil || json.Unmarshal(raw, &value) != nil {
+			return field, false
+		}
+	}
+	if !c.redactNested(value) {
+		return field, false
+	}
+	return zap.Any(field.Key, value), true
+}
+
+// redactNested redacts matching keys in maps within value in place
+func (c *redactCore) redactNested(value interface{}) bool {
+	changed := false
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, inner := range v {
+			if c.redacted[key] {
+				v[key] = redactValue(inner)
+				changed = true
+				continue
+			}
+			if c.redactNested(inner) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, inner := range v {
+			if c.redactNested(inner) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// redactValue returns a short, stable hash standing in for value
+func redactValue(value interface{}) string {
+	sum := sha256.Sum256([]byte(fmt.Sprint(value)))
+	return "redacted:" + hex.EncodeToString(sum[:6])
+}
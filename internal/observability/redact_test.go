@@ -0,0 +1,86 @@
+package observability_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// paymentLog is a nested structured field as handlers might log it
+type paymentLog struct {
+	IntentID string
+	UserID   string
+}
+
+func (p paymentLog) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("payment_intent_id", p.IntentID)
+	enc.AddString("user_id", p.UserID)
+	return nil
+}
+
+func TestLogger_WithRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(&buf), zapcore.DebugLevel)
+	logger := (&observability.Logger{Logger: zap.New(core)}).WithRedaction([]string{"user_id", "payment_intent_id"})
+
+	logger.With(zap.String("user_id", "user-42")).Info("Processing payment approved event",
+		zap.String("reservation_id", "rsv-1"),
+		zap.String("payment_intent_id", "pay-secret"),
+		zap.Object("payment", paymentLog{IntentID: "pay-secret", UserID: "user-42"}),
+		zap.Any("detail", map[string]interface{}{
+			"seats": []map[string]string{{"seat_id": "A1", "user_id": "user-42"}},
+		}),
+	)
+
+	output := buf.String()
+	for _, secret := range []string{"user-42", "pay-secret"} {
+		if strings.Contains(output, secret) {
+			t.Errorf("Log output contains %q: %s", secret, output)
+		}
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("log line is not JSON: %v", err)
+	}
+	if entry["reservation_id"] != "rsv-1" {
+		t.Errorf("reservation_id = %v, want it to pass through", entry["reservation_id"])
+	}
+	hashed, _ := entry["user_id"].(string)
+	if !strings.HasPrefix(hashed, "redacted:") {
+		t.Errorf("user_id = %v, want a redacted hash", entry["user_id"])
+	}
+	nested := entry["payment"].(map[string]interface{})
+	if nested["user_id"] != hashed {
+		t.Errorf("Nested user_id = %v, want the same hash %q", nested["user_id"], hashed)
+	}
+	seat := entry["detail"].(map[string]interface{})["seats"].([]interface{})[0].(map[string]interface{})
+	if seat["seat_id"] != "A1" || seat["user_id"] != hashed {
+		t.Errorf("Unexpected seat %v", seat)
+	}
+}
+
+func TestLogger_WithRedactionKeepsSampling(t *testing.T) {
+	var buf bytes.Buffer
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(&buf), zapcore.DebugLevel)
+	sampled := zapcore.NewSamplerWithOptions(core, time.Minute, 2, 0)
+	logger := (&observability.Logger{Logger: zap.New(sampled)}).WithRedaction([]string{"user_id"})
+
+	for i := 0; i < 5; i++ {
+		logger.Info("Processing payment approved event", zap.String("user_id", "user-42"))
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected the sampler to keep 2 of 5 lines, got %d:\n%s", len(lines), buf.String())
+	}
+	if strings.Contains(buf.String(), "user-42") {
+		t.Errorf("Expected user_id to stay redacted, got %s", buf.String())
+	}
+}
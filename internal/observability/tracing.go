@@ -2,56 +2,99 @@ package observability
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"net/url"
+	"os"
+	"strings"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
 	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/credentials"
 )
 
 // TracingConfig holds OpenTelemetry configuration
 type TracingConfig struct {
-	ServiceName string
-	ServiceVersion string
-	Environment string
+	ServiceName      string
+	ServiceVersion   string
+	Environment      string
 	ExporterEndpoint string
+	Sampler          string  // always, never, ratio
+	SamplerArg       float64 // Sampling ratio when Sampler is ratio
+	Insecure         bool    // Plaintext export for endpoints without a scheme
+	CAFile           string  // Optional CA bundle for TLS export; system roots otherwise
+	Protocol         string  // http (default) or grpc
+}
+
+// OTLP export protocols for TracingConfig.Protocol
+const (
+	ProtocolHTTP = "http"
+	ProtocolGRPC = "grpc"
+)
+
+// Sampling strategies for TracingConfig.Sampler
+const (
+	SamplerAlways = "always"
+	SamplerNever  = "never"
+	SamplerRatio  = "ratio"
+)
+
+// NewSampler builds the configured sampler. Root decisions follow the strategy
+// while child spans inherit the sampling decision of their upstream parent.
+func NewSampler(config TracingConfig) (sdktrace.Sampler, error) {
+	var root sdktrace.Sampler
+	switch config.Sampler {
+	case SamplerAlways, "":
+		root = sdktrace.AlwaysSample()
+	case SamplerNever:
+		root = sdktrace.NeverSample()
+	case SamplerRatio:
+		if config.SamplerArg < 0 || config.SamplerArg > 1 {
+			return nil, fmt.Errorf("sampler ratio must be between 0 and 1, got %v", config.SamplerArg)
+		}
+		root = sdktrace.TraceIDRatioBased(config.SamplerArg)
+	default:
+		return nil, fmt.Errorf("unknown trace sampler %q", config.Sampler)
+	}
+	return sdktrace.ParentBased(root), nil
 }
 
 // InitTracing initializes OpenTelemetry tracing
 func InitTracing(ctx context.Context, config TracingConfig) (*sdktrace.TracerProvider, error) {
-	// Create OTLP HTTP exporter
-	exporter, err := otlptracehttp.New(ctx,
-		otlptracehttp.WithEndpoint(config.ExporterEndpoint),
-		otlptracehttp.WithInsecure(), // Use TLS in production
-	)
+	sampler, err := NewSampler(config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+		return nil, err
 	}
 
-	// Create resource with service information
-	res, err := resource.Merge(
-		resource.Default(),
-		resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceName(config.ServiceName),
-			semconv.ServiceVersion(config.ServiceVersion),
-			semconv.DeploymentEnvironment(config.Environment),
-		),
-	)
+	// Create OTLP exporter
+	exporter, err := NewTraceExporter(ctx, config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create resource: %w", err)
+		return nil, err
 	}
 
+	// Create resource with service information.
+	// Not merged with resource.Default(), whose schema URL conflicts with semconv v1.21.0.
+	res := resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(config.ServiceName),
+		semconv.ServiceVersion(config.ServiceVersion),
+		semconv.DeploymentEnvironment(config.Environment),
+	)
+
 	// Create tracer provider
 	tp := sdktrace.NewTracerProvider(
 		sdktrace.WithBatcher(exporter),
 		sdktrace.WithResource(res),
-		sdktrace.WithSampler(sdktrace.AlwaysSample()), // Use probabilistic sampling in production
+		sdktrace.WithSampler(sampler),
 	)
 
 	// Set global tracer provider
@@ -66,6 +109,108 @@ func InitTracing(ctx context.Context, config TracingConfig) (*sdktrace.TracerPro
 	return tp, nil
 }
 
+// NewTraceExporter creates an OTLP span exporter for the configured protocol
+func NewTraceExporter(ctx context.Context, config TracingConfig) (*otlptrace.Exporter, error) {
+	target, err := resolveExporterTarget(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var exporter *otlptrace.Exporter
+	switch config.Protocol {
+	case ProtocolHTTP, "":
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(target.endpoint)}
+		if target.urlPath != "" {
+			opts = append(opts, otlptracehttp.WithURLPath(target.urlPath))
+		}
+		if target.tlsConfig == nil {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		} else {
+			opts = append(opts, otlptracehttp.WithTLSClientConfig(target.tlsConfig))
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+
+	case ProtocolGRPC:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(target.endpoint)}
+		if target.tlsConfig == nil {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		} else {
+			opts = append(opts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(target.tlsConfig)))
+		}
+		exporter, err = otlptracegrpc.New(ctx, opts...)
+
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol %q", config.Protocol)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+	return exporter, nil
+}
+
+// exporterTarget is the resolved OTLP endpoint and its transport security
+type exporterTarget struct {
+	endpoint  string
+	urlPath   string      // HTTP only
+	tlsConfig *tls.Config // nil for plaintext
+}
+
+// resolveExporterTarget parses the endpoint.
+// An http:// or https:// scheme decides TLS; otherwise config.Insecure does.
+func resolveExporterTarget(config TracingConfig) (exporterTarget, error) {
+	target := exporterTarget{endpoint: config.ExporterEndpoint}
+	insecure := config.Insecure
+
+	if strings.Contains(config.ExporterEndpoint, "://") {
+		u, err := url.Parse(config.ExporterEndpoint)
+		if err != nil {
+			return target, fmt.Errorf("invalid OTLP endpoint %q: %w", config.ExporterEndpoint, err)
+		}
+		switch u.Scheme {
+		case "http":
+			insecure = true
+		case "https":
+			insecure = false
+		default:
+			return target, fmt.Errorf("unsupported OTLP endpoint scheme %q", u.Scheme)
+		}
+		target.endpoint = u.Host
+		if u.Path != "" && u.Path != "/" {
+			target.urlPath = u.Path
+		}
+	}
+
+	if insecure {
+		return target, nil
+	}
+
+	tlsConfig, err := loadTLSConfig(config.CAFile)
+	if err != nil {
+		return target, err
+	}
+	target.tlsConfig = tlsConfig
+	return target, nil
+}
+
+// loadTLSConfig trusts the CA bundle at caFile, or the system roots when empty
+func loadTLSConfig(caFile string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile == "" {
+		return tlsConfig, nil
+	}
+
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA file %s", caFile)
+	}
+	tlsConfig.RootCAs = pool
+	return tlsConfig, nil
+}
+
 // Tracer returns a tracer for the reservation worker
 func Tracer() trace.Tracer {
 	return otel.Tracer("reservation-worker")
@@ -90,4 +235,4 @@ func SetSpanError(span trace.Span, err error) {
 // SetSpanSuccess marks the span as successful
 func SetSpanSuccess(span trace.Span) {
 	span.SetStatus(codes.Ok, "")
-}
\ No newline at end of file
+}
@@ -0,0 +1,279 @@
+package observability_test
+
+import (
+	"context"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+)
+
+func TestNewSampler(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  observability.TracingConfig
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "default is always",
+			config: observability.TracingConfig{},
+			want:   "ParentBased{root:AlwaysOnSampler,remoteParentSampled:AlwaysOnSampler,remoteParentNotSampled:AlwaysOffSampler,localParentSampled:AlwaysOnSampler,localParentNotSampled:AlwaysOffSampler}",
+		},
+		{
+			name:   "never",
+			config: observability.TracingConfig{Sampler: observability.SamplerNever},
+			want:   "ParentBased{root:AlwaysOffSampler,remoteParentSampled:AlwaysOnSampler,remoteParentNotSampled:AlwaysOffSampler,localParentSampled:AlwaysOnSampler,localParentNotSampled:AlwaysOffSampler}",
+		},
+		{
+			name:   "ratio",
+			config: observability.TracingConfig{Sampler: observability.SamplerRatio, SamplerArg: 0.25},
+			want:   "ParentBased{root:TraceIDRatioBased{0.25},remoteParentSampled:AlwaysOnSampler,remoteParentNotSampled:AlwaysOffSampler,localParentSampled:AlwaysOnSampler,localParentNotSampled:AlwaysOffSampler}",
+		},
+		{
+			name:    "ratio out of range",
+			config:  observability.TracingConfig{Sampler: observability.SamplerRatio, SamplerArg: 2},
+			wantErr: true,
+		},
+		{
+			name:    "unknown strategy",
+			config:  observability.TracingConfig{Sampler: "sometimes"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sampler, err := observability.NewSampler(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewSampler() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := sampler.Description(); got != tt.want {
+				t.Errorf("Description() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInitTracing_RatioSampler(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(previous)
+
+	tests := []struct {
+		ratio       float64
+		wantSampled bool
+	}{
+		{ratio: 0, wantSampled: false},
+		{ratio: 1, wantSampled: true},
+	}
+
+	for _, tt := range tests {
+		tp, err := observability.InitTracing(context.Background(), observability.TracingConfig{
+			ServiceName:      "reservation-worker-test",
+			ExporterEndpoint: "localhost:4318",
+			Sampler:          observability.SamplerRatio,
+			SamplerArg:       tt.ratio,
+		})
+		if err != nil {
+			t.Fatalf("InitTracing() error = %v", err)
+		}
+
+		_, span := tp.Tracer("test").Start(context.Background(), "root")
+		if got := span.SpanContext().IsSampled(); got != tt.wantSampled {
+			t.Errorf("ratio %v: root span sampled = %v, want %v", tt.ratio, got, tt.wantSampled)
+		}
+
+		// Children follow a sampled upstream parent even when the ratio is 0
+		parent := trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    trace.TraceID{1},
+			SpanID:     trace.SpanID{1},
+			TraceFlags: trace.FlagsSampled,
+			Remote:     true,
+		})
+		_, child := tp.Tracer("test").Start(trace.ContextWithRemoteSpanContext(context.Background(), parent), "child")
+		if !child.SpanContext().IsSampled() {
+			t.Errorf("ratio %v: child of sampled parent was not sampled", tt.ratio)
+		}
+
+		span.End()
+		child.End()
+		tp.Shutdown(context.Background())
+	}
+}
+
+func TestNewTraceExporter_TLS(t *testing.T) {
+	var received atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	plain := httptest.NewServer(handler)
+	defer plain.Close()
+	secure := httptest.NewTLSServer(handler)
+	defer secure.Close()
+
+	// Trust the test server's self-signed certificate through a CA bundle file
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: secure.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0o600); err != nil {
+		t.Fatalf("failed to write CA file: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		config     observability.TracingConfig
+		wantErr    bool
+		wantExport bool
+	}{
+		{
+			name:       "insecure host:port",
+			config:     observability.TracingConfig{ExporterEndpoint: plain.Listener.Addr().String(), Insecure: true},
+			wantExport: true,
+		},
+		{
+			name:       "http scheme is insecure",
+			config:     observability.TracingConfig{ExporterEndpoint: plain.URL},
+			wantExport: true,
+		},
+		{
+			name:       "TLS with custom CA",
+			config:     observability.TracingConfig{ExporterEndpoint: secure.Listener.Addr().String(), CAFile: caFile},
+			wantExport: true,
+		},
+		{
+			name:       "https scheme overrides insecure flag",
+			config:     observability.TracingConfig{ExporterEndpoint: secure.URL, Insecure: true, CAFile: caFile},
+			wantExport: true,
+		},
+		{
+			name:       "TLS with system roots rejects unknown CA",
+			config:     observability.TracingConfig{ExporterEndpoint: secure.Listener.Addr().String()},
+			wantExport: false,
+		},
+		{
+			name:    "missing CA file",
+			config:  observability.TracingConfig{ExporterEndpoint: secure.Listener.Addr().String(), CAFile: filepath.Join(t.TempDir(), "missing.pem")},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported scheme",
+			config:  observability.TracingConfig{ExporterEndpoint: "ftp://collector:4318"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter, err := observability.NewTraceExporter(context.Background(), tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewTraceExporter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			defer exporter.Shutdown(context.Background())
+
+			// Bound the exporter's retries for the failure case
+			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+
+			received.Store(0)
+			err = exporter.ExportSpans(ctx, tracetest.SpanStubs{{Name: "test"}}.Snapshots())
+			if tt.wantExport {
+				if err != nil || received.Load() != 1 {
+					t.Errorf("Expected span export to succeed, err = %v, requests = %d", err, received.Load())
+				}
+			} else if err == nil || received.Load() != 0 {
+				t.Errorf("Expected span export to fail, err = %v, requests = %d", err, received.Load())
+			}
+		})
+	}
+}
+
+// traceCollector is an OTLP/gRPC collector that counts exports
+type traceCollector struct {
+	coltracepb.UnimplementedTraceServiceServer
+	received atomic.Int32
+}
+
+func (c *traceCollector) Export(ctx context.Context, req *coltracepb.ExportTraceServiceRequest) (*coltracepb.ExportTraceServiceResponse, error) {
+	c.received.Add(1)
+	return &coltracepb.ExportTraceServiceResponse{}, nil
+}
+
+func TestNewTraceExporter_Protocol(t *testing.T) {
+	var httpReceived atomic.Int32
+	httpCollector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpReceived.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer httpCollector.Close()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	grpcCollector := &traceCollector{}
+	server := grpc.NewServer()
+	coltracepb.RegisterTraceServiceServer(server, grpcCollector)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	tests := []struct {
+		name     string
+		protocol string
+		endpoint string
+		received func() int32
+	}{
+		{"default is http", "", httpCollector.Listener.Addr().String(), httpReceived.Load},
+		{"http", observability.ProtocolHTTP, httpCollector.Listener.Addr().String(), httpReceived.Load},
+		{"grpc", observability.ProtocolGRPC, lis.Addr().String(), grpcCollector.received.Load},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter, err := observability.NewTraceExporter(context.Background(), observability.TracingConfig{
+				ExporterEndpoint: tt.endpoint,
+				Insecure:         true,
+				Protocol:         tt.protocol,
+			})
+			if err != nil {
+				t.Fatalf("NewTraceExporter() error = %v", err)
+			}
+			defer exporter.Shutdown(context.Background())
+
+			before := tt.received()
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			if err := exporter.ExportSpans(ctx, tracetest.SpanStubs{{Name: "test"}}.Snapshots()); err != nil {
+				t.Fatalf("ExportSpans() error = %v", err)
+			}
+			if got := tt.received() - before; got != 1 {
+				t.Errorf("Expected the %s collector to receive 1 export, got %d", tt.name, got)
+			}
+		})
+	}
+
+	if _, err := observability.NewTraceExporter(context.Background(), observability.TracingConfig{
+		ExporterEndpoint: "localhost:4317",
+		Protocol:         "thrift",
+	}); err == nil {
+		t.Error("Expected error for unsupported protocol")
+	}
+}
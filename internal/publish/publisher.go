@@ -0,0 +1,54 @@
+package publish
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/traffic-tacos/reservation-worker/internal/handler"
+)
+
+// SQSAPI is the subset of the SQS client used by the publisher
+type SQSAPI interface {
+	SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error)
+}
+
+// EventPublisher sends events to an outbound SQS queue, in the envelope the worker itself consumes
+type EventPublisher struct {
+	sqsClient SQSAPI
+	queueURL  string
+}
+
+// NewEventPublisher creates a publisher sending to queueURL
+func NewEventPublisher(sqsClient SQSAPI, queueURL string) *EventPublisher {
+	return &EventPublisher{
+		sqsClient: sqsClient,
+		queueURL:  queueURL,
+	}
+}
+
+// Publish sends event, carrying its trace ID in the TraceId attribute
+func (p *EventPublisher) Publish(ctx context.Context, event *handler.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", event.Type, err)
+	}
+
+	input := &sqs.SendMessageInput{
+		QueueUrl:    aws.String(p.queueURL),
+		MessageBody: aws.String(string(body)),
+	}
+	if event.TraceID != "" {
+		input.MessageAttributes = map[string]types.MessageAttributeValue{
+			"TraceId": {DataType: aws.String("String"), StringValue: aws.String(event.TraceID)},
+		}
+	}
+
+	if _, err := p.sqsClient.SendMessage(ctx, input); err != nil {
+		return fmt.Errorf("failed to publish %s event: %w", event.Type, err)
+	}
+	return nil
+}
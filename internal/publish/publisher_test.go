@@ -0,0 +1,121 @@
+package publish_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/traffic-tacos/proto-contracts/gen/go/reservation/v1"
+	"github.com/traffic-tacos/reservation-worker/internal/client"
+	"github.com/traffic-tacos/reservation-worker/internal/config"
+	"github.com/traffic-tacos/reservation-worker/internal/handler"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"github.com/traffic-tacos/reservation-worker/internal/publish"
+	"go.uber.org/zap"
+)
+
+// fakeSQS records sent messages
+type fakeSQS struct {
+	sent []*sqs.SendMessageInput
+}
+
+func (f *fakeSQS) SendMessage(_ context.Context, params *sqs.SendMessageInput, _ ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+	f.sent = append(f.sent, params)
+	return &sqs.SendMessageOutput{}, nil
+}
+
+// noInventory fails the test on any inventory call
+type noInventory struct{ t *testing.T }
+
+func (n noInventory) ReleaseHold(context.Context, *reservationv1.ReleaseHoldRequest) error {
+	n.t.Error("Unexpected ReleaseHold")
+	return nil
+}
+
+func (n noInventory) CommitReservation(context.Context, *reservationv1.CommitReservationRequest) error {
+	n.t.Error("Unexpected CommitReservation")
+	return nil
+}
+
+func (n noInventory) ReserveSeat(context.Context, *reservationv1.ReserveSeatRequest) error {
+	n.t.Error("Unexpected ReserveSeat")
+	return nil
+}
+
+// expiredReservation reports every reservation as EXPIRED
+type expiredReservation struct{ t *testing.T }
+
+func (e expiredReservation) UpdateReservationStatus(context.Context, *client.UpdateStatusRequest) error {
+	e.t.Error("Unexpected UpdateReservationStatus")
+	return nil
+}
+
+func (e expiredReservation) GetReservation(_ context.Context, id string) (*client.ReservationDetails, error) {
+	return &client.ReservationDetails{ID: id, Status: client.StatusExpired}, nil
+}
+
+func TestRefundRequiredForApprovalAfterExpiry(t *testing.T) {
+	fake := &fakeSQS{}
+	publisher := publish.NewEventPublisher(fake, "https://sqs.example.com/123/refunds")
+
+	cfg := &config.Config{ApprovedAfterTerminal: config.ApprovedAfterTerminalDrop}
+	metrics := observability.NewMetrics(observability.WithRegistry(prometheus.NewRegistry()))
+	defer metrics.Unregister()
+	h := handler.NewApprovedHandler(noInventory{t}, expiredReservation{t}, cfg, &observability.Logger{Logger: zap.NewNop()}, metrics)
+	h.SetCompensator(handler.NewRefundCompensator(publisher))
+
+	approval := &handler.Event{
+		ID:      "evt-approved-1",
+		Type:    handler.EventTypePaymentApproved,
+		TraceID: "trace-1",
+		Detail: json.RawMessage(`{"reservation_id":"rsv-1","payment_intent_id":"pi-1","amount":5000,"currency":"KRW",` +
+			`"event_id":"concert-1","user_id":"user-1","seat_ids":["A1"],"quantity":1}`),
+	}
+	if err := h.Handle(context.Background(), approval); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	if len(fake.sent) != 1 {
+		t.Fatalf("Expected one published event, got %d", len(fake.sent))
+	}
+	sent := fake.sent[0]
+	if got := aws.ToString(sent.QueueUrl); got != "https://sqs.example.com/123/refunds" {
+		t.Errorf("Published to %s, want the outbound queue", got)
+	}
+	if got := aws.ToString(sent.MessageAttributes["TraceId"].StringValue); got != "trace-1" {
+		t.Errorf("TraceId attribute = %q, want trace-1", got)
+	}
+
+	var event handler.Event
+	if err := json.Unmarshal([]byte(aws.ToString(sent.MessageBody)), &event); err != nil {
+		t.Fatalf("Published body is not an event: %v", err)
+	}
+	if event.ID != "refund-required-evt-approved-1" || event.Type != handler.EventTypePaymentRefundRequired ||
+		event.Source != handler.EventSource || event.Time.IsZero() {
+		t.Errorf("Unexpected envelope %+v", event)
+	}
+	if version, err := event.SchemaVersion(); err != nil || version != handler.CurrentEventVersion {
+		t.Errorf("SchemaVersion() = %d, %v, want %d", version, err, handler.CurrentEventVersion)
+	}
+
+	var detail handler.PaymentRefundRequiredDetail
+	if err := json.Unmarshal(event.Detail, &detail); err != nil {
+		t.Fatalf("Unmarshal detail: %v", err)
+	}
+	want := handler.PaymentRefundRequiredDetail{
+		ReservationID:     "rsv-1",
+		PaymentIntentID:   "pi-1",
+		Amount:            5000,
+		Currency:          "KRW",
+		EventID:           "concert-1",
+		UserID:            "user-1",
+		ReservationStatus: client.StatusExpired,
+		Reason:            "reservation_expired",
+	}
+	if detail != want {
+		t.Errorf("Detail = %+v, want %+v", detail, want)
+	}
+}
@@ -0,0 +1,211 @@
+package redrive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/traffic-tacos/reservation-worker/internal/handler"
+	"go.uber.org/zap"
+)
+
+// SQSAPI is the subset of the SQS client used by the redriver
+type SQSAPI interface {
+	ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
+	SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error)
+	DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error)
+	ChangeMessageVisibility(ctx context.Context, params *sqs.ChangeMessageVisibilityInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error)
+}
+
+// releaseTimeout bounds making held messages visible again, which may run after ctx is cancelled
+const releaseTimeout = 5 * time.Second
+
+// Options controls a redrive run
+type Options struct {
+	SourceQueueURL string   // DLQ to read from
+	TargetQueueURL string   // Main queue to republish to
+	EventTypes     []string // Only redrive these event types (empty = all)
+	RatePerSecond  float64  // Maximum republish rate (0 = unlimited)
+	MaxMessages    int      // Stop after this many matched messages (0 = unlimited)
+	DryRun         bool     // Only report counts, do not republish or delete
+	WaitTimeSecs   int32    // Long polling wait time for the DLQ
+}
+
+// Report summarizes a redrive run
+type Report struct {
+	Received int `json:"received"`
+	Matched  int `json:"matched"`
+	Skipped  int `json:"skipped"`
+	Redriven int `json:"redriven"`
+	Failed   int `json:"failed"`
+}
+
+// Add adds the counts of other to r
+func (r *Report) Add(other *Report) {
+	r.Received += other.Received
+	r.Matched += other.Matched
+	r.Skipped += other.Skipped
+	r.Redriven += other.Redriven
+	r.Failed += other.Failed
+}
+
+// Redriver moves messages from a dead-letter queue back to the main queue
+type Redriver struct {
+	sqsClient SQSAPI
+	options   Options
+	logger    *zap.Logger
+}
+
+// NewRedriver creates a new redriver
+func NewRedriver(sqsClient SQSAPI, options Options, logger *zap.Logger) *Redriver {
+	return &Redriver{
+		sqsClient: sqsClient,
+		options:   options,
+		logger:    logger,
+	}
+}
+
+// Run drains the DLQ until it is empty or MaxMessages is reached. Messages it leaves in
+// the DLQ, filtered out or only counted, stay invisible while it runs so they are not
+// received again, and are made visible once it returns.
+func (r *Redriver) Run(ctx context.Context) (*Report, error) {
+	report := &Report{}
+
+	// Message ID -> latest receipt of messages left in the DLQ
+	held := make(map[string]types.Message)
+	defer r.release(ctx, held)
+
+	var ticker *time.Ticker
+	if r.options.RatePerSecond > 0 && !r.options.DryRun {
+		ticker = time.NewTicker(time.Duration(float64(time.Second) / r.options.RatePerSecond))
+		defer ticker.Stop()
+	}
+
+	for {
+		result, err := r.sqsClient.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:              aws.String(r.options.SourceQueueURL),
+			MaxNumberOfMessages:   10,
+			WaitTimeSeconds:       r.options.WaitTimeSecs,
+			MessageAttributeNames: []string{"All"},
+		})
+		if err != nil {
+			return report, fmt.Errorf("failed to receive messages from DLQ: %w", err)
+		}
+
+		// An empty receive means the DLQ is drained
+		if len(result.Messages) == 0 {
+			return report, nil
+		}
+
+		for i, message := range result.Messages {
+			// Held messages come around again once their visibility timeout ends
+			if _, seen := held[aws.ToString(message.MessageId)]; seen {
+				held[aws.ToString(message.MessageId)] = message
+				continue
+			}
+			report.Received++
+
+			if !r.matches(&message) {
+				report.Skipped++
+				held[aws.ToString(message.MessageId)] = message
+				continue
+			}
+
+			if r.options.MaxMessages > 0 && report.Matched >= r.options.MaxMessages {
+				for _, rest := range result.Messages[i:] {
+					held[aws.ToString(rest.MessageId)] = rest
+				}
+				return report, nil
+			}
+			report.Matched++
+
+			if r.options.DryRun {
+				held[aws.ToString(message.MessageId)] = message
+				continue
+			}
+
+			if ticker != nil {
+				select {
+				case <-ctx.Done():
+					return report, ctx.Err()
+				case <-ticker.C:
+				}
+			}
+
+			if err := r.redrive(ctx, &message); err != nil {
+				report.Failed++
+				r.logger.Error("Failed to redrive message",
+					zap.Error(err),
+					zap.String("message_id", aws.ToString(message.MessageId)),
+				)
+				continue
+			}
+			report.Redriven++
+		}
+	}
+}
+
+// release makes the held messages visible in the DLQ again
+func (r *Redriver) release(ctx context.Context, held map[string]types.Message) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), releaseTimeout)
+	defer cancel()
+
+	for _, message := range held {
+		_, err := r.sqsClient.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+			QueueUrl:          aws.String(r.options.SourceQueueURL),
+			ReceiptHandle:     message.ReceiptHandle,
+			VisibilityTimeout: 0,
+		})
+		if err != nil {
+			r.logger.Warn("Failed to release message, it reappears after the visibility timeout",
+				zap.Error(err),
+				zap.String("message_id", aws.ToString(message.MessageId)),
+			)
+		}
+	}
+}
+
+// matches reports whether the message's event type passes the filter
+func (r *Redriver) matches(message *types.Message) bool {
+	if len(r.options.EventTypes) == 0 {
+		return true
+	}
+
+	var event handler.Event
+	if message.Body == nil || json.Unmarshal([]byte(*message.Body), &event) != nil {
+		return false
+	}
+
+	for _, eventType := range r.options.EventTypes {
+		if event.Type == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// redrive republishes a message to the target queue and removes it from the DLQ
+func (r *Redriver) redrive(ctx context.Context, message *types.Message) error {
+	_, err := r.sqsClient.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:          aws.String(r.options.TargetQueueURL),
+		MessageBody:       message.Body,
+		MessageAttributes: message.MessageAttributes,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+
+	_, err = r.sqsClient.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(r.options.SourceQueueURL),
+		ReceiptHandle: message.ReceiptHandle,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete message from DLQ: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package redrive_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/traffic-tacos/reservation-worker/internal/redrive"
+	"go.uber.org/zap"
+)
+
+// fakeSQS serves queued messages once and records sends, deletes and releases
+type fakeSQS struct {
+	mu       sync.Mutex
+	messages []types.Message
+	sent     []string
+	deleted  []string
+	released []string // receipt handles made visible again
+	sentAt   []time.Time
+}
+
+func newFakeSQS(eventTypes ...string) *fakeSQS {
+	f := &fakeSQS{}
+	for i, eventType := range eventTypes {
+		f.messages = append(f.messages, types.Message{
+			MessageId:     aws.String(fmt.Sprintf("msg-%d", i)),
+			ReceiptHandle: aws.String(fmt.Sprintf("rh-%d", i)),
+			Body:          aws.String(fmt.Sprintf(`{"id":"evt-%d","type":%q,"detail":{}}`, i, eventType)),
+		})
+	}
+	return f
+}
+
+func (f *fakeSQS) ReceiveMessage(_ context.Context, params *sqs.ReceiveMessageInput, _ ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := int(params.MaxNumberOfMessages)
+	if n > len(f.messages) {
+		n = len(f.messages)
+	}
+	batch := f.messages[:n]
+	f.messages = f.messages[n:]
+	return &sqs.ReceiveMessageOutput{Messages: batch}, nil
+}
+
+func (f *fakeSQS) SendMessage(_ context.Context, params *sqs.SendMessageInput, _ ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, aws.ToString(params.MessageBody))
+	f.sentAt = append(f.sentAt, time.Now())
+	return &sqs.SendMessageOutput{}, nil
+}
+
+func (f *fakeSQS) DeleteMessage(_ context.Context, params *sqs.DeleteMessageInput, _ ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleted = append(f.deleted, aws.ToString(params.ReceiptHandle))
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func (f *fakeSQS) ChangeMessageVisibility(_ context.Context, params *sqs.ChangeMessageVisibilityInput, _ ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.released = append(f.released, aws.ToString(params.ReceiptHandle))
+	return &sqs.ChangeMessageVisibilityOutput{}, nil
+}
+
+func TestRedriver_FilterByEventType(t *testing.T) {
+	fake := newFakeSQS("reservation.expired", "payment.approved", "reservation.expired", "payment.failed")
+
+	redriver := redrive.NewRedriver(fake, redrive.Options{
+		SourceQueueURL: "dlq",
+		TargetQueueURL: "main",
+		EventTypes:     []string{"reservation.expired"},
+	}, zap.NewNop())
+
+	report, err := redriver.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if report.Received != 4 || report.Matched != 2 || report.Skipped != 2 || report.Redriven != 2 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+	if len(fake.sent) != 2 {
+		t.Errorf("Expected 2 messages sent, got %d", len(fake.sent))
+	}
+	if len(fake.deleted) != 2 {
+		t.Errorf("Expected 2 messages deleted from DLQ, got %d", len(fake.deleted))
+	}
+	sort.Strings(fake.released)
+	if want := []string{"rh-1", "rh-3"}; !reflect.DeepEqual(fake.released, want) {
+		t.Errorf("Expected filtered messages %v made visible again, got %v", want, fake.released)
+	}
+}
+
+func TestRedriver_DryRun(t *testing.T) {
+	fake := newFakeSQS("reservation.expired", "payment.approved", "payment.failed")
+
+	redriver := redrive.NewRedriver(fake, redrive.Options{
+		SourceQueueURL: "dlq",
+		TargetQueueURL: "main",
+		DryRun:         true,
+		RatePerSecond:  1,
+	}, zap.NewNop())
+
+	report, err := redriver.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if report.Matched != 3 || report.Redriven != 0 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+	if len(fake.sent) != 0 || len(fake.deleted) != 0 {
+		t.Errorf("Dry run must not send or delete, got %d sent, %d deleted", len(fake.sent), len(fake.deleted))
+	}
+	if len(fake.released) != 3 {
+		t.Errorf("Expected all 3 counted messages made visible again, got %v", fake.released)
+	}
+}
+
+func TestRedriver_RateLimit(t *testing.T) {
+	fake := newFakeSQS("reservation.expired", "reservation.expired", "reservation.expired", "reservation.expired", "reservation.expired")
+
+	redriver := redrive.NewRedriver(fake, redrive.Options{
+		SourceQueueURL: "dlq",
+		TargetQueueURL: "main",
+		RatePerSecond:  50, // one message every 20ms
+	}, zap.NewNop())
+
+	start := time.Now()
+	report, err := redriver.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	elapsed := time.Since(start)
+
+	if report.Redriven != 5 {
+		t.Fatalf("Expected 5 redriven, got %d", report.Redriven)
+	}
+	if elapsed < 90*time.Millisecond {
+		t.Errorf("Expected redrive to be paced to ~100ms, took %v", elapsed)
+	}
+	for i := 1; i < len(fake.sentAt); i++ {
+		if gap := fake.sentAt[i].Sub(fake.sentAt[i-1]); gap < 10*time.Millisecond {
+			t.Errorf("Send %d followed previous by %v, want >= ~20ms", i, gap)
+		}
+	}
+}
+
+func TestRedriver_MaxMessages(t *testing.T) {
+	fake := newFakeSQS("reservation.expired", "reservation.expired", "reservation.expired")
+
+	redriver := redrive.NewRedriver(fake, redrive.Options{
+		SourceQueueURL: "dlq",
+		TargetQueueURL: "main",
+		MaxMessages:    2,
+	}, zap.NewNop())
+
+	report, err := redriver.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if report.Redriven != 2 {
+		t.Errorf("Expected 2 redriven, got %d", report.Redriven)
+	}
+	if want := []string{"rh-2"}; !reflect.DeepEqual(fake.released, want) {
+		t.Errorf("Expected messages past the limit %v made visible again, got %v", want, fake.released)
+	}
+}
@@ -0,0 +1,176 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/traffic-tacos/reservation-worker/internal/handler"
+	"go.uber.org/zap"
+)
+
+// S3API is the subset of the S3 client used by the replayer
+type S3API interface {
+	ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// Submitter hands an event to the handlers and waits for its outcome
+type Submitter interface {
+	Submit(ctx context.Context, event *handler.Event) error
+}
+
+// Options controls a replay run
+type Options struct {
+	Bucket        string
+	Prefix        string    // Only objects under this key prefix
+	From          time.Time // Only events at or after this time (zero = no lower bound)
+	To            time.Time // Only events before this time (zero = no upper bound)
+	RatePerSecond float64   // Maximum events submitted per second (0 = unlimited)
+	MaxEvents     int       // Stop after this many matched events (0 = unlimited)
+}
+
+// Report summarizes a replay run
+type Report struct {
+	Objects  int `json:"objects"`
+	Read     int `json:"read"`
+	Matched  int `json:"matched"`
+	Skipped  int `json:"skipped"`
+	Replayed int `json:"replayed"`
+	Failed   int `json:"failed"`
+}
+
+// errMaxEvents ends a run once MaxEvents events matched
+var errMaxEvents = errors.New("max events reached")
+
+// Replayer feeds events archived in S3 through the handlers again
+type Replayer struct {
+	s3Client  S3API
+	submitter Submitter
+	options   Options
+	logger    *zap.Logger
+}
+
+// NewReplayer creates a new replayer
+func NewReplayer(s3Client S3API, submitter Submitter, options Options, logger *zap.Logger) *Replayer {
+	return &Replayer{
+		s3Client:  s3Client,
+		submitter: submitter,
+		options:   options,
+		logger:    logger,
+	}
+}
+
+// Run replays every matching event under the prefix, in key order
+func (r *Replayer) Run(ctx context.Context) (*Report, error) {
+	report := &Report{}
+
+	var ticker *time.Ticker
+	if r.options.RatePerSecond > 0 {
+		ticker = time.NewTicker(time.Duration(float64(time.Second) / r.options.RatePerSecond))
+		defer ticker.Stop()
+	}
+
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(r.options.Bucket),
+		Prefix: aws.String(r.options.Prefix),
+	}
+	for {
+		page, err := r.s3Client.ListObjectsV2(ctx, input)
+		if err != nil {
+			return report, fmt.Errorf("failed to list objects under s3://%s/%s: %w", r.options.Bucket, r.options.Prefix, err)
+		}
+
+		for _, object := range page.Contents {
+			report.Objects++
+			err := r.replayObject(ctx, aws.ToString(object.Key), ticker, report)
+			if errors.Is(err, errMaxEvents) {
+				return report, nil
+			}
+			if err != nil {
+				return report, err
+			}
+		}
+
+		if !aws.ToBool(page.IsTruncated) {
+			return report, nil
+		}
+		input.ContinuationToken = page.NextContinuationToken
+	}
+}
+
+// replayObject replays the events stored in one object: a single event or one per line
+func (r *Replayer) replayObject(ctx context.Context, key string, ticker *time.Ticker, report *Report) error {
+	result, err := r.s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(r.options.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read s3://%s/%s: %w", r.options.Bucket, key, err)
+	}
+	defer result.Body.Close()
+
+	decoder := json.NewDecoder(result.Body)
+	for {
+		var event handler.Event
+		err := decoder.Decode(&event)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			// The rest of the object cannot be located after a syntax error
+			report.Failed++
+			r.logger.Error("Failed to decode archived event", zap.Error(err), zap.String("key", key))
+			return nil
+		}
+		report.Read++
+
+		if !r.inRange(event.Time) {
+			report.Skipped++
+			continue
+		}
+		if r.options.MaxEvents > 0 && report.Matched >= r.options.MaxEvents {
+			return errMaxEvents
+		}
+		report.Matched++
+
+		if ticker != nil {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+		}
+
+		if err := r.submitter.Submit(ctx, &event); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			report.Failed++
+			r.logger.Error("Failed to replay event",
+				zap.Error(err),
+				zap.String("key", key),
+				zap.String("event_id", event.ID),
+				zap.String("event_type", event.Type),
+			)
+			continue
+		}
+		report.Replayed++
+	}
+}
+
+// inRange reports whether an event at t passes the date range filter
+func (r *Replayer) inRange(t time.Time) bool {
+	if !r.options.From.IsZero() && t.Before(r.options.From) {
+		return false
+	}
+	if !r.options.To.IsZero() && !t.Before(r.options.To) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,156 @@
+package replay_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/traffic-tacos/reservation-worker/internal/handler"
+	"github.com/traffic-tacos/reservation-worker/internal/replay"
+	"go.uber.org/zap"
+)
+
+// fakeS3 serves objects of one bucket, listing two keys per page
+type fakeS3 struct {
+	objects map[string]string // key -> body
+}
+
+func (f *fakeS3) ListObjectsV2(_ context.Context, params *s3.ListObjectsV2Input, _ ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	var keys []string
+	for key := range f.objects {
+		if strings.HasPrefix(key, aws.ToString(params.Prefix)) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	// The continuation token is the first key of the page
+	start := 0
+	if token := aws.ToString(params.ContinuationToken); token != "" {
+		start = sort.SearchStrings(keys, token)
+	}
+	end := start + 2
+	if end > len(keys) {
+		end = len(keys)
+	}
+
+	out := &s3.ListObjectsV2Output{IsTruncated: aws.Bool(end < len(keys))}
+	for _, key := range keys[start:end] {
+		out.Contents = append(out.Contents, types.Object{Key: aws.String(key)})
+	}
+	if end < len(keys) {
+		out.NextContinuationToken = aws.String(keys[end])
+	}
+	return out, nil
+}
+
+func (f *fakeS3) GetObject(_ context.Context, params *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	body, ok := f.objects[aws.ToString(params.Key)]
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader(body))}, nil
+}
+
+// fakeSubmitter records submitted events
+type fakeSubmitter struct {
+	mu          sync.Mutex
+	ids         []string
+	submittedAt []time.Time
+}
+
+func (f *fakeSubmitter) Submit(_ context.Context, event *handler.Event) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ids = append(f.ids, event.ID)
+	f.submittedAt = append(f.submittedAt, time.Now())
+	return nil
+}
+
+// archivedEvent returns the JSON of an archived event at t
+func archivedEvent(id string, t string) string {
+	return fmt.Sprintf(`{"id":%q,"type":"reservation.expired","time":%q,"detail":{}}`, id, t)
+}
+
+func TestReplayer_Filters(t *testing.T) {
+	fake := &fakeS3{objects: map[string]string{
+		"events/2024/05/01/a.json": archivedEvent("evt-1", "2024-05-01T10:00:00Z"),
+		"events/2024/05/01/b.json": archivedEvent("evt-2", "2024-05-01T23:59:59Z") + "\n" + archivedEvent("evt-3", "2024-05-02T00:00:00Z"),
+		"events/2024/05/02/c.json": archivedEvent("evt-4", "2024-05-02T08:00:00Z"),
+		"events/2024/04/30/d.json": archivedEvent("evt-5", "2024-04-30T12:00:00Z"),
+		"other/e.json":             archivedEvent("evt-6", "2024-05-01T12:00:00Z"),
+	}}
+
+	tests := []struct {
+		name    string
+		options replay.Options
+		wantIDs []string
+	}{
+		{"prefix only", replay.Options{Prefix: "events/2024/05/"}, []string{"evt-1", "evt-2", "evt-3", "evt-4"}},
+		{"date range", replay.Options{
+			Prefix: "events/",
+			From:   time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+			To:     time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC),
+		}, []string{"evt-1", "evt-2"}},
+		{"max events", replay.Options{Prefix: "events/", MaxEvents: 2}, []string{"evt-5", "evt-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			submitter := &fakeSubmitter{}
+			tt.options.Bucket = "archive"
+
+			report, err := replay.NewReplayer(fake, submitter, tt.options, zap.NewNop()).Run(context.Background())
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			if strings.Join(submitter.ids, ",") != strings.Join(tt.wantIDs, ",") {
+				t.Errorf("Expected replayed events %v, got %v", tt.wantIDs, submitter.ids)
+			}
+			if report.Replayed != len(tt.wantIDs) || report.Matched != len(tt.wantIDs) {
+				t.Errorf("Unexpected report: %+v", report)
+			}
+		})
+	}
+}
+
+func TestReplayer_RateLimit(t *testing.T) {
+	objects := make(map[string]string)
+	for i := 0; i < 5; i++ {
+		objects[fmt.Sprintf("events/%d.json", i)] = archivedEvent(fmt.Sprintf("evt-%d", i), "2024-05-01T10:00:00Z")
+	}
+	submitter := &fakeSubmitter{}
+
+	replayer := replay.NewReplayer(&fakeS3{objects: objects}, submitter, replay.Options{
+		Bucket:        "archive",
+		RatePerSecond: 50, // one event every 20ms
+	}, zap.NewNop())
+
+	start := time.Now()
+	report, err := replayer.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	elapsed := time.Since(start)
+
+	if report.Replayed != 5 {
+		t.Fatalf("Expected 5 replayed, got %d", report.Replayed)
+	}
+	if elapsed < 90*time.Millisecond {
+		t.Errorf("Expected replay to be paced to ~100ms, took %v", elapsed)
+	}
+	for i := 1; i < len(submitter.submittedAt); i++ {
+		if gap := submitter.submittedAt[i].Sub(submitter.submittedAt[i-1]); gap < 10*time.Millisecond {
+			t.Errorf("Submit %d followed previous by %v, want >= ~20ms", i, gap)
+		}
+	}
+}
@@ -14,15 +14,22 @@ type RetryableFunc func(ctx context.Context) error
 
 // Retryer handles retry logic with exponential backoff
 type Retryer struct {
-	config *config.Config
-	logger *zap.Logger
+	attempts int
+	backoff  func(attempt int) time.Duration
+	logger   *zap.Logger
 }
 
 // NewRetryer creates a new retryer
 func NewRetryer(cfg *config.Config, logger *zap.Logger) *Retryer {
+	return NewBackoffRetryer(cfg.MaxRetries, cfg.GetBackoffDuration, logger)
+}
+
+// NewBackoffRetryer creates a retryer making up to attempts attempts, waiting backoff(attempt) after each failed one
+func NewBackoffRetryer(attempts int, backoff func(attempt int) time.Duration, logger *zap.Logger) *Retryer {
 	return &Retryer{
-		config: cfg,
-		logger: logger,
+		attempts: attempts,
+		backoff:  backoff,
+		logger:   logger,
 	}
 }
 
@@ -30,7 +37,7 @@ func NewRetryer(cfg *config.Config, logger *zap.Logger) *Retryer {
 func (r *Retryer) Do(ctx context.Context, operation string, fn RetryableFunc) error {
 	var lastErr error
 
-	for attempt := 0; attempt < r.config.MaxRetries; attempt++ {
+	for attempt := 0; attempt < r.attempts; attempt++ {
 		// Check context before attempt
 		select {
 		case <-ctx.Done():
@@ -53,18 +60,18 @@ func (r *Retryer) Do(ctx context.Context, operation string, fn RetryableFunc) er
 		lastErr = err
 
 		// Don't retry on last attempt
-		if attempt == r.config.MaxRetries-1 {
+		if attempt == r.attempts-1 {
 			break
 		}
 
 		// Calculate backoff duration
-		backoff := r.config.GetBackoffDuration(attempt)
+		backoff := r.backoff(attempt)
 
 		r.logger.Warn("Operation failed, retrying",
 			zap.String("operation", operation),
 			zap.Error(err),
 			zap.Int("attempt", attempt+1),
-			zap.Int("max_retries", r.config.MaxRetries),
+			zap.Int("max_retries", r.attempts),
 			zap.Duration("backoff", backoff),
 		)
 
@@ -77,7 +84,7 @@ func (r *Retryer) Do(ctx context.Context, operation string, fn RetryableFunc) er
 		}
 	}
 
-	return fmt.Errorf("operation %s failed after %d attempts: %w", operation, r.config.MaxRetries, lastErr)
+	return fmt.Errorf("operation %s failed after %d attempts: %w", operation, r.attempts, lastErr)
 }
 
 // DoWithResult executes a function that returns a value with retry logic
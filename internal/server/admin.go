@@ -0,0 +1,58 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Pauser suspends and resumes receiving events
+type Pauser interface {
+	Pause() bool
+	Resume() bool
+	Paused() bool
+}
+
+// statusResponse is the body of GET /api/v1/status and of the pause endpoints
+type statusResponse struct {
+	Paused bool `json:"paused"`
+}
+
+// statusHandler reports whether event intake is paused
+func statusHandler(pauser Pauser) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeStatus(w, pauser)
+	}
+}
+
+// pauseHandler pauses or resumes event intake and responds with the resulting status.
+// Repeating a request is harmless.
+func pauseHandler(pauser Pauser, pause bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if pause {
+			pauser.Pause()
+		} else {
+			pauser.Resume()
+		}
+		writeStatus(w, pauser)
+	}
+}
+
+func writeStatus(w http.ResponseWriter, pauser Pauser) {
+	resp := statusResponse{}
+	if pauser != nil {
+		resp.Paused = pauser.Paused()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
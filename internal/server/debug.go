@@ -0,0 +1,200 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/traffic-tacos/reservation-worker/internal/config"
+	"github.com/traffic-tacos/reservation-worker/internal/worker"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Names of the debug service as seen by grpcui and other reflection clients
+const (
+	debugProtoFile   = "reservationworker/debug/v1/debug.proto"
+	debugPackage     = "reservationworker.debug.v1"
+	DebugServiceName = debugPackage + ".WorkerDebugService"
+)
+
+// InflightLister reports the events workers are handling
+type InflightLister interface {
+	InflightEvents() []worker.InflightEvent
+}
+
+// DebugOptions are the sources of the WorkerDebugService RPCs
+type DebugOptions struct {
+	Config   *config.Config
+	Inflight InflightLister
+
+	// Source of the metrics snapshot; the global registry when nil
+	Gatherer prometheus.Gatherer
+}
+
+// debugService answers WorkerDebugService RPCs. Responses are google.protobuf.Struct
+// values so the service needs no generated code and grpcui renders them as JSON.
+type debugService struct {
+	opts DebugOptions
+}
+
+// workerDebugServer is the handler type of the service descriptor
+type workerDebugServer interface {
+	getConfig(ctx context.Context) (*structpb.Struct, error)
+	getMetrics(ctx context.Context) (*structpb.Struct, error)
+	listInflightEvents(ctx context.Context) (*structpb.Struct, error)
+}
+
+var workerDebugServiceDesc = grpc.ServiceDesc{
+	ServiceName: DebugServiceName,
+	HandlerType: (*workerDebugServer)(nil),
+	Methods: []grpc.MethodDesc{
+		debugMethod("GetConfig", workerDebugServer.getConfig),
+		debugMethod("GetMetrics", workerDebugServer.getMetrics),
+		debugMethod("ListInflightEvents", workerDebugServer.listInflightEvents),
+	},
+	Metadata: debugProtoFile,
+}
+
+// RegisterDebugService registers WorkerDebugService on s and its descriptor for reflection
+func RegisterDebugService(s *grpc.Server, opts DebugOptions) error {
+	if err := registerDebugDescriptor(); err != nil {
+		return err
+	}
+	if opts.Gatherer == nil {
+		opts.Gatherer = prometheus.DefaultGatherer
+	}
+	s.RegisterService(&workerDebugServiceDesc, &debugService{opts: opts})
+	return nil
+}
+
+func (s *debugService) getConfig(ctx context.Context) (*structpb.Struct, error) {
+	if s.opts.Config == nil {
+		return nil, status.Error(codes.Unavailable, "config not available")
+	}
+
+	cfg := *s.opts.Config
+	if len(cfg.OutboundHeaders) > 0 {
+		// Outbound headers commonly carry credentials; show which are set, not their values
+		headers := make(map[string]string, len(cfg.OutboundHeaders))
+		for name := range cfg.OutboundHeaders {
+			headers[name] = "<redacted>"
+		}
+		cfg.OutboundHeaders = headers
+	}
+	if cfg.ReplayEndpointToken != "" {
+		cfg.ReplayEndpointToken = "<redacted>"
+	}
+	return toStruct(cfg)
+}
+
+func (s *debugService) getMetrics(ctx context.Context) (*structpb.Struct, error) {
+	summary, err := summarizeMetrics(s.opts.Gatherer)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return toStruct(summary)
+}
+
+func (s *debugService) listInflightEvents(ctx context.Context) (*structpb.Struct, error) {
+	if s.opts.Inflight == nil {
+		return nil, status.Error(codes.Unavailable, "dispatcher not available")
+	}
+
+	type inflightEvent struct {
+		worker.InflightEvent
+		AgeMS int64 `json:"age_ms"`
+	}
+	events := []inflightEvent{}
+	for _, event := range s.opts.Inflight.InflightEvents() {
+		events = append(events, inflightEvent{event, time.Since(event.Started).Milliseconds()})
+	}
+	return toStruct(map[string]interface{}{"events": events})
+}
+
+// debugMethod adapts an Empty -> Struct RPC to a gRPC method handler
+func debugMethod(name string, call func(workerDebugServer, context.Context) (*structpb.Struct, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			in := new(emptypb.Empty)
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			handle := func(ctx context.Context, _ interface{}) (interface{}, error) {
+				return call(srv.(workerDebugServer), ctx)
+			}
+			if interceptor == nil {
+				return handle(ctx, in)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + DebugServiceName + "/" + name}
+			return interceptor(ctx, in, info, handle)
+		},
+	}
+}
+
+// toStruct converts v to a Struct through its JSON encoding
+func toStruct(v interface{}) (*structpb.Struct, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	out, err := structpb.NewStruct(fields)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return out, nil
+}
+
+var (
+	debugDescriptorOnce sync.Once
+	debugDescriptorErr  error
+)
+
+// registerDebugDescriptor adds the service's file descriptor to the global registry,
+// where the reflection service looks up the services it lists
+func registerDebugDescriptor() error {
+	debugDescriptorOnce.Do(func() {
+		method := func(name string) *descriptorpb.MethodDescriptorProto {
+			return &descriptorpb.MethodDescriptorProto{
+				Name:       proto.String(name),
+				InputType:  proto.String(".google.protobuf.Empty"),
+				OutputType: proto.String(".google.protobuf.Struct"),
+			}
+		}
+		file := &descriptorpb.FileDescriptorProto{
+			Name:       proto.String(debugProtoFile),
+			Package:    proto.String(debugPackage),
+			Dependency: []string{"google/protobuf/empty.proto", "google/protobuf/struct.proto"},
+			Syntax:     proto.String("proto3"),
+			Service: []*descriptorpb.ServiceDescriptorProto{{
+				Name:   proto.String("WorkerDebugService"),
+				Method: []*descriptorpb.MethodDescriptorProto{method("GetConfig"), method("GetMetrics"), method("ListInflightEvents")},
+			}},
+		}
+
+		fd, err := protodesc.NewFile(file, protoregistry.GlobalFiles)
+		if err != nil {
+			debugDescriptorErr = fmt.Errorf("failed to build debug service descriptor: %w", err)
+			return
+		}
+		if err := protoregistry.GlobalFiles.RegisterFile(fd); err != nil {
+			debugDescriptorErr = fmt.Errorf("failed to register debug service descriptor: %w", err)
+		}
+	})
+	return debugDescriptorErr
+}
@@ -0,0 +1,133 @@
+package server_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/traffic-tacos/reservation-worker/internal/config"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"github.com/traffic-tacos/reservation-worker/internal/server"
+	"github.com/traffic-tacos/reservation-worker/internal/worker"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/reflection"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// fakeInflight reports a fixed set of in-flight events
+type fakeInflight []worker.InflightEvent
+
+func (f fakeInflight) InflightEvents() []worker.InflightEvent { return f }
+
+// dialDebugServer serves WorkerDebugService and reflection in-process and connects to it
+func dialDebugServer(t *testing.T, opts server.DebugOptions) *grpc.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	if err := server.RegisterDebugService(s, opts); err != nil {
+		t.Fatalf("RegisterDebugService() error = %v", err)
+	}
+	reflection.Register(s)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestDebugService(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	metrics := observability.NewMetrics(observability.WithRegistry(registry))
+	defer metrics.Unregister()
+	metrics.RecordEventProcessed("reservation.expired", observability.OutcomeSuccess)
+	metrics.SetActiveWorkers(3)
+
+	cfg := &config.Config{
+		WorkerConcurrency: 20,
+		OutboundHeaders:   map[string]string{"X-Internal-Auth": "secret-token"},
+	}
+	inflight := fakeInflight{{ID: "evt-1", Type: "reservation.expired", Attempt: 2, Started: time.Now()}}
+	conn := dialDebugServer(t, server.DebugOptions{Config: cfg, Inflight: inflight, Gatherer: registry})
+
+	tests := []struct {
+		method string
+		check  func(t *testing.T, got map[string]interface{})
+	}{
+		{"GetConfig", func(t *testing.T, got map[string]interface{}) {
+			if got["WorkerConcurrency"] != float64(20) {
+				t.Errorf("WorkerConcurrency = %v, want 20", got["WorkerConcurrency"])
+			}
+			headers, _ := got["OutboundHeaders"].(map[string]interface{})
+			if headers["X-Internal-Auth"] != "<redacted>" {
+				t.Errorf("Expected the outbound header value to be redacted, got %v", headers["X-Internal-Auth"])
+			}
+		}},
+		{"GetMetrics", func(t *testing.T, got map[string]interface{}) {
+			outcomes, _ := got["events_by_outcome"].(map[string]interface{})
+			if outcomes[observability.OutcomeSuccess] != float64(1) || got["active_workers"] != float64(3) {
+				t.Errorf("Unexpected metrics snapshot %v", got)
+			}
+		}},
+		{"ListInflightEvents", func(t *testing.T, got map[string]interface{}) {
+			events, _ := got["events"].([]interface{})
+			if len(events) != 1 {
+				t.Fatalf("Expected one in-flight event, got %v", got["events"])
+			}
+			event := events[0].(map[string]interface{})
+			if event["id"] != "evt-1" || event["attempt"] != float64(2) {
+				t.Errorf("Unexpected in-flight event %v", event)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			out := new(structpb.Struct)
+			if err := conn.Invoke(context.Background(), "/"+server.DebugServiceName+"/"+tt.method, &emptypb.Empty{}, out); err != nil {
+				t.Fatalf("%s() error = %v", tt.method, err)
+			}
+			tt.check(t, out.AsMap())
+		})
+	}
+}
+
+func TestDebugService_Reflection(t *testing.T) {
+	conn := dialDebugServer(t, server.DebugOptions{})
+
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	if err != nil {
+		t.Fatalf("ServerReflectionInfo() error = %v", err)
+	}
+	defer stream.CloseSend()
+
+	err = stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: server.DebugServiceName},
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv() error = %v", err)
+	}
+	if errResp := resp.GetErrorResponse(); errResp != nil {
+		t.Fatalf("Expected %s to resolve through reflection, got %s", server.DebugServiceName, errResp.GetErrorMessage())
+	}
+	if len(resp.GetFileDescriptorResponse().GetFileDescriptorProto()) == 0 {
+		t.Error("Expected the service's file descriptor")
+	}
+}
@@ -21,8 +21,9 @@ type GRPCServer struct {
 	port   int
 }
 
-// NewGRPCServer creates a new gRPC server with health check and reflection
-func NewGRPCServer(port int, logger *observability.Logger) *GRPCServer {
+// NewGRPCServer creates a new gRPC server with health check and reflection,
+// plus WorkerDebugService when debug is set
+func NewGRPCServer(port int, logger *observability.Logger, debug *DebugOptions) (*GRPCServer, error) {
 	// Create gRPC server with OpenTelemetry instrumentation
 	server := grpc.NewServer(
 		grpc.StatsHandler(otelgrpc.NewServerHandler()),
@@ -33,6 +34,13 @@ func NewGRPCServer(port int, logger *observability.Logger) *GRPCServer {
 	grpc_health_v1.RegisterHealthServer(server, healthServer)
 	healthServer.SetServingStatus("reservation-worker", grpc_health_v1.HealthCheckResponse_SERVING)
 
+	// Worker internals for on-call debugging; only ever served on the debug port
+	if debug != nil {
+		if err := RegisterDebugService(server, *debug); err != nil {
+			return nil, err
+		}
+	}
+
 	// Register reflection service for grpcui debugging (only in development)
 	reflection.Register(server)
 
@@ -40,7 +48,7 @@ func NewGRPCServer(port int, logger *observability.Logger) *GRPCServer {
 		server: server,
 		logger: logger,
 		port:   port,
-	}
+	}, nil
 }
 
 // Start starts the gRPC server
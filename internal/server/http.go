@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// HTTPOptions selects the optional endpoints of the HTTP server
+type HTTPOptions struct {
+	EnablePprof bool           // Mount net/http/pprof under /debug/pprof
+	TestEvents  EventSubmitter // Mount POST /api/v1/test-event when set
+	Scrapes     *ScrapeWaiter  // Notified after each /metrics scrape when set
+
+	// Mount POST /api/v1/events/replay when both are set; requests must carry ReplayToken as a bearer token
+	Replay      EventProcessor
+	ReplayToken string
+
+	// Reported by /api/v1/status; with EnableAdmin also toggled by POST /api/v1/pause and /api/v1/resume
+	Pauser      Pauser
+	EnableAdmin bool
+
+	// Source of /metrics and /api/v1/metrics/summary; the global registry when nil
+	Gatherer prometheus.Gatherer
+}
+
+// NewHTTPServer creates the HTTP server for health checks and metrics,
+// plus the optional endpoints selected by opts.
+func NewHTTPServer(port string, opts HTTPOptions) *http.Server {
+	mux := http.NewServeMux()
+
+	// Health check endpoint
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	})
+
+	// Readiness check endpoint
+	mux.HandleFunc("/ready", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("READY"))
+	})
+
+	// Prometheus metrics endpoint
+	var metricsHandler http.Handler = promhttp.Handler()
+	gatherer := prometheus.DefaultGatherer
+	if opts.Gatherer != nil {
+		metricsHandler = promhttp.HandlerFor(opts.Gatherer, promhttp.HandlerOpts{})
+		gatherer = opts.Gatherer
+	}
+	if opts.Scrapes != nil {
+		metricsHandler = opts.Scrapes.wrap(metricsHandler)
+	}
+	mux.Handle("/metrics", metricsHandler)
+
+	// JSON snapshot of the key metrics for dashboards that cannot scrape Prometheus
+	mux.HandleFunc("/api/v1/metrics/summary", metricsSummaryHandler(gatherer))
+
+	// Profiling endpoints, registered explicitly rather than via the
+	// pprof package's side effect on http.DefaultServeMux
+	if opts.EnablePprof {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+
+	// Intake status, and pausing it during downstream maintenance without restarting the pod
+	mux.HandleFunc("/api/v1/status", statusHandler(opts.Pauser))
+	if opts.EnableAdmin && opts.Pauser != nil {
+		mux.HandleFunc("/api/v1/pause", pauseHandler(opts.Pauser, true))
+		mux.HandleFunc("/api/v1/resume", pauseHandler(opts.Pauser, false))
+	}
+
+	// Synthetic events for post-deploy smoke tests
+	if opts.TestEvents != nil {
+		mux.HandleFunc("/api/v1/test-event", testEventHandler(opts.TestEvents))
+	}
+
+	// Reprocessing a single known-bad event without redriving the whole DLQ
+	if opts.Replay != nil && opts.ReplayToken != "" {
+		mux.HandleFunc("/api/v1/events/replay", replayEventHandler(opts.Replay, opts.ReplayToken))
+	}
+
+	return &http.Server{
+		Addr:    fmt.Sprintf(":%s", port),
+		Handler: mux,
+	}
+}
+
+// ScrapeWaiter lets shutdown hold the HTTP server open until Prometheus
+// has collected the final metric values
+type ScrapeWaiter struct {
+	mu   sync.Mutex
+	next chan struct{} // Closed by the next scrape to start
+}
+
+// NewScrapeWaiter creates a ScrapeWaiter
+func NewScrapeWaiter() *ScrapeWaiter {
+	return &ScrapeWaiter{next: make(chan struct{})}
+}
+
+// Wait blocks until a scrape that started after the call has been served, or ctx is done
+func (s *ScrapeWaiter) Wait(ctx context.Context) error {
+	s.mu.Lock()
+	next := s.next
+	s.mu.Unlock()
+
+	select {
+	case <-next:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *ScrapeWaiter) wrap(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		done := s.next
+		s.next = make(chan struct{})
+		s.mu.Unlock()
+
+		h.ServeHTTP(w, r)
+		close(done)
+	})
+}
@@ -0,0 +1,179 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"github.com/traffic-tacos/reservation-worker/internal/server"
+)
+
+func TestNewHTTPServer_Pprof(t *testing.T) {
+	tests := []struct {
+		name        string
+		enablePprof bool
+		path        string
+		wantStatus  int
+	}{
+		{"pprof index enabled", true, "/debug/pprof/", http.StatusOK},
+		{"pprof goroutine profile enabled", true, "/debug/pprof/goroutine?debug=1", http.StatusOK},
+		{"pprof index disabled", false, "/debug/pprof/", http.StatusNotFound},
+		{"pprof cmdline disabled", false, "/debug/pprof/cmdline", http.StatusNotFound},
+		{"health with pprof", true, "/health", http.StatusOK},
+		{"metrics with pprof", true, "/metrics", http.StatusOK},
+		{"health without pprof", false, "/health", http.StatusOK},
+		{"ready without pprof", false, "/ready", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := server.NewHTTPServer("0", server.HTTPOptions{EnablePprof: tt.enablePprof})
+
+			rec := httptest.NewRecorder()
+			srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("GET %s status = %d, want %d", tt.path, rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestScrapeWaiter(t *testing.T) {
+	scrapes := server.NewScrapeWaiter()
+	srv := server.NewHTTPServer("0", server.HTTPOptions{Scrapes: scrapes})
+
+	// A scrape served before Wait is called does not count
+	srv.Handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := scrapes.Wait(ctx); err == nil {
+		t.Fatal("Wait() returned before any new scrape")
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- scrapes.Wait(context.Background())
+	}()
+
+	// Give Wait time to start before the scrape it waits for
+	time.Sleep(10 * time.Millisecond)
+	srv.Handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Wait() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Wait() did not return after a scrape")
+	}
+}
+
+func TestNewHTTPServer_MetricsSummary(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	metrics := observability.NewMetrics(observability.WithRegistry(registry), observability.WithInstanceID("worker-1"))
+	defer metrics.Unregister()
+
+	metrics.RecordEventProcessed("reservation.expired", observability.OutcomeSuccess)
+	metrics.RecordEventProcessed("reservation.approved", observability.OutcomeSuccess)
+	metrics.RecordEventError("reservation.expired", observability.OutcomeDownstreamError, "unavailable")
+	metrics.SetActiveWorkers(4)
+	metrics.AddInflightEvents(3)
+	metrics.AddInflightEvents(-1)
+	metrics.RecordSQSPollError()
+
+	srv := server.NewHTTPServer("0", server.HTTPOptions{Gatherer: registry})
+	rec := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/metrics/summary", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/v1/metrics/summary status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	var summary struct {
+		EventsByOutcome map[string]float64 `json:"events_by_outcome"`
+		ActiveWorkers   float64            `json:"active_workers"`
+		InflightEvents  float64            `json:"inflight_events"`
+		SQSPollErrors   float64            `json:"sqs_poll_errors"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&summary); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	wantOutcomes := map[string]float64{
+		observability.OutcomeSuccess:         2,
+		observability.OutcomeDownstreamError: 1,
+	}
+	if !reflect.DeepEqual(summary.EventsByOutcome, wantOutcomes) {
+		t.Errorf("events_by_outcome = %v, want %v", summary.EventsByOutcome, wantOutcomes)
+	}
+	if summary.ActiveWorkers != 4 || summary.InflightEvents != 2 || summary.SQSPollErrors != 1 {
+		t.Errorf("Unexpected summary %+v", summary)
+	}
+}
+
+// fakePauser records the pause state set through the admin endpoints
+type fakePauser struct {
+	paused bool
+}
+
+func (f *fakePauser) Pause() bool  { changed := !f.paused; f.paused = true; return changed }
+func (f *fakePauser) Resume() bool { changed := f.paused; f.paused = false; return changed }
+func (f *fakePauser) Paused() bool { return f.paused }
+
+func TestNewHTTPServer_PauseResume(t *testing.T) {
+	tests := []struct {
+		name        string
+		enableAdmin bool
+		method      string
+		path        string
+		wantStatus  int
+		wantPaused  bool
+	}{
+		{"status while running", true, http.MethodGet, "/api/v1/status", http.StatusOK, false},
+		{"pause", true, http.MethodPost, "/api/v1/pause", http.StatusOK, true},
+		{"status while paused", true, http.MethodGet, "/api/v1/status", http.StatusOK, true},
+		{"pause again", true, http.MethodPost, "/api/v1/pause", http.StatusOK, true},
+		{"pause needs POST", true, http.MethodGet, "/api/v1/pause", http.StatusMethodNotAllowed, true},
+		{"resume", true, http.MethodPost, "/api/v1/resume", http.StatusOK, false},
+		{"pause without admin endpoints", false, http.MethodPost, "/api/v1/pause", http.StatusNotFound, false},
+	}
+
+	pauser := &fakePauser{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := server.NewHTTPServer("0", server.HTTPOptions{Pauser: pauser, EnableAdmin: tt.enableAdmin})
+
+			rec := httptest.NewRecorder()
+			srv.Handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("%s %s status = %d, want %d", tt.method, tt.path, rec.Code, tt.wantStatus)
+			}
+			if pauser.paused != tt.wantPaused {
+				t.Errorf("Expected paused = %v, got %v", tt.wantPaused, pauser.paused)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+
+			var status struct {
+				Paused bool `json:"paused"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if status.Paused != tt.wantPaused {
+				t.Errorf("Expected reported paused = %v, got %v", tt.wantPaused, status.Paused)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/traffic-tacos/reservation-worker/internal/handler"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"github.com/traffic-tacos/reservation-worker/internal/worker"
+)
+
+// EventProcessor runs an event through the worker pipeline and reports how it concluded
+type EventProcessor interface {
+	Process(ctx context.Context, event *handler.Event) worker.ProcessingResult
+}
+
+// replayEventResponse reports the ProcessingResult of a replayed event
+type replayEventResponse struct {
+	EventID    string `json:"event_id"`
+	EventType  string `json:"event_type"`
+	Outcome    string `json:"outcome"`
+	Attempts   int    `json:"attempts"`
+	DurationMS int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// replayEventHandler runs the event envelope in the request body through the dispatcher,
+// retries included, and responds with its result once it concluded
+func replayEventHandler(processor EventProcessor, token string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var event handler.Event
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&event); err != nil {
+			http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if event.ID == "" || event.Type == "" {
+			http.Error(w, "id and type are required", http.StatusBadRequest)
+			return
+		}
+		if _, err := event.SchemaVersion(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeoutCause(r.Context(), testEventTimeout, observability.CancelCause(observability.CancelReasonRequestTimeout))
+		defer cancel()
+
+		result := processor.Process(ctx, &event)
+		resp := replayEventResponse{
+			EventID:    event.ID,
+			EventType:  event.Type,
+			Outcome:    result.Outcome,
+			Attempts:   result.Attempts,
+			DurationMS: result.Duration.Milliseconds(),
+		}
+
+		status := http.StatusOK
+		if result.Err != nil {
+			resp.Error = result.Err.Error()
+			status = http.StatusUnprocessableEntity
+			if errors.Is(result.Err, context.DeadlineExceeded) {
+				resp.Outcome = "timeout"
+				status = http.StatusGatewayTimeout
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
@@ -0,0 +1,89 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/traffic-tacos/reservation-worker/internal/config"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"github.com/traffic-tacos/reservation-worker/internal/server"
+	"github.com/traffic-tacos/reservation-worker/internal/worker"
+	"go.uber.org/zap"
+)
+
+func TestReplayEventEndpoint(t *testing.T) {
+	clients := &recordingClients{}
+	cfg := &config.Config{WorkerConcurrency: 1, MaxRetries: 1, BackoffBaseMS: 1}
+	metrics := observability.NewMetrics(observability.WithRegistry(prometheus.NewRegistry()))
+	defer metrics.Unregister()
+	dispatcher := worker.NewDispatcher(cfg, clients, clients, &observability.Logger{Logger: zap.NewNop()}, metrics)
+	if err := dispatcher.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer dispatcher.Stop()
+
+	srv := server.NewHTTPServer("0", server.HTTPOptions{Replay: dispatcher, ReplayToken: "s3cret"})
+
+	tests := []struct {
+		name        string
+		token       string
+		body        string
+		wantStatus  int
+		wantOutcome string
+	}{
+		{"valid event", "s3cret", `{"id":"evt-1","type":"reservation.expired","detail":{"reservation_id":"rsv-1","event_id":"concert-1","quantity":1,"seat_ids":["A1"]}}`,
+			http.StatusOK, observability.OutcomeSuccess},
+		{"unknown event type", "s3cret", `{"id":"evt-2","type":"order.shipped","detail":{}}`, http.StatusUnprocessableEntity, observability.OutcomeInvalidPayload},
+		{"missing id", "s3cret", `{"type":"reservation.expired","detail":{}}`, http.StatusBadRequest, ""},
+		{"unsupported version", "s3cret", `{"id":"evt-3","type":"reservation.expired","version":"9","detail":{}}`, http.StatusBadRequest, ""},
+		{"malformed body", "s3cret", `{"id":`, http.StatusBadRequest, ""},
+		{"wrong token", "guess", `{"id":"evt-4","type":"reservation.expired","detail":{}}`, http.StatusUnauthorized, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/events/replay", strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rec := httptest.NewRecorder()
+			srv.Handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("POST status = %d, want %d (body %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantOutcome == "" {
+				return
+			}
+
+			var resp struct {
+				Outcome  string `json:"outcome"`
+				Attempts int    `json:"attempts"`
+				Error    string `json:"error"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("Decode response: %v", err)
+			}
+			if resp.Outcome != tt.wantOutcome || resp.Attempts != 1 {
+				t.Errorf("Got outcome %s after %d attempts, want %s after 1", resp.Outcome, resp.Attempts, tt.wantOutcome)
+			}
+			if (resp.Error != "") != (tt.wantStatus != http.StatusOK) {
+				t.Errorf("Unexpected error %q for status %d", resp.Error, rec.Code)
+			}
+		})
+	}
+}
+
+func TestReplayEventEndpoint_RequiresToken(t *testing.T) {
+	srv := server.NewHTTPServer("0", server.HTTPOptions{Replay: &worker.Dispatcher{}})
+
+	rec := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/events/replay", strings.NewReader(`{}`)))
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected endpoint to be absent without a token, got %d", rec.Code)
+	}
+}
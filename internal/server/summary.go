@@ -0,0 +1,73 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// metricsSummary is the body of GET /api/v1/metrics/summary
+type metricsSummary struct {
+	EventsByOutcome map[string]float64 `json:"events_by_outcome"`
+	ActiveWorkers   float64            `json:"active_workers"`
+	InflightEvents  float64            `json:"inflight_events"`
+	SQSPollErrors   float64            `json:"sqs_poll_errors"`
+}
+
+// metricsSummaryHandler reports a JSON snapshot of the key metrics gathered from gatherer
+func metricsSummaryHandler(gatherer prometheus.Gatherer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		summary, err := summarizeMetrics(gatherer)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(summary)
+	}
+}
+
+// summarizeMetrics gathers the key metrics from gatherer
+func summarizeMetrics(gatherer prometheus.Gatherer) (*metricsSummary, error) {
+	families, err := gatherer.Gather()
+	if err != nil {
+		return nil, fmt.Errorf("failed to gather metrics: %w", err)
+	}
+
+	summary := &metricsSummary{EventsByOutcome: make(map[string]float64)}
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			switch family.GetName() {
+			case "worker_events_total":
+				summary.EventsByOutcome[labelValue(m, "outcome")] += m.GetCounter().GetValue()
+			case "worker_active_goroutines":
+				summary.ActiveWorkers += m.GetGauge().GetValue()
+			case "worker_inflight_events":
+				summary.InflightEvents += m.GetGauge().GetValue()
+			case "sqs_poll_errors_total":
+				summary.SQSPollErrors += m.GetCounter().GetValue()
+			}
+		}
+	}
+	return summary, nil
+}
+
+// labelValue returns the value of the named label of m, or "" when it has none
+func labelValue(m *dto.Metric, name string) string {
+	for _, label := range m.GetLabel() {
+		if label.GetName() == name {
+			return label.GetValue()
+		}
+	}
+	return ""
+}
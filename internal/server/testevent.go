@@ -0,0 +1,96 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/traffic-tacos/reservation-worker/internal/handler"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+)
+
+// testEventTimeout bounds how long a self-test request waits for the event's outcome
+const testEventTimeout = 30 * time.Second
+
+// EventSubmitter runs an event through the worker pipeline and returns its final outcome
+type EventSubmitter interface {
+	Submit(ctx context.Context, event *handler.Event) error
+}
+
+// testEventRequest is the body of POST /api/v1/test-event
+type testEventRequest struct {
+	ID     string          `json:"id,omitempty"`
+	Type   string          `json:"type"`
+	Detail json.RawMessage `json:"detail"`
+}
+
+// testEventResponse reports the outcome of a self-test event
+type testEventResponse struct {
+	EventID    string `json:"event_id"`
+	EventType  string `json:"event_type"`
+	Outcome    string `json:"outcome"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// testEventHandler injects a synthetic event into the dispatcher and responds once it is handled
+func testEventHandler(submitter EventSubmitter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req testEventRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+			http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Type == "" {
+			http.Error(w, "type is required", http.StatusBadRequest)
+			return
+		}
+		if req.ID == "" {
+			req.ID = "self-test-" + uuid.NewString()
+		}
+
+		event := &handler.Event{
+			ID:     req.ID,
+			Type:   req.Type,
+			Source: "self-test",
+			Detail: req.Detail,
+			Time:   time.Now(),
+		}
+
+		ctx, cancel := context.WithTimeoutCause(r.Context(), testEventTimeout, observability.CancelCause(observability.CancelReasonRequestTimeout))
+		defer cancel()
+
+		start := time.Now()
+		err := submitter.Submit(ctx, event)
+		resp := testEventResponse{
+			EventID:    event.ID,
+			EventType:  event.Type,
+			Outcome:    "success",
+			DurationMS: time.Since(start).Milliseconds(),
+		}
+
+		status := http.StatusOK
+		if err != nil {
+			resp.Outcome = "failed"
+			resp.Error = err.Error()
+			status = http.StatusUnprocessableEntity
+			if errors.Is(err, context.DeadlineExceeded) {
+				resp.Outcome = "timeout"
+				status = http.StatusGatewayTimeout
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
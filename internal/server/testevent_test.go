@@ -0,0 +1,133 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/traffic-tacos/proto-contracts/gen/go/reservation/v1"
+	"github.com/traffic-tacos/reservation-worker/internal/client"
+	"github.com/traffic-tacos/reservation-worker/internal/config"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"github.com/traffic-tacos/reservation-worker/internal/server"
+	"github.com/traffic-tacos/reservation-worker/internal/worker"
+	"go.uber.org/zap"
+)
+
+// recordingClients records the downstream calls made by handlers
+type recordingClients struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recordingClients) record(call string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+func (r *recordingClients) ReleaseHold(context.Context, *reservationv1.ReleaseHoldRequest) error {
+	r.record("ReleaseHold")
+	return nil
+}
+
+func (r *recordingClients) CommitReservation(context.Context, *reservationv1.CommitReservationRequest) error {
+	r.record("CommitReservation")
+	return nil
+}
+
+func (r *recordingClients) ReserveSeat(context.Context, *reservationv1.ReserveSeatRequest) error {
+	r.record("ReserveSeat")
+	return nil
+}
+
+func (r *recordingClients) UpdateReservationStatus(_ context.Context, req *client.UpdateStatusRequest) error {
+	r.record("UpdateReservationStatus:" + req.Status)
+	return nil
+}
+
+func (r *recordingClients) GetReservation(_ context.Context, reservationID string) (*client.ReservationDetails, error) {
+	return &client.ReservationDetails{ID: reservationID, Status: client.StatusHold}, nil
+}
+
+func TestTestEventEndpoint(t *testing.T) {
+	clients := &recordingClients{}
+	cfg := &config.Config{WorkerConcurrency: 2, MaxRetries: 1, BackoffBaseMS: 1}
+	metrics := observability.NewMetrics()
+	dispatcher := worker.NewDispatcher(cfg, clients, clients, &observability.Logger{Logger: zap.NewNop()}, metrics)
+	if err := dispatcher.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer dispatcher.Stop()
+
+	srv := server.NewHTTPServer("0", server.HTTPOptions{TestEvents: dispatcher})
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCall   string
+	}{
+		{"reservation expired", `{"type":"reservation.expired","detail":{"reservation_id":"rsv-1","event_id":"concert-1","quantity":1,"seat_ids":["A1"]}}`,
+			http.StatusOK, "UpdateReservationStatus:" + client.StatusExpired},
+		{"payment approved", `{"type":"payment.approved","detail":{"reservation_id":"rsv-2","payment_intent_id":"pay-1","amount":1000,"event_id":"concert-1","quantity":1,"seat_ids":["B1"]}}`,
+			http.StatusOK, "CommitReservation"},
+		{"payment failed", `{"type":"payment.failed","detail":{"reservation_id":"rsv-3","payment_intent_id":"pay-2","amount":1000,"event_id":"concert-1","quantity":1,"seat_ids":["C1"]}}`,
+			http.StatusOK, "UpdateReservationStatus:" + client.StatusCancelled},
+		{"unknown type", `{"type":"order.shipped","detail":{}}`, http.StatusUnprocessableEntity, ""},
+		{"missing type", `{"detail":{}}`, http.StatusBadRequest, ""},
+		{"malformed body", `{"type":`, http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clients.mu.Lock()
+			clients.calls = nil
+			clients.mu.Unlock()
+
+			rec := httptest.NewRecorder()
+			srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/test-event", strings.NewReader(tt.body)))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("POST status = %d, want %d (body %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK {
+				var resp struct {
+					Outcome string `json:"outcome"`
+				}
+				if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Outcome != "success" {
+					t.Errorf("Expected success outcome, got %+v (err %v)", resp, err)
+				}
+			}
+			if tt.wantCall == "" {
+				return
+			}
+
+			// The response is only sent once the handler has run
+			clients.mu.Lock()
+			defer clients.mu.Unlock()
+			found := false
+			for _, call := range clients.calls {
+				found = found || call == tt.wantCall
+			}
+			if !found {
+				t.Errorf("Expected handler to call %s, got %v", tt.wantCall, clients.calls)
+			}
+		})
+	}
+}
+
+func TestTestEventEndpoint_Disabled(t *testing.T) {
+	srv := server.NewHTTPServer("0", server.HTTPOptions{})
+
+	rec := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/test-event", strings.NewReader(`{"type":"payment.approved"}`)))
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected endpoint to be absent when not enabled, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,147 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/traffic-tacos/reservation-worker/internal/client"
+	"github.com/traffic-tacos/reservation-worker/internal/handler"
+)
+
+// bulkCallTimeout bounds a bulk call, which no single caller's context bounds
+const bulkCallTimeout = 2 * time.Second
+
+// batchContext returns the context of a bulk call made for a batch whose first live caller
+// passed ctx: its values, such as trace and correlation IDs, without its cancellation, and
+// bounded by bulkCallTimeout
+func batchContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), bulkCallTimeout)
+}
+
+// statusBatchUpdater is implemented by reservation clients with a bulk status endpoint
+type statusBatchUpdater interface {
+	UpdateReservationStatusBatch(ctx context.Context, reqs []client.UpdateStatusRequest) []error
+}
+
+// pendingUpdate is a status update waiting for its batch to flush
+type pendingUpdate struct {
+	ctx  context.Context
+	req  client.UpdateStatusRequest
+	done chan error
+}
+
+// statusBatcher coalesces status updates from concurrent handlers into bulk calls.
+// A batch flushes when its window elapses or it reaches maxSize; reads pass through.
+type statusBatcher struct {
+	handler.ReservationService
+	updater statusBatchUpdater
+	window  time.Duration
+	maxSize int
+
+	mu      sync.Mutex
+	pending []*pendingUpdate
+	timer   *time.Timer
+
+	// Set once the updater reports it has no bulk endpoint; updates then pass through
+	unsupported atomic.Bool
+}
+
+// newStatusBatcher wraps reservation so its status updates are sent through updater in batches
+func newStatusBatcher(reservation handler.ReservationService, updater statusBatchUpdater, window time.Duration, maxSize int) *statusBatcher {
+	return &statusBatcher{
+		ReservationService: reservation,
+		updater:            updater,
+		window:             window,
+		maxSize:            maxSize,
+	}
+}
+
+// UpdateReservationStatus queues req for the current batch and waits for its result.
+// Without a bulk endpoint it sends req on its own, bound by ctx.
+func (b *statusBatcher) UpdateReservationStatus(ctx context.Context, req *client.UpdateStatusRequest) error {
+	if b.unsupported.Load() {
+		return b.ReservationService.UpdateReservationStatus(ctx, req)
+	}
+
+	p := &pendingUpdate{ctx: ctx, req: *req, done: make(chan error, 1)}
+
+	b.mu.Lock()
+	b.pending = append(b.pending, p)
+	var full []*pendingUpdate
+	if len(b.pending) >= b.maxSize {
+		full = b.takeLocked()
+	} else if len(b.pending) == 1 {
+		b.timer = time.AfterFunc(b.window, b.flushPending)
+	}
+	b.mu.Unlock()
+
+	// The caller that fills the batch sends it rather than waiting for the timer
+	if full != nil {
+		b.flush(full)
+	}
+
+	select {
+	case err := <-p.done:
+		if errors.Is(err, client.ErrBatchUnsupported) {
+			b.unsupported.Store(true)
+			return b.ReservationService.UpdateReservationStatus(ctx, req)
+		}
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// takeLocked removes and returns the pending batch; b.mu must be held
+func (b *statusBatcher) takeLocked() []*pendingUpdate {
+	batch := b.pending
+	b.pending = nil
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	return batch
+}
+
+// flushPending sends whatever is pending when the window elapses
+func (b *statusBatcher) flushPending() {
+	b.mu.Lock()
+	batch := b.takeLocked()
+	b.mu.Unlock()
+
+	if len(batch) > 0 {
+		b.flush(batch)
+	}
+}
+
+// flush sends batch as one bulk call and hands each caller its result. Updates whose
+// caller already gave up are left out. The call carries all the others, so it is bound
+// by none of their contexts.
+func (b *statusBatcher) flush(batch []*pendingUpdate) {
+	live := batch[:0]
+	for _, p := range batch {
+		if err := p.ctx.Err(); err != nil {
+			p.done <- err
+			continue
+		}
+		live = append(live, p)
+	}
+	if len(live) == 0 {
+		return
+	}
+
+	reqs := make([]client.UpdateStatusRequest, len(live))
+	for i, p := range live {
+		reqs[i] = p.req
+	}
+
+	ctx, cancel := batchContext(live[0].ctx)
+	defer cancel()
+	errs := b.updater.UpdateReservationStatusBatch(ctx, reqs)
+	for i, p := range live {
+		p.done <- errs[i]
+	}
+}
@@ -0,0 +1,157 @@
+package worker_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/traffic-tacos/reservation-worker/internal/client"
+	"github.com/traffic-tacos/reservation-worker/internal/config"
+	"github.com/traffic-tacos/reservation-worker/internal/handler"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"github.com/traffic-tacos/reservation-worker/internal/worker"
+)
+
+// batchReservation records bulk status updates
+type batchReservation struct {
+	fakeReservation
+	batchMu       sync.Mutex
+	batches       [][]client.UpdateStatusRequest
+	processingIDs []string // Processing ID carried by each bulk call's context
+	unbounded     bool     // A bulk call's context had no deadline
+	unsupported   bool     // Fail bulk calls as if the API had no bulk endpoint
+}
+
+func (f *batchReservation) UpdateReservationStatusBatch(ctx context.Context, reqs []client.UpdateStatusRequest) []error {
+	f.batchMu.Lock()
+	defer f.batchMu.Unlock()
+	f.batches = append(f.batches, reqs)
+	f.processingIDs = append(f.processingIDs, observability.ProcessingID(ctx))
+	if _, ok := ctx.Deadline(); !ok {
+		f.unbounded = true
+	}
+	errs := make([]error, len(reqs))
+	if f.unsupported {
+		for i := range errs {
+			errs[i] = client.ErrBatchUnsupported
+		}
+	}
+	return errs
+}
+
+func TestDispatcher_BatchesStatusUpdates(t *testing.T) {
+	tests := []struct {
+		name        string
+		windowMS    int
+		maxSize     int
+		unsupported bool
+		wantBatches int
+		wantPerItem int
+	}{
+		{"window coalesces all updates", 100, 50, false, 1, 0},
+		{"max size flushes early", 60000, 4, false, 2, 0},
+		{"batching disabled", 0, 50, false, 0, 8},
+		{"no bulk endpoint falls back to per-item updates", 100, 50, true, 1, 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				WorkerConcurrency: 8,
+				MaxRetries:        1,
+				BackoffBaseMS:     1,
+				BatchWindowMS:     tt.windowMS,
+				BatchMaxSize:      tt.maxSize,
+			}
+			reservation := &batchReservation{unsupported: tt.unsupported}
+			dispatcher := worker.NewDispatcher(cfg, &fakeInventory{}, reservation, testLogger(), testMetrics)
+
+			const events = 8
+			var wg sync.WaitGroup
+			errs := make(chan error, events)
+			for i := 0; i < events; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					event := newEvent(fmt.Sprintf("evt-%d", i), handler.EventTypeReservationExpired, map[string]interface{}{
+						"reservation_id": fmt.Sprintf("rsv-%d", i), "event_id": "concert-1", "qty": 1, "seat_ids": []string{"A1"},
+					})
+					errs <- dispatcher.HandleEvent(context.Background(), event, 1)
+				}(i)
+			}
+			wg.Wait()
+			close(errs)
+
+			for err := range errs {
+				if err != nil {
+					t.Fatalf("HandleEvent() error = %v", err)
+				}
+			}
+
+			batched := 0
+			for _, batch := range reservation.batches {
+				if len(batch) > tt.maxSize {
+					t.Errorf("Batch of %d exceeds max size %d", len(batch), tt.maxSize)
+				}
+				batched += len(batch)
+			}
+			if got := len(reservation.batches); got != tt.wantBatches {
+				t.Errorf("Expected %d batches, got %d", tt.wantBatches, got)
+			}
+			if tt.wantBatches > 0 && batched != events {
+				t.Errorf("Expected all %d updates batched, got %d", events, batched)
+			}
+			if got := reservation.calls(); got != tt.wantPerItem {
+				t.Errorf("Expected %d per-item updates, got %d", tt.wantPerItem, got)
+			}
+			for _, id := range reservation.processingIDs {
+				if id == "" {
+					t.Error("Expected bulk calls to carry a caller's processing ID")
+				}
+			}
+			if reservation.unbounded {
+				t.Error("Expected bulk calls to have a deadline")
+			}
+		})
+	}
+}
+
+func TestDispatcher_BatchSkipsAbandonedUpdates(t *testing.T) {
+	cfg := &config.Config{
+		WorkerConcurrency: 2,
+		MaxRetries:        1,
+		BackoffBaseMS:     1,
+		BatchWindowMS:     100,
+		BatchMaxSize:      50,
+	}
+	reservation := &batchReservation{}
+	dispatcher := worker.NewDispatcher(cfg, &fakeInventory{}, reservation, testLogger(), testMetrics)
+
+	// The abandoned event's caller gives up while its update waits for the batch window
+	abandoned, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var wg sync.WaitGroup
+	for _, id := range []string{"rsv-abandoned", "rsv-live"} {
+		ctx := context.Background()
+		if id == "rsv-abandoned" {
+			ctx = abandoned
+		}
+		wg.Add(1)
+		go func(ctx context.Context, id string) {
+			defer wg.Done()
+			event := newEvent(id, handler.EventTypeReservationExpired, map[string]interface{}{
+				"reservation_id": id, "event_id": "concert-1", "qty": 1, "seat_ids": []string{"A1"},
+			})
+			dispatcher.HandleEvent(ctx, event, 1)
+		}(ctx, id)
+	}
+	wg.Wait()
+
+	reservation.batchMu.Lock()
+	defer reservation.batchMu.Unlock()
+	if len(reservation.batches) != 1 || len(reservation.batches[0]) != 1 || reservation.batches[0][0].ReservationID != "rsv-live" {
+		t.Errorf("Expected one batch with only the live update, got %+v", reservation.batches)
+	}
+}
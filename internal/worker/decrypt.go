@@ -0,0 +1,160 @@
+package worker
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// KMSDecrypter decrypts data encrypted under a KMS key
+type KMSDecrypter interface {
+	Decrypt(ctx context.Context, keyID string, ciphertext []byte) ([]byte, error)
+}
+
+// Message attributes of encrypted payloads
+const (
+	kmsKeyIDAttribute   = "kms-key-id"   // KMS key the body or its data key was encrypted under
+	kmsDataKeyAttribute = "kms-data-key" // Base64 encrypted data key; set for envelope encryption
+)
+
+// Data key cache bounds
+const (
+	dataKeyCacheTTL  = 5 * time.Minute
+	dataKeyCacheSize = 1024
+)
+
+// payloadDecrypter decrypts message bodies carrying the kms-key-id attribute.
+// Bodies are base64 encoded. Without a data key the body is a KMS ciphertext;
+// with one it is a 12-byte nonce followed by AES-GCM ciphertext under the data key.
+type payloadDecrypter struct {
+	kms KMSDecrypter
+
+	mu       sync.Mutex
+	dataKeys map[string]cachedDataKey // Encrypted data key -> plaintext data key
+}
+
+type cachedDataKey struct {
+	key     []byte
+	expires time.Time
+}
+
+func newPayloadDecrypter(kms KMSDecrypter) *payloadDecrypter {
+	return &payloadDecrypter{
+		kms:      kms,
+		dataKeys: make(map[string]cachedDataKey),
+	}
+}
+
+// decrypt returns message with its body decrypted, or message itself when it is not encrypted.
+// Errors from KMS are returned as is so the message is retried; undecryptable payloads
+// are wrapped in errMalformedMessage.
+func (d *payloadDecrypter) decrypt(ctx context.Context, message *types.Message) (*types.Message, error) {
+	keyID := stringAttribute(message, kmsKeyIDAttribute)
+	if keyID == "" {
+		return message, nil
+	}
+	if message.Body == nil {
+		return nil, fmt.Errorf("%w: message body is nil", errMalformedMessage)
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimSpace(*message.Body))
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to base64-decode encrypted body: %w", errMalformedMessage, err)
+	}
+
+	var plaintext []byte
+	if encodedKey := stringAttribute(message, kmsDataKeyAttribute); encodedKey != "" {
+		plaintext, err = d.openEnvelope(ctx, keyID, encodedKey, ciphertext)
+	} else {
+		plaintext, err = d.kms.Decrypt(ctx, keyID, ciphertext)
+		if err != nil {
+			err = fmt.Errorf("failed to decrypt message body: %w", err)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	decrypted := *message
+	decrypted.Body = aws.String(string(plaintext))
+	return &decrypted, nil
+}
+
+// openEnvelope decrypts ciphertext with the data key encodedKey wraps
+func (d *payloadDecrypter) openEnvelope(ctx context.Context, keyID, encodedKey string, ciphertext []byte) ([]byte, error) {
+	key, err := d.dataKey(ctx, keyID, encodedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid data key: %w", errMalformedMessage, err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid data key: %w", errMalformedMessage, err)
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, fmt.Errorf("%w: encrypted body is shorter than its nonce", errMalformedMessage)
+	}
+
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to decrypt message body: %w", errMalformedMessage, err)
+	}
+	return plaintext, nil
+}
+
+// dataKey returns the plaintext data key, asking KMS only on a cache miss
+func (d *payloadDecrypter) dataKey(ctx context.Context, keyID, encodedKey string) ([]byte, error) {
+	now := time.Now()
+
+	d.mu.Lock()
+	cached, ok := d.dataKeys[encodedKey]
+	d.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.key, nil
+	}
+
+	encryptedKey, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to base64-decode data key: %w", errMalformedMessage, err)
+	}
+	key, err := d.kms.Decrypt(ctx, keyID, encryptedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data key: %w", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.dataKeys) >= dataKeyCacheSize {
+		for k, entry := range d.dataKeys {
+			if !now.Before(entry.expires) {
+				delete(d.dataKeys, k)
+			}
+		}
+	}
+	if len(d.dataKeys) < dataKeyCacheSize {
+		d.dataKeys[encodedKey] = cachedDataKey{key: key, expires: now.Add(dataKeyCacheTTL)}
+	}
+	return key, nil
+}
+
+// stringAttribute returns the string value of a message attribute, or "" when unset
+func stringAttribute(message *types.Message, name string) string {
+	attr, ok := message.MessageAttributes[name]
+	if !ok || attr.StringValue == nil {
+		return ""
+	}
+	return *attr.StringValue
+}
@@ -0,0 +1,162 @@
+package worker
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/traffic-tacos/reservation-worker/internal/handler"
+)
+
+// job is a unit of work handed to a worker
+type job struct {
+	event        *handler.Event
+	attempt      int
+	processingID string    // Carried across retries so logs and downstream calls correlate
+	scheduledAt  time.Time // When the retry entered the delay queue
+}
+
+// delayedJob is a job waiting in the delay queue
+type delayedJob struct {
+	job *job
+	due time.Time
+	seq uint64
+}
+
+// delayHeap orders jobs by due time, then by scheduling order
+type delayHeap []*delayedJob
+
+func (h delayHeap) Len() int { return len(h) }
+func (h delayHeap) Less(i, j int) bool {
+	if h[i].due.Equal(h[j].due) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].due.Before(h[j].due)
+}
+func (h delayHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *delayHeap) Push(x interface{}) { *h = append(*h, x.(*delayedJob)) }
+func (h *delayHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return item
+}
+
+// delayQueue holds jobs until their backoff elapses, then emits them in due order
+type delayQueue struct {
+	mu       sync.Mutex
+	items    delayHeap
+	capacity int
+	seq      uint64
+	wake     chan struct{}
+	out      chan *job
+	running  atomic.Bool
+}
+
+// newDelayQueue creates a delay queue holding at most capacity jobs
+func newDelayQueue(capacity int) *delayQueue {
+	return &delayQueue{
+		capacity: capacity,
+		wake:     make(chan struct{}, 1),
+		out:      make(chan *job),
+	}
+}
+
+// schedule queues j until due. It reports false when the queue is full or not running.
+func (q *delayQueue) schedule(j *job, due time.Time) bool {
+	if !q.running.Load() {
+		return false
+	}
+
+	q.mu.Lock()
+	if len(q.items) >= q.capacity {
+		q.mu.Unlock()
+		return false
+	}
+	q.seq++
+	heap.Push(&q.items, &delayedJob{job: j, due: due, seq: q.seq})
+	q.mu.Unlock()
+
+	// Wake the run loop in case this job is due before the one it is waiting on
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// start marks the queue as accepting jobs; it reports false when the queue is disabled
+func (q *delayQueue) start() bool {
+	if q.capacity <= 0 {
+		return false
+	}
+	q.running.Store(true)
+	return true
+}
+
+// flush stops the queue and returns the jobs still waiting in it
+func (q *delayQueue) flush() []*job {
+	q.running.Store(false)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	jobs := make([]*job, 0, len(q.items))
+	for _, item := range q.items {
+		jobs = append(jobs, item.job)
+	}
+	q.items = nil
+	return jobs
+}
+
+// run emits due jobs on out until ctx is cancelled or quit is closed.
+// A due job nobody took is left in the queue for flush.
+func (q *delayQueue) run(ctx context.Context, quit <-chan struct{}) {
+	defer q.running.Store(false)
+
+	for {
+		q.mu.Lock()
+		var next *delayedJob
+		var wait time.Duration
+		if len(q.items) > 0 {
+			if wait = time.Until(q.items[0].due); wait <= 0 {
+				next = heap.Pop(&q.items).(*delayedJob)
+			}
+		}
+		q.mu.Unlock()
+
+		if next != nil {
+			select {
+			case q.out <- next.job:
+				continue
+			case <-ctx.Done():
+			case <-quit:
+			}
+			q.mu.Lock()
+			heap.Push(&q.items, next)
+			q.mu.Unlock()
+			return
+		}
+
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			timeout = timer.C
+		}
+
+		select {
+		case <-timeout:
+		case <-q.wake:
+			if timer != nil {
+				timer.Stop()
+			}
+		case <-ctx.Done():
+			return
+		case <-quit:
+			return
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"go.uber.org/zap"
+)
+
+// deleteBatch collects the outcomes of the messages from one receive and deletes
+// the succeeded ones with a single DeleteMessageBatch once all of them concluded,
+// or at the receive's visibility deadline, whichever comes first, so a slow sibling
+// cannot hold them past their visibility timeout. Messages that succeed after the
+// deadline are deleted on their own. Failed messages are left in the queue, so only
+// they are redelivered.
+type deleteBatch struct {
+	poller *SQSPoller
+	ctx    context.Context
+	timer  *time.Timer
+
+	mu        sync.Mutex
+	pending   int  // Dispatched messages without an outcome yet
+	sealed    bool // No more messages will be added
+	expired   bool // The deadline passed; later successes are deleted right away
+	succeeded []types.Message
+}
+
+// newDeleteBatch creates an empty batch for one receive, flushed at deadline
+// unless it is zero
+func newDeleteBatch(ctx context.Context, poller *SQSPoller, deadline time.Time) *deleteBatch {
+	b := &deleteBatch{poller: poller, ctx: ctx}
+	if !deadline.IsZero() {
+		b.timer = time.AfterFunc(time.Until(deadline), b.expire)
+	}
+	return b
+}
+
+// track adds message to the batch and returns the ack and nack callbacks of its event
+func (b *deleteBatch) track(message *types.Message) (ack func(), nack func(retryable bool)) {
+	b.mu.Lock()
+	b.pending++
+	b.mu.Unlock()
+
+	ack = func() {
+		b.mu.Lock()
+		expired := b.expired
+		if !expired {
+			b.succeeded = append(b.succeeded, *message)
+		}
+		b.mu.Unlock()
+		if expired {
+			b.poller.ackFunc(b.ctx, message)()
+		}
+		b.conclude()
+	}
+	nack = func(retryable bool) {
+		// Make the failure visible right away, as without batching
+		b.poller.nackFunc(b.ctx, message)(retryable)
+		b.conclude()
+	}
+	return ack, nack
+}
+
+// discard drops a tracked message whose event never reached the dispatcher
+func (b *deleteBatch) discard() {
+	b.conclude()
+}
+
+// conclude records the outcome of one tracked message
+func (b *deleteBatch) conclude() {
+	b.mu.Lock()
+	b.pending--
+	ready := b.sealed && b.pending == 0
+	b.mu.Unlock()
+
+	if ready {
+		b.stopTimer()
+		b.flush()
+	}
+}
+
+// expire flushes the messages that succeeded so far once the deadline passed
+func (b *deleteBatch) expire() {
+	b.mu.Lock()
+	b.expired = true
+	b.mu.Unlock()
+
+	b.flush()
+}
+
+// stopTimer cancels the deadline flush of a batch that concluded in time
+func (b *deleteBatch) stopTimer() {
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+}
+
+// seal marks the batch complete; it flushes now if every message already concluded
+func (b *deleteBatch) seal() {
+	b.mu.Lock()
+	b.sealed = true
+	ready := b.pending == 0
+	b.mu.Unlock()
+
+	if ready {
+		b.stopTimer()
+		b.flush()
+	}
+}
+
+// flush deletes the succeeded messages. A receive holds at most 10 messages,
+// which is also the DeleteMessageBatch limit. A failed call, and entries failed
+// through no fault of the request, are retried like single deletes.
+func (b *deleteBatch) flush() {
+	b.mu.Lock()
+	messages := b.succeeded
+	b.succeeded = nil
+	b.mu.Unlock()
+
+	if len(messages) == 0 {
+		return
+	}
+
+	entries := make([]types.DeleteMessageBatchRequestEntry, len(messages))
+	for i := range messages {
+		entries[i] = types.DeleteMessageBatchRequestEntry{
+			Id:            aws.String(strconv.Itoa(i)),
+			ReceiptHandle: messages[i].ReceiptHandle,
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(b.ctx), ackTimeout)
+	defer cancel()
+
+	p := b.poller
+	deleted := 0
+	var failed []types.BatchResultErrorEntry
+	err := p.deleteRetryer.Do(ctx, "sqs_delete_message_batch", func(ctx context.Context) error {
+		result, err := p.sqsClient.DeleteMessageBatch(ctx, &sqs.DeleteMessageBatchInput{
+			QueueUrl: aws.String(p.queueURL),
+			Entries:  entries,
+		})
+		if err != nil {
+			return err
+		}
+		deleted += len(result.Successful)
+
+		// Entries the request got wrong fail again; only the others are retried
+		var retried []types.DeleteMessageBatchRequestEntry
+		failed = failed[:0]
+		for _, entry := range result.Failed {
+			if entry.SenderFault {
+				b.logFailed(messages, entry)
+				continue
+			}
+			failed = append(failed, entry)
+			if i, ok := entryIndex(entry.Id, messages); ok {
+				retried = append(retried, types.DeleteMessageBatchRequestEntry{Id: entry.Id, ReceiptHandle: messages[i].ReceiptHandle})
+			}
+		}
+		entries = retried
+		if len(entries) > 0 {
+			return fmt.Errorf("%d of the batch's deletes failed", len(entries))
+		}
+		return nil
+	})
+	if err != nil {
+		p.logger.Error("Failed to delete SQS message batch",
+			zap.Error(err),
+			zap.Int("message_count", len(entries)),
+		)
+		for _, entry := range failed {
+			b.logFailed(messages, entry)
+		}
+	}
+
+	p.logger.Debug("Deleted message batch from SQS",
+		zap.Int("deleted", deleted),
+		zap.Int("failed", len(messages)-deleted),
+	)
+}
+
+// logFailed logs a batch entry that could not be deleted
+func (b *deleteBatch) logFailed(messages []types.Message, entry types.BatchResultErrorEntry) {
+	var messageID string
+	if i, ok := entryIndex(entry.Id, messages); ok {
+		messageID = aws.ToString(messages[i].MessageId)
+	}
+	b.poller.logger.Error("Failed to delete SQS message",
+		zap.String("message_id", messageID),
+		zap.String("code", aws.ToString(entry.Code)),
+		zap.String("reason", aws.ToString(entry.Message)),
+	)
+}
+
+// entryIndex returns the index into messages that a batch entry id refers to
+func entryIndex(id *string, messages []types.Message) (int, bool) {
+	i, err := strconv.Atoi(aws.ToString(id))
+	if err != nil || i < 0 || i >= len(messages) {
+		return 0, false
+	}
+	return i, true
+}
@@ -2,8 +2,11 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/traffic-tacos/reservation-worker/internal/client"
@@ -11,91 +14,378 @@ import (
 	"github.com/traffic-tacos/reservation-worker/internal/handler"
 	"github.com/traffic-tacos/reservation-worker/internal/observability"
 	"go.uber.org/zap"
+	"golang.org/x/sync/semaphore"
 )
 
+// errEventDropped is the outcome of an event that could not be handed to a worker
+var errEventDropped = errors.New("event dropped before reaching a worker")
+
+// errUnknownEventType is the outcome of an event no handler is registered for
+var errUnknownEventType = errors.New("unknown event type")
+
 // Dispatcher manages worker goroutines and dispatches events to handlers
 type Dispatcher struct {
-	concurrency       int
-	eventsChan        chan *handler.Event
-	workerPool        chan chan *handler.Event
-	workers           []*Worker
-	wg                sync.WaitGroup
-	stopChan          chan struct{}
-	logger            *observability.Logger
-	metrics           *observability.Metrics
-	expiredHandler    *handler.ExpiredHandler
-	approvedHandler   *handler.ApprovedHandler
-	failedHandler     *handler.FailedHandler
-	config            *config.Config
+	concurrency   int
+	eventsChan    chan *handler.Event
+	highChan      chan *handler.Event // High-priority events, routed ahead of eventsChan
+	highStreak    int                 // High-priority events routed since the last normal one
+	fair          *fairQueue          // Per-type queues fed from eventsChan; nil routes eventsChan in arrival order
+	workerPool    chan chan *job
+	workers       []*Worker
+	wg            sync.WaitGroup
+	dispatchWG    sync.WaitGroup
+	inflight      sync.WaitGroup // Jobs routed or waiting for retry but not yet handled
+	retries       *delayQueue
+	stopChan      chan struct{}
+	quitChan      chan struct{}
+	logger        *observability.Logger
+	metrics       *observability.Metrics
+	handlers      map[string]handler.HandlerFunc
+	approved      *handler.ApprovedHandler
+	ignored       map[string]bool // Event types acked without handling
+	config        *config.Config
+	activeWorkers atomic.Int32
+	busyWorkers   atomic.Int32
+	typeLimits    map[string]*typeLimit
+	typeQueued    atomic.Int32 // Events routed to a capped type's queue, waiting for its slot
+	waiters       sync.Map     // *handler.Event -> chan ProcessingResult, for Process callers awaiting the outcome
+	running       sync.Map     // *handler.Event -> InflightEvent, for events a worker is handling
+
+	// Beaten by the dispatch loop, at least every heartbeatInterval while idle
+	heartbeat heartbeat
+
+	// Shutdown accounting
+	inflightJobs atomic.Int64 // Mirrors inflight, which cannot be read
+	draining     atomic.Bool
+	drained      atomic.Int64 // Events concluded while draining
+	dropped      atomic.Int64 // Events dropped while draining
 }
 
 // NewDispatcher creates a new event dispatcher
 func NewDispatcher(
 	config *config.Config,
-	inventoryClient *client.InventoryClient,
-	reservationClient *client.ReservationClient,
+	inventoryClient handler.InventoryService,
+	reservationClient handler.ReservationService,
 	logger *observability.Logger,
 	metrics *observability.Metrics,
 ) *Dispatcher {
-	eventsChan := make(chan *handler.Event, config.WorkerConcurrency*2)
-	workerPool := make(chan chan *handler.Event, config.WorkerConcurrency)
+	// Received events share EVENTS_BUFFER_SIZE: with priorities the high-priority channel
+	// holds half of it, and with DISPATCH_FAIRNESS the fair queue half of what is left
+	eventsBuffer := config.EventsBuffer()
+	highBuffer := 0
+	if config.PriorityAttribute != "" {
+		highBuffer = eventsBuffer / 2
+		eventsBuffer -= highBuffer
+	}
+	var fair *fairQueue
+	if config.DispatchFairness {
+		fair = newFairQueue(eventsBuffer / 2)
+		eventsBuffer = max(eventsBuffer-fair.limit, 0)
+	}
+
+	eventsChan := make(chan *handler.Event, eventsBuffer)
+	highChan := make(chan *handler.Event, highBuffer)
+	workerPool := make(chan chan *job, config.WorkerConcurrency)
+
+	// In dry-run mode downstream mutations are only logged
+	if config.DryRun {
+		inventoryClient = handler.NewDryRunInventory(logger)
+		reservationClient = handler.NewDryRunReservation(reservationClient, logger)
+	}
+
+	// Coalesce status updates into bulk calls when the client supports them
+	if updater, ok := reservationClient.(statusBatchUpdater); ok && config.BatchWindowMS > 0 {
+		reservationClient = newStatusBatcher(reservationClient, updater, time.Duration(config.BatchWindowMS)*time.Millisecond, config.BatchMaxSize)
+	}
 
 	// Create handlers
-	expiredHandler := handler.NewExpiredHandler(inventoryClient, reservationClient, logger, metrics)
-	approvedHandler := handler.NewApprovedHandler(inventoryClient, reservationClient, logger, metrics)
-	failedHandler := handler.NewFailedHandler(inventoryClient, reservationClient, logger, metrics)
+	expiredHandler := handler.NewExpiredHandler(inventoryClient, reservationClient, config, logger, metrics)
+	approvedHandler := handler.NewApprovedHandler(inventoryClient, reservationClient, config, logger, metrics)
+	failedHandler := handler.NewFailedHandler(inventoryClient, reservationClient, config, logger, metrics)
+	cancelledHandler := handler.NewCancelledHandler(inventoryClient, reservationClient, config, logger, metrics)
+
+	// Cross-cutting behavior shared by every handler; recovery runs outermost
+	middlewares := []handler.Middleware{
+		handler.Recovery(logger),
+		handler.Timing(logger),
+	}
+	if config.ConcurrencyPerReservation > 0 {
+		// Ahead of dedup so a duplicate waiting on the lock sees the first delivery's outcome
+		middlewares = append(middlewares, handler.ReservationLimit(config.ConcurrencyPerReservation))
+	}
+	if config.DedupWindowSec > 0 {
+		middlewares = append(middlewares, handler.Dedup(time.Duration(config.DedupWindowSec)*time.Second, logger))
+	}
+	if config.StepStateTTLSec > 0 {
+		middlewares = append(middlewares, handler.StepTracking(time.Duration(config.StepStateTTLSec)*time.Second))
+	}
+	if config.MaxConcurrentDownstream > 0 {
+		// Innermost so only handlers about to call downstream hold a slot
+		middlewares = append(middlewares, handler.DownstreamLimit(config.MaxConcurrentDownstream))
+	}
+
+	expired := handler.Chain(expiredHandler, middlewares...)
+	handlers := map[string]handler.HandlerFunc{
+		handler.EventTypeReservationExpired:     expired,
+		handler.EventTypeReservationHoldExpired: expired,
+		handler.EventTypeReservationCancelled:   handler.Chain(cancelledHandler, middlewares...),
+		handler.EventTypePaymentApproved:        handler.Chain(approvedHandler, middlewares...),
+		handler.EventTypePaymentFailed:          handler.Chain(failedHandler, middlewares...),
+	}
+
+	ignored := make(map[string]bool, len(config.IgnoredEventTypes))
+	for _, eventType := range config.IgnoredEventTypes {
+		ignored[eventType] = true
+	}
+
+	d := &Dispatcher{
+		concurrency: config.WorkerConcurrency,
+		eventsChan:  eventsChan,
+		highChan:    highChan,
+		fair:        fair,
+		workerPool:  workerPool,
+		workers:     make([]*Worker, config.WorkerConcurrency),
+		stopChan:    make(chan struct{}),
+		quitChan:    make(chan struct{}),
+		logger:      logger,
+		metrics:     metrics,
+		handlers:    handlers,
+		approved:    approvedHandler,
+		ignored:     ignored,
+		config:      config,
+		typeLimits:  newTypeLimits(config),
+		retries:     newDelayQueue(config.RetryQueueSize),
+	}
+	d.heartbeat.beat()
+	return d
+}
+
+// typeLimit caps how many events of a type are handled at once. At most as many again
+// wait for a slot; once they do, routing that type blocks the dispatch loop, so the
+// poller's buffer fills instead of events piling up in memory.
+type typeLimit struct {
+	slots *semaphore.Weighted
+	queue chan struct{} // One token per event waiting for a slot
+}
+
+func newTypeLimit(limit int) *typeLimit {
+	return &typeLimit{
+		slots: semaphore.NewWeighted(int64(limit)),
+		queue: make(chan struct{}, limit),
+	}
+}
+
+// newTypeLimits builds limits for event types with a concurrency cap.
+// Types without a cap share the global worker pool unrestricted.
+func newTypeLimits(cfg *config.Config) map[string]*typeLimit {
+	limits := make(map[string]*typeLimit)
 
-	return &Dispatcher{
-		concurrency:     config.WorkerConcurrency,
-		eventsChan:      eventsChan,
-		workerPool:      workerPool,
-		workers:         make([]*Worker, config.WorkerConcurrency),
-		stopChan:        make(chan struct{}),
-		logger:          logger,
-		metrics:         metrics,
-		expiredHandler:  expiredHandler,
-		approvedHandler: approvedHandler,
-		failedHandler:   failedHandler,
-		config:          config,
+	if cfg.ConcurrencyExpired > 0 {
+		// Both expiry events release holds, so they share one cap
+		limit := newTypeLimit(cfg.ConcurrencyExpired)
+		limits[handler.EventTypeReservationExpired] = limit
+		limits[handler.EventTypeReservationHoldExpired] = limit
 	}
+	if cfg.ConcurrencyApproved > 0 {
+		limits[handler.EventTypePaymentApproved] = newTypeLimit(cfg.ConcurrencyApproved)
+	}
+	if cfg.ConcurrencyFailed > 0 {
+		limits[handler.EventTypePaymentFailed] = newTypeLimit(cfg.ConcurrencyFailed)
+	}
+
+	return limits
 }
 
 // Start starts the dispatcher and worker pool
 func (d *Dispatcher) Start(ctx context.Context) error {
+	d.StartWorkers(ctx)
+
+	// Start dispatcher loop
+	d.dispatchWG.Add(1)
+	go func() {
+		defer d.dispatchWG.Done()
+		d.dispatch(ctx)
+	}()
+
+	return nil
+}
+
+// StartWorkers starts the worker pool and retry queue but not the dispatch loop,
+// for callers that run Run themselves
+func (d *Dispatcher) StartWorkers(ctx context.Context) {
 	d.logger.Info("Starting event dispatcher",
 		zap.Int("concurrency", d.concurrency),
+		zap.Int("concurrency_expired", d.config.ConcurrencyExpired),
+		zap.Int("concurrency_approved", d.config.ConcurrencyApproved),
+		zap.Int("concurrency_failed", d.config.ConcurrencyFailed),
+		zap.Int("max_concurrent_downstream", d.config.MaxConcurrentDownstream),
 	)
 
-	// Start workers
-	for i := 0; i < d.concurrency; i++ {
-		worker := NewWorker(i, d.workerPool, d.logger, d.metrics, d)
-		d.workers[i] = worker
+	// Start workers, staggered across the ramp-up window if configured
+	rampUp := time.Duration(d.config.RampUpSec) * time.Second
+	if rampUp <= 0 {
+		for i := 0; i < d.concurrency; i++ {
+			d.startWorker(ctx, i)
+		}
+	} else {
 		d.wg.Add(1)
-		go func(w *Worker) {
+		go func() {
 			defer d.wg.Done()
-			w.Start(ctx)
-		}(worker)
+			d.rampUpWorkers(ctx, rampUp)
+		}()
 	}
 
-	// Start dispatcher loop
+	// Start the retry delay queue so workers are not blocked during backoff
+	if d.retries.start() {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.retries.run(ctx, d.quitChan)
+		}()
+	}
+}
+
+// Run routes events to workers until the dispatcher is stopped or ctx ends. It can be
+// called again after it returned early, e.g. after a panic; Stop waits for the current run.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	d.dispatchWG.Add(1)
+	defer d.dispatchWG.Done()
+
+	d.dispatch(ctx)
+	return ctx.Err()
+}
+
+// startWorker starts the worker with the given index
+func (d *Dispatcher) startWorker(ctx context.Context, id int) {
+	worker := NewWorker(id, d.workerPool, d.logger, d.metrics, d)
+	d.workers[id] = worker
 	d.wg.Add(1)
-	go func() {
+	go func(w *Worker) {
 		defer d.wg.Done()
-		d.dispatch(ctx)
-	}()
+		w.Start(ctx)
+	}(worker)
 
-	// Update metrics
-	d.metrics.SetActiveWorkers(float64(d.concurrency))
+	active := d.activeWorkers.Add(1)
+	d.metrics.SetActiveWorkers(float64(active))
+}
 
-	return nil
+// rampUpWorkers brings workers online evenly over the ramp-up window
+// so downstream services are not hit with a full burst right after deploy
+func (d *Dispatcher) rampUpWorkers(ctx context.Context, rampUp time.Duration) {
+	interval := rampUp / time.Duration(d.concurrency)
+
+	d.logger.Info("Ramping up workers",
+		zap.Int("concurrency", d.concurrency),
+		zap.Duration("ramp_up", rampUp),
+		zap.Duration("interval", interval),
+	)
+
+	for i := 0; i < d.concurrency; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-d.stopChan:
+				return
+			case <-time.After(interval):
+			}
+		}
+		d.startWorker(ctx, i)
+	}
+
+	d.logger.Info("All workers online", zap.Int("concurrency", d.concurrency))
+}
+
+// ActiveWorkers returns the number of workers that have been started
+func (d *Dispatcher) ActiveWorkers() int {
+	return int(d.activeWorkers.Load())
+}
+
+// InflightEvent describes an event a worker is handling
+type InflightEvent struct {
+	ID      string    `json:"id"`
+	Type    string    `json:"type"`
+	Attempt int       `json:"attempt"`
+	Started time.Time `json:"started"`
+}
+
+// InflightEvents returns the events workers are handling, oldest first.
+// Events waiting out a retry backoff off-worker are not included.
+func (d *Dispatcher) InflightEvents() []InflightEvent {
+	var events []InflightEvent
+	d.running.Range(func(_, value interface{}) bool {
+		events = append(events, value.(InflightEvent))
+		return true
+	})
+	sort.Slice(events, func(i, j int) bool { return events[i].Started.Before(events[j].Started) })
+	return events
+}
+
+// FreeWorkers returns how many started workers could take an event right now:
+// those not handling one, less the events already waiting for a worker or a type slot
+func (d *Dispatcher) FreeWorkers() int {
+	free := int(d.activeWorkers.Load()) - int(d.busyWorkers.Load()) - d.buffered() - int(d.typeQueued.Load())
+	return max(free, 0)
 }
 
-// Stop stops the dispatcher and all workers
+// Stop stops the dispatcher once buffered events and pending retries are handled,
+// then stops workers. If the context ends first, whatever is left is nacked for
+// redelivery. The poller must be stopped first.
 func (d *Dispatcher) Stop() {
 	d.logger.Info("Stopping event dispatcher")
+	d.draining.Store(true)
+	inflight := d.inflightJobs.Load() + int64(d.buffered())
+
 	close(d.stopChan)
+	d.dispatchWG.Wait()
+
+	close(d.quitChan)
 	d.wg.Wait()
+	d.activeWorkers.Store(0)
 	d.metrics.SetActiveWorkers(0)
+
+	// A drain cut short by the context leaves events behind; hand them back to their source
+	d.returnAbandoned()
+
+	// Jobs still counted here were abandoned when the context ended the drain early
+	drained := d.drained.Load()
+	dropped := d.dropped.Load() + d.inflightJobs.Load() + int64(d.buffered())
+	d.metrics.RecordShutdown(inflight, drained, dropped)
+	d.logger.Info("Shutdown summary",
+		zap.Int64("inflight", inflight),
+		zap.Int64("drained", drained),
+		zap.Int64("dropped", dropped),
+	)
+}
+
+// returnAbandoned drops the retries and buffered events no worker will handle,
+// so their sources can deliver them again right away
+func (d *Dispatcher) returnAbandoned() {
+	for _, j := range d.retries.flush() {
+		d.drop(j)
+	}
+	for {
+		var event *handler.Event
+		select {
+		case event = <-d.highChan:
+		default:
+			event = d.nextNormal()
+		}
+		if event == nil {
+			return
+		}
+		d.complete(event, observability.OutcomeDropped, 0, errEventDropped)
+	}
+}
+
+// LastHeartbeat returns when the dispatch loop last made progress
+func (d *Dispatcher) LastHeartbeat() time.Time {
+	return d.heartbeat.time()
+}
+
+// SetCompensator hands payment approvals dropped after their reservation ended to c
+func (d *Dispatcher) SetCompensator(c handler.Compensator) {
+	d.approved.SetCompensator(c)
 }
 
 // GetEventsChan returns the events channel for SQS poller
@@ -103,47 +393,281 @@ func (d *Dispatcher) GetEventsChan() chan *handler.Event {
 	return d.eventsChan
 }
 
-// dispatch dispatches events from the channel to available workers
+// GetHighPriorityChan returns the channel for events dispatched ahead of GetEventsChan's
+func (d *Dispatcher) GetHighPriorityChan() chan *handler.Event {
+	return d.highChan
+}
+
+// buffered returns how many received events are waiting to be routed
+func (d *Dispatcher) buffered() int {
+	n := len(d.eventsChan) + len(d.highChan)
+	if d.fair != nil {
+		n += d.fair.len()
+	}
+	return n
+}
+
+// dispatch dispatches events from the channels to available workers
 func (d *Dispatcher) dispatch(ctx context.Context) {
+	idle := time.NewTicker(heartbeatInterval)
+	defer idle.Stop()
+
 	for {
+		d.heartbeat.beat()
+		if event := d.next(); event != nil {
+			d.route(ctx, &job{event: event, attempt: 1})
+			continue
+		}
+
 		select {
 		case <-ctx.Done():
 			d.logger.Info("Dispatcher stopped due to context cancellation")
 			return
 		case <-d.stopChan:
+			d.drain(ctx)
 			d.logger.Info("Dispatcher stopped")
 			return
+		case event := <-d.highChan:
+			d.highStreak++
+			d.route(ctx, &job{event: event, attempt: 1})
 		case event := <-d.eventsChan:
-			// Get an available worker
-			select {
-			case workerChan := <-d.workerPool:
-				// Send event to worker
-				select {
-				case workerChan <- event:
-					// Event dispatched successfully
-				case <-time.After(5 * time.Second):
-					d.logger.Error("Timeout sending event to worker",
-						zap.String("event_type", event.Type),
-						zap.String("event_id", event.ID),
-					)
-				}
-			case <-time.After(30 * time.Second):
-				d.logger.Error("No workers available for event",
-					zap.String("event_type", event.Type),
-					zap.String("event_id", event.ID),
-				)
+			if d.fair != nil {
+				// Queued behind its type's turn; next picks it up
+				d.fair.push(event)
+				continue
 			}
+			d.highStreak = 0
+			d.route(ctx, &job{event: event, attempt: 1})
+		case j := <-d.retries.out:
+			d.routeRetry(ctx, j)
+		case <-idle.C:
+		}
+	}
+}
+
+// next returns the waiting event to route next, or nil when none is waiting. High-priority
+// events go first, except that after PRIORITY_HIGH_BURST of them in a row a waiting normal
+// event does, so a steady stream of high-priority events cannot starve the rest.
+func (d *Dispatcher) next() *handler.Event {
+	if d.highStreak < max(d.config.PriorityHighBurst, 1) {
+		if event := d.nextHigh(); event != nil {
+			return event
+		}
+	}
+	if event := d.nextNormal(); event != nil {
+		d.highStreak = 0
+		return event
+	}
+	return d.nextHigh()
+}
+
+func (d *Dispatcher) nextHigh() *handler.Event {
+	select {
+	case event := <-d.highChan:
+		d.highStreak++
+		return event
+	default:
+		return nil
+	}
+}
+
+// nextNormal returns the next waiting normal event: in arrival order, or with
+// DISPATCH_FAIRNESS the oldest of the next event type in turn
+func (d *Dispatcher) nextNormal() *handler.Event {
+	if d.fair != nil {
+		d.fair.fill(d.eventsChan)
+		return d.fair.pop()
+	}
+
+	select {
+	case event := <-d.eventsChan:
+		return event
+	default:
+		return nil
+	}
+}
+
+// drain routes events still buffered when the dispatcher is stopped,
+// then keeps routing retries until every in-flight job is handled
+func (d *Dispatcher) drain(ctx context.Context) {
+	for len(d.highChan) > 0 {
+		d.route(ctx, &job{event: <-d.highChan, attempt: 1})
+	}
+	for event := d.nextNormal(); event != nil; event = d.nextNormal() {
+		d.route(ctx, &job{event: event, attempt: 1})
+	}
+
+	idle := make(chan struct{})
+	go func() {
+		d.inflight.Wait()
+		close(idle)
+	}()
+
+	for {
+		select {
+		case j := <-d.retries.out:
+			d.routeRetry(ctx, j)
+		case <-idle:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// routeRetry routes a job whose backoff has elapsed
+func (d *Dispatcher) routeRetry(ctx context.Context, j *job) {
+	d.metrics.RecordBackoffWait(j.event.Type, time.Since(j.scheduledAt).Seconds())
+	d.route(ctx, j)
+	// route now tracks the job; drop the count held while it waited in the delay queue
+	d.doneInflight()
+}
+
+// route hands a job to a worker, waiting for a type slot first if the type is capped
+func (d *Dispatcher) route(ctx context.Context, j *job) {
+	d.addInflight()
+
+	limit, ok := d.typeLimits[j.event.Type]
+	if !ok {
+		if !d.sendToWorker(ctx, j) {
+			d.drop(j)
+		}
+		return
+	}
+
+	// Capped types wait for a slot off the dispatch loop so other types keep flowing,
+	// until the type's queue is full
+	select {
+	case limit.queue <- struct{}{}:
+	case <-ctx.Done():
+		d.drop(j)
+		return
+	}
+	d.typeQueued.Add(1)
+
+	d.dispatchWG.Add(1)
+	go func() {
+		defer d.dispatchWG.Done()
+		d.dispatchLimited(ctx, j, limit)
+	}()
+}
+
+// dispatchLimited waits for a slot of the event type's limit before handing the job to a worker
+func (d *Dispatcher) dispatchLimited(ctx context.Context, j *job, limit *typeLimit) {
+	err := limit.slots.Acquire(ctx, 1)
+	<-limit.queue
+	d.typeQueued.Add(-1)
+	if err != nil {
+		d.logger.Warn("Dropped event waiting for concurrency slot",
+			zap.String("event_type", j.event.Type),
+			zap.String("event_id", j.event.ID),
+		)
+		d.drop(j)
+		return
+	}
+
+	// The worker releases the slot once the job is handled
+	if !d.sendToWorker(ctx, j) {
+		limit.slots.Release(1)
+		d.drop(j)
+	}
+}
+
+// drop gives up on a job that never reached a worker, handing the event back to its source
+func (d *Dispatcher) drop(j *job) {
+	d.complete(j.event, observability.OutcomeDropped, j.attempt-1, errEventDropped)
+	d.doneInflight()
+}
+
+// finishJob releases the type slot and in-flight count held by a handled job
+func (d *Dispatcher) finishJob(j *job) {
+	if limit, ok := d.typeLimits[j.event.Type]; ok {
+		limit.slots.Release(1)
+	}
+	d.doneInflight()
+}
+
+// addInflight counts a job as in flight until doneInflight
+func (d *Dispatcher) addInflight() {
+	d.inflightJobs.Add(1)
+	d.metrics.AddInflightEvents(1)
+	d.inflight.Add(1)
+}
+
+func (d *Dispatcher) doneInflight() {
+	d.inflightJobs.Add(-1)
+	d.metrics.AddInflightEvents(-1)
+	d.inflight.Done()
+}
+
+// sendToWorker hands a job to the next available worker
+func (d *Dispatcher) sendToWorker(ctx context.Context, j *job) bool {
+	event := j.event
+
+	// Get an available worker
+	select {
+	case workerChan := <-d.workerPool:
+		// Send event to worker
+		select {
+		case workerChan <- j:
+			// Event dispatched successfully
+			return true
+		case <-time.After(5 * time.Second):
+			d.logger.Error("Timeout sending event to worker",
+				zap.String("event_type", event.Type),
+				zap.String("event_id", event.ID),
+			)
 		}
+	case <-ctx.Done():
+	case <-time.After(30 * time.Second):
+		d.logger.Error("No workers available for event",
+			zap.String("event_type", event.Type),
+			zap.String("event_id", event.ID),
+		)
 	}
+	return false
 }
 
 // HandleEvent routes events to appropriate handlers with retry logic
 func (d *Dispatcher) HandleEvent(ctx context.Context, event *handler.Event, attempt int) error {
 	start := time.Now()
 
+	d.running.Store(event, InflightEvent{ID: event.ID, Type: event.Type, Attempt: attempt, Started: start})
+	defer d.running.Delete(event)
+
+	// One processing ID follows the event through every retry and downstream call
+	if observability.ProcessingID(ctx) == "" {
+		ctx = observability.WithProcessingID(ctx, observability.NewProcessingID())
+	}
+
 	// Add retry attempt to context/logging
+	ctx = observability.WithAttempt(ctx, attempt)
+	ctx = observability.SampleSuccessLogs(ctx, d.config.LogSuccessSampleRate)
 	logger := d.logger.WithEvent(event.Type, "", "")
-	logger = logger.With(zap.Int("attempt", attempt))
+	logger = logger.With(zap.Int("attempt", attempt), observability.ProcessingIDField(ctx), observability.ContextField(ctx))
+
+	// Another consumer's event: ack it so it leaves the queue, without counting a failure
+	if _, handled := d.handlers[event.Type]; !handled && d.ignored[event.Type] {
+		d.metrics.RecordEventProcessed(event.Type, observability.OutcomeIgnored)
+		logger.Debug("Ignoring event type", zap.String("event_type", event.Type), zap.String("event_id", event.ID))
+		return d.complete(event, observability.OutcomeIgnored, attempt, nil)
+	}
+
+	// Stale events are acked unhandled; retries already passed this check on their first attempt,
+	// and events handed in through Process, such as replays of archived events, are exempt
+	_, submitted := d.waiters.Load(event)
+	if maxAge := d.config.MaxEventAge(event.Type); attempt == 1 && maxAge > 0 && !event.Time.IsZero() && !submitted {
+		if age := time.Since(event.Time); age > maxAge {
+			d.metrics.RecordEventProcessed(event.Type, observability.OutcomeStaleDropped)
+			logger.Warn("Dropping stale event",
+				zap.String("event_type", event.Type),
+				zap.String("event_id", event.ID),
+				zap.Duration("age", age),
+				zap.Duration("max_age", maxAge),
+			)
+			return d.complete(event, observability.OutcomeStaleDropped, attempt, nil)
+		}
+	}
 
 	logger.Info("Processing event",
 		zap.String("event_type", event.Type),
@@ -151,63 +675,110 @@ func (d *Dispatcher) HandleEvent(ctx context.Context, event *handler.Event, atte
 		zap.Int("attempt", attempt),
 	)
 
-	var err error
-
 	// Route to appropriate handler
-	switch event.Type {
-	case handler.EventTypeReservationExpired, handler.EventTypeReservationHoldExpired:
-		err = d.expiredHandler.Handle(ctx, event)
-
-	case handler.EventTypePaymentApproved:
-		err = d.approvedHandler.Handle(ctx, event)
-
-	case handler.EventTypePaymentFailed:
-		err = d.failedHandler.Handle(ctx, event)
-
-	default:
-		err = fmt.Errorf("unknown event type: %s", event.Type)
+	handle, ok := d.handlers[event.Type]
+	if !ok {
 		d.metrics.RecordEventProcessed(event.Type, observability.OutcomeInvalidPayload)
 		logger.Error("Unknown event type", zap.String("event_type", event.Type))
-		return err
+		return d.complete(event, observability.OutcomeInvalidPayload, attempt, fmt.Errorf("%w: %s", errUnknownEventType, event.Type))
+	}
+
+	// Abort the handler after HANDLER_TIMEOUT_MS, and before its message can be delivered again
+	handleCtx := ctx
+	if timeout := time.Duration(d.config.HandlerTimeoutMS) * time.Millisecond; timeout > 0 {
+		var cancel context.CancelFunc
+		handleCtx, cancel = context.WithTimeoutCause(handleCtx, timeout, observability.CancelCause(observability.CancelReasonHandlerTimeout))
+		defer cancel()
+	}
+	if deadline := event.VisibilityDeadline(); !deadline.IsZero() {
+		var cancel context.CancelFunc
+		handleCtx, cancel = context.WithDeadlineCause(handleCtx, deadline, observability.CancelCause(observability.CancelReasonVisibilityDeadline))
+		defer cancel()
 	}
+	err := handle(handleCtx, event)
 
 	// Record metrics and handle retry logic
 	duration := time.Since(start)
 	if err != nil {
+		category := client.Classify(err)
+
+		if !category.Retryable() {
+			// Permanent errors fail on the first attempt
+			d.metrics.RecordEventError(event.Type, observability.OutcomeFailed, string(category))
+			d.metrics.RecordEventLatency(event.Type, duration.Seconds())
+			logger.Error("Event processing failed with permanent error",
+				zap.Error(err),
+				zap.String("event_type", event.Type),
+				zap.String("event_id", event.ID),
+				zap.String("error_category", string(category)),
+				observability.CancelReasonField(handleCtx),
+			)
+			return d.complete(event, observability.OutcomeFailed, attempt, err)
+		}
+
+		// The worker's context ended, e.g. at shutdown: hand the event back rather than retry it
+		if ctx.Err() != nil {
+			d.metrics.RecordEventError(event.Type, observability.OutcomeReturned, string(category))
+			d.metrics.RecordEventLatency(event.Type, duration.Seconds())
+			logger.Error("Event processing failed as the worker stopped, returning the event",
+				zap.Error(err),
+				zap.String("event_type", event.Type),
+				zap.String("event_id", event.ID),
+				zap.String("error_category", string(category)),
+				observability.CancelReasonField(handleCtx),
+			)
+			return d.complete(event, observability.OutcomeReturned, attempt, err)
+		}
+
 		if attempt >= d.config.MaxRetries {
 			// Max retries exceeded
-			d.metrics.RecordEventProcessed(event.Type, observability.OutcomeFailed)
+			d.metrics.RecordEventError(event.Type, observability.OutcomeFailed, string(category))
 			d.metrics.RecordEventLatency(event.Type, duration.Seconds())
 			logger.Error("Event processing failed after max retries",
 				zap.Error(err),
 				zap.String("event_type", event.Type),
 				zap.String("event_id", event.ID),
+				zap.String("error_category", string(category)),
 				zap.Int("max_retries", d.config.MaxRetries),
+				observability.CancelReasonField(handleCtx),
 			)
-			return err
+			return d.complete(event, observability.OutcomeFailed, attempt, err)
 		}
 
 		// Retry with backoff
-		d.metrics.RecordEventProcessed(event.Type, observability.OutcomeRetried)
+		d.metrics.RecordEventError(event.Type, observability.OutcomeRetried, string(category))
+		d.metrics.RecordRetry(event.Type)
 		backoffDuration := d.config.GetBackoffDuration(attempt)
 
 		logger.Warn("Event processing failed, retrying",
 			zap.Error(err),
+			zap.String("error_category", string(category)),
 			zap.String("event_type", event.Type),
 			zap.String("event_id", event.ID),
 			zap.Int("attempt", attempt),
 			zap.Duration("backoff", backoffDuration),
+			observability.CancelReasonField(handleCtx),
 		)
 
-		// Wait before retry
+		// Hand the retry to the delay queue so this worker is free during backoff
+		if d.scheduleRetry(ctx, event, attempt+1, backoffDuration) {
+			return nil
+		}
+
+		// Delay queue full or not running: back off on this worker
 		time.Sleep(backoffDuration)
+		d.metrics.RecordBackoffWait(event.Type, backoffDuration.Seconds())
 
 		// Retry
 		return d.HandleEvent(ctx, event, attempt+1)
 	}
 
 	// Success
-	d.metrics.RecordEventProcessed(event.Type, observability.OutcomeSuccess)
+	outcome := observability.OutcomeSuccess
+	if d.config.DryRun {
+		outcome = observability.OutcomeDryRun
+	}
+	d.metrics.RecordEventProcessed(event.Type, outcome)
 	d.metrics.RecordEventLatency(event.Type, duration.Seconds())
 
 	logger.Info("Event processed successfully",
@@ -216,5 +787,100 @@ func (d *Dispatcher) HandleEvent(ctx context.Context, event *handler.Event, atte
 		zap.Duration("duration", duration),
 	)
 
-	return nil
-}
\ No newline at end of file
+	return d.complete(event, outcome, attempt, nil)
+}
+
+// scheduleRetry queues the next attempt of event to run after backoff.
+// It reports false when the delay queue cannot take it.
+func (d *Dispatcher) scheduleRetry(ctx context.Context, event *handler.Event, attempt int, backoff time.Duration) bool {
+	d.addInflight()
+	j := &job{
+		event:        event,
+		attempt:      attempt,
+		processingID: observability.ProcessingID(ctx),
+		scheduledAt:  time.Now(),
+	}
+	if !d.retries.schedule(j, j.scheduledAt.Add(backoff)) {
+		d.doneInflight()
+		return false
+	}
+	return true
+}
+
+// ProcessingResult is the final outcome of an event submitted through Process
+type ProcessingResult struct {
+	// Outcome is the metrics outcome the event was recorded with, such as
+	// observability.OutcomeSuccess or observability.OutcomeFailed. It is empty
+	// when ctx ended before the event finished.
+	Outcome string
+
+	// Attempts is the number of handler attempts made, including retries
+	Attempts int
+
+	// Duration spans queueing, every attempt and the backoff between them
+	Duration time.Duration
+
+	// Err is the handler error of the last attempt, or ctx's error
+	Err error
+}
+
+// Process queues event like a polled message and waits for its final outcome,
+// after any retries. The event is handled however old it is, regardless of MAX_EVENT_AGE.
+func (d *Dispatcher) Process(ctx context.Context, event *handler.Event) ProcessingResult {
+	start := time.Now()
+	done := make(chan ProcessingResult, 1)
+	d.waiters.Store(event, done)
+	defer d.waiters.Delete(event)
+
+	select {
+	case d.eventsChan <- event:
+	case <-ctx.Done():
+		return ProcessingResult{Duration: time.Since(start), Err: ctx.Err()}
+	}
+
+	select {
+	case result := <-done:
+		result.Duration = time.Since(start)
+		return result
+	case <-ctx.Done():
+		return ProcessingResult{Duration: time.Since(start), Err: ctx.Err()}
+	}
+}
+
+// Submit is Process for callers that only need the handler error of the last attempt
+func (d *Dispatcher) Submit(ctx context.Context, event *handler.Event) error {
+	return d.Process(ctx, event).Err
+}
+
+// complete reports the final outcome of event to its source and to a waiting Process caller
+func (d *Dispatcher) complete(event *handler.Event, outcome string, attempt int, err error) error {
+	if d.draining.Load() {
+		if errors.Is(err, errEventDropped) {
+			d.dropped.Add(1)
+		} else {
+			d.drained.Add(1)
+		}
+	}
+	if err == nil {
+		event.Ack()
+	} else {
+		event.Nack(redeliverable(err))
+	}
+	if done, ok := d.waiters.LoadAndDelete(event); ok {
+		done.(chan ProcessingResult) <- ProcessingResult{Outcome: outcome, Attempts: attempt, Err: err}
+	}
+	return err
+}
+
+// redeliverable reports whether a failed event could succeed if delivered again: a handler
+// failure a retry could fix, or an event dropped before reaching a worker, which says
+// nothing about the event itself. Permanent errors and unknown event types would fail the same way.
+func redeliverable(err error) bool {
+	if errors.Is(err, errEventDropped) {
+		return true
+	}
+	if errors.Is(err, errUnknownEventType) {
+		return false
+	}
+	return client.Classify(err).Retryable()
+}
@@ -0,0 +1,1316 @@
+package worker_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/traffic-tacos/proto-contracts/gen/go/reservation/v1"
+	"github.com/traffic-tacos/reservation-worker/internal/client"
+	"github.com/traffic-tacos/reservation-worker/internal/config"
+	"github.com/traffic-tacos/reservation-worker/internal/handler"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"github.com/traffic-tacos/reservation-worker/internal/worker"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// testMetrics is shared because metrics register against the global Prometheus registry
+var testMetrics = observability.NewMetrics()
+
+func testLogger() *observability.Logger {
+	return &observability.Logger{Logger: zap.NewNop()}
+}
+
+// fakeInventory records inventory calls
+type fakeInventory struct {
+	mu       sync.Mutex
+	releases []*reservationv1.ReleaseHoldRequest
+	commits  []*reservationv1.CommitReservationRequest
+	err      error
+	// releaseErr, if set, fails ReleaseHold calls only
+	releaseErr error
+	// releaseBlock, if set, holds ReleaseHold calls until it is closed
+	releaseBlock chan struct{}
+}
+
+func (f *fakeInventory) ReleaseHold(ctx context.Context, req *reservationv1.ReleaseHoldRequest) error {
+	if f.releaseBlock != nil {
+		select {
+		case <-f.releaseBlock:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.releases = append(f.releases, req)
+	if f.releaseErr != nil {
+		return f.releaseErr
+	}
+	return f.err
+}
+
+func (f *fakeInventory) releaseCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.releases)
+}
+
+func (f *fakeInventory) CommitReservation(_ context.Context, req *reservationv1.CommitReservationRequest) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.commits = append(f.commits, req)
+	return f.err
+}
+
+func (f *fakeInventory) ReserveSeat(context.Context, *reservationv1.ReserveSeatRequest) error {
+	return f.err
+}
+
+func (f *fakeInventory) commitCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.commits)
+}
+
+func (f *fakeInventory) calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.releases) + len(f.commits)
+}
+
+// fakeReservation records reservation API calls
+type fakeReservation struct {
+	mu      sync.Mutex
+	updates []*client.UpdateStatusRequest
+	details *client.ReservationDetails
+	err     error
+}
+
+func (f *fakeReservation) UpdateReservationStatus(_ context.Context, req *client.UpdateStatusRequest) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.updates = append(f.updates, req)
+	return f.err
+}
+
+func (f *fakeReservation) GetReservation(_ context.Context, reservationID string) (*client.ReservationDetails, error) {
+	if f.details != nil {
+		return f.details, nil
+	}
+	return &client.ReservationDetails{ID: reservationID, Status: client.StatusHold}, nil
+}
+
+func (f *fakeReservation) calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.updates)
+}
+
+// newEvent builds an event of the given type with a JSON detail
+func newEvent(id, eventType string, detail interface{}) *handler.Event {
+	raw, _ := json.Marshal(detail)
+	return &handler.Event{ID: id, Type: eventType, Source: "test", Time: time.Now(), Detail: raw}
+}
+
+func TestDispatcher_RampUp(t *testing.T) {
+	cfg := &config.Config{
+		WorkerConcurrency: 4,
+		MaxRetries:        1,
+		BackoffBaseMS:     1,
+		RampUpSec:         1,
+	}
+
+	dispatcher := worker.NewDispatcher(cfg, nil, nil, testLogger(), testMetrics)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := dispatcher.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	// Workers come online one every 250ms
+	time.Sleep(100 * time.Millisecond)
+	if got := dispatcher.ActiveWorkers(); got < 1 || got >= cfg.WorkerConcurrency {
+		t.Errorf("Expected partial concurrency early in the ramp-up window, got %d", got)
+	}
+
+	time.Sleep(500 * time.Millisecond)
+	mid := dispatcher.ActiveWorkers()
+	if mid <= 1 || mid >= cfg.WorkerConcurrency {
+		t.Errorf("Expected more workers mid ramp-up, got %d", mid)
+	}
+
+	time.Sleep(600 * time.Millisecond)
+	if got := dispatcher.ActiveWorkers(); got != cfg.WorkerConcurrency {
+		t.Errorf("Expected full concurrency %d by end of window, got %d", cfg.WorkerConcurrency, got)
+	}
+
+	cancel()
+	dispatcher.Stop()
+}
+
+func TestDispatcher_NoRampUp(t *testing.T) {
+	cfg := &config.Config{
+		WorkerConcurrency: 4,
+		MaxRetries:        1,
+		BackoffBaseMS:     1,
+	}
+
+	dispatcher := worker.NewDispatcher(cfg, nil, nil, testLogger(), testMetrics)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := dispatcher.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	if got := dispatcher.ActiveWorkers(); got != cfg.WorkerConcurrency {
+		t.Errorf("Expected all %d workers immediately, got %d", cfg.WorkerConcurrency, got)
+	}
+
+	cancel()
+	dispatcher.Stop()
+}
+
+func TestDispatcher_DryRunSkipsDownstreamCalls(t *testing.T) {
+	cfg := &config.Config{
+		WorkerConcurrency: 1,
+		MaxRetries:        1,
+		BackoffBaseMS:     1,
+		DryRun:            true,
+	}
+
+	inventory := &fakeInventory{}
+	reservation := &fakeReservation{}
+	dispatcher := worker.NewDispatcher(cfg, inventory, reservation, testLogger(), testMetrics)
+
+	events := []*handler.Event{
+		newEvent("evt-1", handler.EventTypeReservationExpired, map[string]interface{}{
+			"reservation_id": "rsv-1", "event_id": "concert-1", "qty": 2, "seat_ids": []string{"A1", "A2"},
+		}),
+		newEvent("evt-2", handler.EventTypePaymentApproved, map[string]interface{}{
+			"reservation_id": "rsv-2", "payment_intent_id": "pay-1", "amount": 1000,
+			"event_id": "concert-1", "qty": 1, "seat_ids": []string{"B1"},
+		}),
+		newEvent("evt-3", handler.EventTypePaymentFailed, map[string]interface{}{
+			"reservation_id": "rsv-3", "payment_intent_id": "pay-2", "amount": 1000,
+			"event_id": "concert-1", "qty": 1, "seat_ids": []string{"C1"},
+		}),
+	}
+
+	for _, event := range events {
+		if err := dispatcher.HandleEvent(context.Background(), event, 1); err != nil {
+			t.Errorf("HandleEvent(%s) error = %v", event.Type, err)
+		}
+	}
+
+	if n := inventory.calls(); n != 0 {
+		t.Errorf("Expected no inventory calls in dry-run mode, got %d", n)
+	}
+	if n := reservation.calls(); n != 0 {
+		t.Errorf("Expected no reservation updates in dry-run mode, got %d", n)
+	}
+}
+
+func TestDispatcher_PerTypeConcurrency(t *testing.T) {
+	cfg := &config.Config{
+		WorkerConcurrency:  4,
+		MaxRetries:         1,
+		BackoffBaseMS:      1,
+		ConcurrencyExpired: 2,
+	}
+
+	inventory := &fakeInventory{releaseBlock: make(chan struct{})}
+	defer close(inventory.releaseBlock)
+	dispatcher := worker.NewDispatcher(cfg, inventory, &fakeReservation{}, testLogger(), testMetrics)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := dispatcher.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer dispatcher.Stop()
+	defer cancel()
+
+	// Fill the expiry cap and its queue with events whose inventory calls never complete
+	events := dispatcher.GetEventsChan()
+	for i := 0; i < 4; i++ {
+		events <- newEvent(fmt.Sprintf("expired-%d", i), handler.EventTypeReservationExpired, map[string]interface{}{
+			"reservation_id": fmt.Sprintf("rsv-%d", i), "event_id": "concert-1", "qty": 1, "seat_ids": []string{"A1"},
+		})
+	}
+
+	events <- newEvent("approved-1", handler.EventTypePaymentApproved, map[string]interface{}{
+		"reservation_id": "rsv-approved", "payment_intent_id": "pay-1", "amount": 1000,
+		"event_id": "concert-1", "qty": 1, "seat_ids": []string{"B1"},
+	})
+
+	waitFor(t, time.Second, func() bool { return inventory.commitCount() == 1 })
+}
+
+func TestDispatcher_PerTypeQueueBackpressure(t *testing.T) {
+	cfg := &config.Config{
+		WorkerConcurrency:  4,
+		MaxRetries:         1,
+		BackoffBaseMS:      1,
+		ConcurrencyExpired: 1,
+	}
+
+	inventory := &fakeInventory{releaseBlock: make(chan struct{})}
+	dispatcher := worker.NewDispatcher(cfg, inventory, &fakeReservation{}, testLogger(), testMetrics)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// One event handled, one queued for the slot, one held by the blocked dispatch loop
+	events := dispatcher.GetEventsChan()
+	for i := 0; i < 5; i++ {
+		events <- newEvent(fmt.Sprintf("expired-%d", i), handler.EventTypeReservationExpired, map[string]interface{}{
+			"reservation_id": fmt.Sprintf("rsv-%d", i), "event_id": "concert-1", "qty": 1, "seat_ids": []string{"A1"},
+		})
+	}
+	if err := dispatcher.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	waitFor(t, time.Second, func() bool { return len(events) == 2 })
+	time.Sleep(50 * time.Millisecond)
+	if n := len(events); n != 2 {
+		t.Errorf("Expected the dispatcher to stop taking events of a full type queue, %d left buffered", n)
+	}
+	if free := dispatcher.FreeWorkers(); free != 0 {
+		t.Errorf("Expected queued and buffered events to take the free workers, got %d free", free)
+	}
+
+	close(inventory.releaseBlock)
+	waitFor(t, time.Second, func() bool { return len(events) == 0 })
+	dispatcher.Stop()
+}
+
+func TestDispatcher_StopDrainsBufferedEvents(t *testing.T) {
+	cfg := &config.Config{
+		WorkerConcurrency: 1,
+		MaxRetries:        1,
+		BackoffBaseMS:     1,
+	}
+
+	reservation := &fakeReservation{}
+	dispatcher := worker.NewDispatcher(cfg, &fakeInventory{}, reservation, testLogger(), testMetrics)
+
+	// Buffer events before the dispatcher starts so they are pending at Stop
+	events := dispatcher.GetEventsChan()
+	for i := 0; i < 2; i++ {
+		events <- newEvent(fmt.Sprintf("expired-%d", i), handler.EventTypeReservationExpired, map[string]interface{}{
+			"reservation_id": fmt.Sprintf("rsv-%d", i), "event_id": "concert-1", "qty": 1, "seat_ids": []string{"A1"},
+		})
+	}
+
+	if err := dispatcher.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	dispatcher.Stop()
+
+	if n := reservation.calls(); n != 2 {
+		t.Errorf("Expected buffered events to be handled before Stop returns, got %d updates", n)
+	}
+}
+
+// flakyInventory fails the first ReleaseHold call and records the processing ID of every call
+type flakyInventory struct {
+	fakeInventory
+	processingIDs []string
+}
+
+func (f *flakyInventory) ReleaseHold(ctx context.Context, req *reservationv1.ReleaseHoldRequest) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.processingIDs = append(f.processingIDs, observability.ProcessingID(ctx))
+	if len(f.processingIDs) == 1 {
+		return errors.New("unavailable")
+	}
+	return nil
+}
+
+func TestDispatcher_ProcessingIDSpansRetries(t *testing.T) {
+	cfg := &config.Config{
+		WorkerConcurrency: 1,
+		MaxRetries:        3,
+		BackoffBaseMS:     1,
+	}
+
+	inventory := &flakyInventory{}
+	dispatcher := worker.NewDispatcher(cfg, inventory, &fakeReservation{}, testLogger(), testMetrics)
+
+	event := newEvent("evt-1", handler.EventTypeReservationExpired, map[string]interface{}{
+		"reservation_id": "rsv-1", "event_id": "concert-1", "qty": 1, "seat_ids": []string{"A1"},
+	})
+	if err := dispatcher.HandleEvent(context.Background(), event, 1); err != nil {
+		t.Fatalf("HandleEvent() error = %v", err)
+	}
+
+	ids := inventory.processingIDs
+	if len(ids) != 2 {
+		t.Fatalf("Expected 2 attempts, got %d", len(ids))
+	}
+	if ids[0] == "" || ids[0] != ids[1] {
+		t.Errorf("Expected the same non-empty processing ID across retries, got %v", ids)
+	}
+}
+
+func TestDispatcher_RetriesDoNotBlockWorkers(t *testing.T) {
+	cfg := &config.Config{
+		WorkerConcurrency: 1,
+		MaxRetries:        3,
+		BackoffBaseMS:     100,
+		RetryQueueSize:    10,
+	}
+
+	inventory := &fakeInventory{releaseErr: errors.New("unavailable")}
+	dispatcher := worker.NewDispatcher(cfg, inventory, &fakeReservation{}, testLogger(), testMetrics)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := dispatcher.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	// Every expiry fails and backs off for at least 200ms
+	events := dispatcher.GetEventsChan()
+	for i := 0; i < 5; i++ {
+		events <- newEvent(fmt.Sprintf("expired-%d", i), handler.EventTypeReservationExpired, map[string]interface{}{
+			"reservation_id": fmt.Sprintf("rsv-%d", i), "event_id": "concert-1", "qty": 1, "seat_ids": []string{"A1"},
+		})
+	}
+	events <- newEvent("approved-1", handler.EventTypePaymentApproved, map[string]interface{}{
+		"reservation_id": "rsv-approved", "payment_intent_id": "pay-1", "amount": 1000,
+		"event_id": "concert-1", "qty": 1, "seat_ids": []string{"B1"},
+	})
+
+	// The single worker is free during backoff, so the healthy event goes through first
+	waitFor(t, 150*time.Millisecond, func() bool { return inventory.commitCount() == 1 })
+	if n := inventory.releaseCount(); n != 5 {
+		t.Errorf("Expected only first attempts before any backoff elapsed, got %d releases", n)
+	}
+
+	dispatcher.Stop()
+}
+
+func TestDispatcher_StopWaitsForScheduledRetries(t *testing.T) {
+	cfg := &config.Config{
+		WorkerConcurrency: 2,
+		MaxRetries:        3,
+		BackoffBaseMS:     10,
+		RetryQueueSize:    10,
+	}
+
+	inventory := &fakeInventory{releaseErr: errors.New("unavailable")}
+	dispatcher := worker.NewDispatcher(cfg, inventory, &fakeReservation{}, testLogger(), testMetrics)
+
+	if err := dispatcher.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	events := dispatcher.GetEventsChan()
+	for i := 0; i < 3; i++ {
+		events <- newEvent(fmt.Sprintf("expired-%d", i), handler.EventTypeReservationExpired, map[string]interface{}{
+			"reservation_id": fmt.Sprintf("rsv-%d", i), "event_id": "concert-1", "qty": 1, "seat_ids": []string{"A1"},
+		})
+	}
+	dispatcher.Stop()
+
+	// Each event runs exactly MaxRetries attempts before Stop returns
+	if n := inventory.releaseCount(); n != 3*cfg.MaxRetries {
+		t.Errorf("Expected %d attempts, got %d", 3*cfg.MaxRetries, n)
+	}
+}
+
+func TestDispatcher_RecordsBackoffWait(t *testing.T) {
+	tests := []struct {
+		name           string
+		retryQueueSize int
+	}{
+		{"delay queue", 10},
+		{"worker sleep", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				WorkerConcurrency: 1,
+				MaxRetries:        3,
+				BackoffBaseMS:     10,
+				RetryQueueSize:    tt.retryQueueSize,
+			}
+
+			inventory := &fakeInventory{releaseErr: errors.New("unavailable")}
+			dispatcher := worker.NewDispatcher(cfg, inventory, &fakeReservation{}, testLogger(), testMetrics)
+
+			eventType := handler.EventTypeReservationExpired
+			backoff := testMetrics.BackoffWait.WithLabelValues(eventType).(prometheus.Histogram)
+			countBefore, sumBefore := histogramSample(t, backoff)
+			retriesBefore := testutil.ToFloat64(testMetrics.RetriesTotal.WithLabelValues(eventType))
+
+			if err := dispatcher.Start(context.Background()); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+			dispatcher.GetEventsChan() <- newEvent("evt-1", eventType, map[string]interface{}{
+				"reservation_id": "rsv-1", "event_id": "concert-1", "qty": 1, "seat_ids": []string{"A1"},
+			})
+			dispatcher.Stop()
+
+			// Attempts 1 and 2 back off for 20ms and 40ms before the final attempt
+			count, sum := histogramSample(t, backoff)
+			if got := count - countBefore; got != 2 {
+				t.Errorf("Expected 2 backoff observations, got %d", got)
+			}
+			if got := sum - sumBefore; got < 0.06 || got > 0.5 {
+				t.Errorf("Expected about 60ms of backoff wait, got %.3fs", got)
+			}
+			if got := testutil.ToFloat64(testMetrics.RetriesTotal.WithLabelValues(eventType)) - retriesBefore; got != 2 {
+				t.Errorf("Expected 2 retries counted, got %v", got)
+			}
+		})
+	}
+}
+
+// panickingInventory panics on the first ReleaseHold call, then succeeds
+type panickingInventory struct {
+	fakeInventory
+	panicked bool
+}
+
+func (f *panickingInventory) ReleaseHold(ctx context.Context, req *reservationv1.ReleaseHoldRequest) error {
+	f.mu.Lock()
+	if !f.panicked {
+		f.panicked = true
+		f.mu.Unlock()
+		panic("inventory client bug")
+	}
+	f.mu.Unlock()
+	return f.fakeInventory.ReleaseHold(ctx, req)
+}
+
+func TestDispatcher_RecoversHandlerPanic(t *testing.T) {
+	cfg := &config.Config{
+		WorkerConcurrency: 1,
+		MaxRetries:        3,
+		BackoffBaseMS:     1,
+	}
+
+	inventory := &panickingInventory{}
+	reservation := &fakeReservation{}
+	dispatcher := worker.NewDispatcher(cfg, inventory, reservation, testLogger(), testMetrics)
+
+	event := newEvent("evt-1", handler.EventTypeReservationExpired, map[string]interface{}{
+		"reservation_id": "rsv-1", "event_id": "concert-1", "qty": 1, "seat_ids": []string{"A1"},
+	})
+	if err := dispatcher.HandleEvent(context.Background(), event, 1); err != nil {
+		t.Fatalf("Expected panic to be retried to success, got %v", err)
+	}
+	if n := reservation.calls(); n != 1 {
+		t.Errorf("Expected the retry to complete the event, got %d updates", n)
+	}
+}
+
+func TestDispatcher_PermanentErrorsAreNotRetried(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		wantAttempts int
+	}{
+		{"invalid argument fails once", status.Error(codes.InvalidArgument, "unknown seat"), 1},
+		{"unavailable is retried", status.Error(codes.Unavailable, "transport is closing"), 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				WorkerConcurrency: 1,
+				MaxRetries:        3,
+				BackoffBaseMS:     1,
+			}
+
+			inventory := &fakeInventory{releaseErr: tt.err}
+			dispatcher := worker.NewDispatcher(cfg, inventory, &fakeReservation{}, testLogger(), testMetrics)
+
+			event := newEvent("evt-1", handler.EventTypeReservationExpired, map[string]interface{}{
+				"reservation_id": "rsv-1", "event_id": "concert-1", "qty": 1, "seat_ids": []string{"A1"},
+			})
+			if err := dispatcher.HandleEvent(context.Background(), event, 1); err == nil {
+				t.Fatal("Expected HandleEvent to fail")
+			}
+			if n := inventory.releaseCount(); n != tt.wantAttempts {
+				t.Errorf("Expected %d attempts, got %d", tt.wantAttempts, n)
+			}
+		})
+	}
+}
+
+func TestDispatcher_AcksOnConclusion(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantAck       int
+		wantNack      int
+		wantRetryable bool
+	}{
+		{"success acks", nil, 1, 0, false},
+		{"permanent failure nacks", status.Error(codes.InvalidArgument, "unknown seat"), 0, 1, false},
+		{"retries exhausted nacks once", status.Error(codes.Unavailable, "transport is closing"), 0, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				WorkerConcurrency: 1,
+				MaxRetries:        3,
+				BackoffBaseMS:     1,
+			}
+			dispatcher := worker.NewDispatcher(cfg, &fakeInventory{releaseErr: tt.err}, &fakeReservation{}, testLogger(), testMetrics)
+
+			var acks, nacks int
+			var retryable bool
+			event := newEvent("evt-1", handler.EventTypeReservationExpired, map[string]interface{}{
+				"reservation_id": "rsv-1", "event_id": "concert-1", "qty": 1, "seat_ids": []string{"A1"},
+			})
+			event.SetAckFuncs(func() { acks++ }, func(r bool) { nacks++; retryable = r })
+
+			dispatcher.HandleEvent(context.Background(), event, 1)
+
+			if acks != tt.wantAck || nacks != tt.wantNack {
+				t.Errorf("Expected %d acks and %d nacks, got %d and %d", tt.wantAck, tt.wantNack, acks, nacks)
+			}
+			if retryable != tt.wantRetryable {
+				t.Errorf("Expected nack retryable = %v, got %v", tt.wantRetryable, retryable)
+			}
+		})
+	}
+}
+
+func TestDispatcher_DroppedEventsAreRedeliverable(t *testing.T) {
+	cfg := &config.Config{
+		WorkerConcurrency:  2,
+		MaxRetries:         1,
+		BackoffBaseMS:      1,
+		ConcurrencyExpired: 1,
+	}
+
+	inventory := &fakeInventory{releaseBlock: make(chan struct{})}
+	defer close(inventory.releaseBlock)
+	dispatcher := worker.NewDispatcher(cfg, inventory, &fakeReservation{}, testLogger(), testMetrics)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := dispatcher.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	// The first event holds the expiry slot, so the second waits for it until ctx ends
+	events := dispatcher.GetEventsChan()
+	nacked := make(chan bool, 1)
+	for i := 0; i < 2; i++ {
+		event := newEvent(fmt.Sprintf("expired-%d", i), handler.EventTypeReservationExpired, map[string]interface{}{
+			"reservation_id": fmt.Sprintf("rsv-%d", i), "event_id": "concert-1", "qty": 1, "seat_ids": []string{"A1"},
+		})
+		if i == 1 {
+			event.SetAckFuncs(func() { t.Error("Expected the dropped event not to be acked") }, func(r bool) { nacked <- r })
+		}
+		events <- event
+	}
+	waitFor(t, time.Second, func() bool { return len(dispatcher.InflightEvents()) == 1 && len(events) == 0 })
+
+	cancel()
+	dispatcher.Stop()
+
+	select {
+	case retryable := <-nacked:
+		if !retryable {
+			t.Error("Expected the dropped event to be nacked as retryable")
+		}
+	default:
+		t.Fatal("Expected the dropped event to be nacked")
+	}
+}
+
+// drainInventory holds releases for rsv-slow until release is closed and fails every other reservation
+type drainInventory struct {
+	fakeInventory
+	release chan struct{}
+	blocked chan struct{}
+}
+
+func (f *drainInventory) ReleaseHold(ctx context.Context, req *reservationv1.ReleaseHoldRequest) error {
+	if req.ReservationId != "rsv-slow" {
+		return f.fakeInventory.ReleaseHold(ctx, req)
+	}
+	close(f.blocked)
+	<-f.release
+	return nil
+}
+
+func TestDispatcher_ShutdownMetrics(t *testing.T) {
+	cfg := &config.Config{
+		WorkerConcurrency: 2,
+		MaxRetries:        3,
+		BackoffBaseMS:     10000,
+		RetryQueueSize:    10,
+	}
+
+	inventory := &drainInventory{
+		fakeInventory: fakeInventory{releaseErr: status.Error(codes.Unavailable, "connection refused")},
+		release:       make(chan struct{}),
+		blocked:       make(chan struct{}),
+	}
+	reservation := &fakeReservation{}
+	dispatcher := worker.NewDispatcher(cfg, inventory, reservation, testLogger(), testMetrics)
+
+	inflightBefore := testutil.ToFloat64(testMetrics.ShutdownInflight)
+	drainedBefore := testutil.ToFloat64(testMetrics.ShutdownDrained)
+	droppedBefore := testutil.ToFloat64(testMetrics.ShutdownDropped)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := dispatcher.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	// One event is mid-handler, the other waits out a long backoff
+	events := dispatcher.GetEventsChan()
+	for _, id := range []string{"rsv-slow", "rsv-retry"} {
+		events <- newEvent(id, handler.EventTypeReservationExpired, map[string]interface{}{
+			"reservation_id": id, "event_id": "concert-1", "qty": 1, "seat_ids": []string{"A1"},
+		})
+	}
+	<-inventory.blocked
+	waitFor(t, time.Second, func() bool { return inventory.releaseCount() == 1 })
+
+	stopped := make(chan struct{})
+	go func() {
+		dispatcher.Stop()
+		close(stopped)
+	}()
+
+	// Let Stop begin draining, finish the slow event, then force the drain to end
+	time.Sleep(50 * time.Millisecond)
+	close(inventory.release)
+	waitFor(t, time.Second, func() bool { return reservation.calls() == 1 })
+	cancel()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Stop() did not return after the context was cancelled")
+	}
+
+	if got := testutil.ToFloat64(testMetrics.ShutdownInflight) - inflightBefore; got != 2 {
+		t.Errorf("worker_shutdown_inflight increased by %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(testMetrics.ShutdownDrained) - drainedBefore; got != 1 {
+		t.Errorf("worker_shutdown_drained increased by %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(testMetrics.ShutdownDropped) - droppedBefore; got != 1 {
+		t.Errorf("worker_shutdown_dropped increased by %v, want 1", got)
+	}
+}
+
+// overlapInventory records the most inventory calls in progress at once
+type overlapInventory struct {
+	mu        sync.Mutex
+	active    int
+	maxActive int
+}
+
+func (f *overlapInventory) call() error {
+	f.mu.Lock()
+	f.active++
+	if f.active > f.maxActive {
+		f.maxActive = f.active
+	}
+	f.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	f.mu.Lock()
+	f.active--
+	f.mu.Unlock()
+	return nil
+}
+
+func (f *overlapInventory) ReleaseHold(context.Context, *reservationv1.ReleaseHoldRequest) error {
+	return f.call()
+}
+
+func (f *overlapInventory) CommitReservation(context.Context, *reservationv1.CommitReservationRequest) error {
+	return f.call()
+}
+
+func (f *overlapInventory) ReserveSeat(context.Context, *reservationv1.ReserveSeatRequest) error {
+	return f.call()
+}
+
+func TestDispatcher_SerializesEventsPerReservation(t *testing.T) {
+	tests := []struct {
+		name          string
+		reservations  []string
+		wantMaxActive int
+	}{
+		{"same reservation", []string{"rsv-1", "rsv-1"}, 1},
+		{"different reservations", []string{"rsv-1", "rsv-2"}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				WorkerConcurrency:         2,
+				MaxRetries:                1,
+				BackoffBaseMS:             1,
+				ConcurrencyPerReservation: 1,
+			}
+
+			inventory := &overlapInventory{}
+			reservation := &fakeReservation{}
+			dispatcher := worker.NewDispatcher(cfg, inventory, reservation, testLogger(), testMetrics)
+
+			// A late expiry and an approval racing on the reservation's status
+			events := dispatcher.GetEventsChan()
+			events <- newEvent("expired-1", handler.EventTypeReservationExpired, map[string]interface{}{
+				"reservation_id": tt.reservations[0], "event_id": "concert-1", "qty": 1, "seat_ids": []string{"A1"},
+			})
+			events <- newEvent("approved-1", handler.EventTypePaymentApproved, map[string]interface{}{
+				"reservation_id": tt.reservations[1], "payment_intent_id": "pay-1", "amount": 1000,
+				"event_id": "concert-1", "qty": 1, "seat_ids": []string{"A1"},
+			})
+
+			if err := dispatcher.Start(context.Background()); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+			dispatcher.Stop()
+
+			if n := reservation.calls(); n != 2 {
+				t.Fatalf("Expected both events to update the reservation, got %d updates", n)
+			}
+			if inventory.maxActive != tt.wantMaxActive {
+				t.Errorf("Expected at most %d handlers in flight, got %d", tt.wantMaxActive, inventory.maxActive)
+			}
+		})
+	}
+}
+
+func TestDispatcher_MaxConcurrentDownstream(t *testing.T) {
+	cfg := &config.Config{
+		WorkerConcurrency:       8,
+		MaxRetries:              1,
+		BackoffBaseMS:           1,
+		MaxConcurrentDownstream: 2,
+	}
+
+	inventory := &overlapInventory{}
+	reservation := &fakeReservation{}
+	dispatcher := worker.NewDispatcher(cfg, inventory, reservation, testLogger(), testMetrics)
+
+	events := dispatcher.GetEventsChan()
+	for i := 0; i < 12; i++ {
+		events <- newEvent(fmt.Sprintf("expired-%d", i), handler.EventTypeReservationExpired, map[string]interface{}{
+			"reservation_id": fmt.Sprintf("rsv-%d", i), "event_id": "concert-1", "qty": 1, "seat_ids": []string{"A1"},
+		})
+	}
+
+	if err := dispatcher.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	dispatcher.Stop()
+
+	if n := reservation.calls(); n != 12 {
+		t.Fatalf("Expected every event to be handled, got %d updates", n)
+	}
+	if inventory.maxActive != 2 {
+		t.Errorf("Expected at most 2 downstream calls in flight, got %d", inventory.maxActive)
+	}
+}
+
+func TestDispatcher_SuccessLogSampling(t *testing.T) {
+	tests := []struct {
+		name       string
+		releaseErr error
+		wantInfo   bool
+		wantErrors bool
+	}{
+		{name: "success is silent", wantInfo: false, wantErrors: false},
+		{name: "failure logs its full trail", releaseErr: errors.New("unavailable"), wantInfo: true, wantErrors: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			logger := (&observability.Logger{Logger: zap.New(core)}).WithSuccessLogSampling()
+
+			cfg := &config.Config{WorkerConcurrency: 1, MaxRetries: 1, BackoffBaseMS: 1, LogSuccessSampleRate: 0}
+			inventory := &fakeInventory{releaseErr: tt.releaseErr}
+			dispatcher := worker.NewDispatcher(cfg, inventory, &fakeReservation{}, logger, testMetrics)
+
+			dispatcher.HandleEvent(context.Background(), newEvent("evt-1", handler.EventTypeReservationExpired, map[string]interface{}{
+				"reservation_id": "rsv-1", "event_id": "concert-1", "qty": 1, "seat_ids": []string{"A1"},
+			}), 1)
+
+			info := logs.FilterLevelExact(zapcore.InfoLevel).Len()
+			errorLines := logs.FilterLevelExact(zapcore.ErrorLevel).Len()
+			if (info > 0) != tt.wantInfo {
+				t.Errorf("Expected info lines: %v, got %d", tt.wantInfo, info)
+			}
+			if (errorLines > 0) != tt.wantErrors {
+				t.Errorf("Expected error lines: %v, got %d", tt.wantErrors, errorLines)
+			}
+		})
+	}
+}
+
+func TestDispatcher_IgnoredEventTypes(t *testing.T) {
+	tests := []struct {
+		name      string
+		eventType string
+		outcome   string
+		wantErr   bool
+		wantAck   int
+		wantNack  int
+	}{
+		{"ignored type is acked", "order.created", observability.OutcomeIgnored, false, 1, 0},
+		{"unknown type fails", "order.shipped", observability.OutcomeInvalidPayload, true, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				WorkerConcurrency: 1,
+				MaxRetries:        1,
+				BackoffBaseMS:     1,
+				IgnoredEventTypes: []string{"order.created"},
+			}
+			dispatcher := worker.NewDispatcher(cfg, &fakeInventory{}, &fakeReservation{}, testLogger(), testMetrics)
+
+			counter := testMetrics.EventsTotal.WithLabelValues(tt.eventType, tt.outcome, observability.CategoryNone)
+			before := testutil.ToFloat64(counter)
+
+			var acks, nacks int
+			event := newEvent("evt-1", tt.eventType, map[string]interface{}{"order_id": "ord-1"})
+			event.SetAckFuncs(func() { acks++ }, func(bool) { nacks++ })
+
+			err := dispatcher.HandleEvent(context.Background(), event, 1)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("HandleEvent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if acks != tt.wantAck || nacks != tt.wantNack {
+				t.Errorf("Expected %d acks and %d nacks, got %d and %d", tt.wantAck, tt.wantNack, acks, nacks)
+			}
+			if got := testutil.ToFloat64(counter) - before; got != 1 {
+				t.Errorf("Expected one %s event recorded, got %v", tt.outcome, got)
+			}
+		})
+	}
+}
+
+func TestDispatcher_RoutesCancelledEvents(t *testing.T) {
+	cfg := &config.Config{WorkerConcurrency: 1, MaxRetries: 1, BackoffBaseMS: 1}
+	inventory := &fakeInventory{}
+	reservation := &fakeReservation{}
+	dispatcher := worker.NewDispatcher(cfg, inventory, reservation, testLogger(), testMetrics)
+
+	duration := testMetrics.ProcessingDuration.WithLabelValues("cancelled", observability.OutcomeSuccess).(prometheus.Histogram)
+	before, _ := histogramSample(t, duration)
+
+	event := newEvent("evt-1", handler.EventTypeReservationCancelled, map[string]interface{}{
+		"reservation_id": "rsv-1", "event_id": "concert-1", "quantity": 1, "seat_ids": []string{"A1"},
+		"user_id": "user-1", "reason": "changed_plans",
+	})
+	if err := dispatcher.HandleEvent(context.Background(), event, 1); err != nil {
+		t.Fatalf("HandleEvent() error = %v", err)
+	}
+
+	if len(reservation.updates) != 1 || reservation.updates[0].Status != client.StatusCancelled {
+		t.Errorf("Expected one CANCELLED status update, got %+v", reservation.updates)
+	}
+	if n := inventory.releaseCount(); n != 1 {
+		t.Errorf("Expected one hold release, got %d", n)
+	}
+	if after, _ := histogramSample(t, duration); after != before+1 {
+		t.Errorf("Expected one cancelled processing duration sample, got %d", after-before)
+	}
+}
+
+func TestDispatcher_MaxEventAge(t *testing.T) {
+	expired := map[string]interface{}{
+		"reservation_id": "rsv-1", "event_id": "concert-1", "qty": 1, "seat_ids": []string{"A1"},
+	}
+	approved := map[string]interface{}{
+		"reservation_id": "rsv-2", "payment_intent_id": "pay-1", "amount": 1000,
+		"event_id": "concert-1", "qty": 1, "seat_ids": []string{"B1"},
+	}
+
+	tests := []struct {
+		name      string
+		eventType string
+		detail    interface{}
+		age       time.Duration
+		outcome   string
+		wantCalls int
+	}{
+		{"stale expired event is dropped", handler.EventTypeReservationExpired, expired, 2 * time.Hour, observability.OutcomeStaleDropped, 0},
+		{"fresh expired event is handled", handler.EventTypeReservationExpired, expired, time.Minute, observability.OutcomeSuccess, 1},
+		{"stale approval is handled", handler.EventTypePaymentApproved, approved, 2 * time.Hour, observability.OutcomeSuccess, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{WorkerConcurrency: 1, MaxRetries: 1, BackoffBaseMS: 1, MaxEventAgeSeconds: 3600}
+			inventory := &fakeInventory{}
+			dispatcher := worker.NewDispatcher(cfg, inventory, &fakeReservation{}, testLogger(), testMetrics)
+
+			counter := testMetrics.EventsTotal.WithLabelValues(tt.eventType, tt.outcome, observability.CategoryNone)
+			before := testutil.ToFloat64(counter)
+
+			var acks int
+			event := newEvent("evt-1", tt.eventType, tt.detail)
+			event.Time = time.Now().Add(-tt.age)
+			event.SetAckFuncs(func() { acks++ }, nil)
+
+			if err := dispatcher.HandleEvent(context.Background(), event, 1); err != nil {
+				t.Fatalf("HandleEvent() error = %v", err)
+			}
+			if acks != 1 {
+				t.Errorf("Expected the event to be acked once, got %d", acks)
+			}
+			if got := inventory.calls(); got != tt.wantCalls {
+				t.Errorf("Expected %d inventory calls, got %d", tt.wantCalls, got)
+			}
+			if got := testutil.ToFloat64(counter) - before; got != 1 {
+				t.Errorf("Expected one %s event recorded, got %v", tt.outcome, got)
+			}
+		})
+	}
+}
+
+func TestDispatcher_InflightEvents(t *testing.T) {
+	cfg := &config.Config{WorkerConcurrency: 1, MaxRetries: 1, BackoffBaseMS: 1}
+	inventory := &fakeInventory{releaseBlock: make(chan struct{})}
+	dispatcher := worker.NewDispatcher(cfg, inventory, &fakeReservation{}, testLogger(), testMetrics)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		dispatcher.HandleEvent(context.Background(), newEvent("evt-1", handler.EventTypeReservationExpired, map[string]interface{}{
+			"reservation_id": "rsv-1", "event_id": "concert-1", "qty": 1, "seat_ids": []string{"A1"},
+		}), 1)
+	}()
+
+	waitFor(t, time.Second, func() bool { return len(dispatcher.InflightEvents()) == 1 })
+	if got := dispatcher.InflightEvents()[0]; got.ID != "evt-1" || got.Type != handler.EventTypeReservationExpired || got.Attempt != 1 {
+		t.Errorf("Unexpected in-flight event %+v", got)
+	}
+
+	close(inventory.releaseBlock)
+	<-done
+	if got := dispatcher.InflightEvents(); len(got) != 0 {
+		t.Errorf("Expected no in-flight events once handled, got %+v", got)
+	}
+}
+
+// deadlineInventory records the deadline of the context each release runs under
+type deadlineInventory struct {
+	fakeInventory
+	deadline    time.Time
+	hasDeadline bool
+}
+
+func (f *deadlineInventory) ReleaseHold(ctx context.Context, req *reservationv1.ReleaseHoldRequest) error {
+	f.deadline, f.hasDeadline = ctx.Deadline()
+	return f.fakeInventory.ReleaseHold(ctx, req)
+}
+
+func TestDispatcher_VisibilityDeadline(t *testing.T) {
+	tests := []struct {
+		name       string
+		visibleIn  time.Duration // 0 = no visibility deadline
+		wantBounds bool
+	}{
+		{"handler context ends with the visibility timeout", 25 * time.Second, true},
+		{"no visibility deadline", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{WorkerConcurrency: 1, MaxRetries: 1, BackoffBaseMS: 1}
+			inventory := &deadlineInventory{}
+			dispatcher := worker.NewDispatcher(cfg, inventory, &fakeReservation{}, testLogger(), testMetrics)
+
+			event := newEvent("evt-1", handler.EventTypeReservationExpired, map[string]interface{}{
+				"reservation_id": "rsv-1", "event_id": "concert-1", "qty": 1, "seat_ids": []string{"A1"},
+			})
+			var deadline time.Time
+			if tt.visibleIn > 0 {
+				deadline = time.Now().Add(tt.visibleIn)
+				event.SetVisibilityDeadline(deadline)
+			}
+
+			if err := dispatcher.HandleEvent(context.Background(), event, 1); err != nil {
+				t.Fatalf("HandleEvent() error = %v", err)
+			}
+			if inventory.hasDeadline != tt.wantBounds {
+				t.Fatalf("Expected handler context deadline set = %v, got %v", tt.wantBounds, inventory.hasDeadline)
+			}
+			if tt.wantBounds && !inventory.deadline.Equal(deadline) {
+				t.Errorf("Expected handler deadline %v, got %v", deadline, inventory.deadline)
+			}
+		})
+	}
+}
+
+func TestDispatcher_LogsCancelReason(t *testing.T) {
+	const after = 20 * time.Millisecond
+
+	tests := []struct {
+		name       string
+		timeoutMS  int                                          // HANDLER_TIMEOUT_MS
+		visibility bool                                         // Set a visibility deadline `after` from now
+		parent     func() (context.Context, context.CancelFunc) // Context the worker handles the event in
+		wantReason string
+	}{
+		{name: "handler timeout", timeoutMS: int(after / time.Millisecond), wantReason: observability.CancelReasonHandlerTimeout},
+		{name: "visibility deadline", visibility: true, wantReason: observability.CancelReasonVisibilityDeadline},
+		{name: "shutdown", parent: func() (context.Context, context.CancelFunc) {
+			ctx, cancel := context.WithCancelCause(context.Background())
+			time.AfterFunc(after, func() { cancel(observability.CancelCause(observability.CancelReasonShutdown)) })
+			return ctx, func() { cancel(nil) }
+		}, wantReason: observability.CancelReasonShutdown},
+		{name: "upstream deadline", parent: func() (context.Context, context.CancelFunc) {
+			return context.WithTimeout(context.Background(), after)
+		}, wantReason: observability.CancelReasonDeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.ErrorLevel)
+			cfg := &config.Config{WorkerConcurrency: 1, MaxRetries: 1, BackoffBaseMS: 1, HandlerTimeoutMS: tt.timeoutMS}
+			// The release blocks until the handler context ends
+			inventory := &fakeInventory{releaseBlock: make(chan struct{})}
+			dispatcher := worker.NewDispatcher(cfg, inventory, &fakeReservation{}, &observability.Logger{Logger: zap.New(core)}, testMetrics)
+
+			event := newEvent("evt-1", handler.EventTypeReservationExpired, map[string]interface{}{
+				"reservation_id": "rsv-1", "event_id": "concert-1", "qty": 1, "seat_ids": []string{"A1"},
+			})
+			if tt.visibility {
+				event.SetVisibilityDeadline(time.Now().Add(after))
+			}
+			ctx, cancel := context.Background(), context.CancelFunc(func() {})
+			if tt.parent != nil {
+				ctx, cancel = tt.parent()
+			}
+			defer cancel()
+
+			if err := dispatcher.HandleEvent(ctx, event, 1); err == nil {
+				t.Fatal("Expected HandleEvent() to fail once its context ended")
+			}
+
+			failures := logs.FilterMessageSnippet("Event processing failed").All()
+			if len(failures) != 1 {
+				t.Fatalf("Expected one failure log line, got %d", len(failures))
+			}
+			if got := failures[0].ContextMap()["cancel_reason"]; got != tt.wantReason {
+				t.Errorf("cancel_reason = %v, want %s", got, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestDispatcher_Process(t *testing.T) {
+	tests := []struct {
+		name         string
+		inventory    handler.InventoryService
+		age          time.Duration
+		wantOutcome  string
+		wantAttempts int
+		wantErr      bool
+	}{
+		{"success", &fakeInventory{}, time.Minute, observability.OutcomeSuccess, 1, false},
+		{"retry then success", &flakyInventory{}, time.Minute, observability.OutcomeSuccess, 2, false},
+		{"permanent failure", &fakeInventory{releaseErr: status.Error(codes.InvalidArgument, "unknown seat")}, time.Minute, observability.OutcomeFailed, 1, true},
+		{"event past the max age is handled", &fakeInventory{}, 2 * time.Hour, observability.OutcomeSuccess, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				WorkerConcurrency:  1,
+				MaxRetries:         3,
+				BackoffBaseMS:      1,
+				MaxEventAgeSeconds: 3600,
+			}
+			dispatcher := worker.NewDispatcher(cfg, tt.inventory, &fakeReservation{}, testLogger(), testMetrics)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if err := dispatcher.Start(ctx); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+			defer dispatcher.Stop()
+
+			event := newEvent("evt-1", handler.EventTypeReservationExpired, map[string]interface{}{
+				"reservation_id": "rsv-1", "event_id": "concert-1", "qty": 1, "seat_ids": []string{"A1"},
+			})
+			event.Time = time.Now().Add(-tt.age)
+			result := dispatcher.Process(ctx, event)
+
+			if result.Outcome != tt.wantOutcome || result.Attempts != tt.wantAttempts {
+				t.Errorf("Process() = %s after %d attempts, want %s after %d", result.Outcome, result.Attempts, tt.wantOutcome, tt.wantAttempts)
+			}
+			if (result.Err != nil) != tt.wantErr {
+				t.Errorf("Process() error = %v, wantErr %v", result.Err, tt.wantErr)
+			}
+			if result.Duration <= 0 {
+				t.Errorf("Expected a positive duration, got %v", result.Duration)
+			}
+		})
+	}
+}
+
+func TestDispatcher_HighPriorityFirst(t *testing.T) {
+	cfg := &config.Config{
+		WorkerConcurrency: 1,
+		MaxRetries:        1,
+		BackoffBaseMS:     1,
+		EventsBufferSize:  12, // Half is the high-priority channel, room for all 6
+		PriorityAttribute: "priority",
+		PriorityHighBurst: 2,
+	}
+	inventory := &fakeInventory{}
+	dispatcher := worker.NewDispatcher(cfg, inventory, &fakeReservation{}, testLogger(), testMetrics)
+
+	// Interleave priorities before any worker runs so every event is waiting at once
+	for _, id := range []string{"high-1", "normal-1", "high-2", "normal-2", "high-3", "high-4", "high-5", "high-6"} {
+		queue := dispatcher.GetEventsChan()
+		if strings.HasPrefix(id, "high") {
+			queue = dispatcher.GetHighPriorityChan()
+		}
+		queue <- newEvent(id, handler.EventTypeReservationExpired, map[string]interface{}{
+			"reservation_id": id, "event_id": "concert-1", "qty": 1, "seat_ids": []string{"A1"},
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := dispatcher.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	waitFor(t, time.Second, func() bool { return inventory.releaseCount() == 8 })
+	dispatcher.Stop()
+
+	var got []string
+	for _, req := range inventory.releases {
+		got = append(got, req.ReservationId)
+	}
+	// Two high-priority events in a row let one waiting normal event through
+	want := []string{"high-1", "high-2", "normal-1", "high-3", "high-4", "normal-2", "high-5", "high-6"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected dispatch order %v, got %v", want, got)
+	}
+}
+
+func TestDispatcher_EventsBufferSplit(t *testing.T) {
+	tests := []struct {
+		name     string
+		fair     bool
+		priority string
+		wantChan int
+		wantHigh int
+	}{
+		{"whole buffer", false, "", 20, 0},
+		{"fairness takes half the buffer", true, "", 10, 0},
+		{"priorities take half the buffer", false, "priority", 10, 10},
+		{"fairness takes half the normal share", true, "priority", 5, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{WorkerConcurrency: 1, EventsBufferSize: 20, DispatchFairness: tt.fair, PriorityAttribute: tt.priority}
+			dispatcher := worker.NewDispatcher(cfg, &fakeInventory{}, &fakeReservation{}, testLogger(), testMetrics)
+
+			if got := cap(dispatcher.GetEventsChan()); got != tt.wantChan {
+				t.Errorf("Expected an events channel of %d, got %d", tt.wantChan, got)
+			}
+			if got := cap(dispatcher.GetHighPriorityChan()); got != tt.wantHigh {
+				t.Errorf("Expected a high-priority channel of %d, got %d", tt.wantHigh, got)
+			}
+		})
+	}
+}
+
+func TestDispatcher_FairAcrossEventTypes(t *testing.T) {
+	tests := []struct {
+		name string
+		fair bool
+		want []string
+	}{
+		{"fairness interleaves types", true, []string{"expired-1", "failed-1", "expired-2", "failed-2", "expired-3"}},
+		{"arrival order", false, []string{"expired-1", "expired-2", "expired-3", "expired-4", "expired-5"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				WorkerConcurrency: 1,
+				MaxRetries:        1,
+				BackoffBaseMS:     1,
+				EventsBufferSize:  24, // Half is the events channel with fairness, room for all 12
+				DispatchFairness:  tt.fair,
+			}
+			inventory := &fakeInventory{}
+			dispatcher := worker.NewDispatcher(cfg, inventory, &fakeReservation{}, testLogger(), testMetrics)
+
+			// A backlog of expiries arrives ahead of two payment failures
+			events := dispatcher.GetEventsChan()
+			for i := 1; i <= 10; i++ {
+				id := fmt.Sprintf("expired-%d", i)
+				events <- newEvent(id, handler.EventTypeReservationExpired, map[string]interface{}{
+					"reservation_id": id, "event_id": "concert-1", "qty": 1, "seat_ids": []string{"A1"},
+				})
+			}
+			for i := 1; i <= 2; i++ {
+				id := fmt.Sprintf("failed-%d", i)
+				events <- newEvent(id, handler.EventTypePaymentFailed, map[string]interface{}{
+					"reservation_id": id, "payment_intent_id": "pay-" + id, "event_id": "concert-1", "qty": 1, "seat_ids": []string{"A1"},
+				})
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if err := dispatcher.Start(ctx); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+			waitFor(t, time.Second, func() bool { return inventory.releaseCount() == 12 })
+			dispatcher.Stop()
+
+			var got []string
+			for _, req := range inventory.releases[:len(tt.want)] {
+				got = append(got, req.ReservationId)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected dispatch order %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package worker
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// contentEncodingAttribute marks message bodies that producers compressed to fit SQS limits
+const contentEncodingAttribute = "Content-Encoding"
+
+// maxDecodedBodySize bounds decompressed bodies so a malformed payload cannot exhaust memory
+const maxDecodedBodySize = 10 << 20
+
+// contentTypeAttribute names the encoding of the event in the body; JSON when absent
+const (
+	contentTypeAttribute = "Content-Type"
+	contentTypeProtobuf  = "application/x-protobuf"
+)
+
+// isProtobufMessage reports whether the Content-Type attribute marks the body as a protobuf event
+func isProtobufMessage(message *types.Message) bool {
+	attr, ok := message.MessageAttributes[contentTypeAttribute]
+	if !ok || attr.StringValue == nil {
+		return false
+	}
+	mediaType, _, _ := strings.Cut(*attr.StringValue, ";")
+	return strings.EqualFold(strings.TrimSpace(mediaType), contentTypeProtobuf)
+}
+
+// decodeMessageBody returns the message body, base64-decoding and gunzipping it
+// when the Content-Encoding attribute is gzip. Protobuf bodies are base64-decoded;
+// other bodies are returned as is.
+func decodeMessageBody(message *types.Message) ([]byte, error) {
+	if message.Body == nil {
+		return nil, fmt.Errorf("message body is nil")
+	}
+	body := []byte(*message.Body)
+
+	attr, ok := message.MessageAttributes[contentEncodingAttribute]
+	if !ok || attr.StringValue == nil || *attr.StringValue == "" {
+		if isProtobufMessage(message) {
+			decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(*message.Body))
+			if err != nil {
+				return nil, fmt.Errorf("failed to base64-decode protobuf body: %w", err)
+			}
+			return decoded, nil
+		}
+		return body, nil
+	}
+
+	switch encoding := strings.ToLower(*attr.StringValue); encoding {
+	case "gzip":
+		compressed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(*message.Body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to base64-decode gzip body: %w", err)
+		}
+		reader, err := gzip.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open gzip body: %w", err)
+		}
+		defer reader.Close()
+
+		decoded, err := io.ReadAll(io.LimitReader(reader, maxDecodedBodySize+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to gunzip body: %w", err)
+		}
+		if len(decoded) > maxDecodedBodySize {
+			return nil, fmt.Errorf("decompressed body exceeds %d bytes", maxDecodedBodySize)
+		}
+		return decoded, nil
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+}
@@ -0,0 +1,73 @@
+package worker
+
+import (
+	"sync/atomic"
+
+	"github.com/traffic-tacos/reservation-worker/internal/handler"
+)
+
+// fairQueue holds received events in one FIFO per event type and hands them out
+// round-robin across types. Only the dispatch loop touches it; size may be read anywhere.
+type fairQueue struct {
+	limit  int
+	queues map[string][]*handler.Event
+	order  []string // Types with queued events, next to be served first
+	size   atomic.Int32
+}
+
+func newFairQueue(limit int) *fairQueue {
+	return &fairQueue{
+		limit:  max(limit, 1),
+		queues: make(map[string][]*handler.Event),
+	}
+}
+
+// fill moves events waiting in events into the queue until it holds limit events.
+// Events beyond that stay in the channel, which the dispatcher sizes to the rest of
+// the events buffer, so the poller backs off once both are full.
+func (q *fairQueue) fill(events <-chan *handler.Event) {
+	for int(q.size.Load()) < q.limit {
+		select {
+		case event := <-events:
+			q.push(event)
+		default:
+			return
+		}
+	}
+}
+
+func (q *fairQueue) push(event *handler.Event) {
+	if len(q.queues[event.Type]) == 0 {
+		q.order = append(q.order, event.Type)
+	}
+	q.queues[event.Type] = append(q.queues[event.Type], event)
+	q.size.Add(1)
+}
+
+// pop returns the oldest event of the next type in turn, or nil when the queue is empty
+func (q *fairQueue) pop() *handler.Event {
+	if len(q.order) == 0 {
+		return nil
+	}
+
+	eventType := q.order[0]
+	queue := q.queues[eventType]
+	event := queue[0]
+	queue[0] = nil
+	q.queues[eventType] = queue[1:]
+	q.size.Add(-1)
+
+	// The type goes to the back of the line, or leaves it once it has nothing queued
+	q.order = q.order[1:]
+	if len(q.queues[eventType]) > 0 {
+		q.order = append(q.order, eventType)
+	} else {
+		delete(q.queues, eventType)
+	}
+	return event
+}
+
+// len returns how many events are queued
+func (q *fairQueue) len() int {
+	return int(q.size.Load())
+}
@@ -0,0 +1,71 @@
+package worker
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+)
+
+// heartbeatInterval is how often an idle dispatcher beats
+const heartbeatInterval = 5 * time.Second
+
+// heartbeat records when a loop last made progress
+type heartbeat struct {
+	last atomic.Int64 // Unix nanoseconds
+}
+
+func (h *heartbeat) beat() {
+	h.last.Store(time.Now().UnixNano())
+}
+
+func (h *heartbeat) time() time.Time {
+	return time.Unix(0, h.last.Load())
+}
+
+// HeartbeatSource reports when a component's loop last made progress
+type HeartbeatSource interface {
+	LastHeartbeat() time.Time
+}
+
+// HealthMonitor reports worker_healthy from the heartbeats of its sources
+type HealthMonitor struct {
+	timeout time.Duration
+	metrics *observability.Metrics
+	sources []HeartbeatSource
+}
+
+// NewHealthMonitor creates a monitor that reports unhealthy once any source
+// has not beaten for timeout
+func NewHealthMonitor(timeout time.Duration, metrics *observability.Metrics, sources ...HeartbeatSource) *HealthMonitor {
+	return &HealthMonitor{timeout: timeout, metrics: metrics, sources: sources}
+}
+
+// Check sets worker_healthy from the current heartbeats and reports whether all are fresh
+func (m *HealthMonitor) Check() bool {
+	healthy := true
+	for _, source := range m.sources {
+		if time.Since(source.LastHeartbeat()) > m.timeout {
+			healthy = false
+			break
+		}
+	}
+	m.metrics.SetWorkerHealthy(healthy)
+	return healthy
+}
+
+// Run checks the heartbeats every interval until ctx ends
+func (m *HealthMonitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		m.Check()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package worker_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/traffic-tacos/reservation-worker/internal/config"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"github.com/traffic-tacos/reservation-worker/internal/worker"
+)
+
+// fakeHeartbeat reports a settable last heartbeat
+type fakeHeartbeat struct {
+	last atomic.Int64
+}
+
+func (f *fakeHeartbeat) beatAgo(age time.Duration) { f.last.Store(time.Now().Add(-age).UnixNano()) }
+func (f *fakeHeartbeat) LastHeartbeat() time.Time  { return time.Unix(0, f.last.Load()) }
+
+func TestHealthMonitor(t *testing.T) {
+	metrics := observability.NewMetrics(observability.WithRegistry(prometheus.NewRegistry()))
+	defer metrics.Unregister()
+
+	poller, dispatcher := &fakeHeartbeat{}, &fakeHeartbeat{}
+	monitor := worker.NewHealthMonitor(time.Minute, metrics, poller, dispatcher)
+
+	// Steps run in order, so each flips the heartbeats left by the previous one
+	tests := []struct {
+		name          string
+		pollerAge     time.Duration
+		dispatcherAge time.Duration
+		want          float64
+	}{
+		{"both fresh", time.Second, time.Second, 1},
+		{"poller stale", 2 * time.Minute, time.Second, 0},
+		{"poller recovered", 0, time.Second, 1},
+		{"dispatcher stale", time.Second, 2 * time.Minute, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poller.beatAgo(tt.pollerAge)
+			dispatcher.beatAgo(tt.dispatcherAge)
+
+			if got := monitor.Check(); got != (tt.want == 1) {
+				t.Errorf("Check() = %v, want %v", got, tt.want == 1)
+			}
+			if got := testutil.ToFloat64(metrics.WorkerHealthy); got != tt.want {
+				t.Errorf("worker_healthy = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHealthMonitor_PausedPollerIsHealthy(t *testing.T) {
+	metrics := observability.NewMetrics(observability.WithRegistry(prometheus.NewRegistry()))
+	defer metrics.Unregister()
+
+	cfg := &config.Config{SQSQueueURL: "test-queue", SQSWaitTime: 1}
+	poller := worker.NewSQSPoller(&fakeSQS{}, cfg, testLogger(), metrics, nil)
+	monitor := worker.NewHealthMonitor(time.Millisecond, metrics, poller)
+
+	// The poller has not polled since it was created, so its heartbeat goes stale
+	time.Sleep(5 * time.Millisecond)
+	if monitor.Check() {
+		t.Fatal("Expected a poller that never polled to be unhealthy")
+	}
+
+	poller.Pause()
+	if !monitor.Check() || testutil.ToFloat64(metrics.WorkerHealthy) != 1 {
+		t.Error("Expected a paused poller to be healthy")
+	}
+}
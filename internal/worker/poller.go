@@ -3,49 +3,250 @@ package worker
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand"
+	"runtime/debug"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/aws/smithy-go"
 	"github.com/traffic-tacos/reservation-worker/internal/config"
+	"github.com/traffic-tacos/reservation-worker/internal/eventpb"
 	"github.com/traffic-tacos/reservation-worker/internal/handler"
 	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"github.com/traffic-tacos/reservation-worker/internal/retry"
 	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
 )
 
+// SQSAPI is the subset of the SQS client used by the poller
+type SQSAPI interface {
+	ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
+	DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error)
+	SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error)
+	ChangeMessageVisibility(ctx context.Context, params *sqs.ChangeMessageVisibilityInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error)
+	DeleteMessageBatch(ctx context.Context, params *sqs.DeleteMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error)
+}
+
+// errMalformedMessage marks messages whose body cannot be decoded into an event
+var errMalformedMessage = errors.New("malformed message")
+
+// errPollerStopping is returned when shutdown interrupts handing a message to the worker pool
+var errPollerStopping = errors.New("poller stopping")
+
+// errSourceMismatch marks events from a producer not allowed for their type
+var errSourceMismatch = errors.New("unexpected event source")
+
+// errDispatchTimeout is returned when the worker pool did not take an event in time
+var errDispatchTimeout = errors.New("timeout sending event to worker pool")
+
+// errDroppedAfterDelete marks an at-most-once message deleted on receive whose event was
+// never handed to the worker pool; it cannot be returned to the queue
+var errDroppedAfterDelete = errors.New("deleted on receive but not dispatched")
+
+// backpressureInterval is how often a paused poller checks the events channel for room
+const backpressureInterval = 50 * time.Millisecond
+
+// Credential errors are retried after credentialRetryDelay instead of the poll error
+// backoff, up to credentialQuickRetries times in a row, since the SDK refreshes
+// credentials on the next call
+const (
+	credentialRetryDelay   = 200 * time.Millisecond
+	credentialQuickRetries = 3
+)
+
+// throttleBackoffMax caps the backoff after throttled receives
+const throttleBackoffMax = time.Minute
+
+// ackTimeout bounds deleting or returning a message, which may run after the poll context is cancelled
+const ackTimeout = 5 * time.Second
+
 // SQSPoller polls SQS for events and sends them to workers
 type SQSPoller struct {
-	sqsClient   *sqs.Client
+	sqsClient   SQSAPI
 	queueURL    string
 	waitTime    int32
+	maxMessages int32
 	logger      *observability.Logger
 	metrics     *observability.Metrics
 	eventsChan  chan *handler.Event
 	stopChan    chan struct{}
 	config      *config.Config
+
+	// doneChan is closed when Start returns; a restarted Start replaces it
+	doneMu   sync.Mutex
+	doneChan chan struct{}
+
+	// pollers is how many receive loops run concurrently
+	pollers int
+
+	// decoder parses message bodies into events
+	decoder handler.EventDecoder
+
+	// decrypter opens KMS-encrypted bodies; nil leaves bodies as received
+	decrypter *payloadDecrypter
+
+	// Receiving is suspended while paused; messages already received are still dispatched
+	pauseMu      sync.Mutex
+	paused       bool
+	pauseChanged chan struct{} // Closed and replaced whenever paused flips
+
+	// workers, when set, caps receives at its free workers plus prefetchExtra
+	workers       WorkerCapacity
+	prefetchExtra int
+
+	// Messages the poll loops' receives may still bring in, so concurrent loops do not
+	// each claim the same free room
+	capacityMu sync.Mutex
+	reserved   int32
+
+	// readiness, when set, holds the first receive until it reports ready
+	readiness ReadinessGate
+
+	// highChan, when set, takes events whose PRIORITY_ATTRIBUTE marks them high priority
+	highChan chan *handler.Event
+
+	// deleteRetryer retries failed deletes within ackTimeout
+	deleteRetryer *retry.Retryer
+
+	// heartbeat beats before each receive
+	heartbeat heartbeat
+}
+
+// ReadinessGate blocks until a dependency of event handling is ready or ctx ends
+type ReadinessGate interface {
+	WaitReady(ctx context.Context) error
+}
+
+// WorkerCapacity reports how many workers could start on an event right away
+type WorkerCapacity interface {
+	FreeWorkers() int
 }
 
 // NewSQSPoller creates a new SQS poller
 func NewSQSPoller(
-	sqsClient *sqs.Client,
+	sqsClient SQSAPI,
 	config *config.Config,
 	logger *observability.Logger,
 	metrics *observability.Metrics,
 	eventsChan chan *handler.Event,
 ) *SQSPoller {
-	return &SQSPoller{
-		sqsClient:  sqsClient,
-		queueURL:   config.SQSQueueURL,
-		waitTime:   int32(config.SQSWaitTime),
-		logger:     logger,
-		metrics:    metrics,
-		eventsChan: eventsChan,
-		stopChan:   make(chan struct{}),
-		config:     config,
+	// Fall back to the SQS maximum when not configured
+	maxMessages := int32(config.SQSMaxMessages)
+	if maxMessages <= 0 {
+		maxMessages = 10
+	}
+
+	p := &SQSPoller{
+		sqsClient:   sqsClient,
+		queueURL:    config.SQSQueueURL,
+		waitTime:    int32(config.SQSWaitTime),
+		maxMessages: maxMessages,
+		logger:      logger,
+		metrics:     metrics,
+		eventsChan:  eventsChan,
+		stopChan:    make(chan struct{}),
+		doneChan:    make(chan struct{}),
+		config:      config,
+		pollers:     max(config.SQSPollerCount, 1),
+		decoder:     handler.NewEventDecoder(config.EventDecoder),
+
+		pauseChanged:  make(chan struct{}),
+		deleteRetryer: newDeleteRetryer(config, logger),
+	}
+	p.heartbeat.beat()
+	return p
+}
+
+// newDeleteRetryer retries deletes SQS_DELETE_RETRIES times, backing off from SQS_DELETE_BACKOFF_MS
+func newDeleteRetryer(cfg *config.Config, logger *observability.Logger) *retry.Retryer {
+	backoff := func(attempt int) time.Duration {
+		return time.Duration(cfg.SQSDeleteBackoffMS) * time.Millisecond << min(attempt, 4)
+	}
+	return retry.NewBackoffRetryer(cfg.SQSDeleteRetries+1, backoff, logger.Logger)
+}
+
+// Pause stops receiving messages until Resume, abandoning a long poll in progress.
+// The poller keeps running, so the process stays healthy. It reports whether the
+// poller was running before.
+func (p *SQSPoller) Pause() bool {
+	if !p.setPaused(true) {
+		return false
+	}
+	p.logger.Warn("SQS poller paused; no messages will be received until it is resumed")
+	return true
+}
+
+// Resume restarts receiving after Pause. It reports whether the poller was paused before.
+func (p *SQSPoller) Resume() bool {
+	if !p.setPaused(false) {
+		return false
+	}
+	p.logger.Info("SQS poller resumed")
+	return true
+}
+
+// Paused reports whether receiving is paused
+func (p *SQSPoller) Paused() bool {
+	paused, _ := p.pauseState()
+	return paused
+}
+
+// setPaused updates the pause state, reporting whether it changed
+func (p *SQSPoller) setPaused(paused bool) bool {
+	p.pauseMu.Lock()
+	defer p.pauseMu.Unlock()
+	if p.paused == paused {
+		return false
 	}
+	p.paused = paused
+	close(p.pauseChanged)
+	p.pauseChanged = make(chan struct{})
+	return true
+}
+
+// pauseState returns the pause state and a channel closed when it next changes
+func (p *SQSPoller) pauseState() (bool, <-chan struct{}) {
+	p.pauseMu.Lock()
+	defer p.pauseMu.Unlock()
+	return p.paused, p.pauseChanged
+}
+
+// LastHeartbeat returns when the poll loop last started a receive. A paused poller
+// is idle on purpose, so it reports the current time.
+func (p *SQSPoller) LastHeartbeat() time.Time {
+	if p.Paused() {
+		return time.Now()
+	}
+	return p.heartbeat.time()
+}
+
+// SetWorkerCapacity sizes each receive to the free workers of workers plus SQS_PREFETCH_EXTRA,
+// so received messages don't sit in the events channel while their visibility timeout runs
+func (p *SQSPoller) SetWorkerCapacity(workers WorkerCapacity) {
+	p.workers = workers
+	p.prefetchExtra = p.config.SQSPrefetchExtra
+}
+
+// SetReadinessGate holds the first receive, for up to STARTUP_READY_TIMEOUT_MS, until gate is ready
+func (p *SQSPoller) SetReadinessGate(gate ReadinessGate) {
+	p.readiness = gate
+}
+
+// SetHighPriorityChan sends events whose PRIORITY_ATTRIBUTE value is a high priority to events
+func (p *SQSPoller) SetHighPriorityChan(events chan *handler.Event) {
+	p.highChan = events
+}
+
+// SetKMSDecrypter enables decryption of message bodies carrying the kms-key-id attribute
+func (p *SQSPoller) SetKMSDecrypter(kms KMSDecrypter) {
+	p.decrypter = newPayloadDecrypter(kms)
 }
 
 // Start begins polling SQS for messages
@@ -53,44 +254,304 @@ func (p *SQSPoller) Start(ctx context.Context) error {
 	p.logger.Info("Starting SQS poller",
 		zap.String("queue_url", p.queueURL),
 		zap.Int32("wait_time", p.waitTime),
+		zap.Int32("max_messages", p.maxMessages),
+		zap.Int("pollers", p.pollers),
 	)
+	defer close(p.begin())
+
+	if !p.warmUp(ctx) {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		p.logger.Info("SQS poller stopped")
+		return nil
+	}
+
+	// A loop that panics stops the others, so Start reports the failure
+	loops, loopCtx := errgroup.WithContext(ctx)
+	for i := 0; i < p.pollers; i++ {
+		loops.Go(func() (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					p.logger.Error("Recovered from poll loop panic",
+						zap.Any("panic", r),
+						zap.ByteString("stack", debug.Stack()),
+					)
+					err = fmt.Errorf("poll loop panic: %v", r)
+				}
+			}()
+			return p.pollLoop(loopCtx)
+		})
+	}
+	err := loops.Wait()
+	switch {
+	case ctx.Err() != nil:
+		p.logger.Info("SQS poller stopped due to context cancellation")
+		return ctx.Err()
+	case err != nil:
+		return err
+	}
+	p.logger.Info("SQS poller stopped")
+	return nil
+}
+
+// begin returns the channel to close when Start returns. After an earlier run it
+// replaces that run's closed channel, so Wait follows the restarted poller.
+func (p *SQSPoller) begin() chan struct{} {
+	p.doneMu.Lock()
+	defer p.doneMu.Unlock()
+	select {
+	case <-p.doneChan:
+		p.doneChan = make(chan struct{})
+	default:
+	}
+	return p.doneChan
+}
+
+// pollLoop receives until the poller is stopped or ctx ends. Each loop keeps its own
+// error streak, so one loop backing off doesn't hold back the others.
+func (p *SQSPoller) pollLoop(ctx context.Context) error {
+	// consecutiveErrors drives the poll error backoff and resets on success
+	consecutiveErrors := 0
 
 	for {
 		select {
 		case <-ctx.Done():
-			p.logger.Info("SQS poller stopped due to context cancellation")
 			return ctx.Err()
 		case <-p.stopChan:
-			p.logger.Info("SQS poller stopped")
 			return nil
 		default:
+			p.heartbeat.beat()
+			if paused, changed := p.pauseState(); paused {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-p.stopChan:
+					return nil
+				case <-changed:
+				}
+				continue
+			}
+
 			if err := p.pollOnce(ctx); err != nil {
-				p.logger.Error("Error polling SQS", zap.Error(err))
-				p.metrics.RecordSQSPollError()
+				// The receive was cut short by ctx ending rather than failing
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				consecutiveErrors++
+				backoff := p.pollErrorBackoff(consecutiveErrors)
+
+				switch {
+				case isThrottlingError(err):
+					backoff = p.throttleBackoff(consecutiveErrors)
+					p.logger.Warn("SQS throttled the receive, backing off",
+						zap.Error(err),
+						zap.Int("consecutive_errors", consecutiveErrors),
+						zap.Duration("backoff", backoff),
+					)
+					p.metrics.RecordSQSThrottled()
+				case isCredentialError(err):
+					if consecutiveErrors <= credentialQuickRetries {
+						backoff = credentialRetryDelay
+					}
+					p.logger.Warn("AWS credentials rejected by SQS, retrying with refreshed credentials",
+						zap.Error(err),
+						zap.Int("consecutive_errors", consecutiveErrors),
+						zap.Duration("backoff", backoff),
+					)
+					p.metrics.RecordCredentialError()
+				default:
+					p.logger.Error("Error polling SQS",
+						zap.Error(err),
+						zap.Int("consecutive_errors", consecutiveErrors),
+						zap.Duration("backoff", backoff),
+					)
+					p.metrics.RecordSQSPollError()
+				}
 
-				// Backoff on error
-				time.Sleep(5 * time.Second)
+				// Backoff on error, escalating while errors persist
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-p.stopChan:
+					return nil
+				case <-time.After(backoff):
+				}
+				continue
 			}
+			consecutiveErrors = 0
 		}
 	}
 }
 
-// Stop stops the SQS poller
+// warmUp waits STARTUP_DELAY_MS and then for the readiness gate before the first receive.
+// A gate still not ready at the timeout is logged and polling starts anyway. It returns
+// false when the poller is stopped or ctx ends meanwhile.
+func (p *SQSPoller) warmUp(ctx context.Context) bool {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-p.stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if delay := time.Duration(p.config.StartupDelayMS) * time.Millisecond; delay > 0 {
+		p.logger.Info("Delaying first SQS receive", zap.Duration("delay", delay))
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(delay):
+		}
+	}
+
+	if p.readiness == nil {
+		return true
+	}
+	waitCtx := ctx
+	if timeout := time.Duration(p.config.StartupReadyTimeoutMS) * time.Millisecond; timeout > 0 {
+		var cancelWait context.CancelFunc
+		waitCtx, cancelWait = context.WithTimeout(ctx, timeout)
+		defer cancelWait()
+	}
+
+	start := time.Now()
+	err := p.readiness.WaitReady(waitCtx)
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		p.logger.Warn("Dependencies not ready before the startup timeout, polling anyway",
+			zap.Error(err),
+			zap.Duration("waited", time.Since(start)),
+		)
+	} else {
+		p.logger.Info("Dependencies ready, starting to poll", zap.Duration("waited", time.Since(start)))
+	}
+	return true
+}
+
+// pollErrorBackoff returns the backoff after consecutiveErrors failed receives in a row.
+// Half of the duration is randomized so pods that failed together don't retry in lockstep.
+func (p *SQSPoller) pollErrorBackoff(consecutiveErrors int) time.Duration {
+	backoff := p.config.GetBackoffDuration(consecutiveErrors - 1)
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// throttleBackoff returns the backoff after the current run of throttled receives.
+// Unlike pollErrorBackoff it keeps doubling up to throttleBackoffMax, so a fleet of
+// pods over the request limit keeps spreading out until SQS accepts their calls.
+func (p *SQSPoller) throttleBackoff(consecutiveErrors int) time.Duration {
+	backoff := time.Duration(p.config.BackoffBaseMS) * time.Millisecond
+	for i := 1; i < consecutiveErrors && backoff < throttleBackoffMax; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, throttleBackoffMax)
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// throttlingErrorCodes are the AWS error codes of requests rejected for exceeding a rate limit
+var throttlingErrorCodes = map[string]bool{
+	"RequestThrottled":    true,
+	"OverLimit":           true,
+	"Throttling":          true,
+	"ThrottlingException": true,
+}
+
+// isThrottlingError reports whether err is SQS rejecting a request for exceeding a rate limit
+func isThrottlingError(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && throttlingErrorCodes[apiErr.ErrorCode()]
+}
+
+// credentialErrorCodes are the AWS error codes of expired or not yet valid credentials
+var credentialErrorCodes = map[string]bool{
+	"ExpiredToken":                true,
+	"ExpiredTokenException":       true,
+	"InvalidClientTokenId":        true,
+	"UnrecognizedClientException": true,
+}
+
+// isCredentialError reports whether err is AWS rejecting the request's credentials
+func isCredentialError(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && credentialErrorCodes[apiErr.ErrorCode()]
+}
+
+// Stop stops the SQS poller, abandoning any in-flight long poll
 func (p *SQSPoller) Stop() {
 	close(p.stopChan)
 }
 
+// Wait blocks until the poll loops have exited or ctx is done
+func (p *SQSPoller) Wait(ctx context.Context) error {
+	p.doneMu.Lock()
+	done := p.doneChan
+	p.doneMu.Unlock()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // pollOnce performs a single SQS polling operation
 func (p *SQSPoller) pollOnce(ctx context.Context) error {
+	// Stopping or pausing cancels the long poll; messages already received are still dispatched
+	_, pauseChanged := p.pauseState()
+	receiveCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-p.stopChan:
+			cancel()
+		case <-pauseChanged:
+			cancel()
+		case <-receiveCtx.Done():
+		}
+	}()
+
+	// Only receive what the worker pool can take, so messages don't wait out their visibility here
+	maxMessages := p.receiveCapacity(receiveCtx)
+	if maxMessages == 0 {
+		return nil
+	}
+	// The room stays claimed until each received message is handed off
+	reserved := maxMessages
+	defer func() { p.releaseCapacity(reserved) }()
+	release := func(n int32) {
+		reserved -= n
+		p.releaseCapacity(n)
+	}
+
 	// Use ReceiveMessage with long polling
-	result, err := p.sqsClient.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
-		QueueUrl:            aws.String(p.queueURL),
-		MaxNumberOfMessages: 10, // Process up to 10 messages at once
-		WaitTimeSeconds:     p.waitTime,
+	input := &sqs.ReceiveMessageInput{
+		QueueUrl:              aws.String(p.queueURL),
+		MaxNumberOfMessages:   maxMessages,
+		WaitTimeSeconds:       p.waitTime,
 		MessageAttributeNames: []string{"All"},
-		AttributeNames:       []types.QueueAttributeName{types.QueueAttributeNameAll},
-	})
+		AttributeNames:        []types.QueueAttributeName{types.QueueAttributeNameAll},
+	}
+	if p.config.SQSVisibilityTimeoutSec > 0 {
+		input.VisibilityTimeout = int32(p.config.SQSVisibilityTimeoutSec)
+	}
+	// Visibility runs from when SQS hands the messages out, which is no earlier than this
+	receivedAt := time.Now()
+	result, err := p.sqsClient.ReceiveMessage(receiveCtx, input)
 	if err != nil {
+		select {
+		case <-p.stopChan:
+			return nil
+		case <-pauseChanged:
+			return nil
+		default:
+		}
 		return fmt.Errorf("failed to receive messages from SQS: %w", err)
 	}
 
@@ -98,24 +559,67 @@ func (p *SQSPoller) pollOnce(ctx context.Context) error {
 		// No messages received, continue polling
 		return nil
 	}
+	release(maxMessages - int32(len(result.Messages)))
 
 	p.logger.Debug("Received messages from SQS",
 		zap.Int("message_count", len(result.Messages)),
 	)
 
+	// Report the receive's failures as a batch: succeeded messages are deleted together
+	var batch *deleteBatch
+	if p.config.SQSDeleteBatch {
+		batch = newDeleteBatch(ctx, p, p.visibilityDeadline(receivedAt))
+		defer batch.seal()
+	}
+
 	// Process each message
-	for _, message := range result.Messages {
-		if err := p.processMessage(ctx, &message); err != nil {
-			p.logger.Error("Failed to process SQS message",
-				zap.Error(err),
-				zap.String("message_id", aws.ToString(message.MessageId)),
-			)
+	for i, message := range result.Messages {
+		if i > 0 {
+			// The previous message was handed off or dealt with
+			release(1)
+		}
+
+		// Messages that keep coming back are not dispatched again
+		if p.isPoisonMessage(&message) {
+			p.handlePoisonMessage(ctx, &message)
 			continue
 		}
 
-		// Delete message from queue after successful processing
-		if err := p.deleteMessage(ctx, &message); err != nil {
-			p.logger.Error("Failed to delete SQS message",
+		if err := p.processMessage(ctx, &message, batch, p.visibilityDeadline(receivedAt)); err != nil {
+			unprocessed := result.Messages[i:]
+			if errors.Is(err, errDroppedAfterDelete) {
+				unprocessed = unprocessed[1:]
+			}
+			// Shutdown interrupted the hand-off; make the rest visible to other pods right away
+			if errors.Is(err, errPollerStopping) || ctx.Err() != nil {
+				p.returnMessages(ctx, unprocessed)
+				return nil
+			}
+			if errors.Is(err, errDroppedAfterDelete) {
+				continue
+			}
+			// Let another consumer, or this one once it caught up, have the message
+			if errors.Is(err, errDispatchTimeout) {
+				p.logger.Warn("Worker pool is saturated, returning message to the queue",
+					zap.String("message_id", aws.ToString(message.MessageId)),
+				)
+				p.returnMessages(ctx, []types.Message{message})
+				continue
+			}
+			// Payloads from a newer schema are parked rather than misparsed
+			if errors.Is(err, handler.ErrUnsupportedVersion) {
+				p.handleUnsupportedVersion(ctx, &message, err)
+				continue
+			}
+			if errors.Is(err, errMalformedMessage) {
+				p.handleMalformedMessage(ctx, &message, err)
+				continue
+			}
+			if errors.Is(err, errSourceMismatch) {
+				p.handleSourceMismatch(ctx, &message, err)
+				continue
+			}
+			p.logger.Error("Failed to process SQS message",
 				zap.Error(err),
 				zap.String("message_id", aws.ToString(message.MessageId)),
 			)
@@ -125,16 +629,57 @@ func (p *SQSPoller) pollOnce(ctx context.Context) error {
 	return nil
 }
 
-// processMessage processes a single SQS message
-func (p *SQSPoller) processMessage(ctx context.Context, message *types.Message) error {
-	if message.Body == nil {
-		return fmt.Errorf("message body is nil")
+// visibilityDeadline returns when messages received at receivedAt should be given up on,
+// a safety margin ahead of their visibility timeout, or the zero time if it is not known
+func (p *SQSPoller) visibilityDeadline(receivedAt time.Time) time.Time {
+	if p.config.SQSVisibilityTimeoutSec <= 0 {
+		return time.Time{}
+	}
+	safe := p.config.SQSVisibilityTimeoutSec - p.config.SQSVisibilityMarginSec
+	return receivedAt.Add(time.Duration(safe) * time.Second)
+}
+
+// processMessage processes a single SQS message. When batch is set,
+// the message is deleted with the rest of its receive instead of on its own.
+// A non-zero deadline bounds the handler's context.
+func (p *SQSPoller) processMessage(ctx context.Context, message *types.Message, batch *deleteBatch, deadline time.Time) error {
+	payload := message
+	if p.decrypter != nil {
+		decrypted, err := p.decrypter.decrypt(ctx, message)
+		if err != nil {
+			return err
+		}
+		payload = decrypted
+	}
+
+	body, err := decodeMessageBody(payload)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errMalformedMessage, err)
+	}
+
+	// Record how long the message waited in the queue before we picked it up
+	sentAt, hasSentAt := getMessageSentTimestamp(message)
+	if hasSentAt {
+		p.metrics.RecordMessageAge(p.ageSince(sentAt).Seconds())
 	}
 
 	// Parse the message body as an event
+	decoder := p.decoder
+	if isProtobufMessage(payload) {
+		decoder = handler.ProtoDecoder{}
+	}
 	var event handler.Event
-	if err := json.Unmarshal([]byte(*message.Body), &event); err != nil {
-		return fmt.Errorf("failed to unmarshal event: %w", err)
+	if err := decoder.Decode(body, &event); err != nil {
+		return fmt.Errorf("%w: failed to unmarshal event: %w", errMalformedMessage, err)
+	}
+
+	if _, err := event.SchemaVersion(); err != nil {
+		return err
+	}
+
+	// Spoofed or misrouted events never reach a handler
+	if !p.config.SourceAllowed(event.Type, event.Source) {
+		return fmt.Errorf("%w: %s from %q", errSourceMismatch, event.Type, event.Source)
 	}
 
 	// Add tracing information if available
@@ -148,6 +693,10 @@ func (p *SQSPoller) processMessage(ctx context.Context, message *types.Message)
 	if event.ID == "" && message.MessageId != nil {
 		event.ID = *message.MessageId
 	}
+	if event.Time.IsZero() && hasSentAt {
+		event.Time = sentAt
+	}
+	event.SetVisibilityDeadline(deadline)
 
 	p.logger.Debug("Processing event",
 		zap.String("event_type", event.Type),
@@ -155,22 +704,247 @@ func (p *SQSPoller) processMessage(ctx context.Context, message *types.Message)
 		zap.String("trace_id", event.TraceID),
 	)
 
+	tracked, deleted := false, false
+	switch {
+	case p.keepMessages():
+		// Dry runs can leave messages in the queue for the real worker
+	case p.config.ProcessingGuarantee(event.Type) == config.GuaranteeAtMostOnce:
+		// Delete before handling so a redelivery can never run the handler again
+		if err := p.deleteMessage(ctx, message); err != nil {
+			return fmt.Errorf("not dispatching at-most-once event: %w", err)
+		}
+		deleted = true
+	case batch != nil:
+		event.SetAckFuncs(batch.track(message))
+		tracked = true
+	default:
+		// The dispatcher acks or nacks the message when processing concludes
+		event.SetAckFuncs(p.ackFunc(ctx, message), p.nackFunc(ctx, message))
+	}
+
 	// Send event to worker pool for processing
+	events := p.eventsChan
+	if p.highChan != nil && p.config.PriorityAttribute != "" && p.config.HighPriority(stringAttribute(message, p.config.PriorityAttribute)) {
+		events = p.highChan
+	}
 	select {
-	case p.eventsChan <- &event:
+	case events <- &event:
 		return nil
 	case <-ctx.Done():
-		return ctx.Err()
+		err = ctx.Err()
+	case <-p.stopChan:
+		err = errPollerStopping
 	case <-time.After(30 * time.Second):
-		return fmt.Errorf("timeout sending event to worker pool")
+		err = errDispatchTimeout
+	}
+
+	// The event will never conclude, so it must not hold up the rest of the batch
+	if tracked {
+		batch.discard()
+	}
+	// Returning a message deleted on receive would fail; its event is lost
+	if deleted {
+		p.logger.Warn("At-most-once event was deleted on receive but never dispatched, dropping it",
+			zap.String("event_type", event.Type),
+			zap.String("event_id", event.ID),
+			zap.Error(err),
+		)
+		p.metrics.RecordEventProcessed(event.Type, observability.OutcomeDropped)
+		return fmt.Errorf("%w: %w", errDroppedAfterDelete, err)
+	}
+	return err
+}
+
+// receiveCapacity returns how many messages the next receive may request: the room
+// left in the events channel and, with a WorkerCapacity set, the free workers plus
+// prefetchExtra, up to maxMessages, less what other loops' receives already claimed.
+// The returned room is claimed until releaseCapacity. While there is no room it waits,
+// and it returns 0 once ctx is done or the poller is stopping.
+func (p *SQSPoller) receiveCapacity(ctx context.Context) int32 {
+	// An unbuffered channel has no fill level to go by
+	buffered := cap(p.eventsChan) > 0
+	if !buffered && p.workers == nil {
+		p.capacityMu.Lock()
+		p.reserved += p.maxMessages
+		p.capacityMu.Unlock()
+		return p.maxMessages
+	}
+
+	paused := false
+	for {
+		free := p.claimCapacity(buffered)
+		if free > 0 {
+			if paused {
+				p.logger.Debug("Workers have room again, resuming SQS receives", zap.Int32("free", free))
+			}
+			if free < p.maxMessages {
+				p.metrics.RecordBackpressure(observability.BackpressureReduced)
+			}
+			return free
+		}
+
+		if !paused {
+			paused = true
+			p.metrics.RecordBackpressure(observability.BackpressurePaused)
+			p.logger.Debug("No room for more events, pausing SQS receives")
+		}
+		select {
+		case <-ctx.Done():
+			return 0
+		case <-p.stopChan:
+			return 0
+		case <-time.After(backpressureInterval):
+		}
+	}
+}
+
+// claimCapacity claims and returns the room for the next receive, or 0 when there is none
+func (p *SQSPoller) claimCapacity(buffered bool) int32 {
+	p.capacityMu.Lock()
+	defer p.capacityMu.Unlock()
+
+	free := p.maxMessages
+	if buffered {
+		free = min(free, int32(cap(p.eventsChan)-len(p.eventsChan))-p.reserved)
+	}
+	if p.workers != nil {
+		free = min(free, int32(p.workers.FreeWorkers()+p.prefetchExtra)-p.reserved)
+	}
+	if free <= 0 {
+		return 0
+	}
+	p.reserved += free
+	return free
+}
+
+// releaseCapacity gives back n messages of room claimed by receiveCapacity
+func (p *SQSPoller) releaseCapacity(n int32) {
+	if n == 0 {
+		return
+	}
+	p.capacityMu.Lock()
+	p.reserved -= n
+	p.capacityMu.Unlock()
+}
+
+// isPoisonMessage reports whether the message exceeded the configured receive count
+func (p *SQSPoller) isPoisonMessage(message *types.Message) bool {
+	if p.config.SQSMaxReceiveCount <= 0 {
+		return false
+	}
+	return getMessageApproximateReceiveCount(message) > p.config.SQSMaxReceiveCount
+}
+
+// handlePoisonMessage moves a poison message to the DLQ, or drops it if no DLQ is configured
+func (p *SQSPoller) handlePoisonMessage(ctx context.Context, message *types.Message) {
+	logger := p.logger.With(
+		zap.Int("receive_count", getMessageApproximateReceiveCount(message)),
+		zap.Int("max_receive_count", p.config.SQSMaxReceiveCount),
+	)
+	p.divertMessage(ctx, message, observability.OutcomePoison, "Removed poison message from queue", logger)
+}
+
+// handleUnsupportedVersion moves a message with an unknown schema version to the DLQ
+func (p *SQSPoller) handleUnsupportedVersion(ctx context.Context, message *types.Message, err error) {
+	logger := p.logger.With(zap.Error(err))
+	p.divertMessage(ctx, message, observability.OutcomeUnsupportedVersion, "Removed message with unsupported schema version", logger)
+}
+
+// handleSourceMismatch moves an event from a producer not allowed for its type to the DLQ
+func (p *SQSPoller) handleSourceMismatch(ctx context.Context, message *types.Message, err error) {
+	logger := p.logger.With(zap.Error(err))
+	p.divertMessage(ctx, message, observability.OutcomeSourceMismatch, "Removed event from an unexpected source", logger)
+}
+
+// handleMalformedMessage applies PARSE_ERROR_POLICY to a message whose body cannot be parsed
+func (p *SQSPoller) handleMalformedMessage(ctx context.Context, message *types.Message, err error) {
+	eventType := peekEventType(message)
+	logger := p.logger.With(
+		zap.Error(err),
+		zap.String("parse_error_policy", p.config.ParseErrorPolicy),
+	)
+
+	switch p.config.ParseErrorPolicy {
+	case config.ParseErrorPolicyDLQ:
+		p.divertMessage(ctx, message, observability.OutcomeInvalidPayload, "Moved unparseable message to DLQ", logger)
+
+	case config.ParseErrorPolicyDrop:
+		logger = logger.With(zap.String("message_id", aws.ToString(message.MessageId)))
+		if p.keepMessages() {
+			logger.Warn("Dry run: keeping unparseable message that would be dropped")
+			return
+		}
+		if err := p.deleteMessage(ctx, message); err != nil {
+			logger.Error("Failed to delete unparseable message", zap.NamedError("delete_error", err))
+			return
+		}
+		p.metrics.RecordEventProcessed(eventType, observability.OutcomeDropped)
+		logger.Warn("Dropped unparseable message")
+
+	default:
+		// Redelivered until MAX_RECEIVE_COUNT or the queue's redrive policy diverts it
+		p.metrics.RecordEventProcessed(eventType, observability.OutcomeRetried)
+		logger.Error("Failed to parse SQS message, leaving it for redelivery",
+			zap.String("message_id", aws.ToString(message.MessageId)),
+			zap.Int("receive_count", getMessageApproximateReceiveCount(message)),
+		)
 	}
 }
 
-// deleteMessage deletes a message from SQS
+// divertMessage takes a message out of normal processing, sending it to the DLQ of its event type if configured
+func (p *SQSPoller) divertMessage(ctx context.Context, message *types.Message, outcome, reason string, logger *zap.Logger) {
+	eventType := peekEventType(message)
+	logger = logger.With(
+		zap.String("message_id", aws.ToString(message.MessageId)),
+		zap.String("event_type", eventType),
+	)
+
+	// Dry runs leave the message in the queue for the real worker to divert
+	if p.keepMessages() {
+		logger.Warn("Dry run: keeping message that would be diverted", zap.String("outcome", outcome))
+		return
+	}
+
+	dlqURL := p.config.DLQURL(eventType)
+	if dlqURL != "" {
+		logger = logger.With(zap.String("dlq_url", dlqURL))
+		if err := p.sendToDLQ(ctx, message, dlqURL); err != nil {
+			// Leave the message in the queue so it isn't lost
+			logger.Error("Failed to move message to DLQ", zap.String("outcome", outcome), zap.Error(err))
+			return
+		}
+	}
+
+	if err := p.deleteMessage(ctx, message); err != nil {
+		logger.Error("Failed to delete diverted message", zap.String("outcome", outcome), zap.Error(err))
+		return
+	}
+
+	p.metrics.RecordEventProcessed(eventType, outcome)
+	logger.Warn(reason, zap.Bool("sent_to_dlq", dlqURL != ""))
+}
+
+// sendToDLQ copies a message to the dead-letter queue at dlqURL
+func (p *SQSPoller) sendToDLQ(ctx context.Context, message *types.Message, dlqURL string) error {
+	_, err := p.sqsClient.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:          aws.String(dlqURL),
+		MessageBody:       message.Body,
+		MessageAttributes: message.MessageAttributes,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send message to DLQ: %w", err)
+	}
+	return nil
+}
+
+// deleteMessage deletes a message from SQS, retrying failures up to SQS_DELETE_RETRIES times
 func (p *SQSPoller) deleteMessage(ctx context.Context, message *types.Message) error {
-	_, err := p.sqsClient.DeleteMessage(ctx, &sqs.DeleteMessageInput{
-		QueueUrl:      aws.String(p.queueURL),
-		ReceiptHandle: message.ReceiptHandle,
+	err := p.deleteRetryer.Do(ctx, "sqs_delete_message", func(ctx context.Context) error {
+		_, err := p.sqsClient.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+			QueueUrl:      aws.String(p.queueURL),
+			ReceiptHandle: message.ReceiptHandle,
+		})
+		return err
 	})
 	if err != nil {
 		return fmt.Errorf("failed to delete message: %w", err)
@@ -183,6 +957,127 @@ func (p *SQSPoller) deleteMessage(ctx context.Context, message *types.Message) e
 	return nil
 }
 
+// keepMessages reports whether messages stay in the queue regardless of outcome
+func (p *SQSPoller) keepMessages() bool {
+	return p.config.DryRun && p.config.DryRunKeepMessage
+}
+
+// ackFunc returns the callback that deletes message once its event is processed.
+// Handlers may finish while shutdown drains, after the poll context is cancelled.
+func (p *SQSPoller) ackFunc(ctx context.Context, message *types.Message) func() {
+	return func() {
+		ackCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), ackTimeout)
+		defer cancel()
+		if err := p.deleteMessage(ackCtx, message); err != nil {
+			p.logger.Error("Failed to delete SQS message",
+				zap.Error(err),
+				zap.String("message_id", aws.ToString(message.MessageId)),
+			)
+		}
+	}
+}
+
+// nackFunc returns the callback that hands message back once its event fails. A permanent
+// failure moves to the DLQ of its event type if one is configured; any other failed message
+// is made visible again right away, bounded by MAX_RECEIVE_COUNT or the queue's redrive policy.
+func (p *SQSPoller) nackFunc(ctx context.Context, message *types.Message) func(retryable bool) {
+	return func(retryable bool) {
+		nackCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), ackTimeout)
+		defer cancel()
+
+		if !retryable && p.deadLetter(nackCtx, message) {
+			return
+		}
+		if err := p.returnMessage(nackCtx, message); err != nil {
+			p.logger.Warn("Failed to return failed message, it reappears after the visibility timeout",
+				zap.String("message_id", aws.ToString(message.MessageId)),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// deadLetter moves a permanently failed message to the DLQ of its event type. It reports
+// false when there is no DLQ, in a dry run that keeps messages, or when the move failed.
+func (p *SQSPoller) deadLetter(ctx context.Context, message *types.Message) bool {
+	if p.keepMessages() {
+		return false
+	}
+	eventType := peekEventType(message)
+	dlqURL := p.config.DLQURL(eventType)
+	if dlqURL == "" {
+		return false
+	}
+
+	logger := p.logger.With(
+		zap.String("message_id", aws.ToString(message.MessageId)),
+		zap.String("event_type", eventType),
+		zap.String("dlq_url", dlqURL),
+	)
+	if err := p.sendToDLQ(ctx, message, dlqURL); err != nil {
+		logger.Error("Failed to move failed message to DLQ", zap.Error(err))
+		return false
+	}
+	// Once copied, the message reappears after the visibility timeout if the delete fails
+	if err := p.deleteMessage(ctx, message); err != nil {
+		logger.Error("Failed to delete failed message moved to DLQ", zap.Error(err))
+		return true
+	}
+	logger.Warn("Moved permanently failed message to DLQ")
+	return true
+}
+
+// returnMessages resets the visibility timeout of messages this pod will not process,
+// so another consumer receives them immediately instead of after the timeout
+func (p *SQSPoller) returnMessages(ctx context.Context, messages []types.Message) {
+	if len(messages) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), ackTimeout)
+	defer cancel()
+
+	for i := range messages {
+		message := &messages[i]
+		if err := p.returnMessage(ctx, message); err != nil {
+			p.logger.Warn("Failed to return message to queue, it reappears after the visibility timeout",
+				zap.String("message_id", aws.ToString(message.MessageId)),
+				zap.Error(err),
+			)
+			continue
+		}
+		p.metrics.RecordEventProcessed(peekEventType(message), observability.OutcomeReturned)
+	}
+
+	p.logger.Info("Returned unprocessed messages to queue on shutdown",
+		zap.Int("message_count", len(messages)),
+	)
+}
+
+// returnMessage makes message visible to consumers again
+func (p *SQSPoller) returnMessage(ctx context.Context, message *types.Message) error {
+	_, err := p.sqsClient.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(p.queueURL),
+		ReceiptHandle:     message.ReceiptHandle,
+		VisibilityTimeout: 0,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to change message visibility: %w", err)
+	}
+	return nil
+}
+
+// ageSince returns how long ago ts was. A producer whose clock runs ahead of ours
+// stamps times in the future; rather than report a negative age, those are clamped
+// to zero and counted so the skew shows up as an NTP problem, not as odd latencies.
+func (p *SQSPoller) ageSince(ts time.Time) time.Duration {
+	age := time.Since(ts)
+	if age < 0 {
+		p.metrics.RecordClockSkew()
+		return 0
+	}
+	return age
+}
+
 // getMessageApproximateReceiveCount gets the approximate receive count from message attributes
 func getMessageApproximateReceiveCount(message *types.Message) int {
 	if message.Attributes == nil {
@@ -196,4 +1091,45 @@ func getMessageApproximateReceiveCount(message *types.Message) int {
 	}
 
 	return 0
-}
\ No newline at end of file
+}
+
+// getMessageSentTimestamp gets the time the message was sent to the queue
+func getMessageSentTimestamp(message *types.Message) (time.Time, bool) {
+	if message.Attributes == nil {
+		return time.Time{}, false
+	}
+
+	sentStr, ok := message.Attributes[string(types.MessageSystemAttributeNameSentTimestamp)]
+	if !ok {
+		return time.Time{}, false
+	}
+
+	sentMS, err := strconv.ParseInt(sentStr, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.UnixMilli(sentMS), true
+}
+
+// peekEventType extracts the event type from a message body for metrics, or "unknown"
+func peekEventType(message *types.Message) string {
+	var envelope struct {
+		Type string `json:"type"`
+	}
+	body, err := decodeMessageBody(message)
+	if err != nil {
+		return "unknown"
+	}
+	if isProtobufMessage(message) {
+		var msg eventpb.Event
+		if proto.Unmarshal(body, &msg) != nil || msg.GetType() == "" {
+			return "unknown"
+		}
+		return msg.GetType()
+	}
+	if json.Unmarshal(body, &envelope) != nil || envelope.Type == "" {
+		return "unknown"
+	}
+	return envelope.Type
+}
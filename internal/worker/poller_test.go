@@ -0,0 +1,2003 @@
+package worker_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/aws/smithy-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/traffic-tacos/proto-contracts/gen/go/reservation/v1"
+	"github.com/traffic-tacos/reservation-worker/internal/config"
+	"github.com/traffic-tacos/reservation-worker/internal/eventpb"
+	"github.com/traffic-tacos/reservation-worker/internal/handler"
+	"github.com/traffic-tacos/reservation-worker/internal/lifecycle"
+	"github.com/traffic-tacos/reservation-worker/internal/observability"
+	"github.com/traffic-tacos/reservation-worker/internal/worker"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeSQS hands out queued messages and records deletes
+type fakeSQS struct {
+	mu           sync.Mutex
+	messages     []types.Message
+	receiveErrs  []error // returned in order by the first receive calls (nil = succeed)
+	deleteErrs   []error // returned in order by the first DeleteMessage calls (nil = succeed)
+	deleteCalls  int
+	receiveTimes []time.Time
+	requested    []int32 // MaxNumberOfMessages of each receive
+	visibility   []int32 // VisibilityTimeout of each receive
+	deleted      []string
+	batchDeletes int                 // DeleteMessageBatch calls; their entries are recorded in deleted
+	batchErrs    []error             // returned in order by the first DeleteMessageBatch calls (nil = succeed)
+	entryFails   map[string]int      // receipt handle -> batch entries of it that fail before one succeeds
+	returned     []string            // receipt handles made visible again
+	sent         map[string][]string // queue URL -> message bodies
+}
+
+func (f *fakeSQS) ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, _ ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	f.mu.Lock()
+	f.receiveTimes = append(f.receiveTimes, time.Now())
+	f.requested = append(f.requested, params.MaxNumberOfMessages)
+	f.visibility = append(f.visibility, params.VisibilityTimeout)
+	if len(f.receiveErrs) > 0 {
+		err := f.receiveErrs[0]
+		f.receiveErrs = f.receiveErrs[1:]
+		if err != nil {
+			f.mu.Unlock()
+			return nil, err
+		}
+	}
+	n := int(params.MaxNumberOfMessages)
+	if n > len(f.messages) {
+		n = len(f.messages)
+	}
+	batch := f.messages[:n]
+	f.messages = f.messages[n:]
+	f.mu.Unlock()
+
+	if len(batch) == 0 {
+		// Simulate a short long-poll so the loop doesn't spin
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	return &sqs.ReceiveMessageOutput{Messages: batch}, nil
+}
+
+func (f *fakeSQS) DeleteMessage(_ context.Context, params *sqs.DeleteMessageInput, _ ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleteCalls++
+	if len(f.deleteErrs) > 0 {
+		err := f.deleteErrs[0]
+		f.deleteErrs = f.deleteErrs[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+	f.deleted = append(f.deleted, aws.ToString(params.ReceiptHandle))
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func (f *fakeSQS) DeleteMessageBatch(_ context.Context, params *sqs.DeleteMessageBatchInput, _ ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batchDeletes++
+	if len(f.batchErrs) > 0 {
+		err := f.batchErrs[0]
+		f.batchErrs = f.batchErrs[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+	out := &sqs.DeleteMessageBatchOutput{}
+	for _, entry := range params.Entries {
+		if handle := aws.ToString(entry.ReceiptHandle); f.entryFails[handle] > 0 {
+			f.entryFails[handle]--
+			out.Failed = append(out.Failed, types.BatchResultErrorEntry{Id: entry.Id, Code: aws.String("InternalError")})
+			continue
+		}
+		f.deleted = append(f.deleted, aws.ToString(entry.ReceiptHandle))
+		out.Successful = append(out.Successful, types.DeleteMessageBatchResultEntry{Id: entry.Id})
+	}
+	return out, nil
+}
+
+func (f *fakeSQS) SendMessage(_ context.Context, params *sqs.SendMessageInput, _ ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.sent == nil {
+		f.sent = make(map[string][]string)
+	}
+	queueURL := aws.ToString(params.QueueUrl)
+	f.sent[queueURL] = append(f.sent[queueURL], aws.ToString(params.MessageBody))
+	return &sqs.SendMessageOutput{}, nil
+}
+
+func (f *fakeSQS) ChangeMessageVisibility(_ context.Context, params *sqs.ChangeMessageVisibilityInput, _ ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if params.VisibilityTimeout == 0 {
+		f.returned = append(f.returned, aws.ToString(params.ReceiptHandle))
+	}
+	return &sqs.ChangeMessageVisibilityOutput{}, nil
+}
+
+// waitFor polls cond until it returns true or the timeout elapses
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func histogramSample(t *testing.T, h prometheus.Histogram) (uint64, float64) {
+	t.Helper()
+	var m dto.Metric
+	if err := h.Write(&m); err != nil {
+		t.Fatalf("failed to read histogram: %v", err)
+	}
+	return m.GetHistogram().GetSampleCount(), m.GetHistogram().GetSampleSum()
+}
+
+func TestSQSPoller_RecordsMessageAge(t *testing.T) {
+	tests := []struct {
+		name     string
+		sentAgo  time.Duration
+		minAge   float64
+		maxAge   float64
+		wantSkew float64
+	}{
+		{"past timestamp", 30 * time.Second, 29, 35, 0},
+		{"future timestamp clamped", -time.Minute, 0, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sentAt := time.Now().Add(-tt.sentAgo)
+			fake := &fakeSQS{messages: []types.Message{{
+				MessageId:     aws.String("msg-1"),
+				ReceiptHandle: aws.String("rh-1"),
+				Body:          aws.String(`{"id":"evt-1","type":"reservation.expired","detail":{}}`),
+				Attributes: map[string]string{
+					"SentTimestamp": strconv.FormatInt(sentAt.UnixMilli(), 10),
+				},
+			}}}
+
+			countBefore, sumBefore := histogramSample(t, testMetrics.MessageAge)
+			skewBefore := testutil.ToFloat64(testMetrics.ClockSkewDetected)
+
+			eventsChan := make(chan *handler.Event, 1)
+			poller := worker.NewSQSPoller(fake, &config.Config{SQSQueueURL: "queue"}, testLogger(), testMetrics, eventsChan)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go poller.Start(ctx)
+
+			select {
+			case <-eventsChan:
+			case <-time.After(time.Second):
+				t.Fatal("Timed out waiting for event to be dispatched")
+			}
+			cancel()
+
+			count, sum := histogramSample(t, testMetrics.MessageAge)
+			if count != countBefore+1 {
+				t.Fatalf("Expected one age observation, got %d", count-countBefore)
+			}
+			if age := sum - sumBefore; age < tt.minAge || age > tt.maxAge {
+				t.Errorf("Expected observed age in [%.0fs, %.0fs], got %.2fs", tt.minAge, tt.maxAge, age)
+			}
+			if skew := testutil.ToFloat64(testMetrics.ClockSkewDetected) - skewBefore; skew != tt.wantSkew {
+				t.Errorf("Expected clock skew counter to increase by %.0f, got %.0f", tt.wantSkew, skew)
+			}
+		})
+	}
+}
+
+func TestSQSPoller_ErrorBackoff(t *testing.T) {
+	errReceive := errors.New("receive failed")
+	fake := &fakeSQS{receiveErrs: []error{
+		errReceive, errReceive, errReceive, errReceive, // escalating backoff
+		nil,        // success resets the streak
+		errReceive, // back to the base backoff
+	}}
+
+	cfg := &config.Config{SQSQueueURL: "queue", BackoffBaseMS: 40}
+	poller := worker.NewSQSPoller(fake, cfg, testLogger(), testMetrics, make(chan *handler.Event, 1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go poller.Start(ctx)
+
+	// Wait until the call following the last scripted error has been made
+	deadline := time.After(3 * time.Second)
+	for {
+		fake.mu.Lock()
+		n := len(fake.receiveTimes)
+		fake.mu.Unlock()
+		if n >= 7 {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("Timed out waiting for receives, got %d", n)
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	cancel()
+
+	fake.mu.Lock()
+	times := append([]time.Time(nil), fake.receiveTimes...)
+	fake.mu.Unlock()
+
+	gap := func(i int) time.Duration { return times[i+1].Sub(times[i]) }
+
+	// Backoff after the Nth consecutive error is within [base*2^(N-1)/2, base*2^(N-1)]
+	if gap(0) > 60*time.Millisecond {
+		t.Errorf("First backoff = %v, want <= ~40ms", gap(0))
+	}
+	if gap(3) < 150*time.Millisecond {
+		t.Errorf("Fourth backoff = %v, want >= ~160ms", gap(3))
+	}
+	if gap(3) <= gap(0) {
+		t.Errorf("Expected backoff to grow: first %v, fourth %v", gap(0), gap(3))
+	}
+
+	// Error after a successful poll starts over at the base backoff
+	if gap(5) > 60*time.Millisecond {
+		t.Errorf("Backoff after reset = %v, want <= ~40ms", gap(5))
+	}
+}
+
+func TestSQSPoller_CredentialErrorRetry(t *testing.T) {
+	errExpired := &smithy.GenericAPIError{Code: "ExpiredToken", Message: "The security token included in the request is expired"}
+	fake := &fakeSQS{receiveErrs: []error{errExpired, errExpired}}
+
+	// A generic error would back off for at least 2.5s
+	cfg := &config.Config{SQSQueueURL: "queue", BackoffBaseMS: 5000}
+	metrics := observability.NewMetrics(observability.WithRegistry(prometheus.NewRegistry()))
+	poller := worker.NewSQSPoller(fake, cfg, testLogger(), metrics, make(chan *handler.Event, 1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go poller.Start(ctx)
+
+	waitFor(t, time.Second, func() bool {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		return len(fake.receiveTimes) >= 3
+	})
+	poller.Stop()
+	poller.Wait(ctx)
+
+	if got := testutil.ToFloat64(metrics.CredentialErrors); got != 2 {
+		t.Errorf("Expected 2 credential errors, got %.0f", got)
+	}
+	if got := testutil.ToFloat64(metrics.SQSPollErrors); got != 0 {
+		t.Errorf("Expected credential errors not to count as poll errors, got %.0f", got)
+	}
+}
+
+func TestSQSPoller_ThrottlingBackoff(t *testing.T) {
+	errThrottled := &smithy.GenericAPIError{Code: "RequestThrottled", Message: "Request is throttled"}
+	fake := &fakeSQS{receiveErrs: []error{errThrottled, errThrottled, errThrottled, errThrottled, errThrottled, errThrottled}}
+
+	cfg := &config.Config{SQSQueueURL: "queue", BackoffBaseMS: 10}
+	metrics := observability.NewMetrics(observability.WithRegistry(prometheus.NewRegistry()))
+	poller := worker.NewSQSPoller(fake, cfg, testLogger(), metrics, make(chan *handler.Event, 1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go poller.Start(ctx)
+
+	waitFor(t, 3*time.Second, func() bool {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		return len(fake.receiveTimes) >= 7
+	})
+	poller.Stop()
+	poller.Wait(ctx)
+
+	fake.mu.Lock()
+	times := append([]time.Time(nil), fake.receiveTimes...)
+	fake.mu.Unlock()
+
+	// Backoff after the Nth throttle is within [base*2^(N-1)/2, base*2^(N-1)], doubling
+	// past the 16x cap of other poll errors
+	for i := 0; i < 6; i++ {
+		floor := time.Duration(10<<i) * time.Millisecond / 2
+		if gap := times[i+1].Sub(times[i]); gap < floor {
+			t.Errorf("Backoff after throttle %d = %v, want >= %v", i+1, gap, floor)
+		}
+	}
+
+	if got := testutil.ToFloat64(metrics.SQSThrottled); got != 6 {
+		t.Errorf("Expected 6 throttled receives, got %.0f", got)
+	}
+	if got := testutil.ToFloat64(metrics.SQSPollErrors); got != 0 {
+		t.Errorf("Expected throttling not to count as poll errors, got %.0f", got)
+	}
+}
+
+func TestSQSPoller_PoisonMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		dlqURL string
+	}{
+		{name: "moved to DLQ", dlqURL: "dlq"},
+		{name: "dropped without DLQ", dlqURL: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeSQS{messages: []types.Message{{
+				MessageId:     aws.String("msg-poison"),
+				ReceiptHandle: aws.String("rh-poison"),
+				Body:          aws.String(`{"id":"evt-1","type":"reservation.expired","detail":{}}`),
+				Attributes:    map[string]string{"ApproximateReceiveCount": "6"},
+			}}}
+
+			cfg := &config.Config{SQSQueueURL: "queue", SQSDLQURL: tt.dlqURL, SQSMaxReceiveCount: 5}
+			eventsChan := make(chan *handler.Event, 1)
+			poller := worker.NewSQSPoller(fake, cfg, testLogger(), testMetrics, eventsChan)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go poller.Start(ctx)
+
+			waitFor(t, time.Second, func() bool {
+				fake.mu.Lock()
+				defer fake.mu.Unlock()
+				return len(fake.deleted) == 1
+			})
+			cancel()
+
+			select {
+			case event := <-eventsChan:
+				t.Fatalf("Poison message must not be dispatched, got event %s", event.ID)
+			default:
+			}
+
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			if tt.dlqURL != "" && len(fake.sent[tt.dlqURL]) != 1 {
+				t.Errorf("Expected poison message in DLQ, got %d", len(fake.sent[tt.dlqURL]))
+			}
+			if tt.dlqURL == "" && len(fake.sent) != 0 {
+				t.Errorf("Expected no sends without a DLQ, got %v", fake.sent)
+			}
+		})
+	}
+}
+
+func TestSQSPoller_DLQPerEventType(t *testing.T) {
+	tests := []struct {
+		eventType string
+		wantDLQ   string
+	}{
+		{"reservation.expired", "expired-dlq"},
+		{"reservation.hold.expired", "expired-dlq"},
+		{"payment.failed", "payment-dlq"},
+		{"payment.approved", "payment-dlq"},
+		{"reservation.cancelled", "default-dlq"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.eventType, func(t *testing.T) {
+			fake := &fakeSQS{messages: []types.Message{{
+				MessageId:     aws.String("msg-poison"),
+				ReceiptHandle: aws.String("rh-poison"),
+				Body:          aws.String(fmt.Sprintf(`{"id":"evt-1","type":%q,"detail":{}}`, tt.eventType)),
+				Attributes:    map[string]string{"ApproximateReceiveCount": "6"},
+			}}}
+
+			cfg := &config.Config{
+				SQSQueueURL:        "queue",
+				SQSMaxReceiveCount: 5,
+				SQSDLQURL:          "default-dlq",
+				DLQExpiredURL:      "expired-dlq",
+				DLQPaymentURL:      "payment-dlq",
+			}
+			poller := worker.NewSQSPoller(fake, cfg, testLogger(), testMetrics, make(chan *handler.Event, 1))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go poller.Start(ctx)
+
+			waitFor(t, time.Second, func() bool {
+				fake.mu.Lock()
+				defer fake.mu.Unlock()
+				return len(fake.deleted) == 1
+			})
+			poller.Stop()
+			poller.Wait(ctx)
+
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			if len(fake.sent) != 1 || len(fake.sent[tt.wantDLQ]) != 1 {
+				t.Errorf("Expected the message in %s only, got %v", tt.wantDLQ, fake.sent)
+			}
+		})
+	}
+}
+
+func TestSQSPoller_UnsupportedVersionGoesToDLQ(t *testing.T) {
+	fake := &fakeSQS{messages: []types.Message{{
+		MessageId:     aws.String("msg-v9"),
+		ReceiptHandle: aws.String("rh-v9"),
+		Body:          aws.String(`{"id":"evt-1","type":"reservation.expired","version":"9.0","detail":{}}`),
+	}}}
+
+	cfg := &config.Config{SQSQueueURL: "queue", SQSDLQURL: "dlq"}
+	eventsChan := make(chan *handler.Event, 1)
+	poller := worker.NewSQSPoller(fake, cfg, testLogger(), testMetrics, eventsChan)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go poller.Start(ctx)
+
+	waitFor(t, time.Second, func() bool {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		return len(fake.deleted) == 1
+	})
+	cancel()
+
+	select {
+	case event := <-eventsChan:
+		t.Fatalf("Unsupported version must not be dispatched, got event %s", event.ID)
+	default:
+	}
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if len(fake.sent["dlq"]) != 1 {
+		t.Errorf("Expected message in DLQ, got %d", len(fake.sent["dlq"]))
+	}
+}
+
+func TestSQSPoller_DryRunKeepsMessages(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		receiveCount string
+		parsePolicy  string
+		dispatched   bool
+	}{
+		{"handled event", `{"id":"evt-1","type":"reservation.expired","detail":{}}`, "1", config.ParseErrorPolicyDLQ, true},
+		{"poison message", `{"id":"evt-1","type":"reservation.expired","detail":{}}`, "6", config.ParseErrorPolicyDLQ, false},
+		{"unsupported version", `{"id":"evt-1","type":"reservation.expired","version":"99","detail":{}}`, "1", config.ParseErrorPolicyDLQ, false},
+		{"unparseable message sent to DLQ", `{"id":"evt-1","type":`, "1", config.ParseErrorPolicyDLQ, false},
+		{"unparseable message dropped", `{"id":"evt-1","type":`, "1", config.ParseErrorPolicyDrop, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeSQS{messages: []types.Message{{
+				MessageId:     aws.String("msg-1"),
+				ReceiptHandle: aws.String("rh-1"),
+				Body:          aws.String(tt.body),
+				Attributes:    map[string]string{"ApproximateReceiveCount": tt.receiveCount},
+			}}}
+
+			cfg := &config.Config{
+				SQSQueueURL:        "queue",
+				SQSDLQURL:          "dlq",
+				SQSMaxReceiveCount: 5,
+				ParseErrorPolicy:   tt.parsePolicy,
+				DryRun:             true,
+				DryRunKeepMessage:  true,
+			}
+			eventsChan := make(chan *handler.Event, 1)
+			poller := worker.NewSQSPoller(fake, cfg, testLogger(), testMetrics, eventsChan)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go poller.Start(ctx)
+
+			if tt.dispatched {
+				select {
+				case <-eventsChan:
+				case <-time.After(time.Second):
+					t.Fatal("Timed out waiting for event to be dispatched")
+				}
+			}
+			// The next receive starts once the message was dealt with
+			waitFor(t, time.Second, func() bool {
+				fake.mu.Lock()
+				defer fake.mu.Unlock()
+				return len(fake.receiveTimes) > 1
+			})
+			poller.Stop()
+			poller.Wait(ctx)
+
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			if len(fake.deleted) != 0 || len(fake.sent) != 0 {
+				t.Errorf("Expected message to be kept in dry-run mode, got deletes %v and sends %v", fake.deleted, fake.sent)
+			}
+		})
+	}
+}
+
+func TestSQSPoller_StopAbandonsLongPoll(t *testing.T) {
+	cfg := &config.Config{SQSQueueURL: "https://sqs.test/queue", SQSWaitTime: 20, BackoffBaseMS: 1}
+	poller := worker.NewSQSPoller(&blockingSQS{}, cfg, testLogger(), testMetrics, make(chan *handler.Event, 1))
+
+	go poller.Start(context.Background())
+	time.Sleep(20 * time.Millisecond)
+
+	poller.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := poller.Wait(ctx); err != nil {
+		t.Fatalf("Wait() error = %v, expected poll loop to exit promptly after Stop", err)
+	}
+}
+
+// blockingSQS long-polls until the request context is cancelled
+type blockingSQS struct {
+	fakeSQS
+}
+
+func (b *blockingSQS) ReceiveMessage(ctx context.Context, _ *sqs.ReceiveMessageInput, _ ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// gzipBase64 compresses s and base64-encodes the result like the producer does
+func gzipBase64(t *testing.T, s string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(s)); err != nil {
+		t.Fatalf("gzip write error = %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzip close error = %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestSQSPoller_ContentEncoding(t *testing.T) {
+	const expired = `{"id":"evt-1","type":"reservation.expired","detail":{"reservation_id":"rsv-1","event_id":"concert-1","quantity":2,"seat_ids":["A1","A2"]}}`
+
+	tests := []struct {
+		name         string
+		body         string
+		encoding     string
+		wantDispatch bool
+	}{
+		{"plain JSON", expired, "", true},
+		{"gzipped and base64 encoded", gzipBase64(t, expired), "gzip", true},
+		{"encoding is case insensitive", gzipBase64(t, expired), "GZIP", true},
+		{"gzip attribute on plain JSON", expired, "gzip", false},
+		{"unsupported encoding", expired, "br", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := types.Message{
+				MessageId:     aws.String("msg-1"),
+				ReceiptHandle: aws.String("rh-1"),
+				Body:          aws.String(tt.body),
+			}
+			if tt.encoding != "" {
+				message.MessageAttributes = map[string]types.MessageAttributeValue{
+					"Content-Encoding": {DataType: aws.String("String"), StringValue: aws.String(tt.encoding)},
+				}
+			}
+			fake := &fakeSQS{messages: []types.Message{message}}
+
+			eventsChan := make(chan *handler.Event, 1)
+			poller := worker.NewSQSPoller(fake, &config.Config{SQSQueueURL: "queue"}, testLogger(), testMetrics, eventsChan)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go poller.Start(ctx)
+
+			select {
+			case event := <-eventsChan:
+				if !tt.wantDispatch {
+					t.Fatalf("Expected undecodable message not to be dispatched, got %s", event.ID)
+				}
+				detail, err := event.ParseEventDetail()
+				if err != nil {
+					t.Fatalf("ParseEventDetail() error = %v", err)
+				}
+				expiredDetail, ok := detail.(*handler.ReservationExpiredDetail)
+				if !ok || expiredDetail.ReservationID != "rsv-1" || len(expiredDetail.SeatIDs) != 2 {
+					t.Errorf("Unexpected detail %+v", detail)
+				}
+			case <-time.After(200 * time.Millisecond):
+				if tt.wantDispatch {
+					t.Fatal("Timed out waiting for event to be dispatched")
+				}
+			}
+		})
+	}
+}
+
+// protoBody marshals msg, gzipping it when gzipped is set, and base64-encodes the result
+func protoBody(t *testing.T, msg *eventpb.Event, gzipped bool) string {
+	t.Helper()
+	raw, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatalf("proto.Marshal() error = %v", err)
+	}
+	if gzipped {
+		return gzipBase64(t, string(raw))
+	}
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+func TestSQSPoller_ContentType(t *testing.T) {
+	const expired = `{"id":"evt-1","type":"reservation.expired","detail":{"reservation_id":"rsv-1","event_id":"concert-1","quantity":2,"seat_ids":["A1","A2"]}}`
+	msg := &eventpb.Event{
+		Id:   "evt-1",
+		Type: handler.EventTypeReservationExpired,
+		Detail: &eventpb.Event_ReservationExpired{ReservationExpired: &eventpb.ReservationExpired{
+			ReservationId: "rsv-1", EventId: "concert-1", Quantity: 2, SeatIds: []string{"A1", "A2"},
+		}},
+	}
+
+	tests := []struct {
+		name         string
+		body         string
+		contentType  string
+		encoding     string
+		wantDispatch bool
+	}{
+		{"JSON without a content type", expired, "", "", true},
+		{"explicit JSON content type", expired, "application/json", "", true},
+		{"base64 protobuf", protoBody(t, msg, false), "application/x-protobuf", "", true},
+		{"content type parameters are ignored", protoBody(t, msg, false), "Application/X-Protobuf; proto=reservation.worker.v1.Event", "", true},
+		{"gzipped protobuf", protoBody(t, msg, true), "application/x-protobuf", "gzip", true},
+		{"protobuf without its content type", protoBody(t, msg, false), "", "", false},
+		{"JSON marked as protobuf", expired, "application/x-protobuf", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := types.Message{
+				MessageId:         aws.String("msg-1"),
+				ReceiptHandle:     aws.String("rh-1"),
+				Body:              aws.String(tt.body),
+				MessageAttributes: map[string]types.MessageAttributeValue{},
+			}
+			if tt.contentType != "" {
+				message.MessageAttributes["Content-Type"] = types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(tt.contentType)}
+			}
+			if tt.encoding != "" {
+				message.MessageAttributes["Content-Encoding"] = types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(tt.encoding)}
+			}
+			fake := &fakeSQS{messages: []types.Message{message}}
+
+			eventsChan := make(chan *handler.Event, 1)
+			poller := worker.NewSQSPoller(fake, &config.Config{SQSQueueURL: "queue"}, testLogger(), testMetrics, eventsChan)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go poller.Start(ctx)
+
+			select {
+			case event := <-eventsChan:
+				if !tt.wantDispatch {
+					t.Fatalf("Expected undecodable message not to be dispatched, got %s", event.ID)
+				}
+				detail, err := event.ParseEventDetail()
+				if err != nil {
+					t.Fatalf("ParseEventDetail() error = %v", err)
+				}
+				want := &handler.ReservationExpiredDetail{ReservationID: "rsv-1", EventID: "concert-1", Quantity: 2, SeatIDs: []string{"A1", "A2"}}
+				if event.ID != "evt-1" || !reflect.DeepEqual(detail, want) {
+					t.Errorf("Unexpected event %s with detail %+v", event.ID, detail)
+				}
+			case <-time.After(200 * time.Millisecond):
+				if tt.wantDispatch {
+					t.Fatal("Timed out waiting for event to be dispatched")
+				}
+			}
+		})
+	}
+}
+
+// fakeKMS "encrypts" by prefixing the plaintext and counts Decrypt calls
+type fakeKMS struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (f *fakeKMS) Decrypt(_ context.Context, keyID string, ciphertext []byte) ([]byte, error) {
+	f.mu.Lock()
+	f.calls++
+	f.mu.Unlock()
+	if keyID != "alias/events" || !bytes.HasPrefix(ciphertext, []byte("kms:")) {
+		return nil, errors.New("InvalidCiphertextException")
+	}
+	return ciphertext[len("kms:"):], nil
+}
+
+// sealEnvelope encrypts plaintext with dataKey like an envelope-encrypting producer
+func sealEnvelope(t *testing.T, dataKey []byte, plaintext string) string {
+	t.Helper()
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		t.Fatalf("aes.NewCipher() error = %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatalf("cipher.NewGCM() error = %v", err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(plaintext), nil))
+}
+
+func TestSQSPoller_KMSDecrypt(t *testing.T) {
+	dataKey := bytes.Repeat([]byte{7}, 32)
+	encryptedKey := base64.StdEncoding.EncodeToString(append([]byte("kms:"), dataKey...))
+	attributes := func(dataKey string) map[string]types.MessageAttributeValue {
+		attrs := map[string]types.MessageAttributeValue{
+			"kms-key-id": {DataType: aws.String("String"), StringValue: aws.String("alias/events")},
+		}
+		if dataKey != "" {
+			attrs["kms-data-key"] = types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(dataKey)}
+		}
+		return attrs
+	}
+	event := func(id string) string {
+		return fmt.Sprintf(`{"id":%q,"type":"reservation.expired","detail":{"reservation_id":"rsv-1","event_id":"concert-1","quantity":1}}`, id)
+	}
+
+	fake := &fakeSQS{messages: []types.Message{
+		{
+			MessageId:         aws.String("msg-direct"),
+			ReceiptHandle:     aws.String("rh-direct"),
+			Body:              aws.String(base64.StdEncoding.EncodeToString([]byte("kms:" + event("evt-direct")))),
+			MessageAttributes: attributes(""),
+		},
+		{
+			MessageId:         aws.String("msg-envelope-1"),
+			ReceiptHandle:     aws.String("rh-envelope-1"),
+			Body:              aws.String(sealEnvelope(t, dataKey, event("evt-envelope-1"))),
+			MessageAttributes: attributes(encryptedKey),
+		},
+		{
+			MessageId:         aws.String("msg-envelope-2"),
+			ReceiptHandle:     aws.String("rh-envelope-2"),
+			Body:              aws.String(sealEnvelope(t, dataKey, event("evt-envelope-2"))),
+			MessageAttributes: attributes(encryptedKey),
+		},
+		{
+			MessageId:     aws.String("msg-plain"),
+			ReceiptHandle: aws.String("rh-plain"),
+			Body:          aws.String(event("evt-plain")),
+		},
+	}}
+
+	kms := &fakeKMS{}
+	eventsChan := make(chan *handler.Event, 4)
+	poller := worker.NewSQSPoller(fake, &config.Config{SQSQueueURL: "queue", SQSMaxMessages: 10}, testLogger(), testMetrics, eventsChan)
+	poller.SetKMSDecrypter(kms)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go poller.Start(ctx)
+
+	var got []string
+	for len(got) < 4 {
+		select {
+		case event := <-eventsChan:
+			got = append(got, event.ID)
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for events, got %v", got)
+		}
+	}
+	poller.Stop()
+	poller.Wait(ctx)
+
+	sort.Strings(got)
+	want := []string{"evt-direct", "evt-envelope-1", "evt-envelope-2", "evt-plain"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected events %v, got %v", want, got)
+	}
+
+	// The envelope data key is decrypted once and then served from the cache
+	kms.mu.Lock()
+	defer kms.mu.Unlock()
+	if kms.calls != 2 {
+		t.Errorf("Expected 2 KMS Decrypt calls, got %d", kms.calls)
+	}
+}
+
+func TestSQSPoller_ReturnsMessagesOnShutdown(t *testing.T) {
+	stop := func(poller *worker.SQSPoller, _ context.CancelFunc) { poller.Stop() }
+
+	tests := []struct {
+		name         string
+		deletePolicy string
+		shutdown     func(poller *worker.SQSPoller, cancel context.CancelFunc)
+		wantReturned []string
+		wantDeleted  []string
+		wantDropped  float64
+	}{
+		{"stop", config.DeletePolicyOnSuccess, stop, []string{"rh-1", "rh-2", "rh-3"}, nil, 0},
+		{"context cancelled", config.DeletePolicyOnSuccess, func(_ *worker.SQSPoller, cancel context.CancelFunc) { cancel() }, []string{"rh-1", "rh-2", "rh-3"}, nil, 0},
+		{"deleted on receive is dropped, not returned", config.DeletePolicyOnReceive, stop, []string{"rh-2", "rh-3"}, []string{"rh-1"}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var messages []types.Message
+			for i := 1; i <= 3; i++ {
+				messages = append(messages, types.Message{
+					MessageId:     aws.String("msg-" + strconv.Itoa(i)),
+					ReceiptHandle: aws.String("rh-" + strconv.Itoa(i)),
+					Body:          aws.String(`{"id":"evt-` + strconv.Itoa(i) + `","type":"reservation.expired","detail":{}}`),
+				})
+			}
+			fake := &fakeSQS{messages: messages}
+
+			// Nobody reads the channel: every worker is busy mid-handler
+			eventsChan := make(chan *handler.Event)
+			cfg := &config.Config{SQSQueueURL: "queue", BackoffBaseMS: 1, DeletePolicy: tt.deletePolicy}
+			poller := worker.NewSQSPoller(fake, cfg, testLogger(), testMetrics, eventsChan)
+
+			dropped := testMetrics.EventsTotal.WithLabelValues("reservation.expired", observability.OutcomeDropped, observability.CategoryNone)
+			droppedBefore := testutil.ToFloat64(dropped)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go poller.Start(ctx)
+
+			waitFor(t, time.Second, func() bool {
+				fake.mu.Lock()
+				defer fake.mu.Unlock()
+				return len(fake.receiveTimes) > 0
+			})
+			tt.shutdown(poller, cancel)
+
+			waitCtx, waitCancel := context.WithTimeout(context.Background(), time.Second)
+			defer waitCancel()
+			if err := poller.Wait(waitCtx); err != nil {
+				t.Fatalf("Wait() error = %v, expected the hand-off to be abandoned", err)
+			}
+
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			if !reflect.DeepEqual(fake.returned, tt.wantReturned) {
+				t.Errorf("Expected %v returned to the queue, got %v", tt.wantReturned, fake.returned)
+			}
+			if !reflect.DeepEqual(fake.deleted, tt.wantDeleted) {
+				t.Errorf("Expected deletes %v, got %v", tt.wantDeleted, fake.deleted)
+			}
+			if got := testutil.ToFloat64(dropped) - droppedBefore; got != tt.wantDropped {
+				t.Errorf("Expected %v dropped, got %v", tt.wantDropped, got)
+			}
+		})
+	}
+}
+
+func TestSQSPoller_DeletePolicy(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+	permanent := status.Error(codes.InvalidArgument, "unknown seat")
+
+	tests := []struct {
+		name            string
+		policy          string
+		releaseErr      error
+		maxReceiveCount int
+		dlqURL          string
+		wantDeleted     int
+		wantReturned    int
+		wantSent        int
+	}{
+		{"on-success deletes after success", config.DeletePolicyOnSuccess, nil, 3, "", 1, 0, 0},
+		{"on-success returns retryable failure", config.DeletePolicyOnSuccess, unavailable, 3, "", 0, 1, 0},
+		{"on-success returns retryable failure without a receive count cap", config.DeletePolicyOnSuccess, unavailable, 0, "", 0, 1, 0},
+		{"on-success returns retryable failure despite a DLQ", config.DeletePolicyOnSuccess, unavailable, 0, "dlq", 0, 1, 0},
+		{"on-success returns permanent failure without a DLQ", config.DeletePolicyOnSuccess, permanent, 0, "", 0, 1, 0},
+		{"on-success moves permanent failure to the DLQ", config.DeletePolicyOnSuccess, permanent, 0, "dlq", 1, 0, 1},
+		{"on-receive deletes failed message", config.DeletePolicyOnReceive, permanent, 3, "", 1, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeSQS{messages: []types.Message{{
+				MessageId:     aws.String("msg-1"),
+				ReceiptHandle: aws.String("rh-1"),
+				Body:          aws.String(`{"id":"evt-1","type":"reservation.expired","detail":{"reservation_id":"rsv-1","event_id":"concert-1","qty":1,"seat_ids":["A1"]}}`),
+			}}}
+			cfg := &config.Config{
+				SQSQueueURL:        "queue",
+				DeletePolicy:       tt.policy,
+				WorkerConcurrency:  1,
+				MaxRetries:         1,
+				BackoffBaseMS:      1,
+				SQSMaxReceiveCount: tt.maxReceiveCount,
+				SQSDLQURL:          tt.dlqURL,
+			}
+
+			inventory := &fakeInventory{releaseErr: tt.releaseErr}
+			dispatcher := worker.NewDispatcher(cfg, inventory, &fakeReservation{}, testLogger(), testMetrics)
+			poller := worker.NewSQSPoller(fake, cfg, testLogger(), testMetrics, dispatcher.GetEventsChan())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if err := dispatcher.Start(ctx); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+			go poller.Start(ctx)
+
+			waitFor(t, time.Second, func() bool { return inventory.releaseCount() > 0 })
+
+			// Stopping drains the dispatcher, so the handler outcome has been reported
+			poller.Stop()
+			poller.Wait(ctx)
+			dispatcher.Stop()
+
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			if len(fake.deleted) != tt.wantDeleted {
+				t.Errorf("Expected %d deletes, got %v", tt.wantDeleted, fake.deleted)
+			}
+			if len(fake.returned) != tt.wantReturned {
+				t.Errorf("Expected %d visibility resets, got %v", tt.wantReturned, fake.returned)
+			}
+			if len(fake.sent[tt.dlqURL]) != tt.wantSent {
+				t.Errorf("Expected %d messages sent to the DLQ, got %v", tt.wantSent, fake.sent)
+			}
+		})
+	}
+}
+
+func TestSQSPoller_ReturnsInterruptedMessages(t *testing.T) {
+	tests := []struct {
+		name      string
+		inventory *fakeInventory
+		started   func(*worker.Dispatcher, *fakeInventory) bool
+	}{
+		{
+			"handler cut off",
+			&fakeInventory{releaseBlock: make(chan struct{})},
+			func(d *worker.Dispatcher, _ *fakeInventory) bool { return len(d.InflightEvents()) == 1 },
+		},
+		{
+			"retry waiting out its backoff",
+			&fakeInventory{releaseErr: status.Error(codes.Unavailable, "connection refused")},
+			func(d *worker.Dispatcher, inv *fakeInventory) bool {
+				return inv.releaseCount() == 1 && len(d.InflightEvents()) == 0
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeSQS{messages: []types.Message{{
+				MessageId:     aws.String("msg-1"),
+				ReceiptHandle: aws.String("rh-1"),
+				Body:          aws.String(`{"id":"evt-1","type":"reservation.expired","detail":{"reservation_id":"rsv-1","event_id":"concert-1","qty":1,"seat_ids":["A1"]}}`),
+			}}}
+			cfg := &config.Config{
+				SQSQueueURL:       "queue",
+				DeletePolicy:      config.DeletePolicyOnSuccess,
+				WorkerConcurrency: 1,
+				MaxRetries:        3,
+				BackoffBaseMS:     10000,
+				RetryQueueSize:    10,
+			}
+
+			dispatcher := worker.NewDispatcher(cfg, tt.inventory, &fakeReservation{}, testLogger(), testMetrics)
+			poller := worker.NewSQSPoller(fake, cfg, testLogger(), testMetrics, dispatcher.GetEventsChan())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if err := dispatcher.Start(ctx); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+			go poller.Start(ctx)
+
+			waitFor(t, time.Second, func() bool { return tt.started(dispatcher, tt.inventory) })
+
+			// Shut down as main does: stop polling, then drain until the context ends
+			poller.Stop()
+			poller.Wait(ctx)
+			stopped := make(chan struct{})
+			go func() {
+				dispatcher.Stop()
+				close(stopped)
+			}()
+			time.Sleep(50 * time.Millisecond)
+			cancel()
+
+			select {
+			case <-stopped:
+			case <-time.After(time.Second):
+				t.Fatal("Stop() did not return after the context was cancelled")
+			}
+
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			if !reflect.DeepEqual(fake.returned, []string{"rh-1"}) {
+				t.Errorf("Expected the interrupted message to be returned, got %v", fake.returned)
+			}
+			if len(fake.deleted) != 0 {
+				t.Errorf("Expected no deletes, got %v", fake.deleted)
+			}
+		})
+	}
+}
+
+// orderedSQS records deletes into a log shared with the consumer
+type orderedSQS struct {
+	fakeSQS
+	log *opLog
+}
+
+func (o *orderedSQS) DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error) {
+	o.log.add("delete")
+	return o.fakeSQS.DeleteMessage(ctx, params, optFns...)
+}
+
+type opLog struct {
+	mu  sync.Mutex
+	ops []string
+}
+
+func (l *opLog) add(op string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.ops = append(l.ops, op)
+}
+
+func (l *opLog) snapshot() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.ops...)
+}
+
+func TestSQSPoller_ProcessingGuarantee(t *testing.T) {
+	tests := []struct {
+		name       string
+		guarantees map[string]string
+		want       []string
+	}{
+		{"at-least-once deletes after handling", map[string]string{"reservation.expired": config.GuaranteeAtLeastOnce}, []string{"handle", "delete"}},
+		{"at-most-once deletes before handling", map[string]string{"reservation.expired": config.GuaranteeAtMostOnce}, []string{"delete", "handle"}},
+		{"other types keep the delete policy", map[string]string{"payment.approved": config.GuaranteeAtMostOnce}, []string{"handle", "delete"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := &opLog{}
+			fake := &orderedSQS{log: log, fakeSQS: fakeSQS{messages: []types.Message{{
+				MessageId:     aws.String("msg-1"),
+				ReceiptHandle: aws.String("rh-1"),
+				Body:          aws.String(`{"id":"evt-1","type":"reservation.expired","detail":{}}`),
+			}}}}
+			cfg := &config.Config{
+				SQSQueueURL:          "queue",
+				DeletePolicy:         config.DeletePolicyOnSuccess,
+				ProcessingGuarantees: tt.guarantees,
+			}
+
+			eventsChan := make(chan *handler.Event)
+			poller := worker.NewSQSPoller(fake, cfg, testLogger(), testMetrics, eventsChan)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go poller.Start(ctx)
+
+			// Stand in for the dispatcher: handle, then report success
+			event := <-eventsChan
+			log.add("handle")
+			event.Ack()
+
+			waitFor(t, time.Second, func() bool { return len(log.snapshot()) == 2 })
+			if got := log.snapshot(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected order %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// selectiveInventory fails releases for the listed reservations
+type selectiveInventory struct {
+	fakeInventory
+	fail map[string]bool
+}
+
+func (f *selectiveInventory) ReleaseHold(ctx context.Context, req *reservationv1.ReleaseHoldRequest) error {
+	f.fakeInventory.ReleaseHold(ctx, req)
+	if f.fail[req.ReservationId] {
+		return status.Error(codes.Unavailable, "connection refused")
+	}
+	return nil
+}
+
+func TestSQSPoller_PartialBatchFailure(t *testing.T) {
+	inventory := &selectiveInventory{fail: map[string]bool{"rsv-2": true, "rsv-5": true, "rsv-8": true}}
+
+	var messages []types.Message
+	for i := 0; i < 10; i++ {
+		messages = append(messages, types.Message{
+			MessageId:     aws.String(fmt.Sprintf("msg-%d", i)),
+			ReceiptHandle: aws.String(fmt.Sprintf("rh-%d", i)),
+			Body:          aws.String(fmt.Sprintf(`{"id":"evt-%d","type":"reservation.expired","detail":{"reservation_id":"rsv-%d","event_id":"concert-1","qty":1,"seat_ids":["A1"]}}`, i, i)),
+		})
+	}
+	fake := &fakeSQS{messages: messages}
+	cfg := &config.Config{
+		SQSQueueURL:        "queue",
+		SQSMaxMessages:     10,
+		SQSDeleteBatch:     true,
+		DeletePolicy:       config.DeletePolicyOnSuccess,
+		WorkerConcurrency:  5, // Room for all 10 messages in the events channel, so they arrive in one receive
+		MaxRetries:         1,
+		BackoffBaseMS:      1,
+		SQSMaxReceiveCount: 5,
+	}
+
+	dispatcher := worker.NewDispatcher(cfg, inventory, &fakeReservation{}, testLogger(), testMetrics)
+	poller := worker.NewSQSPoller(fake, cfg, testLogger(), testMetrics, dispatcher.GetEventsChan())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := dispatcher.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	go poller.Start(ctx)
+
+	waitFor(t, time.Second, func() bool {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		return fake.batchDeletes > 0
+	})
+	poller.Stop()
+	poller.Wait(ctx)
+	dispatcher.Stop()
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if fake.batchDeletes != 1 {
+		t.Errorf("Expected a single DeleteMessageBatch call, got %d", fake.batchDeletes)
+	}
+	sort.Strings(fake.deleted)
+	wantDeleted := []string{"rh-0", "rh-1", "rh-3", "rh-4", "rh-6", "rh-7", "rh-9"}
+	if !reflect.DeepEqual(fake.deleted, wantDeleted) {
+		t.Errorf("Expected deletes %v, got %v", wantDeleted, fake.deleted)
+	}
+	sort.Strings(fake.returned)
+	wantReturned := []string{"rh-2", "rh-5", "rh-8"}
+	if !reflect.DeepEqual(fake.returned, wantReturned) {
+		t.Errorf("Expected failed messages %v to be returned, got %v", wantReturned, fake.returned)
+	}
+}
+
+func TestSQSPoller_BatchDeleteDeadline(t *testing.T) {
+	inventory := &drainInventory{release: make(chan struct{}), blocked: make(chan struct{})}
+
+	fake := &fakeSQS{messages: []types.Message{
+		{
+			MessageId:     aws.String("msg-fast"),
+			ReceiptHandle: aws.String("rh-fast"),
+			Body:          aws.String(`{"id":"evt-fast","type":"reservation.expired","detail":{"reservation_id":"rsv-fast","event_id":"concert-1","qty":1,"seat_ids":["A1"]}}`),
+		},
+		{
+			MessageId:     aws.String("msg-slow"),
+			ReceiptHandle: aws.String("rh-slow"),
+			Body:          aws.String(`{"id":"evt-slow","type":"reservation.expired","detail":{"reservation_id":"rsv-slow","event_id":"concert-1","qty":1,"seat_ids":["A2"]}}`),
+		},
+	}}
+	cfg := &config.Config{
+		SQSQueueURL:             "queue",
+		SQSMaxMessages:          10,
+		SQSDeleteBatch:          true,
+		DeletePolicy:            config.DeletePolicyOnSuccess,
+		WorkerConcurrency:       2,
+		MaxRetries:              1,
+		BackoffBaseMS:           1,
+		SQSVisibilityTimeoutSec: 2,
+		SQSVisibilityMarginSec:  1,
+	}
+
+	dispatcher := worker.NewDispatcher(cfg, inventory, &fakeReservation{}, testLogger(), testMetrics)
+	poller := worker.NewSQSPoller(fake, cfg, testLogger(), testMetrics, dispatcher.GetEventsChan())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := dispatcher.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	go poller.Start(ctx)
+
+	<-inventory.blocked
+	// The slow sibling is still running when the deadline flushes the one that succeeded
+	waitFor(t, 2*time.Second, func() bool {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		return fake.batchDeletes > 0
+	})
+	fake.mu.Lock()
+	if !reflect.DeepEqual(fake.deleted, []string{"rh-fast"}) {
+		t.Errorf("Expected only rh-fast deleted at the deadline, got %v", fake.deleted)
+	}
+	fake.mu.Unlock()
+
+	// Its late success is deleted on its own
+	close(inventory.release)
+	waitFor(t, time.Second, func() bool {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		return len(fake.deleted) == 2
+	})
+	poller.Stop()
+	poller.Wait(ctx)
+	dispatcher.Stop()
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if fake.batchDeletes != 1 {
+		t.Errorf("Expected a single DeleteMessageBatch call, got %d", fake.batchDeletes)
+	}
+}
+
+func TestSQSPoller_ParseErrorPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      string
+		outcome     string
+		wantDeleted int
+		wantSent    int
+	}{
+		{"dlq", config.ParseErrorPolicyDLQ, observability.OutcomeInvalidPayload, 1, 1},
+		{"retry", config.ParseErrorPolicyRetry, observability.OutcomeRetried, 0, 0},
+		{"drop", config.ParseErrorPolicyDrop, observability.OutcomeDropped, 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeSQS{messages: []types.Message{{
+				MessageId:     aws.String("msg-malformed"),
+				ReceiptHandle: aws.String("rh-malformed"),
+				Body:          aws.String(`{"id":"evt-1","type":`),
+			}}}
+
+			cfg := &config.Config{SQSQueueURL: "queue", SQSDLQURL: "dlq", ParseErrorPolicy: tt.policy}
+			eventsChan := make(chan *handler.Event, 1)
+			poller := worker.NewSQSPoller(fake, cfg, testLogger(), testMetrics, eventsChan)
+
+			counter := testMetrics.EventsTotal.WithLabelValues("unknown", tt.outcome, observability.CategoryNone)
+			before := testutil.ToFloat64(counter)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go poller.Start(ctx)
+
+			waitFor(t, time.Second, func() bool { return testutil.ToFloat64(counter) > before })
+			poller.Stop()
+			poller.Wait(ctx)
+
+			if len(eventsChan) != 0 {
+				t.Fatal("Unparseable message must not be dispatched")
+			}
+
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			if len(fake.deleted) != tt.wantDeleted {
+				t.Errorf("Expected %d deletes, got %v", tt.wantDeleted, fake.deleted)
+			}
+			if len(fake.sent["dlq"]) != tt.wantSent {
+				t.Errorf("Expected %d messages in DLQ, got %d", tt.wantSent, len(fake.sent["dlq"]))
+			}
+		})
+	}
+}
+
+func TestSQSPoller_Backpressure(t *testing.T) {
+	var messages []types.Message
+	for i := 0; i < 5; i++ {
+		messages = append(messages, types.Message{
+			MessageId:     aws.String(fmt.Sprintf("msg-%d", i)),
+			ReceiptHandle: aws.String(fmt.Sprintf("rh-%d", i)),
+			Body:          aws.String(fmt.Sprintf(`{"id":"evt-%d","type":"reservation.expired"}`, i)),
+		})
+	}
+	fake := &fakeSQS{messages: messages}
+
+	// Nothing consumes the channel until the poller has had time to pause
+	eventsChan := make(chan *handler.Event, 2)
+	poller := worker.NewSQSPoller(fake, &config.Config{SQSQueueURL: "queue", SQSMaxMessages: 10}, testLogger(), testMetrics, eventsChan)
+
+	paused := testMetrics.PollerBackpressure.WithLabelValues(observability.BackpressurePaused)
+	before := testutil.ToFloat64(paused)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go poller.Start(ctx)
+
+	waitFor(t, time.Second, func() bool { return len(eventsChan) == 2 })
+	waitFor(t, time.Second, func() bool { return testutil.ToFloat64(paused) > before })
+
+	fake.mu.Lock()
+	receives, remaining := len(fake.receiveTimes), len(fake.messages)
+	fake.mu.Unlock()
+	if remaining != 3 {
+		t.Fatalf("Expected the first receive to request only the 2 free slots, %d messages left in the queue", remaining)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	fake.mu.Lock()
+	if got := len(fake.receiveTimes); got != receives {
+		t.Errorf("Expected no receives while the events channel is full, got %d more", got-receives)
+	}
+	fake.mu.Unlock()
+
+	// Draining the channel lets the poller receive the rest
+	var got []string
+	for len(got) < 5 {
+		select {
+		case event := <-eventsChan:
+			got = append(got, event.ID)
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for events, got %v", got)
+		}
+	}
+	poller.Stop()
+	poller.Wait(ctx)
+}
+
+func TestSQSPoller_PrefetchByFreeWorkers(t *testing.T) {
+	var messages []types.Message
+	for i := 0; i < 6; i++ {
+		messages = append(messages, types.Message{
+			MessageId:     aws.String(fmt.Sprintf("msg-%d", i)),
+			ReceiptHandle: aws.String(fmt.Sprintf("rh-%d", i)),
+			Body:          aws.String(fmt.Sprintf(`{"id":"evt-%d","type":"reservation.expired","detail":{"reservation_id":"rsv-%d","event_id":"concert-1","qty":1,"seat_ids":["A%d"]}}`, i, i, i)),
+		})
+	}
+	fake := &fakeSQS{messages: messages}
+
+	cfg := &config.Config{
+		SQSQueueURL:       "queue",
+		SQSMaxMessages:    10,
+		WorkerConcurrency: 1,
+		MaxRetries:        1,
+		BackoffBaseMS:     1,
+		SQSPrefetchExtra:  1,
+	}
+	inventory := &fakeInventory{}
+	dispatcher := worker.NewDispatcher(cfg, inventory, &fakeReservation{}, testLogger(), testMetrics)
+	poller := worker.NewSQSPoller(fake, cfg, testLogger(), testMetrics, dispatcher.GetEventsChan())
+	poller.SetWorkerCapacity(dispatcher)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go dispatcher.Start(ctx)
+	go poller.Start(ctx)
+
+	waitFor(t, 2*time.Second, func() bool { return inventory.releaseCount() == 6 })
+	poller.Stop()
+	poller.Wait(ctx)
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	for i, n := range fake.requested {
+		if n < 1 || n > 2 {
+			t.Errorf("Receive %d requested %d messages, want 1-2 for one free worker plus one prefetched", i, n)
+		}
+	}
+}
+
+func TestSQSPoller_PauseResume(t *testing.T) {
+	fake := &fakeSQS{}
+	poller := worker.NewSQSPoller(fake, &config.Config{SQSQueueURL: "queue"}, testLogger(), testMetrics, make(chan *handler.Event, 1))
+	receives := func() int {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		return len(fake.receiveTimes)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go poller.Start(ctx)
+	waitFor(t, time.Second, func() bool { return receives() > 0 })
+
+	if !poller.Pause() || !poller.Paused() {
+		t.Fatal("Expected Pause() to pause a running poller")
+	}
+	if poller.Pause() {
+		t.Error("Expected a second Pause() to report no change")
+	}
+
+	// Allow a receive in progress when pausing to finish
+	time.Sleep(20 * time.Millisecond)
+	paused := receives()
+	time.Sleep(100 * time.Millisecond)
+	if got := receives(); got != paused {
+		t.Errorf("Expected no receives while paused, got %d more", got-paused)
+	}
+
+	if !poller.Resume() || poller.Paused() {
+		t.Fatal("Expected Resume() to resume a paused poller")
+	}
+	waitFor(t, time.Second, func() bool { return receives() > paused })
+
+	poller.Stop()
+	poller.Wait(ctx)
+}
+
+// fakeGate reports ready once ready is closed
+type fakeGate struct {
+	ready chan struct{}
+}
+
+func (g *fakeGate) WaitReady(ctx context.Context) error {
+	select {
+	case <-g.ready:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestSQSPoller_WaitsForReadiness(t *testing.T) {
+	tests := []struct {
+		name      string
+		timeoutMS int
+		openGate  bool
+	}{
+		{"receives once ready", 0, true},
+		{"polls anyway after the timeout", 150, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeSQS{}
+			cfg := &config.Config{SQSQueueURL: "queue", StartupReadyTimeoutMS: tt.timeoutMS}
+			poller := worker.NewSQSPoller(fake, cfg, testLogger(), testMetrics, make(chan *handler.Event, 1))
+			gate := &fakeGate{ready: make(chan struct{})}
+			poller.SetReadinessGate(gate)
+			receives := func() int {
+				fake.mu.Lock()
+				defer fake.mu.Unlock()
+				return len(fake.receiveTimes)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go poller.Start(ctx)
+
+			time.Sleep(100 * time.Millisecond)
+			if got := receives(); got != 0 {
+				t.Fatalf("Expected no receives before the connection is ready, got %d", got)
+			}
+
+			if tt.openGate {
+				close(gate.ready)
+			}
+			waitFor(t, time.Second, func() bool { return receives() > 0 })
+
+			poller.Stop()
+			poller.Wait(ctx)
+		})
+	}
+}
+
+func TestSQSPoller_StopWhileWaitingForReadiness(t *testing.T) {
+	poller := worker.NewSQSPoller(&fakeSQS{}, &config.Config{SQSQueueURL: "queue"}, testLogger(), testMetrics, make(chan *handler.Event, 1))
+	poller.SetReadinessGate(&fakeGate{ready: make(chan struct{})})
+
+	errs := make(chan error, 1)
+	go func() { errs <- poller.Start(context.Background()) }()
+	poller.Stop()
+
+	select {
+	case err := <-errs:
+		if err != nil {
+			t.Errorf("Start() error = %v, want nil after Stop", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected Stop to end a poller waiting for readiness")
+	}
+}
+
+func TestSQSPoller_VisibilityDeadline(t *testing.T) {
+	tests := []struct {
+		name           string
+		timeoutSec     int
+		marginSec      int
+		wantDeadlineIn time.Duration // from the receive; 0 = no deadline
+	}{
+		{"deadline ahead of the visibility timeout", 30, 5, 25 * time.Second},
+		{"queue default timeout sets no deadline", 0, 5, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeSQS{messages: []types.Message{{
+				MessageId:     aws.String("msg-1"),
+				ReceiptHandle: aws.String("rh-1"),
+				Body:          aws.String(`{"id":"evt-1","type":"reservation.expired","detail":{}}`),
+			}}}
+			cfg := &config.Config{SQSQueueURL: "queue", SQSVisibilityTimeoutSec: tt.timeoutSec, SQSVisibilityMarginSec: tt.marginSec}
+			eventsChan := make(chan *handler.Event, 1)
+			poller := worker.NewSQSPoller(fake, cfg, testLogger(), testMetrics, eventsChan)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			start := time.Now()
+			go poller.Start(ctx)
+
+			var event *handler.Event
+			select {
+			case event = <-eventsChan:
+			case <-time.After(time.Second):
+				t.Fatal("Timed out waiting for event to be dispatched")
+			}
+			cancel()
+
+			fake.mu.Lock()
+			requested := fake.visibility[0]
+			fake.mu.Unlock()
+			if requested != int32(tt.timeoutSec) {
+				t.Errorf("Expected a visibility timeout of %d requested, got %d", tt.timeoutSec, requested)
+			}
+
+			deadline := event.VisibilityDeadline()
+			if tt.wantDeadlineIn == 0 {
+				if !deadline.IsZero() {
+					t.Errorf("Expected no visibility deadline, got %v", deadline)
+				}
+				return
+			}
+			if earliest, latest := start.Add(tt.wantDeadlineIn), time.Now().Add(tt.wantDeadlineIn); deadline.Before(earliest) || deadline.After(latest) {
+				t.Errorf("Expected a deadline about %v after the receive, got %v", tt.wantDeadlineIn, deadline.Sub(start))
+			}
+		})
+	}
+}
+
+func TestSQSPoller_SourceAllowlist(t *testing.T) {
+	tests := []struct {
+		name         string
+		source       string
+		wantDispatch bool
+	}{
+		{"expected producer", "payment-sim-api", true},
+		{"unexpected producer", "reservation-api", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeSQS{messages: []types.Message{{
+				MessageId:     aws.String("msg-1"),
+				ReceiptHandle: aws.String("rh-1"),
+				Body:          aws.String(fmt.Sprintf(`{"id":"evt-1","type":"payment.failed","source":%q,"detail":{}}`, tt.source)),
+			}}}
+			cfg := &config.Config{
+				SQSQueueURL:  "queue",
+				SQSDLQURL:    "dlq",
+				EventSources: map[string]string{"payment.*": "payment-sim-api"},
+			}
+			metrics := observability.NewMetrics(observability.WithRegistry(prometheus.NewRegistry()))
+			defer metrics.Unregister()
+			eventsChan := make(chan *handler.Event, 1)
+			poller := worker.NewSQSPoller(fake, cfg, testLogger(), metrics, eventsChan)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go poller.Start(ctx)
+
+			if tt.wantDispatch {
+				select {
+				case event := <-eventsChan:
+					if event.Source != tt.source {
+						t.Errorf("Expected an event from %s, got %s", tt.source, event.Source)
+					}
+				case <-time.After(time.Second):
+					t.Fatal("Timed out waiting for event to be dispatched")
+				}
+			} else {
+				waitFor(t, time.Second, func() bool {
+					fake.mu.Lock()
+					defer fake.mu.Unlock()
+					return len(fake.deleted) == 1
+				})
+			}
+			poller.Stop()
+			poller.Wait(ctx)
+
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			if diverted := len(fake.sent["dlq"]) == 1; diverted == tt.wantDispatch {
+				t.Errorf("Expected sent to DLQ = %v, got %v", !tt.wantDispatch, diverted)
+			}
+			if !tt.wantDispatch && len(eventsChan) != 0 {
+				t.Error("Expected the mismatched event not to be dispatched")
+			}
+			mismatches := testutil.ToFloat64(metrics.EventsTotal.WithLabelValues("payment.failed", observability.OutcomeSourceMismatch, observability.CategoryNone))
+			want := 0.0
+			if !tt.wantDispatch {
+				want = 1
+			}
+			if mismatches != want {
+				t.Errorf("Expected %v source_mismatch outcomes, got %v", want, mismatches)
+			}
+		})
+	}
+}
+
+func TestSQSPoller_PriorityAttribute(t *testing.T) {
+	tests := []struct {
+		name     string
+		priority string // "" = attribute not set
+		wantHigh bool
+	}{
+		{"high priority", "urgent", true},
+		{"other priority", "low", false},
+		{"no priority", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := types.Message{
+				MessageId:     aws.String("msg-1"),
+				ReceiptHandle: aws.String("rh-1"),
+				Body:          aws.String(`{"id":"evt-1","type":"payment.failed","detail":{}}`),
+			}
+			if tt.priority != "" {
+				message.MessageAttributes = map[string]types.MessageAttributeValue{
+					"priority": {DataType: aws.String("String"), StringValue: aws.String(tt.priority)},
+				}
+			}
+			fake := &fakeSQS{messages: []types.Message{message}}
+			cfg := &config.Config{
+				SQSQueueURL:        "queue",
+				PriorityAttribute:  "priority",
+				PriorityHighValues: []string{"urgent"},
+			}
+			eventsChan := make(chan *handler.Event, 1)
+			highChan := make(chan *handler.Event, 1)
+			poller := worker.NewSQSPoller(fake, cfg, testLogger(), testMetrics, eventsChan)
+			poller.SetHighPriorityChan(highChan)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go poller.Start(ctx)
+
+			var gotHigh bool
+			select {
+			case <-highChan:
+				gotHigh = true
+			case <-eventsChan:
+			case <-time.After(time.Second):
+				t.Fatal("Timed out waiting for event to be dispatched")
+			}
+			poller.Stop()
+			poller.Wait(ctx)
+
+			if gotHigh != tt.wantHigh {
+				t.Errorf("Expected high-priority dispatch = %v, got %v", tt.wantHigh, gotHigh)
+			}
+		})
+	}
+}
+
+func TestSQSPoller_DeleteRetries(t *testing.T) {
+	errDelete := errors.New("InternalError: service unavailable")
+
+	tests := []struct {
+		name        string
+		deleteErrs  []error
+		wantCalls   int
+		wantDeleted bool
+	}{
+		{"transient failure is retried", []error{errDelete}, 2, true},
+		{"gives up after the retries", []error{errDelete, errDelete, errDelete}, 3, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeSQS{
+				messages: []types.Message{{
+					MessageId:     aws.String("msg-1"),
+					ReceiptHandle: aws.String("rh-1"),
+					Body:          aws.String(`{"id":"evt-1","type":"reservation.expired","detail":{}}`),
+				}},
+				deleteErrs: tt.deleteErrs,
+			}
+			cfg := &config.Config{SQSQueueURL: "queue", SQSDeleteRetries: 2, SQSDeleteBackoffMS: 1}
+			eventsChan := make(chan *handler.Event, 1)
+			poller := worker.NewSQSPoller(fake, cfg, testLogger(), testMetrics, eventsChan)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go poller.Start(ctx)
+
+			select {
+			case event := <-eventsChan:
+				event.Ack()
+			case <-time.After(time.Second):
+				t.Fatal("Timed out waiting for event to be dispatched")
+			}
+			poller.Stop()
+			poller.Wait(ctx)
+
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			if fake.deleteCalls != tt.wantCalls {
+				t.Errorf("Expected %d DeleteMessage calls, got %d", tt.wantCalls, fake.deleteCalls)
+			}
+			if deleted := len(fake.deleted) == 1; deleted != tt.wantDeleted {
+				t.Errorf("Expected deleted = %v, got %v", tt.wantDeleted, deleted)
+			}
+		})
+	}
+}
+
+func TestSQSPoller_BatchDeleteRetries(t *testing.T) {
+	errDelete := errors.New("InternalError: service unavailable")
+
+	tests := []struct {
+		name        string
+		batchErrs   []error
+		entryFails  map[string]int
+		wantCalls   int
+		wantDeleted []string
+	}{
+		{"failed call is retried", []error{errDelete}, nil, 2, []string{"rh-1", "rh-2"}},
+		{"failed entry is retried alone", nil, map[string]int{"rh-2": 1}, 2, []string{"rh-1", "rh-2"}},
+		{"gives up after the retries", nil, map[string]int{"rh-2": 3}, 3, []string{"rh-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeSQS{
+				messages: []types.Message{
+					{MessageId: aws.String("msg-1"), ReceiptHandle: aws.String("rh-1"), Body: aws.String(`{"id":"evt-1","type":"reservation.expired","detail":{}}`)},
+					{MessageId: aws.String("msg-2"), ReceiptHandle: aws.String("rh-2"), Body: aws.String(`{"id":"evt-2","type":"reservation.expired","detail":{}}`)},
+				},
+				batchErrs:  tt.batchErrs,
+				entryFails: tt.entryFails,
+			}
+			cfg := &config.Config{SQSQueueURL: "queue", SQSMaxMessages: 10, SQSDeleteBatch: true, SQSDeleteRetries: 2, SQSDeleteBackoffMS: 1}
+			eventsChan := make(chan *handler.Event, 2)
+			poller := worker.NewSQSPoller(fake, cfg, testLogger(), testMetrics, eventsChan)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go poller.Start(ctx)
+
+			for i := 0; i < 2; i++ {
+				select {
+				case event := <-eventsChan:
+					event.Ack()
+				case <-time.After(time.Second):
+					t.Fatal("Timed out waiting for event to be dispatched")
+				}
+			}
+			waitFor(t, time.Second, func() bool {
+				fake.mu.Lock()
+				defer fake.mu.Unlock()
+				return fake.batchDeletes == tt.wantCalls
+			})
+			poller.Stop()
+			poller.Wait(ctx)
+
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			if fake.batchDeletes != tt.wantCalls {
+				t.Errorf("Expected %d DeleteMessageBatch calls, got %d", tt.wantCalls, fake.batchDeletes)
+			}
+			sort.Strings(fake.deleted)
+			if !reflect.DeepEqual(fake.deleted, tt.wantDeleted) {
+				t.Errorf("Expected deletes %v, got %v", tt.wantDeleted, fake.deleted)
+			}
+		})
+	}
+}
+
+// slowSQS holds each receive open for a while, like a long poll, and records how many overlapped
+type slowSQS struct {
+	*fakeSQS
+	active atomic.Int32
+	peak   atomic.Int32
+}
+
+func (s *slowSQS) ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	active := s.active.Add(1)
+	defer s.active.Add(-1)
+	for {
+		peak := s.peak.Load()
+		if active <= peak || s.peak.CompareAndSwap(peak, active) {
+			break
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(50 * time.Millisecond):
+	}
+	return s.fakeSQS.ReceiveMessage(ctx, params, optFns...)
+}
+
+func TestSQSPoller_ConcurrentPollers(t *testing.T) {
+	metrics := observability.NewMetrics(observability.WithRegistry(prometheus.NewRegistry()))
+	defer metrics.Unregister()
+
+	var messages []types.Message
+	for i := 1; i <= 6; i++ {
+		messages = append(messages, types.Message{
+			MessageId:     aws.String(fmt.Sprintf("msg-%d", i)),
+			ReceiptHandle: aws.String(fmt.Sprintf("rh-%d", i)),
+			Body:          aws.String(fmt.Sprintf(`{"id":"evt-%d","type":"reservation.expired","detail":{}}`, i)),
+		})
+	}
+	// The first receive fails; its loop backs off for long while the other two keep receiving
+	fake := &slowSQS{fakeSQS: &fakeSQS{
+		messages:    messages,
+		receiveErrs: []error{errors.New("InternalError: service unavailable")},
+	}}
+	cfg := &config.Config{SQSQueueURL: "queue", SQSMaxMessages: 1, SQSPollerCount: 3, MaxRetries: 5, BackoffBaseMS: 10000}
+	eventsChan := make(chan *handler.Event, len(messages))
+	poller := worker.NewSQSPoller(fake, cfg, testLogger(), metrics, eventsChan)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go poller.Start(ctx)
+
+	for range messages {
+		select {
+		case event := <-eventsChan:
+			event.Ack()
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timed out waiting for events while one poller was backing off")
+		}
+	}
+	poller.Stop()
+	if err := poller.Wait(ctx); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	if got := fake.peak.Load(); got != 3 {
+		t.Errorf("Expected 3 receives in flight at once, got %d", got)
+	}
+	if got := testutil.ToFloat64(metrics.SQSPollErrors); got != 1 {
+		t.Errorf("Expected 1 poll error counted across pollers, got %v", got)
+	}
+}
+
+// panickySQS panics on the first receive, like a bug hit by one bad response
+type panickySQS struct {
+	*fakeSQS
+	panicked atomic.Bool
+}
+
+func (p *panickySQS) ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	if p.panicked.CompareAndSwap(false, true) {
+		panic("unexpected nil response")
+	}
+	return p.fakeSQS.ReceiveMessage(ctx, params, optFns...)
+}
+
+func TestSQSPoller_RestartedAfterCrash(t *testing.T) {
+	metrics := observability.NewMetrics(observability.WithRegistry(prometheus.NewRegistry()))
+	defer metrics.Unregister()
+
+	fake := &panickySQS{fakeSQS: &fakeSQS{messages: []types.Message{{
+		MessageId:     aws.String("msg-1"),
+		ReceiptHandle: aws.String("rh-1"),
+		Body:          aws.String(`{"id":"evt-1","type":"reservation.expired","detail":{}}`),
+	}}}}
+	cfg := &config.Config{SQSQueueURL: "queue"}
+	eventsChan := make(chan *handler.Event, 1)
+	poller := worker.NewSQSPoller(fake, cfg, testLogger(), metrics, eventsChan)
+	supervisor := lifecycle.NewSupervisor(3, time.Millisecond, testLogger(), metrics)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	superviseCtx, stopSupervising := context.WithCancel(ctx)
+	supervised := make(chan error, 1)
+	go func() {
+		supervised <- supervisor.Supervise(superviseCtx, "poller", func() error { return poller.Start(ctx) })
+	}()
+
+	select {
+	case event := <-eventsChan:
+		event.Ack()
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for the restarted poller to dispatch")
+	}
+
+	// Shutdown as main does it: end supervision, then stop the poller and wait for it
+	stopSupervising()
+	poller.Stop()
+	if err := poller.Wait(ctx); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if err := <-supervised; err != nil {
+		t.Errorf("Supervise() error = %v", err)
+	}
+	if got := testutil.ToFloat64(metrics.SubsystemRestarts.WithLabelValues("poller")); got != 1 {
+		t.Errorf("Expected 1 poller restart, got %v", got)
+	}
+}
+
+// freeWorkers reports a fixed number of free workers
+type freeWorkers int
+
+func (f freeWorkers) FreeWorkers() int { return int(f) }
+
+// hangingSQS holds every receive open until its context ends, like an empty long poll
+type hangingSQS struct {
+	*fakeSQS
+}
+
+func (h *hangingSQS) ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, _ ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	h.mu.Lock()
+	h.requested = append(h.requested, params.MaxNumberOfMessages)
+	h.mu.Unlock()
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestSQSPoller_ConcurrentPollersShareCapacity(t *testing.T) {
+	fake := &hangingSQS{fakeSQS: &fakeSQS{}}
+	cfg := &config.Config{SQSQueueURL: "queue", SQSMaxMessages: 10, SQSPollerCount: 3}
+	poller := worker.NewSQSPoller(fake, cfg, testLogger(), testMetrics, make(chan *handler.Event, 100))
+	poller.SetWorkerCapacity(freeWorkers(4))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go poller.Start(ctx)
+
+	waitFor(t, time.Second, func() bool {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		return len(fake.requested) > 0
+	})
+	// Give the other loops time to receive too, had they found room
+	time.Sleep(100 * time.Millisecond)
+	poller.Stop()
+	poller.Wait(ctx)
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	var total int32
+	for _, n := range fake.requested {
+		total += n
+	}
+	if total != 4 {
+		t.Errorf("Expected the 3 loops to request 4 messages between them for 4 free workers, got %v", fake.requested)
+	}
+}
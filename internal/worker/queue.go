@@ -0,0 +1,120 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// QueueURLResolver looks up a queue URL by name
+type QueueURLResolver interface {
+	GetQueueUrl(ctx context.Context, params *sqs.GetQueueUrlInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error)
+}
+
+// ResolveQueueURL returns the URL of the named queue. ownerAccount selects a queue
+// of another AWS account; empty means the caller's account.
+func ResolveQueueURL(ctx context.Context, client QueueURLResolver, name, ownerAccount string) (string, error) {
+	input := &sqs.GetQueueUrlInput{QueueName: aws.String(name)}
+	if ownerAccount != "" {
+		input.QueueOwnerAWSAccountId = aws.String(ownerAccount)
+	}
+
+	result, err := client.GetQueueUrl(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve URL of queue %q: %w", name, err)
+	}
+	return aws.ToString(result.QueueUrl), nil
+}
+
+// defaultRedriveMaxReceiveCount is the maxReceiveCount of created queues when none is given
+const defaultRedriveMaxReceiveCount = 5
+
+// QueueCreator creates queues; used for local development only
+type QueueCreator interface {
+	QueueURLResolver
+	CreateQueue(ctx context.Context, params *sqs.CreateQueueInput, optFns ...func(*sqs.Options)) (*sqs.CreateQueueOutput, error)
+	GetQueueAttributes(ctx context.Context, params *sqs.GetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error)
+}
+
+// EnsureQueue creates the named queue unless it exists, along with a "<name>-dlq"
+// dead letter queue receiving messages after maxReceiveCount receives (0 = 5).
+// It returns the queue URL and whether the queue was created.
+func EnsureQueue(ctx context.Context, client QueueCreator, name string, maxReceiveCount int) (string, bool, error) {
+	if maxReceiveCount <= 0 {
+		maxReceiveCount = defaultRedriveMaxReceiveCount
+	}
+
+	existing, err := client.GetQueueUrl(ctx, &sqs.GetQueueUrlInput{QueueName: aws.String(name)})
+	if err == nil {
+		return aws.ToString(existing.QueueUrl), false, nil
+	}
+	var notFound *types.QueueDoesNotExist
+	if !errors.As(err, &notFound) {
+		return "", false, fmt.Errorf("failed to look up queue %q: %w", name, err)
+	}
+
+	// FIFO queues need a FIFO dead letter queue
+	dlqName := name + "-dlq"
+	attributes := map[string]string{}
+	if base, ok := strings.CutSuffix(name, ".fifo"); ok {
+		dlqName = base + "-dlq.fifo"
+		attributes[string(types.QueueAttributeNameFifoQueue)] = "true"
+	}
+
+	dlq, err := client.CreateQueue(ctx, &sqs.CreateQueueInput{QueueName: aws.String(dlqName), Attributes: attributes})
+	if err != nil {
+		return "", false, fmt.Errorf("failed to create dead letter queue %q: %w", dlqName, err)
+	}
+	dlqAttributes, err := client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       dlq.QueueUrl,
+		AttributeNames: []types.QueueAttributeName{types.QueueAttributeNameQueueArn},
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get ARN of dead letter queue %q: %w", dlqName, err)
+	}
+
+	redrivePolicy, err := json.Marshal(map[string]string{
+		"deadLetterTargetArn": dlqAttributes.Attributes[string(types.QueueAttributeNameQueueArn)],
+		"maxReceiveCount":     strconv.Itoa(maxReceiveCount),
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("failed to marshal redrive policy: %w", err)
+	}
+	attributes[string(types.QueueAttributeNameRedrivePolicy)] = string(redrivePolicy)
+
+	// CreateQueue returns the existing queue when one with the same attributes
+	// was created concurrently
+	created, err := client.CreateQueue(ctx, &sqs.CreateQueueInput{QueueName: aws.String(name), Attributes: attributes})
+	if err != nil {
+		return "", false, fmt.Errorf("failed to create queue %q: %w", name, err)
+	}
+	return aws.ToString(created.QueueUrl), true, nil
+}
+
+// IsLocalStackEndpoint reports whether endpoint points at a local emulator
+// such as LocalStack. An empty endpoint means AWS itself.
+func IsLocalStackEndpoint(endpoint string) bool {
+	if endpoint == "" {
+		return false
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return false
+	}
+
+	host := u.Hostname()
+	if host == "localhost" || host == "localstack" || strings.HasSuffix(host, ".localstack.cloud") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,155 @@
+package worker_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/traffic-tacos/reservation-worker/internal/worker"
+)
+
+// fakeQueueDirectory resolves queue names of known accounts
+type fakeQueueDirectory struct {
+	queues map[string]string // account/name -> URL
+}
+
+func (f *fakeQueueDirectory) GetQueueUrl(_ context.Context, params *sqs.GetQueueUrlInput, _ ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error) {
+	account := aws.ToString(params.QueueOwnerAWSAccountId)
+	if account == "" {
+		account = "self"
+	}
+	url, ok := f.queues[account+"/"+aws.ToString(params.QueueName)]
+	if !ok {
+		return nil, errors.New("AWS.SimpleQueueService.NonExistentQueue")
+	}
+	return &sqs.GetQueueUrlOutput{QueueUrl: aws.String(url)}, nil
+}
+
+func TestResolveQueueURL(t *testing.T) {
+	directory := &fakeQueueDirectory{queues: map[string]string{
+		"self/reservation-events":         "https://sqs.ap-northeast-2.amazonaws.com/111/reservation-events",
+		"222222222222/reservation-events": "https://sqs.ap-northeast-2.amazonaws.com/222222222222/reservation-events",
+	}}
+
+	tests := []struct {
+		name    string
+		queue   string
+		account string
+		want    string
+		wantErr bool
+	}{
+		{"own account", "reservation-events", "", "https://sqs.ap-northeast-2.amazonaws.com/111/reservation-events", false},
+		{"other account", "reservation-events", "222222222222", "https://sqs.ap-northeast-2.amazonaws.com/222222222222/reservation-events", false},
+		{"unknown queue", "missing", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := worker.ResolveQueueURL(context.Background(), directory, tt.queue, tt.account)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveQueueURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveQueueURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeQueueService keeps created queues and their attributes in memory
+type fakeQueueService struct {
+	queues  map[string]map[string]string // name -> attributes
+	created []string
+}
+
+func (f *fakeQueueService) GetQueueUrl(_ context.Context, params *sqs.GetQueueUrlInput, _ ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error) {
+	name := aws.ToString(params.QueueName)
+	if _, ok := f.queues[name]; !ok {
+		return nil, &types.QueueDoesNotExist{Message: aws.String("queue does not exist")}
+	}
+	return &sqs.GetQueueUrlOutput{QueueUrl: aws.String("http://localhost:4566/000000000000/" + name)}, nil
+}
+
+func (f *fakeQueueService) CreateQueue(_ context.Context, params *sqs.CreateQueueInput, _ ...func(*sqs.Options)) (*sqs.CreateQueueOutput, error) {
+	name := aws.ToString(params.QueueName)
+	if _, ok := f.queues[name]; !ok {
+		f.queues[name] = params.Attributes
+		f.created = append(f.created, name)
+	}
+	return &sqs.CreateQueueOutput{QueueUrl: aws.String("http://localhost:4566/000000000000/" + name)}, nil
+}
+
+func (f *fakeQueueService) GetQueueAttributes(_ context.Context, params *sqs.GetQueueAttributesInput, _ ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error) {
+	name := path.Base(aws.ToString(params.QueueUrl))
+	return &sqs.GetQueueAttributesOutput{Attributes: map[string]string{
+		"QueueArn": "arn:aws:sqs:ap-northeast-2:000000000000:" + name,
+	}}, nil
+}
+
+func TestEnsureQueue(t *testing.T) {
+	t.Run("creates queue and DLQ", func(t *testing.T) {
+		service := &fakeQueueService{queues: map[string]map[string]string{}}
+
+		url, created, err := worker.EnsureQueue(context.Background(), service, "reservation-events", 3)
+		if err != nil {
+			t.Fatalf("EnsureQueue() error = %v", err)
+		}
+		if !created {
+			t.Error("Expected the queue to be created")
+		}
+		if url != "http://localhost:4566/000000000000/reservation-events" {
+			t.Errorf("Unexpected queue URL %q", url)
+		}
+		if len(service.created) != 2 || service.created[0] != "reservation-events-dlq" {
+			t.Fatalf("Expected the DLQ and then the queue to be created, got %v", service.created)
+		}
+
+		var policy map[string]string
+		if err := json.Unmarshal([]byte(service.queues["reservation-events"]["RedrivePolicy"]), &policy); err != nil {
+			t.Fatalf("Invalid redrive policy: %v", err)
+		}
+		if policy["deadLetterTargetArn"] != "arn:aws:sqs:ap-northeast-2:000000000000:reservation-events-dlq" || policy["maxReceiveCount"] != "3" {
+			t.Errorf("Unexpected redrive policy %v", policy)
+		}
+	})
+
+	t.Run("no-op when queue exists", func(t *testing.T) {
+		service := &fakeQueueService{queues: map[string]map[string]string{"reservation-events": nil}}
+
+		url, created, err := worker.EnsureQueue(context.Background(), service, "reservation-events", 3)
+		if err != nil {
+			t.Fatalf("EnsureQueue() error = %v", err)
+		}
+		if created || len(service.created) != 0 {
+			t.Errorf("Expected no queue to be created, got %v", service.created)
+		}
+		if url != "http://localhost:4566/000000000000/reservation-events" {
+			t.Errorf("Unexpected queue URL %q", url)
+		}
+	})
+}
+
+func TestIsLocalStackEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     bool
+	}{
+		{"http://localhost:4566", true},
+		{"http://127.0.0.1:4566", true},
+		{"http://localstack:4566", true},
+		{"https://sqs.localhost.localstack.cloud:4566", true},
+		{"", false},
+		{"https://sqs.ap-northeast-2.amazonaws.com", false},
+	}
+
+	for _, tt := range tests {
+		if got := worker.IsLocalStackEndpoint(tt.endpoint); got != tt.want {
+			t.Errorf("IsLocalStackEndpoint(%q) = %v, want %v", tt.endpoint, got, tt.want)
+		}
+	}
+}
@@ -3,7 +3,6 @@ package worker
 import (
 	"context"
 
-	"github.com/traffic-tacos/reservation-worker/internal/handler"
 	"github.com/traffic-tacos/reservation-worker/internal/observability"
 	"go.uber.org/zap"
 )
@@ -11,8 +10,8 @@ import (
 // Worker represents a worker goroutine that processes events
 type Worker struct {
 	id         int
-	workerPool chan chan *handler.Event
-	eventChan  chan *handler.Event
+	workerPool chan chan *job
+	jobChan    chan *job
 	logger     *observability.Logger
 	metrics    *observability.Metrics
 	dispatcher *Dispatcher
@@ -21,7 +20,7 @@ type Worker struct {
 // NewWorker creates a new worker
 func NewWorker(
 	id int,
-	workerPool chan chan *handler.Event,
+	workerPool chan chan *job,
 	logger *observability.Logger,
 	metrics *observability.Metrics,
 	dispatcher *Dispatcher,
@@ -29,7 +28,7 @@ func NewWorker(
 	return &Worker{
 		id:         id,
 		workerPool: workerPool,
-		eventChan:  make(chan *handler.Event),
+		jobChan:    make(chan *job),
 		logger:     logger,
 		metrics:    metrics,
 		dispatcher: dispatcher,
@@ -42,17 +41,23 @@ func (w *Worker) Start(ctx context.Context) {
 
 	for {
 		// Register worker in pool
-		w.workerPool <- w.eventChan
+		w.workerPool <- w.jobChan
 
 		select {
 		case <-ctx.Done():
 			w.logger.Debug("Worker stopped due to context cancellation", zap.Int("worker_id", w.id))
 			return
 
-		case event := <-w.eventChan:
-			if event == nil {
+		case <-w.dispatcher.quitChan:
+			w.logger.Debug("Worker stopped", zap.Int("worker_id", w.id))
+			return
+
+		case j := <-w.jobChan:
+			if j == nil {
 				continue
 			}
+			event := j.event
+			w.dispatcher.busyWorkers.Add(1)
 
 			w.logger.Debug("Worker processing event",
 				zap.Int("worker_id", w.id),
@@ -60,8 +65,14 @@ func (w *Worker) Start(ctx context.Context) {
 				zap.String("event_id", event.ID),
 			)
 
+			// Retries keep the processing ID of the first attempt
+			jobCtx := ctx
+			if j.processingID != "" {
+				jobCtx = observability.WithProcessingID(ctx, j.processingID)
+			}
+
 			// Process event with retry logic
-			if err := w.dispatcher.HandleEvent(ctx, event, 1); err != nil {
+			if err := w.dispatcher.HandleEvent(jobCtx, event, j.attempt); err != nil {
 				w.logger.Error("Worker failed to process event",
 					zap.Error(err),
 					zap.Int("worker_id", w.id),
@@ -69,6 +80,8 @@ func (w *Worker) Start(ctx context.Context) {
 					zap.String("event_id", event.ID),
 				)
 			}
+			w.dispatcher.finishJob(j)
+			w.dispatcher.busyWorkers.Add(-1)
 		}
 	}
 }
\ No newline at end of file